@@ -0,0 +1,117 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// cacheKey is the fingerprint of one input: score.Vitals is comparable, so
+// the struct itself identifies identical device snapshots exactly.
+type cacheKey struct {
+	v  score.Vitals
+	rc int
+}
+
+type cacheEntry struct {
+	key    cacheKey
+	acuity float64
+}
+
+// Cache is a fixed-size least-recently-used cache of acuity scores keyed on
+// (Vitals, resourceCount). A Cache is tied to one parameter set: do not share
+// it between engines with different Params. Safe for concurrent use.
+type Cache struct {
+	mu     sync.Mutex
+	size   int
+	ll     *list.List
+	items  map[cacheKey]*list.Element
+	hits   uint64
+	misses uint64
+}
+
+// CacheStats holds cache counters.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	Len    int
+	Size   int
+}
+
+// HitRate returns Hits / (Hits + Misses), or 0 if there were no lookups.
+func (s CacheStats) HitRate() float64 {
+	n := s.Hits + s.Misses
+	if n == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(n)
+}
+
+// NewCache returns an LRU cache holding up to size entries. Returns nil if
+// size <= 0; a nil *Cache disables caching.
+func NewCache(size int) *Cache {
+	if size <= 0 {
+		return nil
+	}
+	return &Cache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[cacheKey]*list.Element, size),
+	}
+}
+
+func (c *Cache) get(k cacheKey) (float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[k]; ok {
+		c.ll.MoveToFront(el)
+		c.hits++
+		return el.Value.(*cacheEntry).acuity, true
+	}
+	c.misses++
+	return 0, false
+}
+
+func (c *Cache) put(k cacheKey, acuity float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[k]; ok {
+		el.Value.(*cacheEntry).acuity = acuity
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[k] = c.ll.PushFront(&cacheEntry{key: k, acuity: acuity})
+	if c.ll.Len() > c.size {
+		old := c.ll.Back()
+		c.ll.Remove(old)
+		delete(c.items, old.Value.(*cacheEntry).key)
+	}
+}
+
+// Stats returns the current counters.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Len: c.ll.Len(), Size: c.size}
+}
+
+// Reset removes all entries and zeroes the counters.
+func (c *Cache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[cacheKey]*list.Element, c.size)
+	c.hits, c.misses = 0, 0
+}
+
+// WithCache returns a new Engine that memoizes Acuity in an LRU cache of the
+// given size (see NewCache). The receiver is unchanged.
+func (e *Engine) WithCache(size int) *Engine {
+	c := *e
+	c.Cache = NewCache(size)
+	return &c
+}
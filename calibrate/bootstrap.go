@@ -0,0 +1,159 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package calibrate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/olaflaitinen/triagegeist"
+	"github.com/olaflaitinen/triagegeist/norm"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// Aggregates are the historical statistics a site can share without
+// record-level data. Index 0 is level 1.
+type Aggregates struct {
+	// LevelMix is the share of each level, in percent or fractions.
+	LevelMix [5]float64
+	// MeanVitals is the mean of each vital per level, in VitalWeights order
+	// (HR, RR, SBP, DBP, Temp, SpO2, GCS); 0 means unknown.
+	MeanVitals [5][7]float64
+	// MeanResources is the mean resource count per level; all zero means
+	// unknown, and every level then uses half of MaxResources.
+	MeanResources [5]float64
+}
+
+// Bootstrap is an initial site calibration estimated from Aggregates.
+type Bootstrap struct {
+	// Params is the base with VitalWeights and T1..T4 replaced.
+	Params triagegeist.Params
+	// Ranges are the estimated norms: midpoints from the least acute levels,
+	// half-widths from norm.DefaultRanges.
+	Ranges norm.Ranges
+	// LevelScores is the acuity of each level's mean record under Params
+	// and Ranges; the thresholds lie between consecutive scores.
+	LevelScores [5]float64
+}
+
+// Profile returns b as an engine Profile named name, for a ProfileSelector.
+func (b Bootstrap) Profile(name string) triagegeist.Profile {
+	p := b.Params
+	return triagegeist.Profile{Name: name, Ranges: b.Ranges, Params: &p}
+}
+
+// BootstrapFromAggregates estimates a starting calibration for a go-live
+// where record-level history cannot be shared. It is a heuristic meant to be
+// replaced by FitThresholds and FitWeights once records accumulate:
+//
+//   - Each vital's norm midpoint is the mix-weighted mean of levels 4-5
+//     (the default midpoint if unknown).
+//   - Each vital's weight is proportional to the deviation of the mix-weighted
+//     mean of levels 1-2 from that midpoint, scaled so the largest is 1;
+//     vitals with no high-acuity mean keep their base weight.
+//   - Each level's mean record is scored, and T_k is placed between the
+//     scores of levels k and k+1, nearer the level with the smaller share.
+//
+// It is an error if the mix is invalid, base is invalid, or the mean
+// records do not score in strictly decreasing order of acuity.
+func BootstrapFromAggregates(base triagegeist.Params, agg Aggregates) (Bootstrap, error) {
+	var sum float64
+	for i, m := range agg.LevelMix {
+		if !(m >= 0) || math.IsInf(m, 0) {
+			return Bootstrap{}, fmt.Errorf("calibrate: LevelMix[%d] = %v must be non-negative and finite", i, m)
+		}
+		sum += m
+	}
+	if sum == 0 {
+		return Bootstrap{}, fmt.Errorf("calibrate: LevelMix sums to zero")
+	}
+	if !base.Validate() {
+		return Bootstrap{}, fmt.Errorf("calibrate: base params are invalid")
+	}
+	mix := agg.LevelMix
+	for i := range mix {
+		mix[i] /= sum
+	}
+
+	b := Bootstrap{Params: base, Ranges: norm.DefaultRanges()}
+	for i := 0; i < 7; i++ {
+		if m, ok := mixMean(agg, mix, i, 3, 5); ok {
+			_, hw := b.Ranges.At(i)
+			b.Ranges.Set(i, m, hw)
+		}
+	}
+	var dev [7]float64
+	var known [7]bool
+	var max float64
+	for i := 0; i < 7; i++ {
+		m, ok := mixMean(agg, mix, i, 0, 2)
+		if !ok {
+			continue
+		}
+		mid, hw := b.Ranges.At(i)
+		dev[i], known[i] = norm.Deviation(m, mid, hw), true
+		max = math.Max(max, dev[i])
+	}
+	if max > 0 {
+		for i := range dev {
+			if known[i] {
+				b.Params.VitalWeights[i] = dev[i] / max
+			}
+		}
+	}
+
+	resUnknown := agg.MeanResources == [5]float64{}
+	o := b.Params.ScoreOptions()
+	norms := b.Ranges.Array()
+	o.Norms = &norms
+	for l := 0; l < 5; l++ {
+		rc := int(math.Round(agg.MeanResources[l]))
+		if resUnknown {
+			rc = b.Params.MaxResources / 2
+		}
+		v := meanVitals(agg.MeanVitals[l])
+		b.LevelScores[l] = score.AcuityWithOptions(v, rc, b.Params.MaxResources, b.Params.VitalWeights, b.Params.ResourceWeight, o)
+		if l > 0 && !(b.LevelScores[l] < b.LevelScores[l-1]) {
+			return Bootstrap{}, fmt.Errorf("calibrate: level %d mean scores %.4f, not below level %d (%.4f): aggregates do not separate the levels",
+				l+1, b.LevelScores[l], l, b.LevelScores[l-1])
+		}
+	}
+	var t [4]float64
+	for k := 0; k < 4; k++ {
+		hi, lo := b.LevelScores[k], b.LevelScores[k+1]
+		share := 0.5
+		if mix[k]+mix[k+1] > 0 {
+			share = mix[k+1] / (mix[k] + mix[k+1])
+		}
+		t[k] = lo + (hi-lo)*share
+	}
+	b.Params.SetThresholds(t[0], t[1], t[2], t[3])
+	if errs := b.Params.ValidateDetailed(); len(errs) > 0 {
+		return Bootstrap{}, fmt.Errorf("calibrate: bootstrapped params: %w", errors.Join(errs...))
+	}
+	return b, nil
+}
+
+// mixMean returns the mix-weighted mean of vital i over levels from+1..to,
+// skipping unknown (zero) means.
+func mixMean(agg Aggregates, mix [5]float64, i, from, to int) (float64, bool) {
+	var s, w float64
+	for l := from; l < to; l++ {
+		if x := agg.MeanVitals[l][i]; x != 0 && mix[l] > 0 {
+			s += mix[l] * x
+			w += mix[l]
+		}
+	}
+	if w == 0 {
+		return 0, false
+	}
+	return s / w, true
+}
+
+// meanVitals builds a Vitals record from per-level means.
+func meanVitals(m [7]float64) score.Vitals {
+	r := func(x float64) int { return int(math.Round(x)) }
+	return score.Vitals{HR: r(m[0]), RR: r(m[1]), SBP: r(m[2]), DBP: r(m[3]), Temp: m[4], SpO2: r(m[5]), GCS: r(m[6])}
+}
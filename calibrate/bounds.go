@@ -0,0 +1,189 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package calibrate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/olaflaitinen/triagegeist"
+)
+
+// Range is a closed interval [Min, Max]. The zero Range is unconstrained.
+type Range struct {
+	Min, Max float64
+}
+
+// unbounded returns true for the zero Range.
+func (r Range) unbounded() bool {
+	return r == Range{}
+}
+
+// Contains returns true if x lies in r (always for the zero Range).
+func (r Range) Contains(x float64) bool {
+	return r.unbounded() || (x >= r.Min && x <= r.Max)
+}
+
+// Clamp returns x limited to r.
+func (r Range) Clamp(x float64) float64 {
+	if r.unbounded() {
+		return x
+	}
+	return math.Min(math.Max(x, r.Min), r.Max)
+}
+
+// ParamBounds constrains fitted parameters to clinically plausible values.
+// It is consumed by FitThresholds (Options.Bounds), FitWeights
+// (WeightOptions.Bounds), GridSearch and RandomSearch (Space.Bounds): the
+// threshold fit and the searches only consider parameters within bounds,
+// and the weight fit projects its result onto them.
+//
+//	| Field          | Default     | Constraint                       |
+//	|----------------|-------------|----------------------------------|
+//	| VitalWeights   | [0.02, 1]   | Per vital, in VitalWeights order |
+//	| ResourceWeight | [0, 1]      |                                  |
+//	| Thresholds[0]  | [0.5, 0.95] | T1                               |
+//	| Thresholds[1]  | [0.3, 0.8]  | T2                               |
+//	| Thresholds[2]  | [0.15, 0.6] | T3                               |
+//	| Thresholds[3]  | [0.05, 0.4] | T4                               |
+//	| MinGap         | 0.05        | T_k - T_k+1 >= MinGap            |
+//	| WeightOrder    | none        | {i, j}: weight i >= weight j     |
+//
+// A zero Range leaves its parameter unconstrained. The weight bounds apply
+// on the scale FitWeights reports (largest coefficient 1); only the
+// proportions of the weights affect the score.
+type ParamBounds struct {
+	VitalWeights   [7]Range
+	ResourceWeight Range
+	Thresholds     [4]Range
+	MinGap         float64
+	// WeightOrder lists pairs {i, j} of vital indices requiring weight i to
+	// be at least weight j, e.g. {5, 4} for SpO2 at least Temp.
+	WeightOrder [][2]int
+}
+
+// DefaultParamBounds returns the defaults above.
+func DefaultParamBounds() ParamBounds {
+	b := ParamBounds{
+		ResourceWeight: Range{0, 1},
+		Thresholds:     [4]Range{{0.5, 0.95}, {0.3, 0.8}, {0.15, 0.6}, {0.05, 0.4}},
+		MinGap:         0.05,
+	}
+	for i := range b.VitalWeights {
+		b.VitalWeights[i] = Range{0.02, 1}
+	}
+	return b
+}
+
+// Validate returns an error if any Range has Min > Max, MinGap is
+// negative, or a WeightOrder pair is out of range.
+func (b ParamBounds) Validate() error {
+	var errs []error
+	check := func(name string, r Range) {
+		if !(r.Min <= r.Max) {
+			errs = append(errs, fmt.Errorf("%s: Min %v > Max %v", name, r.Min, r.Max))
+		}
+	}
+	for i, r := range b.VitalWeights {
+		check(fmt.Sprintf("VitalWeights[%d]", i), r)
+	}
+	check("ResourceWeight", b.ResourceWeight)
+	for k, r := range b.Thresholds {
+		check(fmt.Sprintf("Thresholds[%d]", k), r)
+	}
+	if !(b.MinGap >= 0) {
+		errs = append(errs, fmt.Errorf("MinGap %v must be non-negative", b.MinGap))
+	}
+	for _, o := range b.WeightOrder {
+		if o[0] < 0 || o[0] > 6 || o[1] < 0 || o[1] > 6 {
+			errs = append(errs, fmt.Errorf("WeightOrder pair %v: indices must be in 0..6", o))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("calibrate: bounds: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Check returns one error per constraint p violates, or nil.
+func (b ParamBounds) Check(p triagegeist.Params) []error {
+	return append(b.checkThresholds(p.Thresholds()), b.checkWeights(p)...)
+}
+
+// Contains returns true if p satisfies every constraint.
+func (b ParamBounds) Contains(p triagegeist.Params) bool {
+	return len(b.Check(p)) == 0
+}
+
+// checkWeights returns the weight range and order violations of p.
+func (b ParamBounds) checkWeights(p triagegeist.Params) []error {
+	var errs []error
+	for i, r := range b.VitalWeights {
+		if !r.Contains(p.VitalWeights[i]) {
+			errs = append(errs, fmt.Errorf("VitalWeights[%d] (%v) outside [%v, %v]", i, p.VitalWeights[i], r.Min, r.Max))
+		}
+	}
+	if !b.ResourceWeight.Contains(p.ResourceWeight) {
+		errs = append(errs, fmt.Errorf("ResourceWeight (%v) outside [%v, %v]", p.ResourceWeight, b.ResourceWeight.Min, b.ResourceWeight.Max))
+	}
+	for _, o := range b.WeightOrder {
+		if p.VitalWeights[o[0]] < p.VitalWeights[o[1]] {
+			errs = append(errs, fmt.Errorf("VitalWeights[%d] (%v) below VitalWeights[%d] (%v)", o[0], p.VitalWeights[o[0]], o[1], p.VitalWeights[o[1]]))
+		}
+	}
+	return errs
+}
+
+// checkThresholds returns the threshold range and gap violations of t.
+func (b ParamBounds) checkThresholds(t [4]float64) []error {
+	var errs []error
+	for k, r := range b.Thresholds {
+		if !r.Contains(t[k]) {
+			errs = append(errs, fmt.Errorf("T%d (%v) outside [%v, %v]", k+1, t[k], r.Min, r.Max))
+		}
+		if k < 3 && t[k]-t[k+1] < b.MinGap-1e-12 {
+			errs = append(errs, fmt.Errorf("T%d - T%d (%v) below MinGap %v", k+1, k+2, t[k]-t[k+1], b.MinGap))
+		}
+	}
+	return errs
+}
+
+// projectWeights clamps the weights of p into their ranges and then
+// resolves WeightOrder violations by setting both weights of a violated pair
+// to their mean. The result may still violate bounds that contradict each
+// other; Check reports that.
+func (b ParamBounds) projectWeights(p triagegeist.Params) triagegeist.Params {
+	for i, r := range b.VitalWeights {
+		p.VitalWeights[i] = r.Clamp(p.VitalWeights[i])
+	}
+	p.ResourceWeight = b.ResourceWeight.Clamp(p.ResourceWeight)
+	for pass := 0; pass < 7; pass++ {
+		ok := true
+		for _, o := range b.WeightOrder {
+			w := &p.VitalWeights
+			if w[o[0]] < w[o[1]] {
+				m := (w[o[0]] + w[o[1]]) / 2
+				w[o[0]], w[o[1]] = m, m
+				ok = false
+			}
+		}
+		if ok {
+			break
+		}
+	}
+	return p
+}
+
+// clampThresholds returns t moved into the threshold ranges, keeping the
+// gap where the ranges allow it.
+func (b ParamBounds) clampThresholds(t [4]float64) [4]float64 {
+	for k, r := range b.Thresholds {
+		t[k] = r.Clamp(t[k])
+		if k > 0 && t[k-1]-t[k] < b.MinGap {
+			t[k] = r.Clamp(t[k-1] - b.MinGap)
+		}
+	}
+	return t
+}
@@ -0,0 +1,320 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+//
+// Package calibrate fits site parameters from labelled reference data: the
+// core workflow for adopting triagegeist at a new site.
+//
+// # Threshold fitting
+//
+// FitThresholds scores every record once with the base Params (thresholds
+// do not affect the acuity score) and then searches T1..T4 by coordinate
+// descent over candidate cut points taken from the score quantiles.
+//
+//	| Objective        | Goal                                                   |
+//	|------------------|--------------------------------------------------------|
+//	| MaxWeightedKappa | Maximise linear weighted kappa against the reference   |
+//	| MinUnderTriage   | Minimise under-triage with over-triage <= OverTriageCap |
+//
+// Under-triage is a predicted level less acute than the reference (pred >
+// ref); over-triage is the reverse. The fitted Params keep every field of
+// the base except T1..T4.
+//
+// Without reference levels, ThresholdsFromMix places T1..T4 at the score
+// quantiles that reproduce a target level mix, e.g. the department's
+// historical case-mix.
+//
+// # Weight fitting
+//
+// FitWeights fits VitalWeights and ResourceWeight to binary high-acuity
+// outcomes by regularised logistic regression and reports cross-validated
+// AUC. Fit weights first, then thresholds.
+//
+// # Probability calibration
+//
+// FitPlatt (logistic) and FitIsotonic (pool-adjacent-violators) map the
+// acuity score to the probability of a high-acuity outcome. Store the
+// result in Params.Calibration; evaluations then report it.
+//
+// # Cold start
+//
+// BootstrapFromAggregates estimates norms, weights and thresholds from
+// aggregate statistics only (level mix, mean vitals per level), for
+// go-lives where record-level history cannot be shared.
+//
+// # Learned models
+//
+// FitModel trains a model.BoostedStumps ensemble on the same records,
+// with its agreement beside that of the base Params, as a middle ground
+// between the linear formula and external models.
+//
+// # Search
+//
+// GridSearch and RandomSearch evaluate combinations from a Space of
+// candidate weights and threshold sets against any ObjectiveFunc (e.g.
+// metrics.WeightedKappa) and return the top k.
+//
+// # Bounds
+//
+// ParamBounds limits weights and thresholds to plausible ranges, with a
+// minimum gap between thresholds and optional weight orderings. Set it as
+// Options.Bounds, WeightOptions.Bounds or Space.Bounds so fitted
+// parameters never leave it. SampleParams draws random valid parameter sets
+// within bounds for robustness studies.
+package calibrate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/olaflaitinen/triagegeist"
+	"github.com/olaflaitinen/triagegeist/metrics"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// Objective selects what FitThresholds optimises.
+type Objective int
+
+const (
+	// MaxWeightedKappa maximises metrics.WeightedKappa.
+	MaxWeightedKappa Objective = iota
+	// MinUnderTriage minimises the under-triage rate subject to the
+	// over-triage rate not exceeding Options.OverTriageCap; ties go to the
+	// higher weighted kappa.
+	MinUnderTriage
+)
+
+// Options controls FitThresholds.
+//
+//	| Field         | Default          | Meaning                                |
+//	|---------------|------------------|----------------------------------------|
+//	| Objective     | MaxWeightedKappa | What to optimise                       |
+//	| OverTriageCap | 0.3              | Max over-triage rate (MinUnderTriage)  |
+//	| Candidates    | 100              | Score quantiles tried as cut points    |
+//	| MaxSweeps     | 20               | Coordinate descent passes over T1..T4  |
+//	| Bounds        | nil              | Threshold bounds (see ParamBounds)     |
+type Options struct {
+	Objective     Objective
+	OverTriageCap float64
+	Candidates    int
+	MaxSweeps     int
+	// Bounds, if non-nil, restricts T1..T4 to its threshold ranges and
+	// MinGap; the search starts from the base thresholds moved into bounds.
+	// Its weight bounds are not checked, since the weights are not fitted.
+	Bounds *ParamBounds
+}
+
+// DefaultOptions returns the defaults above.
+func DefaultOptions() Options {
+	return Options{Objective: MaxWeightedKappa, OverTriageCap: 0.3, Candidates: 100, MaxSweeps: 20}
+}
+
+// Diagnostics describes agreement of assigned levels with the reference.
+type Diagnostics struct {
+	N              int
+	WeightedKappa  float64
+	ExactAgreement float64
+	WithinOne      float64
+	UnderTriage    float64
+	OverTriage     float64
+	Confusion      metrics.ConfusionMatrix
+}
+
+// ThresholdFit is the result of FitThresholds.
+type ThresholdFit struct {
+	Params   triagegeist.Params
+	Fitted   Diagnostics // With the fitted thresholds
+	Baseline Diagnostics // With the base thresholds
+	// Feasible is false if no thresholds met OverTriageCap under
+	// MinUnderTriage; the fit then has the lowest over-triage found.
+	Feasible bool
+	Sweeps   int
+}
+
+// ErrNoData is returned when there are no records to fit.
+var ErrNoData = errors.New("calibrate: no records")
+
+// checkData validates the shared inputs of the fitting functions.
+func checkData(vitals []score.Vitals, resourceCounts, reference []int) error {
+	if len(vitals) == 0 {
+		return ErrNoData
+	}
+	if len(resourceCounts) != len(vitals) || len(reference) != len(vitals) {
+		return fmt.Errorf("calibrate: length mismatch: %d vitals, %d resource counts, %d reference levels",
+			len(vitals), len(resourceCounts), len(reference))
+	}
+	for i, l := range reference {
+		if l < 1 || l > 5 {
+			return fmt.Errorf("calibrate: record %d: reference level %d not in 1..5", i, l)
+		}
+	}
+	return nil
+}
+
+// FitThresholds fits T1..T4 of base to the reference levels (1..5) of the
+// given records. base must be valid. Zero Options fields take their
+// defaults. It is an error if opt.Bounds is invalid or its threshold
+// ranges and MinGap cannot all be met.
+func FitThresholds(base triagegeist.Params, vitals []score.Vitals, resourceCounts, reference []int, opt Options) (ThresholdFit, error) {
+	if err := checkData(vitals, resourceCounts, reference); err != nil {
+		return ThresholdFit{}, err
+	}
+	if errs := base.ValidateDetailed(); len(errs) > 0 {
+		return ThresholdFit{}, fmt.Errorf("calibrate: base params: %w", errors.Join(errs...))
+	}
+	def := DefaultOptions()
+	if opt.OverTriageCap <= 0 {
+		opt.OverTriageCap = def.OverTriageCap
+	}
+	if opt.Candidates <= 0 {
+		opt.Candidates = def.Candidates
+	}
+	if opt.MaxSweeps <= 0 {
+		opt.MaxSweeps = def.MaxSweeps
+	}
+	t := base.Thresholds()
+	if opt.Bounds != nil {
+		if err := opt.Bounds.Validate(); err != nil {
+			return ThresholdFit{}, err
+		}
+		t = opt.Bounds.clampThresholds(t)
+		if errs := opt.Bounds.checkThresholds(t); len(errs) > 0 {
+			return ThresholdFit{}, fmt.Errorf("calibrate: bounds cannot be met: %w", errors.Join(errs...))
+		}
+	}
+	eng := triagegeist.NewEngine(base)
+	scores := make([]float64, len(vitals))
+	for i, v := range vitals {
+		scores[i] = eng.Acuity(v, resourceCounts[i])
+	}
+	s := &search{scores: scores, ref: reference, pred: make([]int, len(scores)), opt: opt}
+	cands := candidates(scores, t, opt.Candidates)
+
+	best, _ := s.objective(t)
+	fit := ThresholdFit{}
+	for fit.Sweeps < opt.MaxSweeps {
+		fit.Sweeps++
+		improved := false
+		for k := 0; k < 4; k++ {
+			var hi, lo float64
+			if k > 0 {
+				hi = t[k-1]
+			}
+			if k < 3 {
+				lo = t[k+1]
+			}
+			for _, c := range cands {
+				if c <= lo || c > 1 || (k > 0 && c >= hi) {
+					continue
+				}
+				trial := t
+				trial[k] = c
+				if opt.Bounds != nil && len(opt.Bounds.checkThresholds(trial)) > 0 {
+					continue
+				}
+				if v, _ := s.objective(trial); v > best+1e-12 {
+					best, t, improved = v, trial, true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+	fit.Params = base
+	fit.Params.SetThresholds(t[0], t[1], t[2], t[3])
+	_, fit.Feasible = s.objective(t)
+	fit.Fitted = s.diagnostics(t)
+	fit.Baseline = s.diagnostics(base.Thresholds())
+	return fit, nil
+}
+
+// candidates returns distinct cut points in (0, 1]: opt quantiles of
+// scores plus the base thresholds, ascending.
+func candidates(scores []float64, base [4]float64, n int) []float64 {
+	sorted := append([]float64(nil), scores...)
+	sort.Float64s(sorted)
+	set := make(map[float64]bool)
+	for k := 1; k <= n; k++ {
+		set[sorted[(len(sorted)-1)*k/n]] = true
+	}
+	for _, b := range base {
+		set[b] = true
+	}
+	out := make([]float64, 0, len(set))
+	for c := range set {
+		if c > 0 && c <= 1 {
+			out = append(out, c)
+		}
+	}
+	sort.Float64s(out)
+	return out
+}
+
+// search holds the precomputed scores for objective evaluation.
+type search struct {
+	scores []float64
+	ref    []int
+	pred   []int
+	opt    Options
+}
+
+// assign fills s.pred with the levels of s.scores under thresholds t.
+func (s *search) assign(t [4]float64) {
+	for i, a := range s.scores {
+		l := 5
+		for k := 0; k < 4; k++ {
+			if a >= t[k] {
+				l = k + 1
+				break
+			}
+		}
+		s.pred[i] = l
+	}
+}
+
+// objective returns the value to maximise for t and whether t satisfies
+// the over-triage cap (always true for MaxWeightedKappa).
+func (s *search) objective(t [4]float64) (float64, bool) {
+	s.assign(t)
+	kappa := metrics.WeightedKappa(s.pred, s.ref)
+	if s.opt.Objective != MinUnderTriage {
+		return kappa, true
+	}
+	var under, over int
+	for i, p := range s.pred {
+		switch {
+		case p > s.ref[i]:
+			under++
+		case p < s.ref[i]:
+			over++
+		}
+	}
+	n := float64(len(s.pred))
+	u, o := float64(under)/n, float64(over)/n
+	if o > s.opt.OverTriageCap {
+		return -2 - o, false
+	}
+	return -u + 1e-6*kappa, true
+}
+
+// diagnostics returns the agreement of the levels under t.
+func (s *search) diagnostics(t [4]float64) Diagnostics {
+	s.assign(t)
+	return agreement(s.pred, s.ref)
+}
+
+// agreement returns the agreement of the levels pred with ref.
+func agreement(pred, ref []int) Diagnostics {
+	groups := make([]int, len(pred))
+	ga := metrics.AgreementByGroup(groups, pred, ref)[0]
+	return Diagnostics{
+		N:              ga.N,
+		WeightedKappa:  ga.WeightedKappa,
+		ExactAgreement: ga.ExactAgreement,
+		WithinOne:      ga.WithinOne,
+		UnderTriage:    ga.UnderTriage,
+		OverTriage:     ga.OverTriage,
+		Confusion:      metrics.NewConfusionMatrix(pred, ref),
+	}
+}
@@ -0,0 +1,553 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package calibrate
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/olaflaitinen/triagegeist"
+	"github.com/olaflaitinen/triagegeist/metrics"
+	"github.com/olaflaitinen/triagegeist/model"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// cohort returns synthetic records labelled by an engine with params p.
+func cohort(n int, seed int64, p triagegeist.Params) ([]score.Vitals, []int, []int) {
+	rng := rand.New(rand.NewSource(seed))
+	eng := triagegeist.NewEngine(p)
+	vs := make([]score.Vitals, n)
+	rcs := make([]int, n)
+	ref := make([]int, n)
+	for i := range vs {
+		vs[i] = score.Vitals{
+			HR:   45 + rng.Intn(120),
+			RR:   8 + rng.Intn(30),
+			SBP:  70 + rng.Intn(110),
+			SpO2: 82 + rng.Intn(18),
+			GCS:  8 + rng.Intn(8),
+		}
+		rcs[i] = rng.Intn(7)
+		ref[i] = eng.Level(vs[i], rcs[i]).Int()
+	}
+	return vs, rcs, ref
+}
+
+func TestFitThresholds_Kappa(t *testing.T) {
+	site := triagegeist.DefaultParams()
+	site.SetThresholds(0.70, 0.50, 0.30, 0.12)
+	vs, rcs, ref := cohort(600, 1, site)
+	fit, err := FitThresholds(triagegeist.DefaultParams(), vs, rcs, ref, DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fit.Params.Validate() || !fit.Feasible {
+		t.Fatalf("fit = %+v", fit)
+	}
+	if fit.Fitted.WeightedKappa < 0.97 || fit.Fitted.WeightedKappa <= fit.Baseline.WeightedKappa {
+		t.Errorf("kappa fitted %v, baseline %v", fit.Fitted.WeightedKappa, fit.Baseline.WeightedKappa)
+	}
+	if d := fit.Params.T1 - 0.70; d < -0.05 || d > 0.05 {
+		t.Errorf("T1 = %v, want about 0.70", fit.Params.T1)
+	}
+	if fit.Params.VitalWeights != site.VitalWeights || fit.Fitted.N != 600 {
+		t.Error("non-threshold fields changed")
+	}
+}
+
+func TestFitThresholds_UnderTriage(t *testing.T) {
+	vs, rcs, ref := cohort(400, 2, triagegeist.PresetStrict())
+	opt := DefaultOptions()
+	opt.Objective = MinUnderTriage
+	opt.OverTriageCap = 0.1
+	fit, err := FitThresholds(triagegeist.PresetLenient(), vs, rcs, ref, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fit.Feasible || fit.Fitted.OverTriage > 0.1 {
+		t.Errorf("over-triage %v exceeds cap", fit.Fitted.OverTriage)
+	}
+	if fit.Fitted.UnderTriage >= fit.Baseline.UnderTriage {
+		t.Errorf("under-triage fitted %v, baseline %v", fit.Fitted.UnderTriage, fit.Baseline.UnderTriage)
+	}
+}
+
+func TestFitThresholds_Errors(t *testing.T) {
+	p := triagegeist.DefaultParams()
+	if _, err := FitThresholds(p, nil, nil, nil, Options{}); err != ErrNoData {
+		t.Errorf("empty: %v", err)
+	}
+	vs := []score.Vitals{{HR: 80}}
+	if _, err := FitThresholds(p, vs, []int{1}, []int{6}, Options{}); err == nil {
+		t.Error("reference level 6 accepted")
+	}
+	if _, err := FitThresholds(p, vs, []int{1, 2}, []int{3}, Options{}); err == nil {
+		t.Error("length mismatch accepted")
+	}
+	p.T4 = 0
+	if _, err := FitThresholds(p, vs, []int{1}, []int{3}, Options{}); err == nil {
+		t.Error("invalid base accepted")
+	}
+}
+
+func TestFitModel(t *testing.T) {
+	site := triagegeist.DefaultParams()
+	site.SetThresholds(0.70, 0.50, 0.30, 0.12)
+	vs, rcs, ref := cohort(600, 9, site)
+	fit, err := FitModel(triagegeist.DefaultParams(), vs, rcs, ref, model.BoostOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fit.Fitted.N != 600 || fit.Fitted.WeightedKappa < 0.75 || fit.Fitted.WeightedKappa <= fit.Baseline.WeightedKappa {
+		t.Errorf("kappa fitted %v, baseline %v", fit.Fitted.WeightedKappa, fit.Baseline.WeightedKappa)
+	}
+	data, err := json.Marshal(fit.Model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back model.BoostedStumps
+	if err := json.Unmarshal(data, &back); err != nil || back.PredictProba(model.Features{}) != fit.Model.PredictProba(model.Features{}) {
+		t.Errorf("round trip: %v", err)
+	}
+	same := make([]int, len(ref))
+	for i := range same {
+		same[i] = 3
+	}
+	if _, err := FitModel(triagegeist.DefaultParams(), vs, rcs, same, model.BoostOptions{}); err == nil {
+		t.Error("a single reference level accepted")
+	}
+}
+
+func TestFitReferenceDistribution(t *testing.T) {
+	p := triagegeist.DefaultParams()
+	vs, rcs, _ := cohort(500, 3, p)
+	scores := ReferenceScores(p, vs, rcs)
+	d, err := FitReferenceDistribution(scores)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.N != 500 || len(d.Quantiles) != triagegeist.DefaultReferencePoints {
+		t.Fatalf("d: N %d, %d quantiles", d.N, len(d.Quantiles))
+	}
+	p.Reference = &d
+	if !p.Validate() {
+		t.Fatal("params with reference invalid")
+	}
+	var below int
+	for _, s := range scores {
+		if s < d.Quantiles[90] {
+			below++
+		}
+	}
+	if below < 440 || below > 460 {
+		t.Errorf("%d of 500 scores below the 90th percentile", below)
+	}
+	r := triagegeist.NewEngine(p).Evaluate(vs[0], rcs[0])
+	if r.Percentile != d.PercentileRank(r.Acuity) || r.Percentile == 0 {
+		t.Errorf("percentile = %v", r.Percentile)
+	}
+	if _, err := FitReferenceDistribution(nil); err != ErrNoData {
+		t.Errorf("empty: %v", err)
+	}
+}
+
+func TestFitWeights(t *testing.T) {
+	// Outcomes driven by hypoxia and tachypnoea only.
+	rng := rand.New(rand.NewSource(4))
+	n := 600
+	vs := make([]score.Vitals, n)
+	rcs := make([]int, n)
+	ys := make([]int, n)
+	for i := range vs {
+		vs[i] = score.Vitals{
+			HR:   50 + rng.Intn(100),
+			RR:   10 + rng.Intn(24),
+			SpO2: 84 + rng.Intn(16),
+			Temp: 35.5 + rng.Float64()*4,
+		}
+		rcs[i] = rng.Intn(7)
+		if vs[i].SpO2 < 91 || vs[i].RR > 28 {
+			ys[i] = 1
+		}
+	}
+	fit, err := FitWeights(triagegeist.DefaultParams(), vs, rcs, ys, DefaultWeightOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := fit.Params.VitalWeights
+	if !fit.Params.Validate() || w[5] < w[0] || w[5] < w[4] || w[1] < w[4] {
+		t.Errorf("weights = %v, resource %v", w, fit.Params.ResourceWeight)
+	}
+	if fit.TrainAUC <= fit.BaselineAUC || len(fit.FoldAUC) != 5 || fit.CVAUC < 0.85 {
+		t.Errorf("AUC train %v, baseline %v, CV %v %v", fit.TrainAUC, fit.BaselineAUC, fit.CVAUC, fit.FoldAUC)
+	}
+	if got := HighAcuityOutcomes([]int{1, 2, 3, 0}, 2); got[0] != 1 || got[1] != 1 || got[2] != 0 || got[3] != 0 {
+		t.Errorf("HighAcuityOutcomes = %v", got)
+	}
+	if _, err := FitWeights(triagegeist.DefaultParams(), vs, rcs, make([]int, n), DefaultWeightOptions()); err == nil {
+		t.Error("single-class outcomes accepted")
+	}
+
+	// With every vital present, the fitted score is the logistic linear
+	// predictor rescaled, so it ranks records identically: the resource
+	// coefficient maps to ResourceWeight and base's score options are used.
+	base := triagegeist.DefaultParams()
+	base.Directions[5] = score.DirectionLow
+	for i := range vs {
+		vs[i].SBP, vs[i].DBP, vs[i].GCS = 90+rng.Intn(60), 55+rng.Intn(40), 9+rng.Intn(7)
+		ys[i] = 0
+		if vs[i].SpO2 < 91 || rcs[i] >= 5 {
+			ys[i] = 1
+		}
+	}
+	fit, err = FitWeights(base, vs, rcs, ys, DefaultWeightOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, sc := base.ScoreOptions(), ReferenceScores(fit.Params, vs, rcs)
+	var csum float64
+	for _, c := range fit.Coef[:7] {
+		csum += c
+	}
+	for i, v := range vs {
+		d := score.DeviationsWithOptions(v, o)
+		z := fit.Coef[7] * score.ScaleResourcesRate(rcs[i], base.MaxResources, base.ResourceScale, base.ResourceRate)
+		for j := range d {
+			z += fit.Coef[j] * d[j]
+		}
+		if want := z / (csum * fit.Params.Divisor()); math.Abs(sc[i]-want) > 1e-9 {
+			t.Fatalf("record %d: score %v, want linear predictor %v", i, sc[i], want)
+		}
+	}
+	if fit.Params.ResourceWeight <= 0 || fit.CVAUC < 0.9 {
+		t.Errorf("resource weight %v, CV AUC %v", fit.Params.ResourceWeight, fit.CVAUC)
+	}
+}
+
+func TestGridAndRandomSearch(t *testing.T) {
+	site := triagegeist.DefaultParams()
+	site.SetThresholds(0.70, 0.50, 0.30, 0.12)
+	vs, rcs, ref := cohort(300, 5, site)
+	var space Space
+	space.VitalWeights[0] = []float64{0.18, 0.5}
+	space.Thresholds = [][4]float64{{0.85, 0.60, 0.35, 0.15}, {0.70, 0.50, 0.30, 0.12}, {0.5, 0.6, 0.3, 0.1}}
+	if space.Size() != 6 {
+		t.Fatalf("Size = %d", space.Size())
+	}
+	top, err := GridSearch(triagegeist.DefaultParams(), space, vs, rcs, ref, metrics.WeightedKappa, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 3 || !top[0].Params.Equal(site) || top[0].Score != 1 {
+		t.Fatalf("best = %+v", top[0])
+	}
+	if top[1].Score > top[0].Score || top[2].Score > top[1].Score {
+		t.Error("candidates not sorted")
+	}
+
+	rtop, err := RandomSearch(triagegeist.DefaultParams(), space, 40, 1, vs, rcs, ref, metrics.WeightedKappa, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rtop) != 1 || !rtop[0].Params.Equal(site) {
+		t.Errorf("random best = %+v", rtop)
+	}
+	if _, err := GridSearch(triagegeist.DefaultParams(), space, vs, rcs, ref, nil, 1); err == nil {
+		t.Error("nil objective accepted")
+	}
+}
+
+func TestParamBounds(t *testing.T) {
+	b := DefaultParamBounds()
+	if err := b.Validate(); err != nil || !b.Contains(triagegeist.DefaultParams()) {
+		t.Fatalf("defaults: %v, %v", err, b.Check(triagegeist.DefaultParams()))
+	}
+	p := triagegeist.DefaultParams()
+	p.SetThresholds(0.99, 0.6, 0.58, 0.15)
+	p.VitalWeights[4] = 0
+	if errs := b.Check(p); len(errs) != 3 {
+		t.Errorf("Check = %v", errs)
+	}
+
+	site := triagegeist.DefaultParams()
+	site.SetThresholds(0.70, 0.50, 0.30, 0.12)
+	vs, rcs, ref := cohort(400, 6, site)
+	b.Thresholds[0] = Range{0.75, 0.95}
+	opt := DefaultOptions()
+	opt.Bounds = &b
+	fit, err := FitThresholds(triagegeist.DefaultParams(), vs, rcs, ref, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := b.Check(fit.Params); len(errs) > 0 || fit.Fitted.WeightedKappa <= fit.Baseline.WeightedKappa {
+		t.Errorf("bounded fit %v: %v, kappa %v", fit.Params.Thresholds(), errs, fit.Fitted.WeightedKappa)
+	}
+
+	var space Space
+	space.Thresholds = [][4]float64{{0.70, 0.50, 0.30, 0.12}, {0.85, 0.60, 0.35, 0.15}}
+	space.Bounds = &b
+	top, err := GridSearch(triagegeist.DefaultParams(), space, vs, rcs, ref, metrics.WeightedKappa, 2)
+	if err != nil || len(top) != 1 || top[0].Params.T1 != 0.85 {
+		t.Errorf("bounded search = %+v, %v", top, err)
+	}
+
+	rng := rand.New(rand.NewSource(7))
+	ys := make([]int, len(vs))
+	for i := range vs {
+		vs[i].Temp = 35.5 + rng.Float64()*4
+		if vs[i].SpO2 < 90 {
+			ys[i] = 1
+		}
+	}
+	wb := DefaultParamBounds()
+	wb.WeightOrder = [][2]int{{4, 5}}
+	wopt := DefaultWeightOptions()
+	wopt.Bounds = &wb
+	wfit, err := FitWeights(triagegeist.DefaultParams(), vs, rcs, ys, wopt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := wfit.Params.VitalWeights; len(wb.checkWeights(wfit.Params)) > 0 || w[4] < w[5] {
+		t.Errorf("bounded weights = %v", w)
+	}
+
+	bad := DefaultParamBounds()
+	bad.Thresholds = [4]Range{{0.5, 0.5}, {0.5, 0.5}, {0.5, 0.5}, {0.5, 0.5}}
+	opt.Bounds = &bad
+	if _, err := FitThresholds(triagegeist.DefaultParams(), vs, rcs, ref, opt); err == nil {
+		t.Error("unsatisfiable bounds accepted")
+	}
+	bad.Thresholds[0] = Range{0.9, 0.5}
+	if err := bad.Validate(); err == nil {
+		t.Error("Min > Max accepted")
+	}
+}
+
+func TestSampleParams(t *testing.T) {
+	b := DefaultParamBounds()
+	b.WeightOrder = [][2]int{{5, 4}}
+	ps, err := SampleParams(triagegeist.DefaultParams(), b, 50, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 50 {
+		t.Fatalf("got %d samples", len(ps))
+	}
+	distinct := map[float64]bool{}
+	for i, p := range ps {
+		if !p.Validate() || !b.Contains(p) {
+			t.Errorf("sample %d invalid or out of bounds: %v", i, b.Check(p))
+		}
+		distinct[p.T1] = true
+	}
+	if len(distinct) < 45 {
+		t.Errorf("only %d distinct T1 values", len(distinct))
+	}
+	again, _ := SampleParams(triagegeist.DefaultParams(), b, 50, 1)
+	if !again[7].Equal(ps[7]) {
+		t.Error("same seed gave different samples")
+	}
+
+	// Only the thresholds vary when the weight ranges are zero.
+	var tb ParamBounds
+	tb.Thresholds = b.Thresholds
+	ps, err = SampleParams(triagegeist.DefaultParams(), tb, 10, 2)
+	if err != nil || ps[0].VitalWeights != triagegeist.DefaultParams().VitalWeights {
+		t.Errorf("threshold-only sampling: %v", err)
+	}
+
+	tight := DefaultParamBounds()
+	tight.MinGap = 0.3
+	if _, err := SampleParams(triagegeist.DefaultParams(), tight, 5, 1); err == nil {
+		t.Error("unsatisfiable bounds did not fail")
+	}
+	if _, err := SampleParams(triagegeist.DefaultParams(), b, -1, 1); err == nil {
+		t.Error("negative n accepted")
+	}
+}
+
+func TestThresholdsFromMix(t *testing.T) {
+	scores := make([]float64, 200)
+	for i := range scores {
+		scores[i] = float64(i+1) / 201
+	}
+	rand.New(rand.NewSource(3)).Shuffle(len(scores), func(i, j int) { scores[i], scores[j] = scores[j], scores[i] })
+	th, err := ThresholdsFromMix(scores, [5]float64{5, 20, 40, 25, 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := triagegeist.DefaultParams()
+	p.SetThresholds(th[0], th[1], th[2], th[3])
+	if !p.Validate() {
+		t.Fatalf("invalid thresholds %v", th)
+	}
+	var counts [6]int
+	for _, s := range scores {
+		counts[triagegeist.FromScore(s, p)]++
+	}
+	if counts != [6]int{0, 10, 40, 80, 50, 20} {
+		t.Errorf("counts = %v for thresholds %v", counts, th)
+	}
+	if frac, _ := ThresholdsFromMix(scores, [5]float64{0.05, 0.2, 0.4, 0.25, 0.1}); frac != th {
+		t.Errorf("fractions give %v, percent %v", frac, th)
+	}
+
+	if _, err := ThresholdsFromMix(nil, [5]float64{1, 1, 1, 1, 1}); err != ErrNoData {
+		t.Errorf("nil scores: %v", err)
+	}
+	for _, mix := range [][5]float64{{}, {-1, 1, 1, 1, 1}, {10, 0, 40, 40, 10}} {
+		if _, err := ThresholdsFromMix(scores, mix); err == nil {
+			t.Errorf("mix %v should fail", mix)
+		}
+	}
+	tied := []float64{0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.9}
+	if _, err := ThresholdsFromMix(tied, [5]float64{10, 20, 20, 20, 30}); err == nil {
+		t.Error("heavily tied scores should fail")
+	}
+}
+
+func TestBootstrapFromAggregates(t *testing.T) {
+	agg := Aggregates{
+		LevelMix: [5]float64{5, 20, 40, 25, 10},
+		MeanVitals: [5][7]float64{
+			{128, 30, 88, 52, 38.4, 88, 10},
+			{112, 24, 104, 62, 38.0, 93, 14},
+			{96, 19, 124, 76, 37.4, 96, 15},
+			{86, 17, 128, 80, 37.0, 98, 15},
+			{80, 16, 126, 80, 36.9, 98, 15},
+		},
+		MeanResources: [5]float64{5, 4, 3, 1, 0},
+	}
+	b, err := BootstrapFromAggregates(triagegeist.DefaultParams(), agg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Params.Validate() || !b.Ranges.Valid() {
+		t.Fatalf("invalid bootstrap: %+v", b)
+	}
+	if mid, _ := b.Ranges.At(0); math.Abs(mid-(25*86+10*80)/35.0) > 1e-9 {
+		t.Errorf("HR midpoint = %v", mid)
+	}
+	max := 0.0
+	for _, w := range b.Params.VitalWeights {
+		max = math.Max(max, w)
+	}
+	if max != 1 {
+		t.Errorf("weights not scaled to 1: %v", b.Params.VitalWeights)
+	}
+	eng := triagegeist.NewEngine(b.Params).WithProfiles(triagegeist.ProfileSelectorFunc(func(triagegeist.PatientContext) triagegeist.Profile {
+		return b.Profile("site")
+	}))
+	for l := 0; l < 5; l++ {
+		v := meanVitals(agg.MeanVitals[l])
+		if got := eng.EvaluateWithContext(v, int(agg.MeanResources[l]), triagegeist.PatientContext{}).Level.Int(); got != l+1 {
+			t.Errorf("level %d mean record scored level %d (scores %v)", l+1, got, b.LevelScores)
+		}
+	}
+
+	flat := agg
+	flat.MeanVitals[4] = flat.MeanVitals[0]
+	if _, err := BootstrapFromAggregates(triagegeist.DefaultParams(), flat); err == nil {
+		t.Error("non-separating aggregates should fail")
+	}
+	if _, err := BootstrapFromAggregates(triagegeist.DefaultParams(), Aggregates{}); err == nil {
+		t.Error("empty mix should fail")
+	}
+}
+
+// logisticOutcomes returns scores uniform on [0, 1) with outcomes drawn
+// from P(y = 1 | s) = 1 / (1 + e^-(6s - 3)).
+func logisticOutcomes(n int, seed int64) ([]float64, []int) {
+	rng := rand.New(rand.NewSource(seed))
+	scores := make([]float64, n)
+	outcomes := make([]int, n)
+	for i := range scores {
+		scores[i] = rng.Float64()
+		if rng.Float64() < 1/(1+math.Exp(-(6*scores[i]-3))) {
+			outcomes[i] = 1
+		}
+	}
+	return scores, outcomes
+}
+
+func TestFitPlatt(t *testing.T) {
+	scores, outcomes := logisticOutcomes(5000, 11)
+	c, err := FitPlatt(scores, outcomes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Valid() || c.N != 5000 || math.Abs(c.A-6) > 0.8 || math.Abs(c.B+3) > 0.4 {
+		t.Errorf("fit %+v, want A 6, B -3", c)
+	}
+	if p := c.Probability(0.5); math.Abs(p-0.5) > 0.05 {
+		t.Errorf("P(0.5) = %v, want 0.5", p)
+	}
+	if _, err := FitPlatt(scores[:3], []int{1, 1, 1}); err == nil {
+		t.Error("expected an error for one class")
+	}
+	if _, err := FitPlatt(nil, nil); err != ErrNoData {
+		t.Errorf("empty: %v", err)
+	}
+	if _, err := FitPlatt(scores[:2], []int{0, 2}); err == nil {
+		t.Error("expected an error for outcome 2")
+	}
+}
+
+func TestFitIsotonic(t *testing.T) {
+	scores, outcomes := logisticOutcomes(5000, 12)
+	c, err := FitIsotonic(scores, outcomes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Valid() || c.N != 5000 {
+		t.Fatalf("invalid fit: %d knots", len(c.X))
+	}
+	for _, s := range []float64{0.2, 0.5, 0.8} {
+		want := 1 / (1 + math.Exp(-(6*s - 3)))
+		if p := c.Probability(s); math.Abs(p-want) > 0.12 {
+			t.Errorf("P(%v) = %v, want about %v", s, p, want)
+		}
+	}
+	// Violators are pooled: 0.3 (y 1) and 0.4 (y 0) average to 0.5.
+	small, err := FitIsotonic([]float64{0.1, 0.3, 0.4, 0.9, 0.1}, []int{0, 1, 0, 1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := small.Probability(0.35); p != 0.5 {
+		t.Errorf("pooled block = %v, want 0.5 (knots %v %v)", p, small.X, small.Y)
+	}
+	if small.Probability(0) != 0 || small.Probability(1) != 1 {
+		t.Errorf("ends: %v, %v", small.Probability(0), small.Probability(1))
+	}
+	if _, err := FitIsotonic([]float64{0.2, math.NaN()}, []int{0, 1}); err == nil {
+		t.Error("expected an error for a NaN score")
+	}
+}
+
+func TestFitZNorms(t *testing.T) {
+	vs, _, _ := cohort(400, 11, triagegeist.DefaultParams())
+	z, err := FitZNorms(vs, 2.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hr := z[0]; !(hr.Mean > 90 && hr.Mean < 120) || !(hr.SD > 25 && hr.SD < 45) || hr.Saturation != 2.5 {
+		t.Errorf("HR = %+v, want mean about 104.5, SD about 34.6", hr)
+	}
+	if !z[3].IsZero() || !z[4].IsZero() {
+		t.Errorf("DBP and Temp are never present, want zero: %+v, %+v", z[3], z[4])
+	}
+	p := triagegeist.DefaultParams()
+	p.ZScores = z
+	if !p.Validate() {
+		t.Errorf("params with fitted z-scores invalid: %v", p.ValidateDetailed())
+	}
+	if _, err := FitZNorms(nil, 0); err != ErrNoData {
+		t.Errorf("empty: err = %v, want ErrNoData", err)
+	}
+	if _, err := FitZNorms(vs, -1); err == nil {
+		t.Error("expected an error for a negative saturation")
+	}
+}
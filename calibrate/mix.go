@@ -0,0 +1,60 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package calibrate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ThresholdsFromMix returns T1..T4 such that the given acuity scores fall
+// into levels 1..5 in the proportions of mix, e.g. {5, 20, 40, 25, 10} to
+// match a department's historical case-mix. mix may be in percent or
+// fractions; it is normalised by its sum. Each threshold is the midpoint
+// between the lowest score inside the more acute levels and the highest
+// score outside them, so the split is exact when scores are distinct; tied
+// scores stay in the same level. It is an error if the thresholds are not
+// strictly decreasing in (0, 1], e.g. because of heavy ties or a level that
+// would need a cut at score 0.
+func ThresholdsFromMix(scores []float64, mix [5]float64) ([4]float64, error) {
+	var t [4]float64
+	if len(scores) == 0 {
+		return t, ErrNoData
+	}
+	var sum float64
+	for i, m := range mix {
+		if m < 0 || math.IsNaN(m) || math.IsInf(m, 0) {
+			return t, fmt.Errorf("calibrate: mix[%d] = %v must be non-negative and finite", i, m)
+		}
+		sum += m
+	}
+	if sum == 0 {
+		return t, fmt.Errorf("calibrate: mix sums to zero")
+	}
+	desc := append([]float64(nil), scores...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(desc)))
+	n := len(desc)
+	var cum float64
+	for k := 0; k < 4; k++ {
+		cum += mix[k] / sum
+		c := int(math.Round(cum * float64(n)))
+		// desc[c-1] is the last score inside levels 1..k+1 and desc[c] the
+		// first outside; 1 and 0 bound the ends.
+		above, below := 1.0, 0.0
+		if c > 0 {
+			above = desc[c-1]
+		}
+		if c < n {
+			below = desc[c]
+		}
+		t[k] = (above + below) / 2
+	}
+	for k := 0; k < 4; k++ {
+		if t[k] <= 0 || t[k] > 1 || (k > 0 && t[k] >= t[k-1]) {
+			return t, fmt.Errorf("calibrate: mix is not achievable with these scores: thresholds %v", t)
+		}
+	}
+	return t, nil
+}
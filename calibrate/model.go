@@ -0,0 +1,52 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package calibrate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/olaflaitinen/triagegeist"
+	"github.com/olaflaitinen/triagegeist/model"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// ModelFit is the result of FitModel.
+type ModelFit struct {
+	Model    model.BoostedStumps
+	Fitted   Diagnostics // Most probable level of Model
+	Baseline Diagnostics // Levels of the base Params
+}
+
+// FitModel fits a model.BoostedStumps ensemble to the reference levels
+// (1..5) of the given records, on the standard feature vector
+// model.FeaturesOf(v, resourceCount, base.MaxResources). Baseline is the
+// agreement of base on the same records, so the learned model can be
+// weighed against the formula. Model serialises with encoding/json; store
+// it beside the site's parameters. base must be valid. Zero opt fields take
+// the model.DefaultBoostOptions.
+func FitModel(base triagegeist.Params, vitals []score.Vitals, resourceCounts, reference []int, opt model.BoostOptions) (ModelFit, error) {
+	if err := checkData(vitals, resourceCounts, reference); err != nil {
+		return ModelFit{}, err
+	}
+	if errs := base.ValidateDetailed(); len(errs) > 0 {
+		return ModelFit{}, fmt.Errorf("calibrate: base params: %w", errors.Join(errs...))
+	}
+	eng := triagegeist.NewEngine(base)
+	x := make([]model.Features, len(vitals))
+	baseline := make([]int, len(vitals))
+	for i, v := range vitals {
+		x[i] = model.FeaturesOf(v, resourceCounts[i], base.MaxResources)
+		baseline[i] = eng.Level(v, resourceCounts[i]).Int()
+	}
+	m, ok := model.FitBoostedStumps(x, reference, opt)
+	if !ok {
+		return ModelFit{}, errors.New("calibrate: model: fewer than two distinct reference levels")
+	}
+	return ModelFit{
+		Model:    m,
+		Fitted:   agreement(model.PredictLevels(m, x), reference),
+		Baseline: agreement(baseline, reference),
+	}, nil
+}
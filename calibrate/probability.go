@@ -0,0 +1,140 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package calibrate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/olaflaitinen/triagegeist"
+)
+
+// plattIterations bounds the Newton iterations of FitPlatt; it converges
+// in well under ten on realistic data.
+const plattIterations = 50
+
+// checkOutcomes validates scores and binary outcomes for the probability
+// fits and returns the number of positive outcomes.
+func checkOutcomes(scores []float64, outcomes []int) (int, error) {
+	if len(scores) == 0 {
+		return 0, ErrNoData
+	}
+	if len(outcomes) != len(scores) {
+		return 0, fmt.Errorf("calibrate: length mismatch: %d scores, %d outcomes", len(scores), len(outcomes))
+	}
+	var pos int
+	for i, y := range outcomes {
+		if y != 0 && y != 1 {
+			return 0, fmt.Errorf("calibrate: record %d: outcome %d not 0 or 1", i, y)
+		}
+		if math.IsNaN(scores[i]) || math.IsInf(scores[i], 0) {
+			return 0, fmt.Errorf("calibrate: record %d: score %v is not finite", i, scores[i])
+		}
+		pos += y
+	}
+	if pos == 0 || pos == len(outcomes) {
+		return 0, fmt.Errorf("calibrate: outcomes contain only one class")
+	}
+	return pos, nil
+}
+
+// FitPlatt fits Platt scaling, p = 1 / (1 + e^-(A·s + B)), of acuity
+// scores to binary high-acuity outcomes (0 or 1) by maximum likelihood
+// (Newton's method), with Platt's smoothed targets (N+ + 1) / (N+ + 2) and
+// 1 / (N- + 2) against overfitting small cohorts. Store the result in
+// Params.Calibration. Outcomes must contain both classes.
+func FitPlatt(scores []float64, outcomes []int) (triagegeist.ProbabilityCalibration, error) {
+	pos, err := checkOutcomes(scores, outcomes)
+	if err != nil {
+		return triagegeist.ProbabilityCalibration{}, err
+	}
+	neg := len(outcomes) - pos
+	hi := (float64(pos) + 1) / (float64(pos) + 2)
+	lo := 1 / (float64(neg) + 2)
+	a, b := 0.0, math.Log((float64(pos)+1)/(float64(neg)+1))
+	for it := 0; it < plattIterations; it++ {
+		// Gradient and Hessian of the negative log-likelihood.
+		var ga, gb, haa, hab, hbb float64
+		for i, s := range scores {
+			t := lo
+			if outcomes[i] == 1 {
+				t = hi
+			}
+			p := 1 / (1 + math.Exp(-(a*s + b)))
+			d := p - t
+			w := math.Max(p*(1-p), 1e-12)
+			ga += d * s
+			gb += d
+			haa += w * s * s
+			hab += w * s
+			hbb += w
+		}
+		haa += 1e-12
+		hbb += 1e-12
+		det := haa*hbb - hab*hab
+		if det <= 0 {
+			break
+		}
+		da := (hbb*ga - hab*gb) / det
+		db := (haa*gb - hab*ga) / det
+		a, b = a-da, b-db
+		if math.Abs(da) < 1e-10 && math.Abs(db) < 1e-10 {
+			break
+		}
+	}
+	return triagegeist.ProbabilityCalibration{Method: triagegeist.CalibrationPlatt, A: a, B: b, N: len(scores)}, nil
+}
+
+// FitIsotonic fits isotonic regression of binary high-acuity outcomes (0 or
+// 1) on acuity scores by pool-adjacent-violators: the non-decreasing step
+// function closest to the outcomes in least squares. Each pooled block
+// becomes knots at its lowest and highest score, between which
+// ProbabilityCalibration interpolates linearly. Unlike FitPlatt it assumes
+// no shape beyond monotonicity, so it needs more data. Store the result in
+// Params.Calibration. Outcomes must contain both classes.
+func FitIsotonic(scores []float64, outcomes []int) (triagegeist.ProbabilityCalibration, error) {
+	if _, err := checkOutcomes(scores, outcomes); err != nil {
+		return triagegeist.ProbabilityCalibration{}, err
+	}
+	idx := make([]int, len(scores))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool { return scores[idx[i]] < scores[idx[j]] })
+
+	// Blocks start with ties pooled, then merge while they violate order.
+	type block struct {
+		lo, hi float64 // lowest and highest score
+		sum, n float64 // outcome sum and count
+	}
+	var blocks []block
+	for _, i := range idx {
+		s, y := scores[i], float64(outcomes[i])
+		if k := len(blocks) - 1; k >= 0 && blocks[k].hi == s {
+			blocks[k].sum += y
+			blocks[k].n++
+		} else {
+			blocks = append(blocks, block{lo: s, hi: s, sum: y, n: 1})
+		}
+		for k := len(blocks) - 1; k > 0 && blocks[k-1].sum/blocks[k-1].n >= blocks[k].sum/blocks[k].n; k-- {
+			blocks[k-1].hi = blocks[k].hi
+			blocks[k-1].sum += blocks[k].sum
+			blocks[k-1].n += blocks[k].n
+			blocks = blocks[:k]
+		}
+	}
+
+	c := triagegeist.ProbabilityCalibration{Method: triagegeist.CalibrationIsotonic, N: len(scores)}
+	for _, b := range blocks {
+		y := b.sum / b.n
+		c.X = append(c.X, b.lo)
+		c.Y = append(c.Y, y)
+		if b.hi > b.lo {
+			c.X = append(c.X, b.hi)
+			c.Y = append(c.Y, y)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,36 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package calibrate
+
+import (
+	"github.com/olaflaitinen/triagegeist"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// FitReferenceDistribution summarises reference acuity scores as a compact
+// quantile sketch (triagegeist.DefaultReferencePoints quantiles, whatever
+// the cohort size). Store it in Params.Reference so it travels with the
+// parameters it was fitted under; engines then report percentile ranks
+// against it. Returns ErrNoData if scores holds no non-NaN value.
+func FitReferenceDistribution(scores []float64) (triagegeist.ReferenceDistribution, error) {
+	d := triagegeist.NewReferenceDistribution(scores, triagegeist.DefaultReferencePoints)
+	if !d.Valid() {
+		return triagegeist.ReferenceDistribution{}, ErrNoData
+	}
+	return d, nil
+}
+
+// ReferenceScores returns the acuity of each record under p, for
+// FitReferenceDistribution. It returns nil if the lengths differ.
+func ReferenceScores(p triagegeist.Params, vitals []score.Vitals, resourceCounts []int) []float64 {
+	if len(vitals) != len(resourceCounts) {
+		return nil
+	}
+	eng := triagegeist.NewEngine(p)
+	out := make([]float64, len(vitals))
+	for i, v := range vitals {
+		out[i] = eng.Acuity(v, resourceCounts[i])
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package calibrate
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/olaflaitinen/triagegeist"
+)
+
+// MaxSampleAttempts bounds the draws SampleParams makes per requested
+// parameter set before giving up on bounds that are (nearly) unsatisfiable.
+const MaxSampleAttempts = 1000
+
+// SampleParams draws n parameter sets from base with every bounded weight
+// and threshold drawn uniformly from its Range, seeded for
+// reproducibility, for robustness studies: score a cohort under each and
+// look at the spread of the cohort-level results. Parameters with a zero
+// Range keep their base value. Draws that violate MinGap, WeightOrder or
+// Params.Validate are rejected and redrawn, so every result is valid and
+// within bounds. It is an error if base or bounds are invalid, n is
+// negative, or fewer than n draws in n*MaxSampleAttempts are accepted.
+func SampleParams(base triagegeist.Params, bounds ParamBounds, n int, seed int64) ([]triagegeist.Params, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("calibrate: n must be non-negative, got %d", n)
+	}
+	if !base.Validate() {
+		return nil, fmt.Errorf("calibrate: base params are invalid")
+	}
+	if err := bounds.Validate(); err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(seed))
+	draw := func(r Range, x float64) float64 {
+		if r.unbounded() {
+			return x
+		}
+		return r.Min + rng.Float64()*(r.Max-r.Min)
+	}
+	out := make([]triagegeist.Params, 0, n)
+	for attempts := 0; len(out) < n; attempts++ {
+		if attempts == n*MaxSampleAttempts {
+			return nil, fmt.Errorf("calibrate: only %d of %d samples satisfied the bounds in %d draws", len(out), n, attempts)
+		}
+		p := base
+		for i, r := range bounds.VitalWeights {
+			p.VitalWeights[i] = draw(r, p.VitalWeights[i])
+		}
+		p.ResourceWeight = draw(bounds.ResourceWeight, p.ResourceWeight)
+		t := p.Thresholds()
+		for k, r := range bounds.Thresholds {
+			t[k] = draw(r, t[k])
+		}
+		p.SetThresholds(t[0], t[1], t[2], t[3])
+		if p.Validate() && bounds.Contains(p) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
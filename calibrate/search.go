@@ -0,0 +1,194 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package calibrate
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/olaflaitinen/triagegeist"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// MaxGridSize is the largest number of combinations GridSearch enumerates.
+const MaxGridSize = 1_000_000
+
+// ObjectiveFunc scores predicted against reference levels; higher is
+// better. metrics.WeightedKappa is one.
+type ObjectiveFunc func(pred, ref []int) float64
+
+// Space lists candidate values per parameter. A nil or empty list keeps
+// the base value. Thresholds holds whole [T1, T2, T3, T4] sets, since the
+// four must stay ordered.
+type Space struct {
+	VitalWeights   [7][]float64
+	ResourceWeight []float64
+	Thresholds     [][4]float64
+	// Bounds, if non-nil, skips combinations outside it like invalid ones.
+	Bounds *ParamBounds
+}
+
+// dims returns the list lengths of s, one per searched dimension (0 for
+// a dimension that keeps the base value).
+func (s Space) dims() [9]int {
+	var d [9]int
+	for i, w := range s.VitalWeights {
+		d[i] = len(w)
+	}
+	d[7] = len(s.ResourceWeight)
+	d[8] = len(s.Thresholds)
+	return d
+}
+
+// Size returns the number of combinations in s.
+func (s Space) Size() int {
+	n := 1
+	for _, d := range s.dims() {
+		if d > 0 {
+			if n > MaxGridSize/d {
+				return MaxGridSize + 1
+			}
+			n *= d
+		}
+	}
+	return n
+}
+
+// apply returns base with the values at idx applied.
+func (s Space) apply(base triagegeist.Params, idx [9]int) triagegeist.Params {
+	p := base
+	for i, w := range s.VitalWeights {
+		if len(w) > 0 {
+			p.VitalWeights[i] = w[idx[i]]
+		}
+	}
+	if len(s.ResourceWeight) > 0 {
+		p.ResourceWeight = s.ResourceWeight[idx[7]]
+	}
+	if len(s.Thresholds) > 0 {
+		t := s.Thresholds[idx[8]]
+		p.SetThresholds(t[0], t[1], t[2], t[3])
+	}
+	return p
+}
+
+// Candidate is one evaluated parameter set.
+type Candidate struct {
+	Params triagegeist.Params
+	Score  float64
+}
+
+// GridSearch evaluates every combination in space applied to base and
+// returns the k best by objective, best first (ties keep enumeration
+// order). Invalid combinations are skipped. It is an error if the space
+// has more than MaxGridSize combinations.
+func GridSearch(base triagegeist.Params, space Space, vitals []score.Vitals, resourceCounts, reference []int, objective ObjectiveFunc, k int) ([]Candidate, error) {
+	if err := checkSearch(vitals, resourceCounts, reference, objective, k); err != nil {
+		return nil, err
+	}
+	if space.Bounds != nil {
+		if err := space.Bounds.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if n := space.Size(); n > MaxGridSize {
+		return nil, fmt.Errorf("calibrate: grid has more than %d combinations", MaxGridSize)
+	}
+	dims := space.dims()
+	var idx [9]int
+	top := &topK{k: k}
+	for {
+		top.offer(evaluate(space.apply(base, idx), space.Bounds, vitals, resourceCounts, reference, objective))
+		// Advance the mixed-radix counter over the non-empty dimensions.
+		i := 0
+		for ; i < len(idx); i++ {
+			if dims[i] == 0 {
+				continue
+			}
+			idx[i]++
+			if idx[i] < dims[i] {
+				break
+			}
+			idx[i] = 0
+		}
+		if i == len(idx) {
+			break
+		}
+	}
+	return top.items, nil
+}
+
+// RandomSearch evaluates n combinations drawn uniformly (with replacement)
+// from space, seeded for reproducibility, and returns the k best by
+// objective, best first. Invalid combinations are skipped.
+func RandomSearch(base triagegeist.Params, space Space, n int, seed int64, vitals []score.Vitals, resourceCounts, reference []int, objective ObjectiveFunc, k int) ([]Candidate, error) {
+	if err := checkSearch(vitals, resourceCounts, reference, objective, k); err != nil {
+		return nil, err
+	}
+	if space.Bounds != nil {
+		if err := space.Bounds.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	rng := rand.New(rand.NewSource(seed))
+	dims := space.dims()
+	top := &topK{k: k}
+	for j := 0; j < n; j++ {
+		var idx [9]int
+		for i, d := range dims {
+			if d > 0 {
+				idx[i] = rng.Intn(d)
+			}
+		}
+		top.offer(evaluate(space.apply(base, idx), space.Bounds, vitals, resourceCounts, reference, objective))
+	}
+	return top.items, nil
+}
+
+func checkSearch(vitals []score.Vitals, resourceCounts, reference []int, objective ObjectiveFunc, k int) error {
+	if err := checkData(vitals, resourceCounts, reference); err != nil {
+		return err
+	}
+	if objective == nil {
+		return fmt.Errorf("calibrate: nil objective")
+	}
+	if k <= 0 {
+		return fmt.Errorf("calibrate: k must be positive, got %d", k)
+	}
+	return nil
+}
+
+// evaluate scores p on the data; ok is false if p is invalid or outside
+// bounds (if non-nil).
+func evaluate(p triagegeist.Params, bounds *ParamBounds, vitals []score.Vitals, resourceCounts, reference []int, objective ObjectiveFunc) (Candidate, bool) {
+	if !p.Validate() || (bounds != nil && !bounds.Contains(p)) {
+		return Candidate{}, false
+	}
+	eng := triagegeist.NewEngine(p)
+	pred := make([]int, len(vitals))
+	for i, v := range vitals {
+		pred[i] = eng.Level(v, resourceCounts[i]).Int()
+	}
+	return Candidate{Params: p, Score: objective(pred, reference)}, true
+}
+
+// topK keeps the k highest-scoring candidates, best first.
+type topK struct {
+	k     int
+	items []Candidate
+}
+
+func (t *topK) offer(c Candidate, ok bool) {
+	if !ok || (len(t.items) == t.k && c.Score <= t.items[t.k-1].Score) {
+		return
+	}
+	i := sort.Search(len(t.items), func(i int) bool { return t.items[i].Score < c.Score })
+	t.items = append(t.items, Candidate{})
+	copy(t.items[i+1:], t.items[i:])
+	t.items[i] = c
+	if len(t.items) > t.k {
+		t.items = t.items[:t.k]
+	}
+}
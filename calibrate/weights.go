@@ -0,0 +1,241 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package calibrate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/olaflaitinen/triagegeist"
+	"github.com/olaflaitinen/triagegeist/metrics"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// WeightOptions controls FitWeights.
+//
+//	| Field        | Default | Meaning                                      |
+//	|--------------|---------|----------------------------------------------|
+//	| Iterations   | 2000    | Full-batch projected gradient steps          |
+//	| LearningRate | 0.5     | Step size on the mean log-likelihood         |
+//	| L2           | 0.001   | Ridge penalty on the coefficients            |
+//	| Folds        | 5       | Cross-validation folds (< 2 disables CV)     |
+//	| Seed         | 1       | Shuffle seed for fold assignment             |
+//	| Bounds       | nil     | Weight bounds (see ParamBounds)              |
+type WeightOptions struct {
+	Iterations   int
+	LearningRate float64
+	L2           float64
+	Folds        int
+	Seed         int64
+	// Bounds, if non-nil, constrains the fitted weights: they are clamped
+	// into its weight ranges and WeightOrder is enforced. Its threshold
+	// bounds are not checked, since the thresholds are not fitted.
+	Bounds *ParamBounds
+}
+
+// DefaultWeightOptions returns the defaults above.
+func DefaultWeightOptions() WeightOptions {
+	return WeightOptions{Iterations: 2000, LearningRate: 0.5, L2: 0.001, Folds: 5, Seed: 1}
+}
+
+// WeightFit is the result of FitWeights.
+type WeightFit struct {
+	// Params is base with VitalWeights and ResourceWeight replaced. The
+	// thresholds are unchanged; refit them with FitThresholds.
+	Params triagegeist.Params
+	// Coef holds the logistic coefficients of the seven deviations and the
+	// resource ratio; Intercept is the unconstrained bias.
+	Coef      [8]float64
+	Intercept float64
+	// TrainAUC and BaselineAUC are the in-sample AUCs of the acuity score
+	// under the fitted and the base weights.
+	TrainAUC    float64
+	BaselineAUC float64
+	// FoldAUC holds the held-out AUC of each cross-validation fold, of the
+	// acuity score under the Params fitted on the other folds, and CVAUC
+	// their mean; both are empty/0 if CV is disabled.
+	FoldAUC []float64
+	CVAUC   float64
+}
+
+// HighAcuityOutcomes returns 1 for reference levels 1..maxLevel and 0
+// otherwise, e.g. maxLevel 2 for "resuscitation or emergent".
+func HighAcuityOutcomes(reference []int, maxLevel int) []int {
+	out := make([]int, len(reference))
+	for i, l := range reference {
+		if l >= 1 && l <= maxLevel {
+			out[i] = 1
+		}
+	}
+	return out
+}
+
+// FitWeights fits VitalWeights and ResourceWeight of base to binary
+// high-acuity outcomes (0 or 1) by L2-regularised logistic regression on
+// the score's inputs: the seven deviations as base scores them (see
+// Params.ScoreOptions and score.DeviationsWithOptions; 0 for a missing
+// vital) and the resource ratio under base.ResourceScale and ResourceRate.
+// Coefficients are constrained to be non-negative, since a larger deviation
+// must not lower acuity. The vital coefficients are scaled so the largest
+// becomes 1; since the vital component is their weighted mean, the
+// resource coefficient maps to ResourceWeight divided by the vital
+// coefficients' sum. base must be valid and outcomes must contain both
+// classes. With opt.Bounds the weights are then projected onto the bounds;
+// it is an error if they still violate them.
+func FitWeights(base triagegeist.Params, vitals []score.Vitals, resourceCounts, outcomes []int, opt WeightOptions) (WeightFit, error) {
+	if len(vitals) == 0 {
+		return WeightFit{}, ErrNoData
+	}
+	if len(resourceCounts) != len(vitals) || len(outcomes) != len(vitals) {
+		return WeightFit{}, fmt.Errorf("calibrate: length mismatch: %d vitals, %d resource counts, %d outcomes",
+			len(vitals), len(resourceCounts), len(outcomes))
+	}
+	var pos int
+	for i, y := range outcomes {
+		if y != 0 && y != 1 {
+			return WeightFit{}, fmt.Errorf("calibrate: record %d: outcome %d not 0 or 1", i, y)
+		}
+		pos += y
+	}
+	if pos == 0 || pos == len(outcomes) {
+		return WeightFit{}, fmt.Errorf("calibrate: outcomes contain only one class")
+	}
+	if !base.Validate() {
+		return WeightFit{}, fmt.Errorf("calibrate: base params are invalid")
+	}
+	if opt.Bounds != nil {
+		if err := opt.Bounds.Validate(); err != nil {
+			return WeightFit{}, err
+		}
+	}
+	def := DefaultWeightOptions()
+	if opt.Iterations <= 0 {
+		opt.Iterations = def.Iterations
+	}
+	if opt.LearningRate <= 0 {
+		opt.LearningRate = def.LearningRate
+	}
+	if opt.L2 < 0 {
+		opt.L2 = def.L2
+	}
+
+	x := make([][8]float64, len(vitals))
+	o := base.ScoreOptions()
+	for i, v := range vitals {
+		d := score.DeviationsWithOptions(v, o)
+		copy(x[i][:7], d[:])
+		x[i][7] = score.ScaleResourcesRate(resourceCounts[i], base.MaxResources, base.ResourceScale, base.ResourceRate)
+	}
+
+	var err error
+	fit := WeightFit{}
+	fit.Coef, fit.Intercept = fitLogistic(x, outcomes, nil, opt)
+	if fit.Params, err = coefParams(base, fit.Coef, opt.Bounds); err != nil {
+		return WeightFit{}, err
+	}
+	fit.TrainAUC = metrics.AUC(ReferenceScores(fit.Params, vitals, resourceCounts), outcomes)
+	fit.BaselineAUC = metrics.AUC(ReferenceScores(base, vitals, resourceCounts), outcomes)
+
+	if opt.Folds >= 2 && opt.Folds <= len(vitals) {
+		fold := make([]int, len(vitals))
+		for i, j := range rand.New(rand.NewSource(opt.Seed)).Perm(len(vitals)) {
+			fold[j] = i % opt.Folds
+		}
+		for k := 0; k < opt.Folds; k++ {
+			train := make([]bool, len(vitals))
+			for i := range train {
+				train[i] = fold[i] != k
+			}
+			coef, _ := fitLogistic(x, outcomes, train, opt)
+			p, err := coefParams(base, coef, opt.Bounds)
+			if err != nil {
+				return WeightFit{}, fmt.Errorf("calibrate: fold %d: %w", k, err)
+			}
+			var vs []score.Vitals
+			var rcs, ys []int
+			for i := range x {
+				if !train[i] {
+					vs, rcs, ys = append(vs, vitals[i]), append(rcs, resourceCounts[i]), append(ys, outcomes[i])
+				}
+			}
+			fit.FoldAUC = append(fit.FoldAUC, metrics.AUC(ReferenceScores(p, vs, rcs), ys))
+		}
+		for _, a := range fit.FoldAUC {
+			fit.CVAUC += a
+		}
+		fit.CVAUC /= float64(len(fit.FoldAUC))
+	}
+	return fit, nil
+}
+
+// coefParams returns base with VitalWeights and ResourceWeight set from the
+// logistic coefficients coef (see FitWeights), projected onto bounds if it
+// is non-nil. If every vital coefficient is 0, the weights of base are
+// kept.
+func coefParams(base triagegeist.Params, coef [8]float64, bounds *ParamBounds) (triagegeist.Params, error) {
+	p := base
+	var max, sum float64
+	for _, c := range coef[:7] {
+		max, sum = math.Max(max, c), sum+c
+	}
+	if max > 0 {
+		for i := 0; i < 7; i++ {
+			p.VitalWeights[i] = coef[i] / max
+		}
+		p.ResourceWeight = coef[7] / sum
+	}
+	if bounds != nil {
+		p = bounds.projectWeights(p)
+		if errs := bounds.checkWeights(p); len(errs) > 0 {
+			return triagegeist.Params{}, fmt.Errorf("calibrate: bounds cannot be met: %w", errors.Join(errs...))
+		}
+	}
+	return p, nil
+}
+
+// fitLogistic fits non-negative coefficients and a free intercept by
+// projected gradient ascent on the mean penalised log-likelihood of the rows
+// with use[i] true (all rows if use is nil).
+func fitLogistic(x [][8]float64, y []int, use []bool, opt WeightOptions) ([8]float64, float64) {
+	var coef [8]float64
+	var b float64
+	var n float64
+	for i := range x {
+		if use == nil || use[i] {
+			n++
+		}
+	}
+	if n == 0 {
+		return coef, 0
+	}
+	for it := 0; it < opt.Iterations; it++ {
+		var g [8]float64
+		var gb float64
+		for i, f := range x {
+			if use != nil && !use[i] {
+				continue
+			}
+			r := float64(y[i]) - 1/(1+math.Exp(-linear(coef, b, f)))
+			for j, v := range f {
+				g[j] += r * v
+			}
+			gb += r
+		}
+		for j := range coef {
+			coef[j] = math.Max(0, coef[j]+opt.LearningRate*(g[j]/n-opt.L2*coef[j]))
+		}
+		b += opt.LearningRate * gb / n
+	}
+	return coef, b
+}
+
+func linear(coef [8]float64, b float64, f [8]float64) float64 {
+	z := b
+	for j, v := range f {
+		z += coef[j] * v
+	}
+	return z
+}
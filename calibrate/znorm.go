@@ -0,0 +1,51 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package calibrate
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/olaflaitinen/triagegeist/score"
+	"github.com/olaflaitinen/triagegeist/stats"
+)
+
+// MinZRecords is the number of present values a vital needs for FitZNorms
+// to estimate its distribution.
+const MinZRecords = 30
+
+// FitZNorms estimates each vital's population mean and sample standard
+// deviation from the site's records, for Params.ZScores, with the given
+// saturation (0 for score.DefaultZSaturation). Only present values count.
+// A vital with fewer than MinZRecords present values, or no spread, is left
+// zero and keeps its norm. Fit on patients representative of the normal
+// presentation (e.g. the least acute levels): the z-score measures
+// distance from their mean. Returns ErrNoData if vitals is empty.
+func FitZNorms(vitals []score.Vitals, saturation float64) ([7]score.ZNorm, error) {
+	var z [7]score.ZNorm
+	if len(vitals) == 0 {
+		return z, ErrNoData
+	}
+	if saturation != 0 && !(saturation > 0 && !math.IsInf(saturation, 0)) {
+		return z, fmt.Errorf("calibrate: z saturation %v must be 0 or finite and > 0", saturation)
+	}
+	var cols [7][]float64
+	for _, v := range vitals {
+		x := score.VitalsToValues(v)
+		for i, ok := range score.Present(v) {
+			if ok {
+				cols[i] = append(cols[i], x[i])
+			}
+		}
+	}
+	for i, c := range cols {
+		if len(c) < MinZRecords {
+			continue
+		}
+		if sd := stats.StdDev(c); sd > 0 {
+			z[i] = score.ZNorm{Mean: stats.Mean(c), SD: sd, Saturation: saturation}
+		}
+	}
+	return z, nil
+}
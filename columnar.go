@@ -0,0 +1,110 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"fmt"
+
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// VitalColumns holds vitals for many records as parallel columns (struct
+// of arrays), entry i of each column belonging to record i, as read from a
+// columnar store. A nil column means that vital is missing for every
+// record; a non-nil column must have one entry per record. Values follow
+// score.Vitals: 0 is unknown, GCS is the total.
+type VitalColumns struct {
+	HR   []int
+	RR   []int
+	SBP  []int
+	DBP  []int
+	Temp []float64
+	SpO2 []int
+	GCS  []int
+
+	OnOxygen []bool
+	FiO2     []float64
+}
+
+// check returns an error unless every non-nil column has n entries.
+func (c VitalColumns) check(n int) error {
+	cols := [...]struct {
+		name string
+		len  int
+		set  bool
+	}{
+		{"HR", len(c.HR), c.HR != nil},
+		{"RR", len(c.RR), c.RR != nil},
+		{"SBP", len(c.SBP), c.SBP != nil},
+		{"DBP", len(c.DBP), c.DBP != nil},
+		{"Temp", len(c.Temp), c.Temp != nil},
+		{"SpO2", len(c.SpO2), c.SpO2 != nil},
+		{"GCS", len(c.GCS), c.GCS != nil},
+		{"OnOxygen", len(c.OnOxygen), c.OnOxygen != nil},
+		{"FiO2", len(c.FiO2), c.FiO2 != nil},
+	}
+	for _, col := range cols {
+		if col.set && col.len != n {
+			return fmt.Errorf("triagegeist: columnar: %s has %d entries, want %d", col.name, col.len, n)
+		}
+	}
+	return nil
+}
+
+// row returns record i as score.Vitals.
+func (c VitalColumns) row(i int) score.Vitals {
+	var v score.Vitals
+	if c.HR != nil {
+		v.HR = c.HR[i]
+	}
+	if c.RR != nil {
+		v.RR = c.RR[i]
+	}
+	if c.SBP != nil {
+		v.SBP = c.SBP[i]
+	}
+	if c.DBP != nil {
+		v.DBP = c.DBP[i]
+	}
+	if c.Temp != nil {
+		v.Temp = c.Temp[i]
+	}
+	if c.SpO2 != nil {
+		v.SpO2 = c.SpO2[i]
+	}
+	if c.GCS != nil {
+		v.GCS = c.GCS[i]
+	}
+	if c.OnOxygen != nil {
+		v.OnOxygen = c.OnOxygen[i]
+	}
+	if c.FiO2 != nil {
+		v.FiO2 = c.FiO2[i]
+	}
+	return v
+}
+
+// BatchAcuityColumnar writes the acuity of record i of cols, with
+// resourceCounts[i], to out[i], scoring like Acuity. The record count is
+// len(resourceCounts); it fails if a column or out has another length.
+//
+// It is meant for scoring tens of millions of historical records: it does
+// not allocate per record, and it bypasses the engine's Cache and per-call
+// Instruments latency (the batch size is still recorded). Reuse out across
+// calls.
+func (e *Engine) BatchAcuityColumnar(cols VitalColumns, resourceCounts []int, out []float64) error {
+	n := len(resourceCounts)
+	if err := cols.check(n); err != nil {
+		return err
+	}
+	if len(out) != n {
+		return fmt.Errorf("triagegeist: columnar: out has %d entries, want %d", len(out), n)
+	}
+	e.Instruments.observeBatch(n)
+	o := e.scoreOptions(e.P)
+	for i, rc := range resourceCounts {
+		out[i] = score.AcuityWithOptions(cols.row(i), rc, e.P.MaxResources, e.P.VitalWeights, e.P.ResourceWeight, o)
+	}
+	return nil
+}
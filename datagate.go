@@ -0,0 +1,93 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// DataRequirement is the minimum data an evaluation needs before its score
+// is reported (see Params.MinimumData). A score from one mildly abnormal
+// vital is misleadingly low for a patient whose other vitals are unknown;
+// below the requirement the Evaluate methods report an insufficient-data
+// outcome instead. The zero value requires nothing.
+type DataRequirement struct {
+	// MinPresent is the number of vitals that must be measured, in [0, 7].
+	MinPresent int
+	// AnyOf, if any entry is set, requires at least one of the marked
+	// vitals (VitalWeights order) to be measured.
+	AnyOf [7]bool
+}
+
+// DefaultDataRequirement returns the suggested requirement: at least three
+// vitals, including one of RR, SpO2 and GCS.
+func DefaultDataRequirement() DataRequirement {
+	return DataRequirement{MinPresent: 3, AnyOf: [7]bool{1: true, 5: true, 6: true}}
+}
+
+// IsZero returns true if r requires nothing.
+func (r DataRequirement) IsZero() bool {
+	return r == DataRequirement{}
+}
+
+// Valid returns true if MinPresent is in [0, 7].
+func (r DataRequirement) Valid() bool {
+	return r.MinPresent >= 0 && r.MinPresent <= 7
+}
+
+// Unmet returns why the measured vitals (in VitalWeights order) fall short
+// of r, e.g. "2 of 3 vitals; none of rr, spo2, gcs", or "" if r is met.
+func (r DataRequirement) Unmet(measured [7]bool) string {
+	var n int
+	var any, want bool
+	var names []string
+	for i, ok := range measured {
+		if ok {
+			n++
+		}
+		if r.AnyOf[i] {
+			want = true
+			any = any || ok
+			names = append(names, Field(i).String())
+		}
+	}
+	var why []string
+	if n < r.MinPresent {
+		why = append(why, fmt.Sprintf("%d of %d vitals", n, r.MinPresent))
+	}
+	if want && !any {
+		why = append(why, "none of "+strings.Join(names, ", "))
+	}
+	return strings.Join(why, "; ")
+}
+
+// measuredVitals returns the vitals of r that were measured: present and not
+// imputed, or measured as 0.
+func (r EvaluateResult) measuredVitals() [7]bool {
+	m := score.Present(r.Vitals)
+	for i := range m {
+		m[i] = (m[i] && !r.Imputed[i]) || r.MeasuredZero[i]
+	}
+	return m
+}
+
+// gate applies p.MinimumData to r: if r's measured vitals fall short, it
+// clears the score and its level, candidates, percentile and probability,
+// and records the reason in r.Insufficient.
+func gate(r EvaluateResult, p Params) EvaluateResult {
+	if p.MinimumData.IsZero() {
+		return r
+	}
+	why := p.MinimumData.Unmet(r.measuredVitals())
+	if why == "" {
+		return r
+	}
+	r.Insufficient = why
+	r.Acuity, r.Level, r.Candidates, r.Deferred = 0, 0, [2]Level{}, false
+	r.Percentile, r.Probability = 0, 0
+	return r
+}
@@ -15,10 +15,26 @@
 //	|-----------|-------------------------------------------------------------------------|
 //	| score     | Acuity formula, Vitals struct, VitalComponent, ResourceComponent, Acuity, AcuityWithNorms, default norms and weights. |
 //	| norm      | Reference ranges (Ranges), Deviation, NormalizeLinear, ClampToRange, CriticalBounds, WeightedDeviationSum, DefaultRanges, PediatricRanges. |
-//	| metrics   | ConfusionMatrix, TP/FP/FN/TN, Sensitivity, Specificity, PPV, NPV, F1, CohenKappa, BinaryCM, AUC, CalibrationError, WeightedKappa. |
-//	| stats     | Mean, Variance, StdDev, SE, CI95, Median, Percentile, LevelDistribution, ScoreStats, LevelStats, RMSE, MAE, ExactAgreement, WithinLevel. |
-//	| validate  | Vitals validation (Vitals, ClampVitals, VitalsValid), ResourceCount, Params validation (ParamsLike, Params, ParamsValid), AtLeastOneVital. |
-//	| export    | Result struct, FromVitalsScoreLevel, ToJSON, CSVHeader, ToCSVRow, WriteCSV, Batch, LevelReport, ComputeSummary, ReadResultJSON, ResultToVitals. |
+//	| metrics   | ConfusionMatrix, TP/FP/FN/TN, Sensitivity, Specificity, PPV, NPV, F1, CohenKappa, BinaryCM, AUC, CalibrationError, WeightedKappa, AgreementByGroup. |
+//	| stats     | Mean, Variance, StdDev, SE, CI95, Median, Percentile, LevelDistribution, ScoreStats, LevelStats, RMSE, MAE, ExactAgreement, WithinLevel, KaplanMeier, LogRank, PCA, KMeans, Silhouette. |
+//	| validate  | Vitals validation (Vitals, ClampVitals, VitalsValid), ResourceCount, Params validation (ParamsChecker, Params, ParamsValid, ParamError), AtLeastOneVital, NormalizeBatch. |
+//	| export    | Result struct, FromVitalsScoreLevel, ToJSON, CSVHeader, ToCSVRow, WriteCSV, Batch, LevelReport, ComputeSummary, ReadResultJSON, ResultToVitals, Embed (deviation-space export). |
+//	| privacy   | Differential privacy: Laplace/Gaussian mechanisms, Budget accounting, NoisyLevelReport, NoisySummary. |
+//	| model     | Predictor interface, standard feature vector, OrdinalLogistic (proportional-odds) baseline and BoostedStumps ensemble, with fit and predict. |
+//	| calibrate | Fit site parameters from labelled data: FitThresholds (weighted kappa or under-triage objective) with diagnostics, FitReferenceDistribution, FitWeights, GridSearch, RandomSearch, ThresholdsFromMix, BootstrapFromAggregates for cold starts, ParamBounds to keep fits clinically plausible, and SampleParams for robustness studies. |
+//	| notify    | Localized escalation messages (plain text, HTML, FHIR CommunicationRequest) from configurable templates. |
+//	| ops       | Operational helpers: resus/majors/minors/fast-track zone assignment from configurable rules. |
+//	| similar   | k-nearest-neighbour retrieval of past cases (levels, outcomes) in deviation space. |
+//	| pipeline  | Configurable Pipeline (ingest CSV/FHIR, validate, score, metrics, report) with replaceable stages. |
+//	| v1        | Stable core API: own Engine, Params, Level, Vitals, EvaluateResult and Result types with conversions to the root types, Scorer interface, core constructors. |
+//
+// # API stability
+//
+// The root package and subpackages may add fields and functions in any
+// minor release; helpers and fields superseded by a newer API keep working
+// and are marked Deprecated for at least one minor release before removal.
+// The v1 package is the compatibility boundary: its types are defined there,
+// not aliased, and its names, fields and signatures do not change within v1.
 //
 // # Acuity score
 //
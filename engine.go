@@ -4,11 +4,15 @@
 package triagegeist
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/olaflaitinen/triagegeist/score"
 )
 
 // Engine evaluates acuity and level from vitals and resource count using
-// a fixed parameter set. Safe for concurrent use; no mutable state.
+// a fixed parameter set. Safe for concurrent use; the only mutable state is
+// the optional Cache, which is internally synchronised.
 //
 // All methods that take (vitals, resourceCount) use the engine's Params
 // for weights, thresholds, and maxResources. The engine does not modify
@@ -24,20 +28,80 @@ import (
 //	| BatchLevel          | []Level                   | Batch level only          |
 //	| Evaluate            | EvaluateResult            | Single with struct        |
 //	| BatchEvaluate       | []EvaluateResult          | Batch with struct         |
+//	| EvaluateWithContext | EvaluateResult            | Single, profile from ctx  |
+//	| Rescore             | EvaluateResult            | Partial update of a prior |
+//	| EvaluateMap         | (EvaluateResult, error)   | Map input, custom signals |
+//
+// Profiles selects the norm profile (adult, paediatric band, geriatric,
+// obstetric) per evaluation in EvaluateWithContext; nil means
+// DefaultProfileSelector. The other methods always use the package norms.
+// Staleness controls which prior vitals Rescore keeps. Cache, if non-nil,
+// memoizes Acuity for repeated identical inputs (see WithCache). Instruments,
+// if non-nil, records evaluation count, latency and batch sizes. Hooks run
+// around every evaluation (see WithHooks). Actions, if non-nil, overrides the
+// built-in wait targets and recommended actions (see WithActionPolicy).
+// Reference, if non-nil, overrides Params.Reference for percentile ranks.
+// Flags names the experimental behaviors enabled for this engine; they are
+// recorded in every EvaluateResult (see WithFlags). Imputer, if non-nil,
+// fills missing vitals after the hooks' BeforeEvaluate and before scoring
+// in the Evaluate family (see WithImputer). Registry, if non-nil, holds the
+// custom signals EvaluateMap accepts (see WithRegistry). Deviations, if
+// non-nil, replaces the deviation curve of selected vitals in every
+// evaluation (see WithDeviationFunc). Mode names the operating mode the
+// engine serves, e.g. the active SurgeMode, and is recorded in every
+// EvaluateResult; it does not affect scoring.
 type Engine struct {
-	P Params
+	P           Params
+	Profiles    ProfileSelector
+	Staleness   StalenessPolicy
+	Cache       *Cache
+	Instruments *Instruments
+	Hooks       []Hook
+	Actions     *ActionPolicy
+	Reference   *ReferenceDistribution
+	Flags       Flags
+	Imputer     Imputer
+	Registry    *score.Registry
+	Deviations  *[7]score.DeviationFunc
+	Mode        string
+
+	hashes *hashMemo
 }
 
 // NewEngine returns an engine with the given parameters. Use DefaultParams()
 // if no custom calibration is needed.
 func NewEngine(p Params) *Engine {
-	return &Engine{P: p}
+	return &Engine{P: p, hashes: &hashMemo{}}
 }
 
 // Acuity returns the normalized acuity score in [0, 1] for the given vitals
 // and resource count, using the engine's parameters.
 func (e *Engine) Acuity(v score.Vitals, resourceCount int) float64 {
-	return score.Acuity(v, resourceCount, e.P.MaxResources, e.P.VitalWeights, e.P.ResourceWeight)
+	if e.Instruments != nil {
+		defer e.Instruments.observe(time.Now())
+	}
+	if e.Cache == nil {
+		return e.acuity(v, resourceCount)
+	}
+	k := cacheKey{v: v, rc: resourceCount}
+	if a, ok := e.Cache.get(k); ok {
+		return a
+	}
+	a := e.acuity(v, resourceCount)
+	e.Cache.put(k, a)
+	return a
+}
+
+func (e *Engine) acuity(v score.Vitals, resourceCount int) float64 {
+	return score.AcuityWithOptions(v, resourceCount, e.P.MaxResources, e.P.VitalWeights, e.P.ResourceWeight, e.scoreOptions(e.P))
+}
+
+// AcuityGradient returns the derivative of Acuity with respect to each
+// vital at v, per unit of the vital (see score.AcuityGradient). Pass it to
+// score.MostInfluential with e.g. the norm half-widths to find the vital
+// that currently moves the score most.
+func (e *Engine) AcuityGradient(v score.Vitals, resourceCount int) [7]float64 {
+	return score.AcuityGradient(v, resourceCount, e.P.MaxResources, e.P.VitalWeights, e.P.ResourceWeight, e.scoreOptions(e.P))
 }
 
 // Level returns the discrete triage level (1 to 5) for the given vitals and
@@ -61,6 +125,7 @@ func (e *Engine) BatchScoreAndLevel(vitals []score.Vitals, resourceCounts []int)
 	if len(resourceCounts) != n {
 		return nil, nil
 	}
+	e.Instruments.observeBatch(n)
 	acuities = make([]float64, n)
 	levels = make([]Level, n)
 	for i := 0; i < n; i++ {
@@ -75,6 +140,7 @@ func (e *Engine) BatchAcuity(vitals []score.Vitals, resourceCounts []int) []floa
 	if len(resourceCounts) != n {
 		return nil
 	}
+	e.Instruments.observeBatch(n)
 	out := make([]float64, n)
 	for i := 0; i < n; i++ {
 		out[i] = e.Acuity(vitals[i], resourceCounts[i])
@@ -88,6 +154,7 @@ func (e *Engine) BatchLevel(vitals []score.Vitals, resourceCounts []int) []Level
 	if len(resourceCounts) != n {
 		return nil
 	}
+	e.Instruments.observeBatch(n)
 	out := make([]Level, n)
 	for i := 0; i < n; i++ {
 		out[i] = e.Level(vitals[i], resourceCounts[i])
@@ -101,8 +168,20 @@ func (e *Engine) Params() Params {
 }
 
 // WithParams returns a new Engine with the given params. The receiver is unchanged.
+// The new engine has no Cache, since cached scores belong to the old params.
 func (e *Engine) WithParams(p Params) *Engine {
-	return NewEngine(p)
+	c := *e
+	c.P = p
+	c.Cache = nil
+	return &c
+}
+
+// WithProfiles returns a new Engine that uses sel for profile selection. The
+// receiver is unchanged.
+func (e *Engine) WithProfiles(sel ProfileSelector) *Engine {
+	c := *e
+	c.Profiles = sel
+	return &c
 }
 
 // ScoreAndLevelWithResourceClamp evaluates ScoreAndLevel after clamping resourceCount
@@ -122,12 +201,307 @@ func (e *Engine) ScoreAndLevelWithResourceClamp(v score.Vitals, resourceCount in
 type EvaluateResult struct {
 	Acuity float64
 	Level  Level
+	// Profile is the norm profile name chosen by EvaluateWithContext; empty
+	// for evaluations that use the package norms.
+	Profile string
+	// Vitals and ResourceCount are the inputs of the evaluation, kept so
+	// that Rescore can apply partial updates.
+	Vitals        score.Vitals
+	ResourceCount int
+	// Context is the patient context given to EvaluateWithContext.
+	Context PatientContext
+	// Time is when the vitals were taken. Evaluate leaves it zero; set it
+	// to enable the engine's StalenessPolicy in Rescore. Rescore keeps
+	// prev's Time, the measurement time of the vitals it carries forward,
+	// unless they were stale and dropped.
+	Time time.Time
+	// RescoredAt is when Rescore last merged new vitals into the result;
+	// zero if it was never rescored. See EvaluatedAt.
+	RescoredAt time.Time
+	// Sources and Reliability record the measurement source of each vital
+	// and the weight factor applied for it by EvaluateWithSources. Other
+	// evaluations leave Reliability zero (no factors applied).
+	Sources     score.Sources
+	Reliability [7]float64
+	// ID and Breakdown are set by EvaluateDetailed.
+	ID        string
+	Breakdown *Breakdown
+	// ParamsName and ParamsVersion come from the Provenance of the Params
+	// used; ParamsHash is their computed Params.Hash, so it identifies the
+	// parameters actually scored with even if Provenance.Hash is stale.
+	ParamsName    string
+	ParamsVersion string
+	ParamsHash    string
+	// FormulaVersion is the acuity formula of the Params used (see
+	// Params.Formula).
+	FormulaVersion FormulaVersion
+	// Deferred is true when Acuity lies in the gray zone around a threshold
+	// (Params.GrayZone); the case must then go to clinician review and
+	// Candidates holds the two levels on either side, more acute first.
+	// Otherwise both Candidates equal Level.
+	Deferred   bool
+	Candidates [2]Level
+	// Percentile is the percentile rank of Acuity (0..100) against the
+	// engine's ReferenceDistribution or Params.Reference; 0 if neither is set.
+	Percentile float64
+	// Probability is the calibrated probability of a high-acuity outcome
+	// at Acuity under Params.Calibration; 0 if it is not set.
+	Probability float64
+	// Flags are the engine's feature flags at evaluation time, sorted; nil
+	// if none.
+	Flags []string
+	// Mode is the engine's Mode at evaluation time, e.g. the active
+	// SurgeMode; empty if none.
+	Mode string
+	// Imputed marks, in VitalWeights order, the vitals the engine's Imputer
+	// filled; Vitals holds the imputed values that were scored.
+	Imputed [7]bool
+	// Extended holds the extended signs given to EvaluateExtended.
+	Extended score.ExtendedVitals
+	// MeasuredZero marks, in VitalWeights order, the vitals given to
+	// EvaluateOpt as a measured 0; Vitals holds them as 0.
+	MeasuredZero [7]bool
+	// Custom holds the registered custom signals given to EvaluateMap.
+	Custom map[score.VitalName]float64
+	// Trend is the trend term added to the score by EvaluateTrend or
+	// Rescore (see Params.TrendWeights).
+	Trend float64
+	// Insufficient is non-empty when the measured vitals fall short of
+	// Params.MinimumData, and says why (e.g. "2 of 3 vitals"). Acuity,
+	// Level, Candidates, Percentile and Probability are then 0: the
+	// patient needs more observations, not a score.
+	Insufficient string
 }
 
 // Evaluate returns a single EvaluateResult.
 func (e *Engine) Evaluate(v score.Vitals, resourceCount int) EvaluateResult {
+	v, resourceCount = e.before(v, resourceCount)
+	v, imputed := e.impute(v)
 	a, l := e.ScoreAndLevel(v, resourceCount)
-	return EvaluateResult{Acuity: a, Level: l}
+	return e.finish(EvaluateResult{Acuity: a, Level: l, Vitals: v, ResourceCount: resourceCount, Imputed: imputed}, e.P)
+}
+
+// finish completes a scored result under p, in order: the gray-zone
+// decision, the engine's annotations, the minimum-data gate and the
+// AfterEvaluate hooks. Every method of the Evaluate family ends with it.
+func (e *Engine) finish(r EvaluateResult, p Params) EvaluateResult {
+	return e.after(gate(e.annotate(grayZone(r, p), p), p))
+}
+
+// EvaluateWithSources is like Evaluate but down-weights each vital by the
+// reliability of its source (see Params.Reliability and
+// score.Reliability.Factors). The sources and applied factors are recorded in
+// the result. Results are not cached.
+func (e *Engine) EvaluateWithSources(v score.Vitals, resourceCount int, src score.Sources) EvaluateResult {
+	if e.Instruments != nil {
+		defer e.Instruments.observe(time.Now())
+	}
+	v, resourceCount = e.before(v, resourceCount)
+	v, imputed := e.impute(v)
+	f := e.P.Reliability.Factors(src)
+	o := e.scoreOptions(e.P)
+	o.Reliability = &f
+	a := score.AcuityWithOptions(v, resourceCount, e.P.MaxResources, e.P.VitalWeights, e.P.ResourceWeight, o)
+	return e.finish(EvaluateResult{
+		Acuity:        a,
+		Level:         FromScore(a, e.P),
+		Vitals:        v,
+		ResourceCount: resourceCount,
+		Sources:       src,
+		Reliability:   f,
+		Imputed:       imputed,
+	}, e.P)
+}
+
+// EvaluateExtended is like Evaluate but also scores the extended signs in
+// x with Params.ExtendedWeights (see score.Options.Extended) and records x
+// in the result. With all ExtendedWeights 0 it scores like Evaluate.
+// Results are not cached.
+func (e *Engine) EvaluateExtended(v score.Vitals, x score.ExtendedVitals, resourceCount int) EvaluateResult {
+	return e.evaluateOptions(v, resourceCount, evalExtras{extended: x})
+}
+
+// EvaluateOpt is like Evaluate for vitals with explicit presence: a vital
+// measured as 0 (e.g. RR 0, apnea) is scored as present at 0 instead of
+// as missing (see score.VitalsOpt and score.Options.MeasuredZero), and is
+// marked in EvaluateResult.MeasuredZero. The engine's Imputer does not fill
+// measured zeros. Results are not cached.
+func (e *Engine) EvaluateOpt(v score.VitalsOpt, resourceCount int) EvaluateResult {
+	return e.evaluateOptions(v.Vitals(), resourceCount, evalExtras{zero: v.MeasuredZero()})
+}
+
+// EvaluateMap is like Evaluate for map-based input, so that device feeds
+// can add signals without a code change: built-in names fill the vitals and
+// the extended signs (scored as in EvaluateExtended), and any other name
+// must be registered in the engine's Registry, whose spec scores it (see
+// score.SplitMap and score.Options.Custom). It fails on an unknown name or
+// a non-finite value. Results are not cached.
+func (e *Engine) EvaluateMap(m map[score.VitalName]float64, resourceCount int) (EvaluateResult, error) {
+	v, x, custom, err := score.SplitMap(m, e.Registry)
+	if err != nil {
+		return EvaluateResult{}, fmt.Errorf("triagegeist: %w", err)
+	}
+	return e.evaluateOptions(v, resourceCount, evalExtras{extended: x, custom: custom}), nil
+}
+
+// EvaluateUnits is like Evaluate for vitals whose temperature and blood
+// pressure are in the source's units (see score.VitalsWithUnits), converted
+// to Celsius and mmHg before scoring; Vitals in the result holds the
+// converted values. It fails if a unit is not defined.
+func (e *Engine) EvaluateUnits(v score.VitalsWithUnits, resourceCount int) (EvaluateResult, error) {
+	c, err := v.Vitals()
+	if err != nil {
+		return EvaluateResult{}, fmt.Errorf("triagegeist: %w", err)
+	}
+	return e.Evaluate(c, resourceCount), nil
+}
+
+// EvaluateF is like Evaluate for fractional vitals (see score.VitalsF):
+// each vital's deviation is taken at its exact value, so an RR of 17.5
+// scores between 17 and 18. Vitals in the result holds the rounded values;
+// vitals the engine's Imputer fills are scored at the imputed value.
+// Results are not cached.
+func (e *Engine) EvaluateF(v score.VitalsF, resourceCount int) EvaluateResult {
+	x := v.Values()
+	return e.evaluateOptions(v.Vitals(), resourceCount, evalExtras{exact: &x})
+}
+
+// EvaluateTrend is like Evaluate but also adds the trend term for the
+// deterioration since prev, measured elapsed earlier, weighted by
+// Params.TrendWeights (see score.TrendComponent). The term added is
+// recorded in EvaluateResult.Trend. With all TrendWeights 0, or elapsed
+// <= 0, it scores like Evaluate. Results are not cached.
+func (e *Engine) EvaluateTrend(v score.Vitals, resourceCount int, prev score.Vitals, elapsed time.Duration) EvaluateResult {
+	return e.evaluateOptions(v, resourceCount, evalExtras{previous: &prev, elapsed: elapsed})
+}
+
+// WithRegistry returns a new Engine whose EvaluateMap accepts the custom
+// signals registered in r. The receiver is unchanged; r is shared, so
+// signals registered later are accepted too.
+func (e *Engine) WithRegistry(r *score.Registry) *Engine {
+	c := *e
+	c.Registry = r
+	return &c
+}
+
+// WithDeviationFunc returns a new Engine that scores vital f with fn in
+// place of its deviation curve (see score.DeviationFunc and
+// score.Options.Deviations), e.g. a score.DeviationTable for the ordinal
+// GCS. A nil fn restores the curve. The new engine has no Cache; the
+// receiver is unchanged.
+func (e *Engine) WithDeviationFunc(f Field, fn score.DeviationFunc) *Engine {
+	c := *e
+	var d [7]score.DeviationFunc
+	if e.Deviations != nil {
+		d = *e.Deviations
+	}
+	if f >= FieldHR && f <= FieldGCS {
+		d[f] = fn
+	}
+	c.Deviations = nil
+	for _, fn := range d {
+		if fn != nil {
+			c.Deviations = &d
+			break
+		}
+	}
+	c.Cache = nil
+	return &c
+}
+
+// scoreOptions returns p.ScoreOptions() with the engine's Deviations and
+// the behaviors of its feature flags.
+func (e *Engine) scoreOptions(p Params) score.Options {
+	o := p.ScoreOptions()
+	o.Deviations = e.Deviations
+	return e.flagOptions(o, p)
+}
+
+// evalExtras holds the per-call inputs of evaluateOptions beyond the vitals
+// and resource count.
+type evalExtras struct {
+	zero     [7]bool
+	extended score.ExtendedVitals
+	custom   map[score.VitalName]float64
+	previous *score.Vitals
+	elapsed  time.Duration
+	exact    *[7]float64
+	// profile, if non-nil, is the profile selected for ctx: its ranges and
+	// Profile.ParamsFor(e.P) are used and its ScoreFactor applied.
+	profile *Profile
+	ctx     PatientContext
+	// prevLevel, if valid, is the level the patient held before; the new
+	// level then follows Params.LevelWithHysteresis.
+	prevLevel Level
+}
+
+// evaluateOptions implements EvaluateWithContext, EvaluateExtended,
+// EvaluateOpt, EvaluateMap, EvaluateTrend and EvaluateF.
+func (e *Engine) evaluateOptions(v score.Vitals, resourceCount int, in evalExtras) EvaluateResult {
+	if e.Instruments != nil {
+		defer e.Instruments.observe(time.Now())
+	}
+	v, resourceCount = e.before(v, resourceCount)
+	p := e.P
+	norms := score.DefaultNorms()
+	var imputed [7]bool
+	if in.profile != nil {
+		p = in.profile.ParamsFor(e.P)
+		norms = in.profile.Ranges.Array()
+		v, imputed = e.imputeRanges(v, in.profile.Ranges)
+	} else {
+		v, imputed = e.impute(v)
+	}
+	for i, z := range in.zero {
+		if z && imputed[i] {
+			copyField(&v, score.Vitals{}, Field(i))
+			imputed[i] = false
+		}
+	}
+	o := e.scoreOptions(p)
+	if in.profile != nil {
+		o = e.withNorms(o, p, &norms)
+	}
+	if !in.extended.IsZero() {
+		o.Extended = &in.extended
+	}
+	o.MeasuredZero = in.zero
+	o.Custom, o.Registry = in.custom, e.Registry
+	if in.exact != nil {
+		x, vals := *in.exact, score.VitalsToValues(v)
+		for i, imp := range imputed {
+			if imp {
+				x[i] = vals[i]
+			}
+		}
+		o.Exact = &x
+	}
+	var trend float64
+	if in.previous != nil && in.elapsed > 0 {
+		o.Previous, o.TrendHours = in.previous, in.elapsed.Hours()
+		trend = score.TrendComponent(*in.previous, v, o.TrendHours, o.TrendWeights, o.TrendScales, norms)
+	}
+	a := score.AcuityWithOptions(v, resourceCount, p.MaxResources, p.VitalWeights, p.ResourceWeight, o)
+	r := EvaluateResult{
+		Vitals:        v,
+		ResourceCount: resourceCount,
+		Imputed:       imputed,
+		Extended:      in.extended,
+		MeasuredZero:  in.zero,
+		Custom:        in.custom,
+		Trend:         trend,
+	}
+	if in.profile != nil {
+		if in.profile.ScoreFactor > 0 {
+			a = score.Normalize(a*in.profile.ScoreFactor, 1)
+		}
+		r.Profile, r.Context = in.profile.Name, in.ctx
+	}
+	r.Acuity, r.Level = a, FromScore(a, p)
+	if p.Hysteresis > 0 && in.prevLevel.Valid() {
+		r.Level = p.LevelWithHysteresis(a, in.prevLevel)
+	}
+	return e.finish(r, p)
 }
 
 // BatchEvaluate returns a slice of EvaluateResult for each (vitals, resourceCount) pair.
@@ -136,6 +510,7 @@ func (e *Engine) BatchEvaluate(vitals []score.Vitals, resourceCounts []int) []Ev
 	if len(resourceCounts) != n {
 		return nil
 	}
+	e.Instruments.observeBatch(n)
 	out := make([]EvaluateResult, n)
 	for i := 0; i < n; i++ {
 		out[i] = e.Evaluate(vitals[i], resourceCounts[i])
@@ -143,6 +518,37 @@ func (e *Engine) BatchEvaluate(vitals []score.Vitals, resourceCounts []int) []Ev
 	return out
 }
 
+// SelectProfile returns the profile the engine would use for ctx.
+func (e *Engine) SelectProfile(ctx PatientContext) Profile {
+	if e.Profiles == nil {
+		return selectDefaultProfile(ctx)
+	}
+	return e.Profiles.Select(ctx)
+}
+
+// EvaluateWithContext selects a norm profile for ctx, scores v against the
+// profile's ranges with Profile.ParamsFor(e.P), applies the
+// profile's ScoreFactor, and records the profile name in the result.
+func (e *Engine) EvaluateWithContext(v score.Vitals, resourceCount int, ctx PatientContext) EvaluateResult {
+	prof := e.SelectProfile(ctx)
+	return e.evaluateOptions(v, resourceCount, evalExtras{profile: &prof, ctx: ctx})
+}
+
+// BatchEvaluateWithContext returns EvaluateWithContext for each
+// (vitals, resourceCount, ctx) triple. All slices must have the same length.
+func (e *Engine) BatchEvaluateWithContext(vitals []score.Vitals, resourceCounts []int, ctxs []PatientContext) []EvaluateResult {
+	n := len(vitals)
+	if len(resourceCounts) != n || len(ctxs) != n {
+		return nil
+	}
+	e.Instruments.observeBatch(n)
+	out := make([]EvaluateResult, n)
+	for i := 0; i < n; i++ {
+		out[i] = e.EvaluateWithContext(vitals[i], resourceCounts[i], ctxs[i])
+	}
+	return out
+}
+
 // CountByLevel returns the number of evaluations in results that have the given level.
 func CountByLevel(results []EvaluateResult, level Level) int {
 	var c int
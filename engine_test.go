@@ -1,9 +1,22 @@
 package triagegeist
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/olaflaitinen/triagegeist/export"
+	"github.com/olaflaitinen/triagegeist/norm"
 	"github.com/olaflaitinen/triagegeist/score"
+	"github.com/olaflaitinen/triagegeist/validate"
 )
 
 func TestEngine_AcuityAndLevel(t *testing.T) {
@@ -78,3 +91,2204 @@ func BenchmarkEngine_Acuity(b *testing.B) {
 		_ = eng.Acuity(benchVitals, benchResources)
 	}
 }
+
+func TestEngine_ResourceScale(t *testing.T) {
+	v := score.Vitals{HR: 80}
+	p := DefaultParams()
+	lin := NewEngine(p)
+	p.ResourceScale = score.ResourceLog1p
+	if !p.Validate() {
+		t.Fatal("log1p params should validate")
+	}
+	log := NewEngine(p)
+	if lin.Acuity(v, 1) >= log.Acuity(v, 1) {
+		t.Errorf("log1p should weigh the first resource more than linear")
+	}
+	if lin.Acuity(v, 6) != log.Acuity(v, 6) {
+		t.Errorf("scales should agree at MaxResources")
+	}
+	p.ResourceScale = 99
+	if p.Validate() || ValidateParamsExternal(p) {
+		t.Error("unknown ResourceScale should not validate")
+	}
+}
+
+func TestEngine_EvaluateWithContext(t *testing.T) {
+	eng := NewDefaultEngine()
+	v := score.Vitals{HR: 130, RR: 36, SBP: 85, SpO2: 97}
+	adult := eng.EvaluateWithContext(v, 1, PatientContext{AgeYears: 40})
+	infant := eng.EvaluateWithContext(v, 1, PatientContext{AgeYears: 0.5})
+	if adult.Profile != ProfileAdult || infant.Profile != ProfileInfant {
+		t.Fatalf("profiles: adult=%q infant=%q", adult.Profile, infant.Profile)
+	}
+	if infant.Acuity >= adult.Acuity {
+		t.Errorf("infant vitals should deviate less on infant norms: %v >= %v", infant.Acuity, adult.Acuity)
+	}
+	if neonate := eng.EvaluateWithContext(v, 1, PatientContext{AgeYears: 10.0 / 365}); neonate.Profile != ProfileNeonate {
+		t.Errorf("10-day-old profile = %q, want %q", neonate.Profile, ProfileNeonate)
+	}
+	if a := eng.Acuity(v, 1); a != adult.Acuity {
+		t.Errorf("adult profile should match package norms: %v != %v", adult.Acuity, a)
+	}
+	if r := eng.EvaluateWithContext(v, 1, PatientContext{AgeYears: 30, Pregnant: true}); r.Profile != ProfileObstetric {
+		t.Errorf("pregnant should select obstetric, got %q", r.Profile)
+	}
+	fixed := eng.WithProfiles(ProfileSelectorFunc(func(PatientContext) Profile {
+		return Profile{Name: "site", Ranges: norm.DefaultRanges()}
+	}))
+	if r := fixed.EvaluateWithContext(v, 1, PatientContext{AgeYears: 0.5}); r.Profile != "site" {
+		t.Errorf("custom selector not used, got %q", r.Profile)
+	}
+}
+
+func TestEngine_GeriatricProfile(t *testing.T) {
+	if !PresetGeriatric().Validate() {
+		t.Fatal("PresetGeriatric should validate")
+	}
+	if f := GeriatricCompensation(70); f != 1 {
+		t.Errorf("GeriatricCompensation(70) = %v, want 1", f)
+	}
+	if f := GeriatricCompensation(85); f <= 1 || f > MaxCompensation {
+		t.Errorf("GeriatricCompensation(85) = %v", f)
+	}
+	if f := GeriatricCompensation(110); f != MaxCompensation {
+		t.Errorf("GeriatricCompensation(110) = %v, want cap", f)
+	}
+	eng := NewDefaultEngine()
+	v := score.Vitals{HR: 95, RR: 20, SBP: 110, Temp: 37.4, SpO2: 94}
+	r70 := eng.EvaluateWithContext(v, 2, PatientContext{AgeYears: 70})
+	r85 := eng.EvaluateWithContext(v, 2, PatientContext{AgeYears: 85})
+	if r85.Profile != ProfileGeriatric || r85.Acuity <= r70.Acuity {
+		t.Errorf("85-year-old should score above 70-year-old: %+v vs %+v", r85, r70)
+	}
+	adult := eng.EvaluateWithContext(v, 2, PatientContext{AgeYears: 40})
+	if r70.Acuity <= adult.Acuity {
+		t.Errorf("geriatric norms should score blunted vitals higher: %v <= %v", r70.Acuity, adult.Acuity)
+	}
+}
+
+func TestEngine_FrailtyProfile(t *testing.T) {
+	eng := NewDefaultEngine()
+	v := score.Vitals{HR: 88, RR: 18, SBP: 102, Temp: 37.2, SpO2: 95}
+	robust := eng.EvaluateWithContext(v, 1, PatientContext{AgeYears: 80})
+	frail := eng.EvaluateWithContext(v, 1, PatientContext{AgeYears: 80, Frailty: norm.FrailtyFromCFS(7)})
+	if frail.Profile != ProfileGeriatricFrail || frail.Acuity <= robust.Acuity {
+		t.Errorf("frail hypotensive patient should score higher: %+v vs %+v", frail, robust)
+	}
+	if adult := eng.EvaluateWithContext(v, 1, PatientContext{AgeYears: 40, Frailty: 0.6}); adult.Profile != ProfileAdult {
+		t.Errorf("frailty outside the geriatric profile: %s", adult.Profile)
+	}
+	off := eng.WithProfiles(ProfileSelectorWithFrailty(nil)).EvaluateWithContext(v, 1, PatientContext{AgeYears: 80, Frailty: 0.6})
+	if off.Profile != ProfileGeriatric || off.Acuity != robust.Acuity {
+		t.Errorf("nil frailty hook: %+v", off)
+	}
+	calls := 0
+	hook := func(r norm.Ranges, f float64) norm.Ranges {
+		calls++
+		return norm.FrailtyAdjust(r, f)
+	}
+	custom := eng.WithProfiles(ProfileSelectorWithFrailty(hook)).EvaluateWithContext(v, 1, PatientContext{AgeYears: 80, Frailty: 0.6})
+	if calls != 1 || custom.Acuity != frail.Acuity {
+		t.Errorf("custom hook: %d calls, acuity %v want %v", calls, custom.Acuity, frail.Acuity)
+	}
+}
+
+func TestEngine_PediatricProfile(t *testing.T) {
+	pp := PresetPediatric()
+	if !pp.Validate() || pp.VitalWeights[1] <= DefaultParams().VitalWeights[1] || pp.T1 >= DefaultParams().T1 {
+		t.Fatalf("PresetPediatric = %+v", pp)
+	}
+	sel := DefaultProfileSelector()
+	for _, age := range []float64{0.5, 5, 15} {
+		if p := sel.Select(PatientContext{AgeYears: age}).ParamsFor(DefaultParams()); !p.Equal(pp) {
+			t.Errorf("age %v: profile params %v", age, p)
+		}
+	}
+	if p := sel.Select(PatientContext{AgeYears: 40}).ParamsFor(DefaultParams()); !p.Equal(DefaultParams()) {
+		t.Errorf("adult profile params %v", p)
+	}
+	// The profile overlays weights and thresholds only; the engine's other
+	// settings are kept.
+	site := DefaultParams()
+	site.MaxResources, site.ResourceWeight, site.GrayZone = 3, 0.4, 0.02
+	for _, age := range []float64{5, 80} {
+		p := sel.Select(PatientContext{AgeYears: age}).ParamsFor(site)
+		if p.MaxResources != 3 || p.ResourceWeight != 0.4 || p.GrayZone != 0.02 {
+			t.Errorf("age %v: engine params replaced: %+v", age, p)
+		}
+	}
+	if p := sel.Select(PatientContext{AgeYears: 80}).ParamsFor(site); p.VitalWeights != PresetGeriatric().VitalWeights || p.T1 != PresetGeriatric().T1 {
+		t.Errorf("geriatric overlay = %+v", p)
+	}
+	few := NewEngine(site).EvaluateWithContext(score.Vitals{HR: 80, RR: 16, SBP: 120, SpO2: 98}, 3, PatientContext{AgeYears: 5})
+	many := NewDefaultEngine().EvaluateWithContext(score.Vitals{HR: 80, RR: 16, SBP: 120, SpO2: 98}, 3, PatientContext{AgeYears: 5})
+	if few.Acuity <= many.Acuity {
+		t.Errorf("profiled evaluation ignores the engine's resource settings: %v <= %v", few.Acuity, many.Acuity)
+	}
+	// Tachypnoea and hypoxia in a child weigh more than in the adult formula.
+	v := score.Vitals{HR: 110, RR: 40, SBP: 100, SpO2: 90}
+	eng := NewDefaultEngine()
+	child := eng.EvaluateWithContext(v, 1, PatientContext{AgeYears: 5})
+	plain := NewEngine(DefaultParams()).WithProfiles(ProfileSelectorFunc(func(PatientContext) Profile {
+		return Profile{Name: ProfileChild, Ranges: norm.PediatricRanges()}
+	})).EvaluateWithContext(v, 1, PatientContext{AgeYears: 5})
+	if child.Acuity <= plain.Acuity || child.Level > plain.Level {
+		t.Errorf("pediatric preset: %v (L%d), default weights: %v (L%d)", child.Acuity, child.Level, plain.Acuity, plain.Level)
+	}
+}
+
+func TestEngine_ObstetricTrimester(t *testing.T) {
+	eng := NewDefaultEngine()
+	v := score.Vitals{HR: 95, SBP: 105, DBP: 62}
+	r := eng.EvaluateWithContext(v, 0, PatientContext{AgeYears: 30, GestationalWeeks: 22})
+	if r.Profile != ProfileObstetricT2 {
+		t.Fatalf("22 weeks should select %q, got %q", ProfileObstetricT2, r.Profile)
+	}
+	adult := eng.EvaluateWithContext(v, 0, PatientContext{AgeYears: 30})
+	if r.Acuity >= adult.Acuity {
+		t.Errorf("second-trimester vitals should deviate less on obstetric norms: %v >= %v", r.Acuity, adult.Acuity)
+	}
+}
+
+func TestEngine_RescoreBatch(t *testing.T) {
+	eng := NewDefaultEngine()
+	vs := []score.Vitals{
+		{HR: 120, RR: 24, SBP: 90, SpO2: 92},
+		{HR: 80, RR: 16, SBP: 120, SpO2: 98},
+	}
+	var results []export.Result
+	for i, v := range vs {
+		a, l := eng.ScoreAndLevel(v, 2)
+		r := export.FromVitalsScoreLevel(v, 2, a, l.Int(), l.String())
+		r.ID = string(rune('a' + i))
+		results = append(results, r)
+	}
+	same := eng.RescoreBatch(results, DefaultParams())
+	if im := ComputeRescoreImpact(same); im.Unchanged != 2 || im.N != 2 {
+		t.Errorf("rescoring with the same params should not change levels: %+v", im)
+	}
+	strict := eng.RescoreBatch(results, PresetStrict())
+	if strict[0].ID != "a" || strict[0].OldAcuity != strict[0].NewAcuity {
+		t.Errorf("thresholds only should keep acuity: %+v", strict[0])
+	}
+	if im := ComputeRescoreImpact(strict); im.LessAcute != 0 {
+		t.Errorf("stricter thresholds should not down-triage: %+v", im)
+	}
+	// Profile-scored results are rescored against their own profile.
+	infant := eng.EvaluateWithContext(score.Vitals{HR: 150, RR: 45, SBP: 80, SpO2: 97}, 1, PatientContext{AgeYears: 0.5}).ToExport()
+	if infant.Profile != ProfileInfant || infant.AgeYears != 0.5 {
+		t.Fatalf("export = %+v", infant)
+	}
+	rs := eng.RescoreBatch([]export.Result{infant}, DefaultParams())
+	if rs[0].NewAcuity != infant.Acuity || rs[0].Changed() {
+		t.Errorf("infant rescored against adult norms: %+v", rs[0])
+	}
+}
+
+func TestEngine_Rescore(t *testing.T) {
+	eng := NewDefaultEngine()
+	prev := eng.Evaluate(score.Vitals{HR: 90, RR: 18, SBP: 120, SpO2: 97}, 2)
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	prev.Time = t0
+
+	r := eng.RescoreAt(prev, score.Vitals{SpO2: 86}, t0.Add(10*time.Minute))
+	want := score.Vitals{HR: 90, RR: 18, SBP: 120, SpO2: 86}
+	if r.Vitals != want || r.ResourceCount != 2 {
+		t.Errorf("merged vitals = %+v, rc %d", r.Vitals, r.ResourceCount)
+	}
+	if r.Acuity <= prev.Acuity {
+		t.Errorf("lower SpO2 should raise acuity: %v <= %v", r.Acuity, prev.Acuity)
+	}
+	// The measurement time of the carried vitals is kept; the rescore time
+	// is recorded separately.
+	if !r.Time.Equal(t0) || !r.RescoredAt.Equal(t0.Add(10*time.Minute)) || !r.EvaluatedAt().Equal(r.RescoredAt) {
+		t.Errorf("Time = %v, RescoredAt = %v", r.Time, r.RescoredAt)
+	}
+	if !prev.EvaluatedAt().Equal(t0) {
+		t.Errorf("EvaluatedAt of a plain result = %v", prev.EvaluatedAt())
+	}
+
+	// A profile-scored result keeps its trend, extended signs and measured
+	// zeros through a rescore.
+	tp, err := NewParamsBuilder().Trend([7]float64{0.1, 0.1, 0.05, 0, 0, 0.1, 0.1}, [7]float64{}).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	teng := NewEngine(tp)
+	pp := teng.EvaluateWithContext(score.Vitals{HR: 100, RR: 24, SBP: 100, SpO2: 97}, 1, PatientContext{AgeYears: 5})
+	pp.Time, pp.Extended, pp.MeasuredZero[4] = t0, score.ExtendedVitals{Lactate: 4.5}, true
+	pr := teng.RescoreAt(pp, score.Vitals{HR: 140, SpO2: 90}, t0.Add(time.Hour))
+	if pr.Profile != ProfileChild || pr.Trend <= 0 || pr.Extended != pp.Extended || !pr.MeasuredZero[4] {
+		t.Errorf("profiled rescore = %+v", pr)
+	}
+	plain := teng.EvaluateWithContext(pr.Vitals, 1, PatientContext{AgeYears: 5})
+	if pr.Acuity <= plain.Acuity {
+		t.Errorf("profiled rescore dropped the trend and extended terms: %v <= %v", pr.Acuity, plain.Acuity)
+	}
+
+	eng.Staleness = StalenessPolicy{MaxAge: 30 * time.Minute}
+	r = eng.RescoreAt(prev, score.Vitals{SpO2: 86}, t0.Add(time.Hour))
+	if r.Vitals != (score.Vitals{SpO2: 86}) || !r.Time.Equal(t0.Add(time.Hour)) {
+		t.Errorf("stale prior vitals should be dropped, got %+v at %v", r.Vitals, r.Time)
+	}
+	// Staleness runs from the last rescore, so a fresh follow-up keeps the
+	// vitals measured then.
+	r = eng.RescoreAt(r, score.Vitals{HR: 110}, t0.Add(65*time.Minute))
+	if r.Vitals != (score.Vitals{HR: 110, SpO2: 86}) || !r.Time.Equal(t0.Add(time.Hour)) {
+		t.Errorf("second rescore after a stale one = %+v at %v", r.Vitals, r.Time)
+	}
+}
+
+func TestEngine_Cache(t *testing.T) {
+	eng := NewDefaultEngine().WithCache(2)
+	a := score.Vitals{HR: 120}
+	b := score.Vitals{HR: 60}
+	c := score.Vitals{HR: 150}
+	want := NewDefaultEngine().Acuity(a, 1)
+	if got := eng.Acuity(a, 1); got != want {
+		t.Errorf("cached engine Acuity = %v, want %v", got, want)
+	}
+	eng.Acuity(a, 1)
+	eng.Acuity(b, 1)
+	eng.Acuity(c, 1) // evicts a
+	eng.Acuity(a, 1)
+	s := eng.Cache.Stats()
+	if s.Hits != 1 || s.Misses != 4 || s.Len != 2 {
+		t.Errorf("Stats = %+v, want 1 hit, 4 misses, len 2", s)
+	}
+	if eng.WithParams(PresetStrict()).Cache != nil {
+		t.Error("WithParams should drop the cache")
+	}
+}
+
+func TestMergeTimedVitals(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	monitor := NewTimedVitals(score.Vitals{HR: 110, SpO2: 93, OnOxygen: true}, t0.Add(5*time.Minute))
+	manual := NewTimedVitals(score.Vitals{HR: 90, RR: 24}, t0)
+	m := MergeTimedVitals(monitor, manual)
+	if m.Vitals.HR != 110 || m.Vitals.RR != 24 || m.Vitals.SpO2 != 93 {
+		t.Errorf("older HR should not win: %+v", m.Vitals)
+	}
+	// oxygen is stamped at t0, before the monitor's reading, so it stays on
+	if !m.Vitals.OnOxygen {
+		t.Error("older room-air observation should not switch oxygen off")
+	}
+	off := NewTimedVitals(score.Vitals{}, t0.Add(10*time.Minute))
+	if MergeTimedVitals(m, off).Vitals.OnOxygen {
+		t.Error("newer room-air observation should switch oxygen off")
+	}
+	snap := m.Snapshot(t0.Add(12*time.Minute), StalenessPolicy{MaxAge: 10 * time.Minute})
+	if snap.RR != 0 || snap.HR != 110 {
+		t.Errorf("Snapshot = %+v, want RR dropped as stale, HR kept", snap)
+	}
+}
+
+func TestEngine_EvaluateWithSources(t *testing.T) {
+	eng := NewDefaultEngine()
+	v := score.Vitals{HR: 150, RR: 16, SBP: 120, DBP: 80, Temp: 37, SpO2: 98, GCS: 15}
+	plain := eng.Evaluate(v, 0)
+	if r := eng.EvaluateWithSources(v, 0, score.Sources{}); r.Acuity != plain.Acuity {
+		t.Errorf("unknown sources: acuity %v, want %v", r.Acuity, plain.Acuity)
+	}
+	var src score.Sources
+	src[0] = score.SourceWearable
+	r := eng.EvaluateWithSources(v, 0, src)
+	if r.Acuity >= plain.Acuity {
+		t.Errorf("wearable HR should lower acuity: %v >= %v", r.Acuity, plain.Acuity)
+	}
+	if r.Reliability[0] != score.WearableReliability || r.Reliability[1] != 1 || r.Sources != src {
+		t.Errorf("explanation not recorded: %+v %+v", r.Sources, r.Reliability)
+	}
+}
+
+func TestEngine_Instruments(t *testing.T) {
+	eng := NewDefaultEngine().WithInstruments(8)
+	v := score.Vitals{HR: 100, RR: 20}
+	eng.Evaluate(v, 1)
+	eng.BatchEvaluate([]score.Vitals{v, v, v}, []int{0, 1, 2})
+	eng.EvaluateWithContext(v, 1, PatientContext{AgeYears: 40})
+	s := eng.Instruments.Snapshot()
+	if s.Evaluations != 5 || s.Batches != 1 || s.MaxBatch != 3 || s.MeanBatch != 3 {
+		t.Errorf("Snapshot = %+v", s)
+	}
+	if s.P99 < s.P50 || s.MeanLatency <= 0 {
+		t.Errorf("latencies = %v %v %v", s.P50, s.P99, s.MeanLatency)
+	}
+	eng.Instruments.Reset()
+	if s := eng.Instruments.Snapshot(); s.Evaluations != 0 || s.P50 != 0 {
+		t.Errorf("after Reset: %+v", s)
+	}
+	if NewDefaultEngine().BatchAcuity([]score.Vitals{v}, []int{0}) == nil {
+		t.Error("uninstrumented batch should work")
+	}
+}
+
+func TestInstruments_StagesAndSLOs(t *testing.T) {
+	in := NewInstruments(16)
+	if s := in.Snapshot(); len(s.SLOs) != 1 || s.SLOs[0].Threshold != 5*time.Millisecond || !s.SLOs[0].Met {
+		t.Fatalf("default SLOs = %+v", s.SLOs)
+	}
+	in.SetSLOs(SLO{Stage: StageValidate, Percentile: 50, Threshold: time.Millisecond}, SLO{Stage: NumStages})
+	for _, d := range []time.Duration{50 * time.Microsecond, 3 * time.Millisecond, 4 * time.Millisecond, time.Second} {
+		in.ObserveStage(StageValidate, d)
+	}
+	in.ObserveStage(StageModel, 200*time.Microsecond)
+	s := in.Snapshot()
+	v := s.Stages[StageValidate]
+	if v.Count != 4 || s.Evaluations != 0 || s.Stages[StageModel].Count != 1 {
+		t.Errorf("counts: %+v", s)
+	}
+	// Buckets: 100µs, …, 2.5ms, 5ms, …, 100ms, overflow.
+	if h := v.Histogram; len(h.Counts) != len(h.Bounds)+1 || h.Counts[0] != 1 || h.Counts[5] != 2 || h.Counts[len(h.Counts)-1] != 1 {
+		t.Errorf("histogram = %+v", h)
+	}
+	if len(s.SLOs) != 1 || s.SLOs[0].Violations != 3 || s.SLOs[0].Met {
+		t.Errorf("SLOs = %+v", s.SLOs)
+	}
+	in.Reset()
+	if s := in.Snapshot(); s.Stages[StageValidate].Count != 0 || s.SLOs[0].Violations != 0 || len(s.SLOs) != 1 {
+		t.Errorf("after Reset: %+v", s)
+	}
+	var nilIn *Instruments
+	nilIn.ObserveStage(StageModel, time.Second)
+	nilIn.SetSLOs(SLO{Stage: StageModel})
+	nilIn.SetBuckets([]time.Duration{time.Millisecond})
+	nilIn.Reset()
+	if s := NewDefaultEngine().Instruments.Snapshot(); s.Evaluations != 0 || s.SLOs != nil {
+		t.Errorf("nil Snapshot = %+v", s)
+	}
+	if StageModel.String() != "model" || Stage(9).String() != "unknown" {
+		t.Error("Stage.String")
+	}
+}
+
+func TestManagedEngine_SwapParams(t *testing.T) {
+	m := NewManagedEngine(NewDefaultEngine().WithCache(16))
+	v := score.Vitals{HR: 120, RR: 24}
+	before := m.Acuity(v, 2)
+	if m.SwapParams(Params{}) {
+		t.Error("invalid params should be rejected")
+	}
+	p := DefaultParams()
+	p.ResourceWeight = 0
+	if !m.SwapParams(p) {
+		t.Fatal("SwapParams rejected valid params")
+	}
+	if m.Acuity(v, 2) == before || !m.Params().Equal(p) {
+		t.Error("swap not applied")
+	}
+	if c := m.Engine().Cache; c == nil || c.Stats().Size != 16 {
+		t.Error("cache should be recreated with the same size")
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if i == 0 {
+					m.SwapParams(PresetStrict())
+				} else {
+					m.Evaluate(v, j%6)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// A concurrent Swap is never overwritten by a SwapParams built from the
+	// engine it replaced.
+	m = NewManagedEngine(NewDefaultEngine())
+	imputing := NewDefaultEngine().WithImputer(MidpointImputer{})
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if i == 0 {
+					m.Swap(imputing)
+				} else {
+					m.SwapParams(PresetStrict())
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if m.Engine().Imputer == nil {
+		t.Error("SwapParams lost a concurrent Swap")
+	}
+}
+
+func TestEngine_Hooks(t *testing.T) {
+	var order []string
+	fahrenheit := HookFuncs{
+		Before: func(v score.Vitals, rc int) (score.Vitals, int) {
+			order = append(order, "before-1")
+			if v.Temp > 50 {
+				v.Temp = (v.Temp - 32) * 5 / 9
+			}
+			return v, rc
+		},
+		After: func(r EvaluateResult) EvaluateResult {
+			order = append(order, "after-1")
+			return r
+		},
+	}
+	audit := HookFuncs{
+		Before: func(v score.Vitals, rc int) (score.Vitals, int) {
+			order = append(order, "before-2")
+			return v, rc
+		},
+		After: func(r EvaluateResult) EvaluateResult {
+			order = append(order, "after-2")
+			return r
+		},
+	}
+	base := NewDefaultEngine()
+	eng := base.WithHooks(fahrenheit, audit)
+	r := eng.Evaluate(score.Vitals{HR: 90, Temp: 102.2}, 1)
+	if want := base.Evaluate(score.Vitals{HR: 90, Temp: 39}, 1); r.Acuity != want.Acuity || r.Vitals.Temp != 39 {
+		t.Errorf("normalised acuity %v (Temp %v), want %v", r.Acuity, r.Vitals.Temp, want.Acuity)
+	}
+	if got := strings.Join(order, ","); got != "before-1,before-2,after-2,after-1" {
+		t.Errorf("hook order = %s", got)
+	}
+	if len(base.Hooks) != 0 {
+		t.Error("WithHooks modified the receiver")
+	}
+	order = nil
+	eng.EvaluateWithContext(score.Vitals{HR: 90}, 0, PatientContext{AgeYears: 30})
+	if len(order) != 4 {
+		t.Errorf("EvaluateWithContext ran %d hook calls, want 4", len(order))
+	}
+}
+
+func TestComputeTransitions(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	at := func(l Level, min int) EvaluateResult {
+		return EvaluateResult{Level: l, Time: t0.Add(time.Duration(min) * time.Minute)}
+	}
+	sessions := [][]EvaluateResult{
+		{at(3, 0), at(2, 60), at(2, 90)},
+		{at(3, 30), at(3, 0)},  // out of order
+		{at(3, 0), at(4, 300)}, // beyond the horizon
+	}
+	m := ComputeTransitions(sessions, 2*time.Hour)
+	if m.Total(3) != 2 || m.Counts[3][2] != 1 || m.Counts[3][3] != 1 || m.Counts[2][2] != 1 {
+		t.Fatalf("Counts = %v", m.Counts)
+	}
+	if got := m.Deterioration(3); got != 0.5 {
+		t.Errorf("Deterioration(3) = %v, want 0.5", got)
+	}
+	if got := m.Improvement(3); got != 0 {
+		t.Errorf("Improvement(3) = %v, want 0", got)
+	}
+	if all := ComputeTransitions(sessions, 0); all.Counts[3][4] != 1 {
+		t.Error("horizon 0 should count every pair")
+	}
+	var buf strings.Builder
+	if err := m.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := "from,to,count,probability\n2,2,1,1\n3,2,1,0.5\n3,3,1,0.5\n"; buf.String() != want {
+		t.Errorf("WriteCSV = %q", buf.String())
+	}
+}
+
+func TestEngine_EvaluateInterval(t *testing.T) {
+	eng := NewDefaultEngine()
+	v := score.Vitals{HR: 110, RR: 22, SBP: 100, SpO2: 93, GCS: 14}
+	iv := eng.EvaluateInterval(v, 2, DefaultMeasurementError())
+	if !(iv.Low < iv.Acuity && iv.Acuity < iv.High) {
+		t.Errorf("interval [%v, %v] should contain %v strictly", iv.Low, iv.High, iv.Acuity)
+	}
+	if iv.Level != eng.Level(v, 2) {
+		t.Errorf("Level = %v, want %v", iv.Level, eng.Level(v, 2))
+	}
+	zero := eng.EvaluateInterval(v, 2, MeasurementError{})
+	if zero.Low != zero.Acuity || zero.High != zero.Acuity || zero.Straddles {
+		t.Errorf("zero error interval = %+v", zero)
+	}
+	// Raise HR until the interval crosses a threshold.
+	var found bool
+	for hr := 80; hr <= 200 && !found; hr++ {
+		found = eng.EvaluateInterval(score.Vitals{HR: hr, RR: 20, SpO2: 95}, 2, DefaultMeasurementError()).Straddles
+	}
+	if !found {
+		t.Error("no interval straddled a threshold")
+	}
+	if normal := eng.EvaluateInterval(score.Vitals{HR: 80}, 0, DefaultMeasurementError()); normal.Low != 0 {
+		t.Errorf("HR at midpoint: Low = %v, want 0", normal.Low)
+	}
+}
+
+func TestEngine_AcuityEstimate(t *testing.T) {
+	eng := NewDefaultEngine()
+	v := score.Vitals{HR: 110, RR: 22, SBP: 100, SpO2: 93, GCS: 14}
+	e := eng.AcuityEstimate(v, 2, DefaultMeasurementError())
+	if e.Acuity != eng.Acuity(v, 2) {
+		t.Errorf("Acuity = %v, want %v", e.Acuity, eng.Acuity(v, 2))
+	}
+	if e.SE() <= 0 || e.SE() > 0.2 {
+		t.Errorf("SE = %v", e.SE())
+	}
+	if z := eng.AcuityEstimate(v, 2, MeasurementError{}); z.Variance != 0 {
+		t.Errorf("zero error variance = %v", z.Variance)
+	}
+}
+
+func TestEngine_Simulate(t *testing.T) {
+	eng := NewDefaultEngine()
+	v := score.Vitals{HR: 110, RR: 22, SBP: 100, SpO2: 93, GCS: 14}
+	sim, err := eng.Simulate(v, 2, GaussianNoise(DefaultMeasurementError()), 2000, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sim.Acuity != eng.Acuity(v, 2) || sim.Level != eng.Level(v, 2) {
+		t.Errorf("Acuity, Level = %v, %v", sim.Acuity, sim.Level)
+	}
+	var total float64
+	for _, p := range sim.LevelProb {
+		total += p
+	}
+	if math.Abs(total-1) > 1e-12 {
+		t.Errorf("level probabilities sum to %v", total)
+	}
+	if sim.SD <= 0 || math.Abs(sim.Mean-sim.Acuity) > 3*sim.SD {
+		t.Errorf("Mean %v, SD %v for acuity %v", sim.Mean, sim.SD, sim.Acuity)
+	}
+	if !sort.Float64sAreSorted(sim.Scores) || sim.Quantile(0) > sim.Quantile(0.5) || sim.Quantile(0.5) > sim.Quantile(1) {
+		t.Error("scores or quantiles out of order")
+	}
+	again, _ := eng.Simulate(v, 2, GaussianNoise(DefaultMeasurementError()), 2000, 1)
+	if again.Mean != sim.Mean {
+		t.Error("same seed gave a different result")
+	}
+	still, _ := eng.Simulate(v, 2, NoiseModel{}, 10, 1)
+	if still.SD > 1e-12 || still.Prob(still.Level) != 1 {
+		t.Errorf("no noise: %+v", still)
+	}
+	uniform := NoiseModel{0: {Dist: NoiseUniform, Scale: 40}}
+	if u, _ := eng.Simulate(v, 2, uniform, 500, 2); u.SD <= 0 {
+		t.Error("uniform noise did not move the score")
+	}
+	if _, err := eng.Simulate(v, 2, NoiseModel{}, 0, 1); err == nil {
+		t.Error("expected an error for n = 0")
+	}
+	if _, err := eng.Simulate(v, 2, NoiseModel{{Scale: -1}}, 10, 1); err == nil {
+		t.Error("expected an error for a negative scale")
+	}
+}
+
+func TestEngine_AcuityGradient(t *testing.T) {
+	eng := NewDefaultEngine()
+	v := score.Vitals{HR: 130, RR: 16, SBP: 120, Temp: 37.4, SpO2: 92, GCS: 15}
+	g := eng.AcuityGradient(v, 1)
+	if g != eng.AcuityEstimate(v, 1, MeasurementError{}).Gradient {
+		t.Error("AcuityGradient differs from AcuityEstimate's gradient")
+	}
+	var hw [7]float64
+	for i, n := range score.DefaultNorms() {
+		hw[i] = n[1]
+	}
+	if i, _ := score.MostInfluential(g, hw); i != 5 {
+		t.Errorf("most influential vital = %d, want 5 (SpO2); gradient %v", i, g)
+	}
+}
+
+func TestEngine_EvaluateUnits(t *testing.T) {
+	eng := NewDefaultEngine()
+	r, err := eng.EvaluateUnits(score.VitalsWithUnits{HR: 110, RR: 22, SBP: 13.3, Temp: 102.2, SpO2: 94, TempUnit: score.Fahrenheit, PressureUnit: score.KPa}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := eng.Evaluate(score.Vitals{HR: 110, RR: 22, SBP: 100, Temp: 39, SpO2: 94}, 2)
+	if r.Vitals.SBP != 100 || math.Abs(r.Vitals.Temp-39) > 1e-9 || math.Abs(r.Acuity-want.Acuity) > 1e-9 {
+		t.Errorf("EvaluateUnits = %+v, want acuity %v", r.Vitals, want.Acuity)
+	}
+	if _, err := eng.EvaluateUnits(score.VitalsWithUnits{HR: 80, PressureUnit: 9}, 0); err == nil || !strings.HasPrefix(err.Error(), "triagegeist: score: units:") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestEngine_EvaluateDetailed(t *testing.T) {
+	eng := NewDefaultEngine()
+	t0 := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	v := score.Vitals{HR: 120, RR: 24, SBP: 95, SpO2: 92}
+	r := eng.EvaluateDetailed("enc-7", v, 3, t0)
+	if r.ID != "enc-7" || !r.Time.Equal(t0) || r.Breakdown == nil {
+		t.Fatalf("metadata not recorded: %+v", r)
+	}
+	var sum float64
+	for _, c := range r.Breakdown.Contribution {
+		sum += c
+	}
+	sum += r.Breakdown.Resource
+	if d := sum - r.Acuity; d > 1e-12 || d < -1e-12 {
+		t.Errorf("breakdown sums to %v, acuity %v", sum, r.Acuity)
+	}
+	// The breakdown sums to the score whatever options produced it.
+	sumOf := func(b Breakdown) float64 {
+		s := b.Other + b.Resource + b.Adjustment
+		for _, c := range b.Contribution {
+			s += c
+		}
+		return s
+	}
+	xp := DefaultParams()
+	xp.MAPWeight, xp.RespiratoryWeight, xp.QSOFABump = 0.1, 0.2, 0.1
+	xeng := NewEngine(xp)
+	old := score.Vitals{HR: 118, RR: 26, SBP: 92, DBP: 58, SpO2: 90, GCS: 14}
+	pr := xeng.EvaluateWithContext(old, 2, PatientContext{AgeYears: 85})
+	if b := xeng.Breakdown(pr); math.Abs(sumOf(b)-pr.Acuity) > 1e-12 || b.Other <= 0 || b.Adjustment <= 0 {
+		t.Errorf("profiled breakdown %+v sums to %v, acuity %v", b, sumOf(b), pr.Acuity)
+	}
+	xp.Normalization = score.NormalizeMax
+	if b, a := ComputeBreakdown(old, 2, xp), NewEngine(xp).Acuity(old, 2); math.Abs(sumOf(b)-a) > 1e-12 {
+		t.Errorf("NormalizeMax breakdown sums to %v, acuity %v", sumOf(b), a)
+	}
+	if r.ParamsHash != DefaultParams().Hash() || r.ParamsHash == PresetStrict().Hash() || len(r.ParamsHash) != 16 {
+		t.Errorf("ParamsHash = %q", r.ParamsHash)
+	}
+	x := r.ToExport()
+	if x.ID != "enc-7" || !x.Timestamp.Equal(t0) || x.Level != r.Level.Int() || x.HR != 120 {
+		t.Errorf("ToExport = %+v", x)
+	}
+	if eng.Rescore(r, score.Vitals{HR: 100}).ID != "enc-7" {
+		t.Error("Rescore should keep the ID")
+	}
+}
+
+func TestEngine_ActionPolicy(t *testing.T) {
+	ap := DefaultActionPolicy()
+	for _, l := range AllLevels() {
+		if ap.WaitTimeMinutes(l) != l.WaitTimeMinutes() {
+			t.Errorf("default policy wait for %v differs", l)
+		}
+	}
+	ap.WaitMinutes = [6]int{0, 0, 10, 30, 90, 180}
+	ap.Actions[Level3Urgent] = []string{"Assessment within 30 min"}
+	if !ap.Valid() {
+		t.Fatal("policy should be valid")
+	}
+	eng, err := NewDefaultEngine().WithActionPolicy(ap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eng.WaitTimeMinutes(Level2Emergent) != 10 || eng.WaitTimeMinutes(Level(9)) != 180 {
+		t.Error("policy wait targets not applied")
+	}
+	if a := eng.RecommendedActions(Level3Urgent); len(a) != 1 || a[0] != "Assessment within 30 min" {
+		t.Errorf("RecommendedActions = %v", a)
+	}
+	if NewDefaultEngine().WaitTimeMinutes(Level2Emergent) != 15 {
+		t.Error("engine without policy should use built-in targets")
+	}
+	ap.WaitMinutes[4] = 5
+	ap.WaitMinutes[1] = -1
+	if err := ap.Validate(); ap.Valid() || err == nil || !strings.Contains(err.Error(), "WaitMinutes[4]") || !strings.Contains(err.Error(), "WaitMinutes[1]") {
+		t.Errorf("negative and decreasing wait targets should be invalid: %v", err)
+	}
+	if bad, err := eng.WithActionPolicy(ap); bad != nil || err == nil || !strings.Contains(err.Error(), "WaitMinutes[4]") {
+		t.Errorf("an invalid policy should be refused: %v", err)
+	}
+}
+
+func TestEngine_GrayZone(t *testing.T) {
+	p := DefaultParams()
+	p.GrayZone = 0.03
+	if !p.Validate() || !ValidateParamsExternal(p) {
+		t.Fatal("GrayZone 0.03 should be valid")
+	}
+	if a, b, d := p.GrayZoneCandidates(0.58); !d || a != Level2Emergent || b != Level3Urgent {
+		t.Errorf("0.58 near T2: %v %v %v", a, b, d)
+	}
+	if a, b, d := p.GrayZoneCandidates(0.50); d || a != Level3Urgent || b != Level3Urgent {
+		t.Errorf("0.50: %v %v %v", a, b, d)
+	}
+	eng := NewEngine(p)
+	var found bool
+	for hr := 80; hr <= 220; hr += 2 {
+		r := eng.Evaluate(score.Vitals{HR: hr, RR: 22, SpO2: 94}, 2)
+		if r.Deferred {
+			found = true
+			if r.Candidates[0] != r.Candidates[1]-1 {
+				t.Errorf("candidates %v not adjacent", r.Candidates)
+			}
+		} else if r.Candidates != [2]Level{r.Level, r.Level} {
+			t.Errorf("confident result has candidates %v, level %v", r.Candidates, r.Level)
+		}
+	}
+	if !found {
+		t.Error("no evaluation fell in the gray zone")
+	}
+	p.GrayZone = MaxGrayZone + 0.01
+	if p.Validate() || ValidateParamsExternal(p) {
+		t.Error("GrayZone above MaxGrayZone should be invalid")
+	}
+	p.GrayZone = MaxGrayZone
+	if !p.Validate() || !ValidateParamsExternal(p) {
+		t.Error("MaxGrayZone should be valid in both validators")
+	}
+}
+
+func TestParams_LevelWithHysteresis(t *testing.T) {
+	p := DefaultParams()
+	p.Hysteresis = 0.02
+	cases := []struct {
+		s    float64
+		prev Level
+		want Level
+	}{
+		{0.61, Level3Urgent, Level2Emergent}, // escalation is immediate
+		{0.63, Level3Urgent, Level2Emergent},
+		{0.59, Level2Emergent, Level2Emergent}, // below T2 by only 0.01
+		{0.57, Level2Emergent, Level3Urgent},   // below T2 by 0.03
+		{0.10, Level2Emergent, Level5NonUrgent},
+		{0.90, Level5NonUrgent, Level1Resuscitation},
+		{0.61, Level(0), Level2Emergent},
+	}
+	for _, c := range cases {
+		if got := p.LevelWithHysteresis(c.s, c.prev); got != c.want {
+			t.Errorf("LevelWithHysteresis(%v, %v) = %v, want %v", c.s, c.prev, got, c.want)
+		}
+	}
+	// Even at MaxHysteresis every level, Level 1 included, is reachable.
+	p.Hysteresis = MaxHysteresis + 0.01
+	if p.Validate() || ValidateParamsExternal(p) {
+		t.Error("Hysteresis above MaxHysteresis should be invalid in both validators")
+	}
+	p.Hysteresis = MaxHysteresis
+	if !p.Validate() || !ValidateParamsExternal(p) {
+		t.Error("MaxHysteresis should be valid in both validators")
+	}
+	for prev := Level1Resuscitation; prev <= Level5NonUrgent; prev++ {
+		if got := p.LevelWithHysteresis(p.T1, prev); got != Level1Resuscitation {
+			t.Errorf("score T1 from %v gives %v, want Level 1", prev, got)
+		}
+	}
+	p.Hysteresis = 0
+	for s := 0.0; s <= 1; s += 0.01 {
+		if p.LevelWithHysteresis(s, Level3Urgent) != FromScore(s, p) {
+			t.Fatalf("zero hysteresis differs from FromScore at %v", s)
+		}
+	}
+}
+
+func TestEngine_RescoreHysteresis(t *testing.T) {
+	p := DefaultParams()
+	p.Hysteresis = 0.25
+	eng := NewEngine(p)
+	prev := eng.Evaluate(score.Vitals{HR: 140, RR: 30, SBP: 85, SpO2: 88}, 4)
+	r := eng.Rescore(prev, score.Vitals{HR: 100, RR: 20})
+	if FromScore(r.Acuity, p) == prev.Level || r.Level != prev.Level {
+		t.Errorf("level stepped down from %v to %v within the hysteresis margin", prev.Level, r.Level)
+	}
+	if r.Candidates != [2]Level{r.Level, r.Level} {
+		t.Errorf("Candidates = %v", r.Candidates)
+	}
+	// Hooks see the held level, not the one hysteresis replaced.
+	var seen Level
+	hooked := eng.WithHooks(HookFuncs{After: func(r EvaluateResult) EvaluateResult { seen = r.Level; return r }})
+	if r := hooked.Rescore(prev, score.Vitals{HR: 100, RR: 20}); seen != prev.Level || r.Level != seen {
+		t.Errorf("hook saw %v, result %v, want held %v", seen, r.Level, prev.Level)
+	}
+	calm := eng.Evaluate(score.Vitals{HR: 80, RR: 16, SBP: 120, SpO2: 98}, 0)
+	worse := eng.Rescore(calm, score.Vitals{HR: 150, RR: 34, SBP: 80, SpO2: 85})
+	if worse.Level != FromScore(worse.Acuity, p) || worse.Level >= calm.Level {
+		t.Errorf("escalation delayed: %v -> %v (acuity %v)", calm.Level, worse.Level, worse.Acuity)
+	}
+}
+
+func BenchmarkEngine_AcuityCached(b *testing.B) {
+	eng := NewDefaultEngine().WithCache(1024)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = eng.Acuity(benchVitals, benchResources)
+	}
+}
+
+func TestRecommendQueue(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(m int) time.Time { return now.Add(-time.Duration(m) * time.Minute) }
+	ps := []WaitingPatient{
+		{Result: EvaluateResult{ID: "l3-new", Level: Level3Urgent, Acuity: 0.5}, Arrival: ago(5)},
+		{Result: EvaluateResult{ID: "l5-long", Level: Level5NonUrgent, Acuity: 0.1}, Arrival: ago(250)},
+		{Result: EvaluateResult{ID: "l1", Level: Level1Resuscitation, Acuity: 0.9}, Arrival: now},
+		{Result: EvaluateResult{ID: "l2", Level: Level2Emergent, Acuity: 0.7}, Arrival: ago(1)},
+		{Result: EvaluateResult{ID: "deferred", Level: Level3Urgent, Acuity: 0.6, Deferred: true,
+			Candidates: [2]Level{Level2Emergent, Level3Urgent}}, Arrival: ago(10)},
+	}
+	q := NewDefaultEngine().RecommendQueue(ps, now)
+	var ids []string
+	for _, e := range q {
+		ids = append(ids, e.Result.ID)
+	}
+	want := []string{"l1", "l5-long", "deferred", "l2", "l3-new"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("order = %v, want %v", ids, want)
+	}
+	if !q[1].Overdue() || q[1].Waited != 250*time.Minute {
+		t.Errorf("l5-long entry = %+v", q[1])
+	}
+	if q[2].Level != Level2Emergent || q[2].Slack != 5*time.Minute {
+		t.Errorf("deferred entry = %+v", q[2])
+	}
+
+	// An insufficient-data result is reassessed now, not given a level 5 wait.
+	gp := DefaultParams()
+	gp.MinimumData = DefaultDataRequirement()
+	gated := NewEngine(gp)
+	thin := gated.Evaluate(score.Vitals{HR: 95}, 0)
+	if thin.Insufficient == "" || gated.WaitTimeMinutesFor(thin) != 0 {
+		t.Fatalf("insufficient result %+v waits %d", thin, gated.WaitTimeMinutesFor(thin))
+	}
+	thin.ID = "insufficient"
+	q = gated.RecommendQueue(append(ps, WaitingPatient{Result: thin, Arrival: now}), now)
+	if q[0].Result.ID != "l1" || q[1].Result.ID != "insufficient" || !q[1].Reassess || q[1].Slack != 0 {
+		t.Errorf("insufficient entry = %+v", q[1])
+	}
+}
+
+func TestParams_JSONRoundTrip(t *testing.T) {
+	p := PresetGeriatric()
+	p.GrayZone = 0.02
+	p.ResourceScale = score.ResourceSqrt
+	p.Reliability[score.SourceMonitor][0] = 0.9
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var q Params
+	if err := json.Unmarshal(b, &q); err != nil {
+		t.Fatal(err)
+	}
+	if !q.Equal(p) {
+		t.Errorf("round trip: got %+v, want %+v", q, p)
+	}
+
+	var partial Params
+	if err := json.Unmarshal([]byte(`{"t1": 0.9, "reliability": {"wearable": [1, 1, 1, 1, 1, 1, 1]}}`), &partial); err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultParams()
+	want.T1 = 0.9
+	want.Reliability[score.SourceWearable] = [7]float64{1, 1, 1, 1, 1, 1, 1}
+	if !partial.Equal(want) {
+		t.Errorf("partial = %+v", partial)
+	}
+
+	for in, msg := range map[string]string{
+		`{"t2": 0.9}`:                 "T2 (0.9) must be less than T1 (0.85)",
+		`{"vital_weights": [1, 2]}`:   "vital_weights has 2 values",
+		`{"resource_scale": "cubic"}`: `unknown resource_scale "cubic"`,
+		`{"tl": 0.9}`:                 `unknown field "tl"`,
+		`{"vital_weights": [0.1, 0.1, 0.1, 1.5, 0.1, 0.1, 0.1]}`: "VitalWeights[3] (1.5) must be in [0, 1]",
+	} {
+		var bad Params
+		err := json.Unmarshal([]byte(in), &bad)
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%s: error %v, want %q", in, err, msg)
+		}
+	}
+}
+
+func TestLoadSaveParams(t *testing.T) {
+	dir := t.TempDir()
+	p := PresetStrict()
+	p.GCSBanded = true
+	p.Hysteresis = 0.03
+	for _, name := range []string{"site.yaml", "site.json"} {
+		path := filepath.Join(dir, name)
+		if err := SaveParams(path, p); err != nil {
+			t.Fatal(err)
+		}
+		q, err := LoadParams(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !q.Equal(p) {
+			t.Errorf("%s: got %+v, want %+v", name, q, p)
+		}
+	}
+
+	yml := filepath.Join(dir, "override.yml")
+	src := "# site override\nt1: 0.9   # stricter resus\nvital_weights:\n- 0.2\n- 0.2\n- 0.2\n- 0.1\n- 0.1\n- 0.1\n- 0.1\ngcs_bands:\n  mild: 0.4\n"
+	if err := os.WriteFile(yml, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	q, err := LoadParams(yml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.T1 != 0.9 || q.VitalWeights[0] != 0.2 || q.GCSBands.Mild != 0.4 || q.GCSBands.Severe != 1 || q.T2 != DefaultParams().T2 {
+		t.Errorf("override = %+v", q)
+	}
+
+	bad := filepath.Join(dir, "bad.yaml")
+	if err := os.WriteFile(bad, []byte("t1: 0.5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadParams(bad); err == nil || !strings.Contains(err.Error(), "T2 (0.6) must be less than T1 (0.5)") {
+		t.Errorf("invalid file: %v", err)
+	}
+	p.T4 = 0
+	if err := SaveParams(filepath.Join(dir, "invalid.json"), p); err == nil {
+		t.Error("SaveParams accepted invalid params")
+	}
+}
+
+func TestParams_Transform(t *testing.T) {
+	p := DefaultParams()
+	p.Transform = score.DeviationTransform{Kind: score.TransformPower, Saturation: 2}
+	v := score.Vitals{HR: 125, RR: 16, SBP: 120, DBP: 80, Temp: 37, SpO2: 98}
+	lin, pow := NewEngine(DefaultParams()).Acuity(v, 1), NewEngine(p).Acuity(v, 1)
+	if !(pow < lin) {
+		t.Errorf("power transform acuity %v, want below linear %v", pow, lin)
+	}
+	if b := ComputeBreakdown(v, 1, p); b.Deviation[0] != p.Transform.Apply(45.0/40) {
+		t.Errorf("breakdown deviation = %v", b.Deviation[0])
+	}
+	if p.Equal(DefaultParams()) {
+		t.Error("Equal ignores Transform")
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"site.yaml", "site.json"} {
+		path := filepath.Join(dir, name)
+		if err := SaveParams(path, p); err != nil {
+			t.Fatal(err)
+		}
+		q, err := LoadParams(path)
+		if err != nil || !q.Equal(p) {
+			t.Errorf("%s: got %+v, %v", name, q.Transform, err)
+		}
+	}
+	if b, _ := json.Marshal(DefaultParams()); strings.Contains(string(b), "deviation_transform") {
+		t.Errorf("default params write deviation_transform: %s", b)
+	}
+	var q Params
+	if err := json.Unmarshal([]byte(`{"deviation_transform": {"kind": "cubic"}}`), &q); err == nil {
+		t.Error("unknown transform kind accepted")
+	}
+
+	p.Transform.Saturation = 0.5
+	if p.Validate() {
+		t.Error("Saturation 0.5 accepted")
+	}
+	if errs := p.ValidateDetailed(); len(errs) != 1 || !strings.Contains(errs[0].Error(), "Transform.Saturation") {
+		t.Errorf("ValidateDetailed = %v", errs)
+	}
+}
+
+func TestParams_HalfWidths(t *testing.T) {
+	p, err := NewParamsBuilder().HalfWidths([7]float64{5: 4}, [7]float64{5: 20}).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := score.Vitals{HR: 80, RR: 16, SpO2: 94}
+	if a, d := NewEngine(p).Acuity(v, 0), NewEngine(DefaultParams()).Acuity(v, 0); !(a > d) {
+		t.Errorf("narrow low SpO2 half-width: %v, want above %v", a, d)
+	}
+	if b := ComputeBreakdown(v, 0, p); b.Deviation[5] != 1 {
+		t.Errorf("breakdown SpO2 deviation = %v, want 1", b.Deviation[5])
+	}
+	path := filepath.Join(t.TempDir(), "site.yaml")
+	if err := SaveParams(path, p); err != nil {
+		t.Fatal(err)
+	}
+	if q, err := LoadParams(path); err != nil || !q.Equal(p) {
+		t.Errorf("round trip: %+v, %v", q.HalfWidths, err)
+	}
+	p.HalfWidths.High[2] = math.Inf(1)
+	if errs := p.ValidateDetailed(); p.Validate() || len(errs) != 1 || !strings.Contains(errs[0].Error(), "HalfWidths.High[2]") {
+		t.Errorf("ValidateDetailed = %v", errs)
+	}
+}
+
+func TestParams_Directions(t *testing.T) {
+	p, err := NewParamsBuilder().Direction(5, score.DirectionLow).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := score.Vitals{HR: 80, RR: 16, SpO2: 100}
+	if a := NewEngine(p).Acuity(v, 0); a != 0 {
+		t.Errorf("SpO2 100 with DirectionLow: acuity %v, want 0", a)
+	}
+	if b := ComputeBreakdown(v, 0, p); b.Deviation[5] != 0 {
+		t.Errorf("breakdown SpO2 deviation = %v", b.Deviation[5])
+	}
+	data, err := json.Marshal(p)
+	if err != nil || !strings.Contains(string(data), `"directions":{"spo2":"low"}`) {
+		t.Fatalf("MarshalJSON = %s, %v", data, err)
+	}
+	var q Params
+	if err := json.Unmarshal(data, &q); err != nil || !q.Equal(p) {
+		t.Errorf("round trip: %v, %v", q.Directions, err)
+	}
+	path := filepath.Join(t.TempDir(), "site.yaml")
+	if err := SaveParams(path, p); err != nil {
+		t.Fatal(err)
+	}
+	if q, err := LoadParams(path); err != nil || !q.Equal(p) {
+		t.Errorf("YAML round trip: %v, %v", q.Directions, err)
+	}
+	for _, bad := range []string{`{"directions": {"lactate": "low"}}`, `{"directions": {"hr": "up"}}`} {
+		if err := json.Unmarshal([]byte(bad), &q); err == nil {
+			t.Errorf("%s accepted", bad)
+		}
+	}
+	if _, err := NewParamsBuilder().Direction(7, score.DirectionLow).Build(); err == nil {
+		t.Error("Direction index 7 accepted")
+	}
+	p.Directions[0] = 5
+	if errs := p.ValidateDetailed(); p.Validate() || len(errs) != 1 || !strings.Contains(errs[0].Error(), "Directions[0]") {
+		t.Errorf("ValidateDetailed = %v", errs)
+	}
+}
+
+func TestParams_MissingPolicy(t *testing.T) {
+	sparse := score.Vitals{HR: 105}
+	stable := score.Vitals{HR: 88, RR: 18, SBP: 132, DBP: 84, Temp: 37.4, SpO2: 96, GCS: 15}
+	def := NewEngine(DefaultParams())
+	if !(def.Acuity(sparse, 0) > def.Acuity(stable, 0)) {
+		t.Fatal("expected the sparse record to outscore the stable one under MissingIgnore")
+	}
+	p, err := NewParamsBuilder().MissingPolicy(score.MissingPenalizeNeutral, 0).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := NewEngine(p)
+	if !(eng.Acuity(sparse, 0) < eng.Acuity(stable, 0)) {
+		t.Errorf("neutral: sparse %v, stable %v", eng.Acuity(sparse, 0), eng.Acuity(stable, 0))
+	}
+	for _, m := range []score.MissingPolicy{score.MissingPenalizeNeutral, score.MissingPenalizeWorst} {
+		p.MissingPolicy = m
+		b := ComputeBreakdown(sparse, 2, p)
+		var sum float64
+		for _, c := range b.Contribution {
+			sum += c
+		}
+		if got := NewEngine(p).Acuity(sparse, 2); math.Abs(sum+b.Resource-got) > 1e-12 {
+			t.Errorf("%v: breakdown sums to %v, acuity %v", m, sum+b.Resource, got)
+		}
+	}
+
+	p.MissingPolicy, p.MinPresentVitals = score.MissingRequireMinimum, 3
+	data, _ := json.Marshal(p)
+	var q Params
+	if err := json.Unmarshal(data, &q); err != nil || !q.Equal(p) {
+		t.Errorf("round trip %s: %v", data, err)
+	}
+	env := map[string]string{"TRIAGEGEIST_MISSING_POLICY": "worst", "TRIAGEGEIST_MIN_PRESENT_VITALS": "2"}
+	if q, err := ParamsFromEnv(func(k string) (string, bool) { v, ok := env[k]; return v, ok }); err != nil || q.MissingPolicy != score.MissingPenalizeWorst || q.MinPresentVitals != 2 {
+		t.Errorf("ParamsFromEnv = %v, %v", q.MissingPolicy, err)
+	}
+	p.MinPresentVitals = 8
+	if errs := p.ValidateDetailed(); p.Validate() || len(errs) != 1 || !strings.Contains(errs[0].Error(), "MinPresentVitals") {
+		t.Errorf("ValidateDetailed = %v", errs)
+	}
+}
+
+func TestImputer(t *testing.T) {
+	v := score.Vitals{HR: 118, RR: 24}
+	eng := NewEngine(DefaultParams()).WithImputer(MidpointImputer{})
+	r := eng.Evaluate(v, 1)
+	if want := [7]bool{false, false, true, true, true, true, true}; r.Imputed != want {
+		t.Errorf("Imputed = %v, want %v", r.Imputed, want)
+	}
+	if r.Vitals.HR != 118 || r.Vitals.SBP != 120 || r.Vitals.Temp != 37 || r.Vitals.GCS != 15 {
+		t.Errorf("midpoint-imputed vitals = %+v", r.Vitals)
+	}
+	if want := NewEngine(DefaultParams()).Acuity(r.Vitals, 1); r.Acuity != want {
+		t.Errorf("acuity %v, want the score of the imputed vitals %v", r.Acuity, want)
+	}
+	d := eng.EvaluateDetailed("p1", v, 1, time.Time{})
+	if d.Breakdown.Imputed != r.Imputed || !d.Breakdown.Present[2] {
+		t.Errorf("breakdown = %+v", d.Breakdown)
+	}
+	if got := strings.Join(d.ToExport().Imputed, ","); got != "sbp,dbp,temp,spo2,gcs" {
+		t.Errorf("export Imputed = %q", got)
+	}
+
+	m := CohortMeanImputer([]score.Vitals{{SBP: 100, SpO2: 90}, {SBP: 120}, {HR: 70}})
+	if m.Means[2] != 110 || m.Means[5] != 90 || m.Means[4] != 0 {
+		t.Errorf("cohort means = %v", m.Means)
+	}
+	if got := m.Impute(v); got.SBP != 110 || got.SpO2 != 90 || got.Temp != 0 || got.HR != 118 {
+		t.Errorf("mean-imputed vitals = %+v", got)
+	}
+
+	prev := score.Vitals{HR: 90, SBP: 88, GCSEye: 3, GCSVerbal: 4, GCSMotor: 6}
+	cf := NewEngine(DefaultParams()).WithImputer(CarryForwardImputer{Previous: prev})
+	r = cf.Evaluate(v, 1)
+	if r.Vitals.HR != 118 || r.Vitals.SBP != 88 || score.GCSTotal(r.Vitals) != 13 || r.Imputed[3] || !r.Imputed[6] {
+		t.Errorf("carry-forward = %+v, imputed %v", r.Vitals, r.Imputed)
+	}
+	// Under a profile, midpoints come from the profile's ranges.
+	infant := NewDefaultEngine().WithImputer(MidpointImputer{})
+	ri := infant.EvaluateWithContext(score.Vitals{HR: 140, RR: 40, SpO2: 98}, 0, PatientContext{AgeYears: 0.5})
+	mid := norm.InfantRanges()
+	if ri.Profile != ProfileInfant || ri.Vitals.SBP != int(mid.SBP[0]) || ri.Vitals.DBP != int(mid.DBP[0]) {
+		t.Errorf("infant imputed vitals = %+v (profile %q)", ri.Vitals, ri.Profile)
+	}
+	if want := NewDefaultEngine().EvaluateWithContext(ri.Vitals, 0, PatientContext{AgeYears: 0.5}); ri.Acuity != want.Acuity {
+		t.Errorf("infant acuity %v, want the score of the imputed vitals %v", ri.Acuity, want.Acuity)
+	}
+
+	// A rescore must not treat imputed values as measured.
+	rs := cf.WithImputer(nil).Rescore(r, score.Vitals{RR: 18})
+	if rs.Vitals.SBP != 0 || rs.Vitals.HR != 118 || rs.Vitals.RR != 18 {
+		t.Errorf("rescore kept imputed vitals: %+v", rs.Vitals)
+	}
+}
+
+func TestEngine_EvaluateExtended(t *testing.T) {
+	v := score.Vitals{HR: 92, RR: 20, SBP: 118, SpO2: 96}
+	x := score.ExtendedVitals{Lactate: 4.5, CapRefill: 3.5}
+	def := NewEngine(DefaultParams())
+	if got, want := def.EvaluateExtended(v, x, 1).Acuity, def.Evaluate(v, 1).Acuity; got != want {
+		t.Errorf("default ExtendedWeights: %v, want %v", got, want)
+	}
+	p, err := NewParamsBuilder().ExtendedWeights(score.DefaultExtendedWeights()).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := NewEngine(p)
+	r := eng.EvaluateExtended(v, x, 1)
+	if !(r.Acuity > eng.Evaluate(v, 1).Acuity) || r.Extended != x {
+		t.Errorf("EvaluateExtended = %+v", r)
+	}
+	if e := r.ToExport(); e.Lactate != 4.5 || e.CapRefill != 3.5 || e.Glucose != 0 {
+		t.Errorf("ToExport = %+v", e)
+	}
+	if got := eng.Rescore(r, score.Vitals{SpO2: 95}); got.Extended != x {
+		t.Errorf("Rescore dropped the extended signs: %+v", got.Extended)
+	}
+
+	data, _ := json.Marshal(p)
+	if !strings.Contains(string(data), `"lactate":0.15`) {
+		t.Errorf("JSON %s", data)
+	}
+	var q Params
+	if err := json.Unmarshal(data, &q); err != nil || !q.Equal(p) {
+		t.Errorf("round trip: %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"extended_weights":{"ketones":0.1}}`), &q); err == nil {
+		t.Error("expected an error for an unknown extended sign")
+	}
+	p.ExtendedWeights[2] = 1.5
+	if errs := p.ValidateDetailed(); p.Validate() || len(errs) != 1 || !strings.Contains(errs[0].Error(), "ExtendedWeights[2]") {
+		t.Errorf("ValidateDetailed = %v", errs)
+	}
+}
+
+func TestEngine_EvaluateF(t *testing.T) {
+	eng := NewEngine(DefaultParams())
+	v := score.Vitals{HR: 104, RR: 22, SBP: 112, Temp: 38.1, SpO2: 94}
+	f := score.FloatVitals(v)
+	if got, want := eng.EvaluateF(f, 2).Acuity, eng.Acuity(v, 2); math.Abs(got-want) > 1e-12 {
+		t.Errorf("EvaluateF of whole numbers = %v, want %v", got, want)
+	}
+	f.HR = 104.4
+	r := eng.EvaluateF(f, 2)
+	if r.Vitals.HR != 104 || !(r.Acuity > eng.Acuity(v, 2)) {
+		t.Errorf("EvaluateF(HR 104.4) = %v (HR %d), want above %v", r.Acuity, r.Vitals.HR, eng.Acuity(v, 2))
+	}
+}
+
+func TestEngine_MinimumData(t *testing.T) {
+	p := DefaultParams()
+	p.MinimumData = DefaultDataRequirement()
+	eng := NewEngine(p)
+	r := eng.Evaluate(score.Vitals{HR: 118}, 2)
+	if r.Insufficient != "1 of 3 vitals; none of rr, spo2, gcs" || r.Acuity != 0 || r.Level != 0 || r.Candidates != ([2]Level{}) {
+		t.Errorf("one vital: %+v", r)
+	}
+	if r := eng.Evaluate(score.Vitals{HR: 118, SBP: 100, Temp: 38.5}, 2); r.Insufficient != "none of rr, spo2, gcs" {
+		t.Errorf("no RR/SpO2/GCS: Insufficient = %q", r.Insufficient)
+	}
+	full := score.Vitals{HR: 118, SBP: 100, SpO2: 93}
+	if r := eng.Evaluate(full, 2); r.Insufficient != "" || r.Acuity != NewEngine(DefaultParams()).Acuity(full, 2) || !r.Level.Valid() {
+		t.Errorf("sufficient: %+v", r)
+	}
+	// A measured zero counts as measured.
+	if r := eng.EvaluateOpt(score.VitalsOpt{HR: score.Int(40), RR: score.Int(0), SpO2: score.Int(70)}, 0); r.Insufficient != "" {
+		t.Errorf("measured zero: Insufficient = %q", r.Insufficient)
+	}
+	if ex := eng.Evaluate(score.Vitals{HR: 118}, 2).ToExport(); ex.Insufficient == "" || ex.Level != 0 {
+		t.Errorf("export: %+v", ex)
+	}
+	data, _ := json.Marshal(p)
+	var q Params
+	if err := json.Unmarshal(data, &q); err != nil || !q.Equal(p) || !strings.Contains(string(data), `"minimum_data":{"min_present":3,"any_of":["rr","spo2","gcs"]}`) {
+		t.Errorf("round trip %s: %v", data, err)
+	}
+	path := filepath.Join(t.TempDir(), "site.yaml")
+	if err := SaveParams(path, p); err != nil {
+		t.Fatal(err)
+	}
+	if q, err := LoadParams(path); err != nil || !q.Equal(p) {
+		t.Errorf("yaml round trip: %+v, %v", q.MinimumData, err)
+	}
+	p.MinimumData.MinPresent = 8
+	if errs := p.ValidateDetailed(); p.Validate() || len(errs) != 1 || !strings.Contains(errs[0].Error(), "MinimumData.MinPresent") {
+		t.Errorf("ValidateDetailed = %v", errs)
+	}
+}
+
+func TestEngine_EvaluateOpt(t *testing.T) {
+	eng := NewEngine(DefaultParams()).WithImputer(MidpointImputer{})
+	o := score.VitalsOpt{HR: score.Int(130), RR: score.Int(0), SpO2: score.Int(85)}
+	r := eng.EvaluateOpt(o, 2)
+	if !r.MeasuredZero[1] || r.Imputed[1] || r.Vitals.RR != 0 {
+		t.Fatalf("EvaluateOpt = %+v", r)
+	}
+	if missing := eng.Evaluate(o.Vitals(), 2); !(r.Acuity > missing.Acuity) {
+		t.Errorf("apnea %v not above imputed RR %v", r.Acuity, missing.Acuity)
+	}
+	if got := eng.EvaluateOpt(score.OptFromVitals(o.Vitals()), 2); got.Acuity != eng.Evaluate(o.Vitals(), 2).Acuity {
+		t.Errorf("no measured zeros: %v", got.Acuity)
+	}
+	again := eng.Rescore(r, score.Vitals{HR: 128})
+	if !again.MeasuredZero[1] || again.Acuity < r.Acuity-0.05 {
+		t.Errorf("Rescore dropped the measured zero: %+v", again)
+	}
+	if got := eng.Rescore(r, score.Vitals{RR: 12}); got.MeasuredZero[1] || got.Vitals.RR != 12 {
+		t.Errorf("Rescore kept a re-measured zero: %+v", got)
+	}
+}
+
+func TestEngine_EvaluateMap(t *testing.T) {
+	m := map[score.VitalName]float64{"hr": 118, "rr": 24, "spo2": 91, "icp": 28}
+	eng := NewEngine(DefaultParams())
+	if _, err := eng.EvaluateMap(m, 2); err == nil || !strings.Contains(err.Error(), "icp") {
+		t.Errorf("unregistered signal: %v", err)
+	}
+	reg := score.NewRegistry()
+	eng = eng.WithRegistry(reg)
+	if err := reg.Register("icp", score.SignalSpec{Norm: [2]float64{10, 10}, Weight: 0.1}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := eng.EvaluateMap(m, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := score.Vitals{HR: 118, RR: 24, SpO2: 91}
+	if r.Vitals != v || r.Custom["icp"] != 28 || !(r.Acuity > eng.Evaluate(v, 2).Acuity) {
+		t.Errorf("EvaluateMap = %+v", r)
+	}
+	if e := r.ToExport(); e.Custom["icp"] != 28 {
+		t.Errorf("ToExport.Custom = %v", e.Custom)
+	}
+	if got := eng.Rescore(r, score.Vitals{HR: 110}); got.Custom["icp"] != 28 {
+		t.Errorf("Rescore dropped the custom signal: %v", got.Custom)
+	}
+}
+
+func TestEngine_EvaluateTrend(t *testing.T) {
+	prev := score.Vitals{HR: 92, RR: 20, SBP: 112, SpO2: 95, GCS: 15}
+	now := score.Vitals{HR: 112, RR: 26, SBP: 98, SpO2: 92, GCS: 15}
+	def := NewEngine(DefaultParams())
+	if got := def.EvaluateTrend(now, 1, prev, time.Hour); got.Acuity != def.Evaluate(now, 1).Acuity || got.Trend != 0 {
+		t.Errorf("default TrendWeights: %+v", got)
+	}
+	p, err := NewParamsBuilder().Trend([7]float64{0.1, 0.1, 0.05, 0, 0, 0.1, 0.1}, [7]float64{}).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := NewEngine(p)
+	r := eng.EvaluateTrend(now, 1, prev, time.Hour)
+	if !(r.Trend > 0) || math.Abs(r.Acuity-(eng.Evaluate(now, 1).Acuity+r.Trend)) > 1e-12 {
+		t.Errorf("EvaluateTrend = %+v", r)
+	}
+
+	t0 := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	first := eng.EvaluateDetailed("p1", prev, 1, t0)
+	again := eng.RescoreAt(first, score.Vitals{HR: 112, RR: 26}, t0.Add(30*time.Minute))
+	if !(again.Trend > 0) {
+		t.Errorf("Rescore trend = %v", again.Trend)
+	}
+
+	data, _ := json.Marshal(p)
+	var q Params
+	if err := json.Unmarshal(data, &q); err != nil || !q.Equal(p) || !strings.Contains(string(data), `"trend"`) {
+		t.Errorf("round trip %s: %v", data, err)
+	}
+	p.TrendScales[4] = -1
+	if errs := p.ValidateDetailed(); p.Validate() || len(errs) != 1 || !strings.Contains(errs[0].Error(), "TrendScales[4]") {
+		t.Errorf("ValidateDetailed = %v", errs)
+	}
+}
+
+func TestParams_DeviationCaps(t *testing.T) {
+	moderate := score.Vitals{HR: 125, RR: 22, SBP: 105, SpO2: 94}
+	extreme := score.Vitals{HR: 190, RR: 22, SBP: 105, SpO2: 94}
+	def := NewEngine(DefaultParams())
+	if def.Acuity(moderate, 1) != def.Acuity(extreme, 1) {
+		t.Fatal("expected the hard cap to flatten HR 125 and HR 190")
+	}
+	p, err := NewParamsBuilder().DeviationCaps([7]float64{3, 3, 3, 3, 0, 0, 0}).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := NewEngine(p)
+	if !(eng.Acuity(extreme, 1) > eng.Acuity(moderate, 1)) {
+		t.Errorf("capped: HR 190 %v, HR 125 %v", eng.Acuity(extreme, 1), eng.Acuity(moderate, 1))
+	}
+	data, _ := json.Marshal(p)
+	var q Params
+	if err := json.Unmarshal(data, &q); err != nil || !q.Equal(p) || !strings.Contains(string(data), `"deviation_caps":[3,3,3,3,0,0,0]`) {
+		t.Errorf("round trip %s: %v", data, err)
+	}
+	p.DeviationCaps[4] = 0.5
+	if errs := p.ValidateDetailed(); p.Validate() || len(errs) != 1 || !strings.Contains(errs[0].Error(), "DeviationCaps[4]") {
+		t.Errorf("ValidateDetailed = %v", errs)
+	}
+}
+
+func TestParams_ZScores(t *testing.T) {
+	z := [7]score.ZNorm{0: {Mean: 88, SD: 15}, 4: {Mean: 36.9, SD: 0.5, Saturation: 4}}
+	p, err := NewParamsBuilder().ZScores(z).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := score.Vitals{HR: 118, RR: 18, SBP: 120, Temp: 37.9, SpO2: 97}
+	if got, want := NewEngine(p).Acuity(v, 1), NewEngine(DefaultParams()).Acuity(v, 1); got == want {
+		t.Errorf("z-scores did not change the acuity %v", got)
+	}
+	for _, name := range []string{"site.yaml", "site.json"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := SaveParams(path, p); err != nil {
+			t.Fatal(err)
+		}
+		if q, err := LoadParams(path); err != nil || !q.Equal(p) {
+			t.Errorf("%s: round trip %+v, %v", name, q.ZScores, err)
+		}
+	}
+	var q Params
+	if err := json.Unmarshal([]byte(`{"z_scores": {"pulse": {"mean": 80, "sd": 10}}}`), &q); err == nil || !strings.Contains(err.Error(), `unknown z_scores vital "pulse"`) {
+		t.Errorf("unknown vital: err = %v", err)
+	}
+	p.ZScores[2] = score.ZNorm{Mean: 120}
+	if errs := p.ValidateDetailed(); p.Validate() || len(errs) != 1 || !strings.Contains(errs[0].Error(), "ZScores[2].SD") {
+		t.Errorf("ValidateDetailed = %v", errs)
+	}
+}
+
+func TestParams_Normalization(t *testing.T) {
+	v := score.Vitals{HR: 80, RR: 16, SBP: 120, DBP: 80, Temp: 37, SpO2: 60, GCS: 15}
+	p, err := NewParamsBuilder().Normalization(score.NormalizeSoftmax, 4).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, def := NewEngine(p).Acuity(v, 0), NewEngine(DefaultParams()).Acuity(v, 0); !(got > 4*def) {
+		t.Errorf("softmax acuity %v, want well above the weighted mean %v", got, def)
+	}
+	data, _ := json.Marshal(p)
+	var q Params
+	if err := json.Unmarshal(data, &q); err != nil || !q.Equal(p) || !strings.Contains(string(data), `"normalization":"softmax","softmax_sharpness":4`) {
+		t.Errorf("round trip %s: %v", data, err)
+	}
+	env := map[string]string{"TRIAGEGEIST_NORMALIZATION": "max"}
+	if e, err := ParamsFromEnv(func(k string) (string, bool) { v, ok := env[k]; return v, ok }); err != nil || e.Normalization != score.NormalizeMax {
+		t.Errorf("from env: %v, %v", e.Normalization, err)
+	}
+	p.Normalization = 7
+	if errs := p.ValidateDetailed(); p.Validate() || len(errs) != 1 || !strings.Contains(errs[0].Error(), "Normalization") {
+		t.Errorf("ValidateDetailed = %v", errs)
+	}
+}
+
+func TestParams_FormulaVersion(t *testing.T) {
+	p := DefaultParams()
+	if p.Formula() != FormulaV1 || FormulaV1.String() != "v1" {
+		t.Errorf("default formula %v", p.Formula())
+	}
+	r := NewEngine(p).Evaluate(score.Vitals{HR: 118, RR: 24, SBP: 95}, 2)
+	if r.FormulaVersion != FormulaV1 || r.ToExport().FormulaVersion != 1 {
+		t.Errorf("result formula %v", r.FormulaVersion)
+	}
+	q := p
+	q.FormulaVersion = FormulaV1
+	if !p.Equal(q) {
+		t.Error("0 and FormulaV1 differ")
+	}
+	q.FormulaVersion = LatestFormula + 1
+	if errs := q.ValidateDetailed(); q.Validate() || len(errs) != 1 || !strings.Contains(errs[0].Error(), "FormulaVersion") {
+		t.Errorf("undefined formula version: %v", errs)
+	}
+	q.FormulaVersion = FormulaV1
+	data, err := json.Marshal(q)
+	if err != nil || !strings.Contains(string(data), `"formula_version":1`) {
+		t.Fatalf("marshal: %s %v", data, err)
+	}
+	var back Params
+	if err := json.Unmarshal(data, &back); err != nil || back.FormulaVersion != FormulaV1 {
+		t.Errorf("round trip: %v %v", back.FormulaVersion, err)
+	}
+	if q, err := NewParamsBuilder().FormulaVersion(FormulaV1).Build(); err != nil || q.FormulaVersion != FormulaV1 {
+		t.Errorf("builder: %v %v", q.FormulaVersion, err)
+	}
+}
+
+func TestLoadRanges(t *testing.T) {
+	dir := t.TempDir()
+	r := norm.PediatricRanges()
+	for _, name := range []string{"ranges.yaml", "ranges.json"} {
+		path := filepath.Join(dir, name)
+		if err := SaveRanges(path, r); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := LoadRanges(path); err != nil || got != r {
+			t.Errorf("%s: %+v %v", name, got, err)
+		}
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "ranges.yaml"))
+	if !strings.HasPrefix(string(data), "hr: [100, 50]\nrr: [24, 14]\n") {
+		t.Errorf("YAML:\n%s", data)
+	}
+	path := filepath.Join(dir, "asym.yml")
+	a := norm.DefaultAsymmetricRanges()
+	if err := SaveAsymmetricRanges(path, a); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LoadAsymmetricRanges(path); err != nil || got != a {
+		t.Errorf("asymmetric: %+v %v", got, err)
+	}
+	os.WriteFile(path, []byte("# site\nhr: [80, 40]\n"), 0o644)
+	if _, err := LoadRanges(path); err == nil || !strings.Contains(err.Error(), "missing rr") {
+		t.Errorf("incomplete file: %v", err)
+	}
+	if err := SaveRanges(path, norm.Ranges{HR: [2]float64{80, -1}}); err == nil {
+		t.Error("invalid ranges saved")
+	}
+}
+
+func TestEngine_WithDeviationFunc(t *testing.T) {
+	gcs, err := score.DeviationTable([]float64{8, 9, 13, 14, 15}, []float64{1, 0.8, 0.4, 0.2, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := score.Vitals{HR: 80, RR: 16, SBP: 120, Temp: 37, SpO2: 98, GCS: 14}
+	plain := NewDefaultEngine().WithCache(8)
+	plain.Acuity(v, 1)
+	eng := plain.WithDeviationFunc(FieldGCS, gcs)
+	p := DefaultParams()
+	o := p.ScoreOptions()
+	o.Deviations = &[7]score.DeviationFunc{6: gcs}
+	want := score.AcuityWithOptions(v, 1, p.MaxResources, p.VitalWeights, p.ResourceWeight, o)
+	if eng.Cache != nil || eng.Acuity(v, 1) != want || !(want > plain.Acuity(v, 1)) {
+		t.Errorf("Acuity = %v, want %v (plain %v)", eng.Acuity(v, 1), want, plain.Acuity(v, 1))
+	}
+	r := eng.EvaluateDetailed("gcs", v, 1, time.Time{})
+	if r.Acuity != want || r.Breakdown.Deviation[6] != 0.2 {
+		t.Errorf("EvaluateDetailed = %v, breakdown %+v", r.Acuity, r.Breakdown)
+	}
+	if c := eng.EvaluateWithContext(v, 1, PatientContext{AgeYears: 40}); c.Acuity != want {
+		t.Errorf("EvaluateWithContext = %v, want %v", c.Acuity, want)
+	}
+	if off := eng.WithDeviationFunc(FieldGCS, nil); off.Deviations != nil || off.Acuity(v, 1) != plain.Acuity(v, 1) {
+		t.Error("a nil DeviationFunc should restore the curve")
+	}
+}
+
+func TestParams_ResourceRate(t *testing.T) {
+	v := score.Vitals{HR: 95, RR: 18, SpO2: 96}
+	p, err := NewParamsBuilder().ResourceScale(score.ResourceExp).ResourceRate(1).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow := DefaultParams()
+	slow.ResourceScale = score.ResourceExp
+	eng, def := NewEngine(p), NewEngine(slow)
+	if !(eng.Acuity(v, 2) > def.Acuity(v, 2)) || eng.Acuity(v, 6) != def.Acuity(v, 6) {
+		t.Errorf("rate 1: %v, %v; default rate: %v, %v", eng.Acuity(v, 2), eng.Acuity(v, 6), def.Acuity(v, 2), def.Acuity(v, 6))
+	}
+	if b := ComputeBreakdown(v, 2, p); math.Abs(b.Resource*(score.WeightSum(p.VitalWeights)+p.ResourceWeight)-score.ResourceComponentWithOptions(2, 6, p.ResourceWeight, p.ScoreOptions())) > 1e-12 {
+		t.Errorf("Breakdown.Resource = %v", b.Resource)
+	}
+	data, _ := json.Marshal(p)
+	var q Params
+	if err := json.Unmarshal(data, &q); err != nil || !q.Equal(p) || !strings.Contains(string(data), `"resource_scale":"exp","resource_rate":1`) {
+		t.Errorf("round trip %s: %v", data, err)
+	}
+	p.ResourceRate = -1
+	if errs := p.ValidateDetailed(); p.Validate() || len(errs) != 1 || !strings.Contains(errs[0].Error(), "ResourceRate") {
+		t.Errorf("ValidateDetailed = %v", errs)
+	}
+}
+
+func TestEngine_BatchAcuityColumnar(t *testing.T) {
+	vitals := []score.Vitals{
+		{HR: 120, RR: 24, SBP: 90, Temp: 38.5, SpO2: 92, GCS: 14},
+		{HR: 80, RR: 16, SBP: 120, Temp: 37, SpO2: 98, GCS: 15, OnOxygen: true, FiO2: 0.4},
+		{},
+	}
+	rcs := []int{3, 0, 1}
+	cols := VitalColumns{
+		HR:       []int{120, 80, 0},
+		RR:       []int{24, 16, 0},
+		SBP:      []int{90, 120, 0},
+		Temp:     []float64{38.5, 37, 0},
+		SpO2:     []int{92, 98, 0},
+		GCS:      []int{14, 15, 0},
+		OnOxygen: []bool{false, true, false},
+		FiO2:     []float64{0, 0.4, 0},
+	}
+	p := DefaultParams()
+	p.MAPWeight = 0.1
+	eng := NewEngine(p)
+	out := make([]float64, 3)
+	if err := eng.BatchAcuityColumnar(cols, rcs, out); err != nil {
+		t.Fatal(err)
+	}
+	for i, a := range eng.BatchAcuity(vitals, rcs) {
+		if out[i] != a {
+			t.Errorf("record %d: %v, want %v", i, out[i], a)
+		}
+	}
+	if allocs := testing.AllocsPerRun(100, func() { _ = eng.BatchAcuityColumnar(cols, rcs, out) }); allocs != 0 {
+		t.Errorf("allocs = %v, want 0", allocs)
+	}
+	if err := eng.BatchAcuityColumnar(cols, rcs, out[:2]); err == nil {
+		t.Error("expected an error for a short out")
+	}
+	cols.SBP = cols.SBP[:2]
+	if err := eng.BatchAcuityColumnar(cols, rcs, out); err == nil || !strings.Contains(err.Error(), "SBP") {
+		t.Errorf("short column: %v", err)
+	}
+}
+
+func BenchmarkEngine_BatchAcuityColumnar(b *testing.B) {
+	const n = 1024
+	cols := VitalColumns{HR: make([]int, n), RR: make([]int, n), SBP: make([]int, n), SpO2: make([]int, n)}
+	for i := 0; i < n; i++ {
+		cols.HR[i], cols.RR[i], cols.SBP[i], cols.SpO2[i] = 60+i%80, 12+i%20, 80+i%80, 85+i%15
+	}
+	rcs, out := make([]int, n), make([]float64, n)
+	eng := NewEngine(DefaultParams())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = eng.BatchAcuityColumnar(cols, rcs, out)
+	}
+}
+
+func TestParams_ContinuousLevel(t *testing.T) {
+	top := DefaultParams()
+	top.T1 = 1
+	for _, p := range []Params{DefaultParams(), PresetStrict(), PresetResearch(), top} {
+		prev := 0.0
+		for i := 0; i <= 1000; i++ {
+			s := float64(i) / 1000
+			c := p.ScoreToLevelContinuous(s)
+			if c < 1 || c > 5 || (i > 0 && !(c < prev)) {
+				t.Fatalf("T1 %v: level(%v) = %v, previous %v", p.T1, s, c, prev)
+			}
+			prev = c
+			if l := Level(math.Ceil(c - 0.5)); l != FromScore(s, p) {
+				t.Errorf("T1 %v: level(%v) = %v rounds to %v, FromScore %v", p.T1, s, c, l, FromScore(s, p))
+			}
+			if back := p.LevelToScore(c); math.Abs(back-s) > 1e-12 {
+				t.Errorf("T1 %v: LevelToScore(%v) = %v, want %v", p.T1, c, back, s)
+			}
+		}
+	}
+	p := DefaultParams()
+	if p.ScoreToLevelContinuous(p.T2) != 2.5 || p.LevelToScore(2.5) != p.T2 || p.LevelToScore(5) != 0 || p.LevelToScore(1) != 1 {
+		t.Error("knots not exact")
+	}
+	if p.ScoreToLevelContinuous(1.3) != 1 || p.ScoreToLevelContinuous(-0.2) != 5 || p.LevelToScore(0) != 1 || p.LevelToScore(7) != 0 {
+		t.Error("out-of-range inputs not clamped")
+	}
+	if top.LevelToScore(1.2) != 1 {
+		t.Errorf("T1 = 1: LevelToScore(1.2) = %v", top.LevelToScore(1.2))
+	}
+}
+
+func TestParams_ValidateDetailed(t *testing.T) {
+	for _, p := range []Params{DefaultParams(), PresetStrict(), PresetLenient(), PresetGeriatric(), PresetPediatric(), PresetResearch()} {
+		if errs := p.ValidateDetailed(); errs != nil {
+			t.Errorf("preset: %v", errs)
+		}
+	}
+	p := DefaultParams()
+	p.T1, p.T2 = 0.65, 0.7
+	p.VitalWeights[2] = -0.1
+	p.GCSBanded = true
+	p.GCSBands.Mild = 0.8
+	p.GrayZone = 0.3
+	errs := p.ValidateDetailed()
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	want := []string{
+		"VitalWeights[2] (-0.1) must be in [0, 1]",
+		"T2 (0.7) must be less than T1 (0.65)",
+		"GCSBands.Moderate (0.7) must be at least GCSBands.Mild (0.8)",
+		"GrayZone (0.3) must be in [0, 0.25]",
+	}
+	if strings.Join(msgs, "|") != strings.Join(want, "|") {
+		t.Errorf("errors =\n%s\nwant\n%s", strings.Join(msgs, "\n"), strings.Join(want, "\n"))
+	}
+	var pe ParamError
+	if !errors.As(errs[1], &pe) || pe.Field != "T2" || pe.Value != 0.7 {
+		t.Errorf("ParamError = %+v", pe)
+	}
+
+	// ValidateDetailed and Validate agree field by field.
+	mutations := []func(*Params){
+		func(p *Params) { p.MaxResources = -1 },
+		func(p *Params) { p.ResourceWeight = -1 },
+		func(p *Params) { p.T1 = 1.1 },
+		func(p *Params) { p.T4 = 0 },
+		func(p *Params) { p.T3 = p.T2 },
+		func(p *Params) { p.MAPWeight = 2 },
+		func(p *Params) { p.ResourceScale = 99 },
+		func(p *Params) { p.RespiratoryWeight = -0.5 },
+		func(p *Params) { p.QSOFABump = 1.5 },
+		func(p *Params) { p.Reliability[score.SourceWearable][6] = 2 },
+		func(p *Params) { p.Hysteresis = -0.01 },
+		func(p *Params) { p.GCSBands.Normal = -1 },
+		func(p *Params) { p.VitalWeights[0] = math.NaN() },
+		func(p *Params) { p.ResourceWeight = math.Inf(1) },
+		func(p *Params) { p.Reference = &ReferenceDistribution{} },
+	}
+	for i, m := range mutations {
+		q := DefaultParams()
+		m(&q)
+		if q.Validate() != (len(q.ValidateDetailed()) == 0) || q.Validate() != ValidateParamsExternal(q) {
+			t.Errorf("mutation %d: Validate %v, external %v, ValidateDetailed %v", i, q.Validate(), ValidateParamsExternal(q), q.ValidateDetailed())
+		}
+	}
+
+	// validate.Params checks Params directly and groups by field.
+	r := validate.Params(p)
+	if r.Valid || r.WeightsOK || r.ThresholdsOK || !r.MaxResOK || !r.ResourceWOK || len(r.Errors) != 4 {
+		t.Errorf("validate.Params = %+v", r)
+	}
+	// The deprecated validate.ParamsLike applies the same rules to its fields.
+	p.Reliability[score.SourceWearable][6] = 2
+	pl := validate.ParamsLike{
+		VitalWeights: p.VitalWeights, MaxResources: p.MaxResources, ResourceWeight: p.ResourceWeight,
+		T1: p.T1, T2: p.T2, T3: p.T3, T4: p.T4, GCSBanded: p.GCSBanded, GCSBands: p.GCSBands,
+		Reliability: p.Reliability, GrayZone: p.GrayZone,
+	}
+	if got, want := fmt.Sprint(pl.ValidateDetailed()), fmt.Sprint(p.ValidateDetailed()); got != want {
+		t.Errorf("ParamsLike errors = %s, want %s", got, want)
+	}
+}
+
+func TestReferenceDistribution(t *testing.T) {
+	scores := make([]float64, 101)
+	for i := range scores {
+		scores[i] = float64(i) / 100
+	}
+	d := NewReferenceDistribution(scores, 11)
+	if !d.Valid() || d.N != 101 || len(d.Quantiles) != 11 || d.Quantiles[5] != 0.5 {
+		t.Fatalf("d = %+v", d)
+	}
+	for s, want := range map[float64]float64{-1: 0, 0: 0, 0.25: 25, 0.93: 93, 1: 100, 2: 100} {
+		if got := d.PercentileRank(s); math.Abs(got-want) > 1e-9 {
+			t.Errorf("PercentileRank(%v) = %v, want %v", s, got, want)
+		}
+	}
+	tied := ReferenceDistribution{Quantiles: []float64{0, 0.2, 0.2, 0.2, 1}}
+	if got := tied.PercentileRank(0.2); got != 50 {
+		t.Errorf("tied rank = %v", got)
+	}
+	if (ReferenceDistribution{}).PercentileRank(0.5) != 0 || NewReferenceDistribution(nil, 0).Valid() {
+		t.Error("empty distribution should be invalid")
+	}
+
+	eng := NewDefaultEngine()
+	v := score.Vitals{HR: 120, RR: 24, SBP: 90, SpO2: 92}
+	if eng.Evaluate(v, 3).Percentile != 0 {
+		t.Error("percentile set without a reference")
+	}
+	r := eng.WithReference(d).Evaluate(v, 3)
+	if math.Abs(r.Percentile-r.Acuity*100) > 1e-9 || r.ToExport().Percentile != r.Percentile {
+		t.Errorf("percentile = %v for acuity %v", r.Percentile, r.Acuity)
+	}
+}
+
+func TestParamsBuilder(t *testing.T) {
+	p, err := NewParamsBuilder().
+		Weights(0.2, 0.2, 0.15, 0.1, 0.1, 0.15, 0.1).
+		Thresholds(0.8, 0.55, 0.3, 0.12).
+		MaxResources(5).
+		GrayZone(0.02).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultParams()
+	want.VitalWeights = [7]float64{0.2, 0.2, 0.15, 0.1, 0.1, 0.15, 0.1}
+	want.SetThresholds(0.8, 0.55, 0.3, 0.12)
+	want.MaxResources = 5
+	want.GrayZone = 0.02
+	if !p.Equal(want) {
+		t.Errorf("p = %+v", p)
+	}
+
+	_, err = ParamsBuilderFrom(p).Weights(0.1, 0.2).Weight(9, 1).Thresholds(0.5, 0.6, 0.3, 0.1).Build()
+	if err == nil {
+		t.Fatal("invalid builder should fail")
+	}
+	for _, s := range []string{"Weights: got 2 values, want 7", "Weight: index 9 not in 0..6", "T2 (0.6) must be less than T1 (0.5)"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("error %q lacks %q", err, s)
+		}
+	}
+}
+
+func TestParams_Asymmetric(t *testing.T) {
+	p := DefaultParams()
+	p.SplitWeights()
+	v := score.Vitals{HR: 118, RR: 24, SBP: 85, SpO2: 93}
+	if NewEngine(p).Acuity(v, 2) != NewDefaultEngine().Acuity(v, 2) || !p.Validate() || p.Equal(DefaultParams()) {
+		t.Fatal("SplitWeights should score like the symmetric weights")
+	}
+	p.AsymmetricWeights.Low[2] = 0.6
+	if NewEngine(p).Acuity(v, 2) <= NewDefaultEngine().Acuity(v, 2) {
+		t.Error("raising the low SBP weight should raise acuity for hypotension")
+	}
+	if w := p.WeightsFor(v); w[2] != 0.6 || ComputeBreakdown(v, 2, p).Contribution[2] <= ComputeBreakdown(v, 2, DefaultParams()).Contribution[2] {
+		t.Errorf("WeightsFor = %v", w)
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var q Params
+	if err := json.Unmarshal(b, &q); err != nil || !q.Equal(p) {
+		t.Fatalf("round trip: %v, %s", err, b)
+	}
+	if b, _ := json.Marshal(DefaultParams()); strings.Contains(string(b), "asymmetric") {
+		t.Errorf("symmetric params serialized asymmetric weights: %s", b)
+	}
+	path := filepath.Join(t.TempDir(), "site.yaml")
+	if err := SaveParams(path, p); err != nil {
+		t.Fatal(err)
+	}
+	if q, err := LoadParams(path); err != nil || !q.Equal(p) {
+		t.Errorf("YAML round trip: %v", err)
+	}
+
+	p.AsymmetricWeights.High[5] = 2
+	if p.Validate() || ValidateParamsExternal(p) {
+		t.Error("weight 2 should be invalid")
+	}
+	if errs := p.ValidateDetailed(); len(errs) != 1 || errs[0].Error() != "AsymmetricWeights.High[5] (2) must be in [0, 1]" {
+		t.Errorf("ValidateDetailed = %v", errs)
+	}
+	if err := json.Unmarshal([]byte(`{"asymmetric_weights": {"low": [1], "high": [1]}}`), &q); err == nil {
+		t.Error("short asymmetric_weights should fail")
+	}
+}
+
+func TestParams_Provenance(t *testing.T) {
+	p := DefaultParams()
+	p.Provenance = Provenance{
+		Name:          "site-a",
+		Version:       "2026.3",
+		CalibratedAt:  time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Author:        "calibration team",
+		SourceDataset: "ed-2025-q4",
+	}
+	if p.Hash() != DefaultParams().Hash() || !p.Equal(DefaultParams()) {
+		t.Error("provenance should not affect Hash or Equal")
+	}
+	for _, name := range []string{"site.json", "site.yaml"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := SaveParams(path, p); err != nil {
+			t.Fatal(err)
+		}
+		q, err := LoadParams(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		want := p.Provenance
+		want.Hash = p.Hash()
+		if q.Provenance != want {
+			t.Errorf("%s: provenance = %+v, want %+v", name, q.Provenance, want)
+		}
+	}
+
+	stale := p
+	stale.Provenance.Hash = p.Hash()
+	stale.T4 = 0.1
+	b, _ := json.Marshal(stale)
+	var q Params
+	if err := json.Unmarshal(b, &q); err == nil || !strings.Contains(err.Error(), "provenance hash") {
+		t.Errorf("stale hash: %v", err)
+	}
+
+	if in, err := hashInput(p); err != nil || strings.Contains(string(in), "schema_version") {
+		t.Errorf("hash input should leave out schema_version: %s %v", in, err)
+	}
+	nan := p
+	nan.T4 = math.NaN()
+	if h := nan.Hash(); h != "" {
+		t.Errorf("unencodable params hash = %q, want empty", h)
+	}
+
+	p.Provenance.Hash = p.Hash()
+	r := NewEngine(p).Evaluate(score.Vitals{HR: 110, RR: 22}, 2)
+	x := r.ToExport()
+	if r.ParamsName != "site-a" || x.ParamsVersion != "2026.3" || x.ParamsHash != p.Hash() {
+		t.Errorf("provenance not echoed: %+v", x)
+	}
+	// An edit in code leaves Provenance.Hash stale; the result reports the
+	// parameters actually used, with or without the engine's memo.
+	stale = p
+	stale.T4 = 0.1
+	for _, eng := range []*Engine{NewEngine(stale), {P: stale}} {
+		if r := eng.Evaluate(score.Vitals{HR: 110}, 2); r.ParamsHash != stale.Hash() || r.ParamsHash == p.Provenance.Hash {
+			t.Errorf("ParamsHash = %q, want computed %q", r.ParamsHash, stale.Hash())
+		}
+	}
+}
+
+func TestParamsWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "site.yaml")
+	if err := os.WriteFile(path, []byte("t4: 0.12\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewManagedEngine(NewDefaultEngine())
+	events := make(chan ReloadEvent, 8)
+	w := NewParamsWatcher(path, m, func(ev ReloadEvent) { events <- ev })
+	if err := w.Start(5 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	if ev := <-events; ev.Err != nil || m.Params().T4 != 0.12 {
+		t.Fatalf("initial load: %+v, T4 %v", ev.Err, m.Params().T4)
+	}
+
+	replace := func(src string) {
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	replace("t4: 0.9\n")
+	if ev := <-events; ev.Err == nil || m.Params().T4 != 0.12 {
+		t.Errorf("invalid file should be rejected: %v, T4 %v", ev.Err, m.Params().T4)
+	}
+	replace("t4: 0.1\n")
+	if ev := <-events; ev.Err != nil || ev.Params.T4 != 0.1 || m.Params().T4 != 0.1 {
+		t.Errorf("reload: %v, T4 %v", ev.Err, m.Params().T4)
+	}
+	w.Stop()
+	if changed, err := w.Check(); changed || err != nil {
+		t.Errorf("unchanged file: %v, %v", changed, err)
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected event %+v", ev)
+	default:
+	}
+	// Reloads parse the bytes that were hashed, not a second read.
+	if p, err := parseParams(filepath.Join(t.TempDir(), "absent.yaml"), []byte("t4: 0.11\n")); err != nil || p.T4 != 0.11 {
+		t.Errorf("parseParams: %v, T4 %v", err, p.T4)
+	}
+}
+
+func TestParamsFromEnv(t *testing.T) {
+	env := map[string]string{
+		"TRIAGEGEIST_T1":             "0.8",
+		"TRIAGEGEIST_WEIGHT_SPO2":    " 0.2 ",
+		"TRIAGEGEIST_GCS_BANDED":     "true",
+		"TRIAGEGEIST_RESOURCE_SCALE": "sqrt",
+		"TRIAGEGEIST_HYSTERESIS":     "",
+	}
+	lookup := func(k string) (string, bool) { v, ok := env[k]; return v, ok }
+	p, err := ParamsFromEnv(lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultParams()
+	want.T1, want.VitalWeights[5], want.GCSBanded, want.ResourceScale = 0.8, 0.2, true, score.ResourceSqrt
+	if !p.Equal(want) {
+		t.Errorf("p = %+v, want %+v", p, want)
+	}
+
+	path := filepath.Join(t.TempDir(), "site.yaml")
+	os.WriteFile(path, []byte("t4: 0.12\n"), 0o644)
+	env = map[string]string{"TRIAGEGEIST_CONFIG": path, "TRIAGEGEIST_T3": "0.4"}
+	if p, err := ParamsFromEnv(lookup); err != nil || p.T4 != 0.12 || p.T3 != 0.4 {
+		t.Errorf("layered over file: T3 %v, T4 %v, %v", p.T3, p.T4, err)
+	}
+
+	// The file's provenance no longer describes overridden parameters.
+	site := DefaultParams()
+	site.Provenance = Provenance{Name: "site-a", Version: "2026.3"}
+	if err := SaveParams(path, site); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := ParamsFromEnv(lookup); err != nil || p.Provenance.Name != "site-a" || p.Provenance.Version != "" || p.Provenance.Hash != p.Hash() {
+		t.Errorf("overridden provenance = %+v, %v", p.Provenance, err)
+	}
+	env["TRIAGEGEIST_PARAMS_VERSION"] = "2026.3-env"
+	if p, err := ParamsFromEnv(lookup); err != nil || p.Provenance.Version != "2026.3-env" {
+		t.Errorf("explicit version = %+v, %v", p.Provenance, err)
+	}
+	env = map[string]string{"TRIAGEGEIST_CONFIG": path, "TRIAGEGEIST_PARAMS_NAME": "site-b"}
+	if p, err := ParamsFromEnv(lookup); err != nil || p.Provenance.Version != "2026.3" || p.Provenance.Hash != site.Hash() {
+		t.Errorf("provenance-only override = %+v, %v", p.Provenance, err)
+	}
+
+	env = map[string]string{"TRIAGEGEIST_T1": "high", "TRIAGEGEIST_MAX_RESOURCES": "1.5"}
+	_, err = ParamsFromEnv(lookup)
+	if err == nil || !strings.Contains(err.Error(), "TRIAGEGEIST_T1") || !strings.Contains(err.Error(), "TRIAGEGEIST_MAX_RESOURCES") {
+		t.Errorf("malformed values: %v", err)
+	}
+	env = map[string]string{"TRIAGEGEIST_T4": "0.9"}
+	if _, err := ParamsFromEnv(lookup); err == nil {
+		t.Error("invalid thresholds accepted")
+	}
+
+	t.Setenv("TRIAGEGEIST_T_1", "0.8")
+	t.Setenv("TRIAGEGEIST_T2", "0.55")
+	if p, unknown, err := LoadParamsFromEnv(); err != nil || p.T2 != 0.55 || len(unknown) != 1 || unknown[0] != "TRIAGEGEIST_T_1" {
+		t.Errorf("unknown variable should be returned, not fail: %v, T2 %v, unknown %v", err, p.T2, unknown)
+	}
+	if u := UnknownEnvVars(); len(u) != 1 || u[0] != "TRIAGEGEIST_T_1" {
+		t.Errorf("UnknownEnvVars = %v", u)
+	}
+}
+
+func TestSurgeScheduler(t *testing.T) {
+	m := NewManagedEngine(NewDefaultEngine())
+	surge := DefaultParams()
+	surge.T4 = 0.12
+	night := DefaultParams()
+	night.T1 = 0.75
+	var switches []SurgeSwitch
+	s, err := NewSurgeScheduler(m, DefaultParams(), func(sw SurgeSwitch) { switches = append(switches, sw) },
+		SurgeMode{Name: "surge", Params: surge, EnterSignal: 140, ExitSignal: 100},
+		SurgeMode{Name: "night", Params: night, Start: 22 * time.Hour, End: 6 * time.Hour},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noon := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, ok := s.SetSignal(120, noon); ok || s.Active() != SurgeModeNormal {
+		t.Fatalf("below EnterSignal: active %q", s.Active())
+	}
+	if sw, ok := s.SetSignal(150, noon); !ok || sw.From != SurgeModeNormal || sw.To != "surge" || sw.Signal != 150 || m.Params().T4 != 0.12 {
+		t.Fatalf("enter surge: %+v, T4 %v", sw, m.Params().T4)
+	}
+	if _, ok := s.SetSignal(110, noon); ok {
+		t.Error("left surge above ExitSignal")
+	}
+	if r := m.Evaluate(score.Vitals{HR: 80}, 1); r.Mode != "surge" || r.ParamsName != "" || r.ToExport().Mode != "surge" {
+		t.Errorf("Mode = %q, ParamsName = %q", r.Mode, r.ParamsName)
+	}
+	s.SetSignal(90, noon)
+	if sw, ok := s.Update(noon.Add(11 * time.Hour)); !ok || sw.To != "night" || m.Params().T1 != 0.75 {
+		t.Errorf("night window: %+v", sw)
+	}
+	if s.Active() != "night" || len(s.History()) != 3 || len(switches) != 3 {
+		t.Errorf("active %q, history %+v", s.Active(), s.History())
+	}
+	// The mode is recorded apart from the provenance of a named parameter set.
+	named := DefaultParams()
+	named.Provenance.Name = "site-a"
+	ns, err := NewSurgeScheduler(NewManagedEngine(NewDefaultEngine()), named, nil, SurgeMode{Name: "surge", Params: surge, EnterSignal: 140})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := ns.engine.Evaluate(score.Vitals{HR: 80}, 1); r.Mode != SurgeModeNormal || r.ParamsName != "site-a" {
+		t.Errorf("Mode = %q, ParamsName = %q", r.Mode, r.ParamsName)
+	}
+
+	if _, err := NewSurgeScheduler(m, DefaultParams(), nil, SurgeMode{Name: SurgeModeNormal, Params: surge}); err == nil {
+		t.Error("reserved mode name accepted")
+	}
+	bad := DefaultParams()
+	bad.T4 = 0.9
+	if _, err := NewSurgeScheduler(m, DefaultParams(), nil, SurgeMode{Name: "bad", Params: bad}); err == nil {
+		t.Error("invalid mode params accepted")
+	}
+}
+
+func TestMigrateParams(t *testing.T) {
+	v1 := `{"vital_weights": [0.2, 0.22, 0.16, 0.1, 0.08, 0.16, 0.1], "max_resources": 6, "resource_weight": 0.25,
+		"t1": 0.8, "t2": 0.55, "t3": 0.3, "t4": 0.12, "map_weight": 0, "resource_scale": "linear", "gcs_banded": false,
+		"gcs_bands": {"normal": 0, "mild": 0.35, "moderate": 0.7, "severe": 1}, "respiratory_weight": 0, "qsofa_bump": 0}`
+	p, r, err := MigrateParams([]byte(v1), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PresetStrict()
+	want.VitalWeights[0] = 0.2
+	if !p.Equal(want) {
+		t.Errorf("p = %+v", p)
+	}
+	if !r.Migrated() || r.From != 1 || r.To != ParamsSchemaVersion || len(r.Steps) != ParamsSchemaVersion-1 ||
+		strings.Join(r.Defaulted, ",") != "gray_zone,hysteresis,reliability" {
+		t.Errorf("report = %+v", r)
+	}
+
+	path := filepath.Join(t.TempDir(), "site.yaml")
+	os.WriteFile(path, []byte("schema_version: 3\nt4: 0.12\n"), 0o644)
+	if r, err := MigrateParamsFile(path); err != nil || r.From != 3 || len(r.Steps) != ParamsSchemaVersion-3 {
+		t.Fatalf("MigrateParamsFile = %+v, %v", r, err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), fmt.Sprintf("schema_version: %d\n", ParamsSchemaVersion)) {
+		t.Errorf("rewritten file:\n%s", data)
+	}
+	if r, err := MigrateParamsFile(path); err != nil || r.Migrated() || len(r.Defaulted) != 0 {
+		t.Errorf("current file migrated again: %+v, %v", r, err)
+	}
+	if q, err := LoadParams(path); err != nil || q.T4 != 0.12 {
+		t.Errorf("LoadParams after migration: %v", err)
+	}
+
+	if _, _, err := MigrateParams([]byte(`{"schema_version": 99}`), false); err == nil {
+		t.Error("newer schema accepted by MigrateParams")
+	}
+	var q Params
+	if err := json.Unmarshal([]byte(`{"schema_version": 99}`), &q); err == nil {
+		t.Error("newer schema accepted by UnmarshalJSON")
+	}
+}
+
+func TestFlags(t *testing.T) {
+	f := NewFlags(" Interaction_Terms", "asymmetric_norms", "", "interaction_terms")
+	if f.Len() != 2 || f.String() != "asymmetric_norms,interaction_terms" || !f.Enabled("INTERACTION_TERMS") || f.Enabled("other") {
+		t.Fatalf("f = %v", f)
+	}
+	if (Flags{}).Names() != nil || (Flags{}).Enabled("") {
+		t.Error("zero Flags should be empty")
+	}
+
+	base := NewDefaultEngine()
+	eng := base.WithFlags("interaction_terms").WithFlags("asymmetric_norms")
+	if base.Flags.Len() != 0 || !eng.Enabled("asymmetric_norms") || !eng.Enabled("interaction_terms") {
+		t.Fatalf("base = %v, eng = %v", base.Flags, eng.Flags)
+	}
+	v := score.Vitals{HR: 120, RR: 24, SBP: 90, SpO2: 92}
+	if base.Evaluate(v, 3).Flags != nil {
+		t.Error("flags recorded without any set")
+	}
+	r := eng.EvaluateWithContext(v, 3, PatientContext{})
+	want := []string{"asymmetric_norms", "interaction_terms"}
+	if strings.Join(r.Flags, ",") != strings.Join(want, ",") {
+		t.Errorf("Flags = %v, want %v", r.Flags, want)
+	}
+	x := r.ToExport()
+	x.Flags[0] = "mutated"
+	if r.Flags[0] != "asymmetric_norms" {
+		t.Error("ToExport shares the Flags slice")
+	}
+	b, _ := json.Marshal(base.Evaluate(v, 3).ToExport())
+	if strings.Contains(string(b), "flags") {
+		t.Errorf("empty flags serialized: %s", b)
+	}
+
+	// The built-in flags change scoring.
+	p := DefaultParams()
+	resp := p
+	resp.RespiratoryWeight = InteractionRespiratoryWeight
+	if got, want := base.WithFlags(FlagInteractionTerms).Acuity(v, 3), NewEngine(resp).Acuity(v, 3); got != want || got == base.Acuity(v, 3) {
+		t.Errorf("interaction_terms: %v, want %v", got, want)
+	}
+	asym := score.AcuityWithAsymmetricRanges(v, 3, p.MaxResources, p.VitalWeights, p.ResourceWeight, norm.DefaultAsymmetricRanges())
+	if got := base.WithFlags(FlagAsymmetricNorms).Evaluate(v, 3).Acuity; math.Abs(got-asym) > 1e-12 || got == base.Acuity(v, 3) {
+		t.Errorf("asymmetric_norms: %v, want %v", got, asym)
+	}
+	ctx := PatientContext{AgeYears: 0.5}
+	if got, want := base.WithFlags(FlagAsymmetricNorms).EvaluateWithContext(v, 3, ctx).Acuity, base.EvaluateWithContext(v, 3, ctx).Acuity; got != want {
+		t.Errorf("asymmetric_norms should keep profile ranges: %v, want %v", got, want)
+	}
+
+	// Flag changes on a managed engine are audited.
+	m := NewManagedEngine(base)
+	if ch, ok := m.SetFlags(FlagInteractionTerms); !ok || ch.From != nil || strings.Join(ch.To, ",") != FlagInteractionTerms {
+		t.Errorf("SetFlags = %+v, %v", ch, ok)
+	}
+	if _, ok := m.SetFlags(" Interaction_Terms "); ok {
+		t.Error("unchanged flags recorded")
+	}
+	m.SetFlags()
+	if h := m.FlagHistory(); len(h) != 2 || h[1].To != nil || m.Engine().Flags.Len() != 0 {
+		t.Errorf("FlagHistory = %+v", h)
+	}
+}
+
+func TestParams_Calibration(t *testing.T) {
+	v := score.Vitals{HR: 120, RR: 26, SBP: 95, SpO2: 91}
+	platt := ProbabilityCalibration{Method: CalibrationPlatt, A: 6, B: -3, N: 100}
+	p, err := NewParamsBuilder().Calibration(platt).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewEngine(p).Evaluate(v, 2)
+	if want := 1 / (1 + math.Exp(-(6*r.Acuity - 3))); math.Abs(r.Probability-want) > 1e-12 {
+		t.Errorf("Probability = %v, want %v", r.Probability, want)
+	}
+	if r.ToExport().Probability != r.Probability {
+		t.Error("export lost the probability")
+	}
+	if NewDefaultEngine().Evaluate(v, 2).Probability != 0 {
+		t.Error("probability reported without a calibration")
+	}
+	iso := ProbabilityCalibration{Method: CalibrationIsotonic, X: []float64{0.2, 0.4, 0.6}, Y: []float64{0.1, 0.1, 0.7}, N: 50}
+	if got := iso.Probability(0.5); math.Abs(got-0.4) > 1e-12 {
+		t.Errorf("isotonic P(0.5) = %v, want 0.4", got)
+	}
+	if iso.Probability(0) != 0.1 || iso.Probability(0.9) != 0.7 {
+		t.Error("isotonic calibration should be constant beyond its knots")
+	}
+	for _, c := range []ProbabilityCalibration{platt, iso} {
+		q := DefaultParams()
+		q.Calibration = &c
+		path := filepath.Join(t.TempDir(), "site.yaml")
+		if err := SaveParams(path, q); err != nil {
+			t.Fatal(err)
+		}
+		got, err := LoadParams(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(q) || got.Equal(DefaultParams()) {
+			t.Errorf("%s calibration lost: %+v", c.Method, got.Calibration)
+		}
+	}
+	bad := DefaultParams()
+	bad.Calibration = &ProbabilityCalibration{Method: CalibrationIsotonic, X: []float64{0.5, 0.2}, Y: []float64{0.1, 0.2}}
+	if errs := bad.ValidateDetailed(); bad.Validate() || len(errs) != 1 || !strings.Contains(errs[0].Error(), "Calibration") {
+		t.Errorf("ValidateDetailed = %v", errs)
+	}
+}
+
+func TestParams_ReferenceRoundTrip(t *testing.T) {
+	d := NewReferenceDistribution([]float64{0.1, 0.2, 0.4, 0.8}, 5)
+	p := DefaultParams()
+	p.Reference = &d
+	path := filepath.Join(t.TempDir(), "site.yaml")
+	if err := SaveParams(path, p); err != nil {
+		t.Fatal(err)
+	}
+	q, err := LoadParams(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !q.Equal(p) || q.Equal(DefaultParams()) {
+		t.Errorf("reference lost: %+v", q.Reference)
+	}
+	p.Reference = &ReferenceDistribution{Quantiles: []float64{0.5, 0.1}}
+	if p.Validate() || len(p.ValidateDetailed()) != 1 {
+		t.Error("decreasing quantiles accepted")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+//
+// Pipeline example: the advanced example's steps (ingest, validate, score,
+// metrics, report) run through the reusable pipeline package, with a custom
+// report stage writing the level report and results as CSV.
+//
+// Run from repository root: go run ./examples/pipeline
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/olaflaitinen/triagegeist"
+	"github.com/olaflaitinen/triagegeist/export"
+	"github.com/olaflaitinen/triagegeist/pipeline"
+)
+
+// A small audit extract with reference levels assigned by triage nurses.
+const audit = `id,hr,rr,sbp,dbp,temp,spo2,gcs,resource_count,reference_level
+e01,120,24,90,,,92,,3,2
+e02,80,16,120,80,,98,,1,4
+e03,140,28,85,,,88,,4,1
+e04,70,14,130,,36.8,99,,0,5
+e05,100,20,100,,,94,,2,3
+e06,90,18,115,75,37.0,97,,1,4
+e07,130,26,88,,,91,,4,2
+e08,75,12,125,,,98,,0,5
+e09,,,,,,,,2,3
+e10,135,27,86,,,89,14,4,1
+`
+
+func main() {
+	p := pipeline.Pipeline{
+		Engine: triagegeist.NewStrictEngine(),
+		Source: pipeline.CSVSource(strings.NewReader(audit)),
+		Report: func(out pipeline.Output) error {
+			fmt.Println("--- Level report ---")
+			if err := export.WriteReportRowsCSV(os.Stdout, out.Levels); err != nil {
+				return err
+			}
+			fmt.Println("--- Results ---")
+			return export.WriteCSV(os.Stdout, out.Results)
+		},
+	}
+	out, err := p.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("--- Run summary ---")
+	fmt.Printf("Scored: %d, rejected: %d\n", len(out.Results), len(out.Rejected))
+	for _, r := range out.Rejected {
+		fmt.Printf("  rejected %s: %s\n", r.Record.ID, r.Reason)
+	}
+	fmt.Printf("Mean acuity: %.4f (95%% CI %.4f-%.4f)\n", out.Scores.Mean, out.Scores.CI95Lo, out.Scores.CI95Hi)
+	if out.Confusion != nil {
+		fmt.Printf("Agreement with reference: accuracy %.4f, kappa %.4f (n=%d)\n",
+			out.Confusion.OverallAccuracy(), out.Kappa, out.Confusion.Total)
+	}
+}
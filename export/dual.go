@@ -0,0 +1,174 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LegacyResult is the 13-field result schema of CSVHeader, for consumers
+// that have not migrated to the extended schema of Result.
+type LegacyResult struct {
+	HR            int       `json:"hr"`
+	RR            int       `json:"rr"`
+	SBP           int       `json:"sbp"`
+	DBP           int       `json:"dbp"`
+	Temp          float64   `json:"temp"`
+	SpO2          int       `json:"spo2"`
+	GCS           int       `json:"gcs"`
+	ResourceCount int       `json:"resource_count"`
+	Acuity        float64   `json:"acuity"`
+	Level         int       `json:"level"`
+	LevelLabel    string    `json:"level_label"`
+	Timestamp     time.Time `json:"timestamp,omitempty"`
+	ID            string    `json:"id,omitempty"`
+}
+
+// Legacy returns r restricted to the legacy schema.
+func (r Result) Legacy() LegacyResult {
+	return LegacyResult{
+		HR:            r.HR,
+		RR:            r.RR,
+		SBP:           r.SBP,
+		DBP:           r.DBP,
+		Temp:          r.Temp,
+		SpO2:          r.SpO2,
+		GCS:           r.GCS,
+		ResourceCount: r.ResourceCount,
+		Acuity:        r.Acuity,
+		Level:         r.Level,
+		LevelLabel:    r.LevelLabel,
+		Timestamp:     r.Timestamp,
+		ID:            r.ID,
+	}
+}
+
+// ExtendedCSVHeader returns CSVHeader followed by the JSON-only fields of
+// Result, so the extended schema can also be loaded as CSV.
+func ExtendedCSVHeader() []string {
+	return append(CSVHeader(), "on_oxygen", "fio2", "profile", "percentile", "flags",
+		"params_name", "params_version", "params_hash", "imputed",
+		"glucose", "lactate", "etco2", "cap_refill", "pain")
+}
+
+// ToExtendedCSVRow returns r in ExtendedCSVHeader order. Flags and Imputed
+// are joined with ";". Extended signs that were not recorded are empty.
+func (r Result) ToExtendedCSVRow() []string {
+	row := append(r.ToCSVRow(),
+		strconv.FormatBool(r.OnOxygen),
+		strconv.FormatFloat(r.FiO2, 'f', -1, 64),
+		r.Profile,
+		strconv.FormatFloat(r.Percentile, 'f', -1, 64),
+		strings.Join(r.Flags, ";"),
+		r.ParamsName,
+		r.ParamsVersion,
+		r.ParamsHash,
+		strings.Join(r.Imputed, ";"),
+	)
+	x := ResultToExtendedVitals(r)
+	vals := x.Values()
+	for i, ok := range x.Present() {
+		cell := ""
+		if ok {
+			cell = strconv.FormatFloat(vals[i], 'f', -1, 64)
+		}
+		row = append(row, cell)
+	}
+	return row
+}
+
+// Format selects the encoding of a DualWriter.
+type Format int
+
+const (
+	// FormatCSV writes a header row followed by one row per result.
+	FormatCSV Format = iota
+	// FormatJSONLines writes one JSON object per line.
+	FormatJSONLines
+)
+
+// DualWriter writes every result twice: in the legacy 13-field schema and
+// in the extended schema. It lets warehouse loaders migrate on their own
+// timeline during a schema transition. Call Flush when done.
+type DualWriter struct {
+	format           Format
+	legacy, extended io.Writer
+	lcsv, ecsv       *csv.Writer
+	header           bool
+}
+
+// NewDualWriter returns a DualWriter writing the legacy schema to legacy and
+// the extended schema to extended, both in format.
+func NewDualWriter(legacy, extended io.Writer, format Format) *DualWriter {
+	d := &DualWriter{format: format, legacy: legacy, extended: extended}
+	if format == FormatCSV {
+		d.lcsv, d.ecsv = csv.NewWriter(legacy), csv.NewWriter(extended)
+	}
+	return d
+}
+
+// Write writes r to both outputs. In CSV format the first call writes the
+// header rows.
+func (d *DualWriter) Write(r Result) error {
+	if d.format == FormatJSONLines {
+		if err := json.NewEncoder(d.legacy).Encode(r.Legacy()); err != nil {
+			return err
+		}
+		return json.NewEncoder(d.extended).Encode(r)
+	}
+	if err := d.writeHeader(); err != nil {
+		return err
+	}
+	if err := d.lcsv.Write(r.ToCSVRow()); err != nil {
+		return err
+	}
+	return d.ecsv.Write(r.ToExtendedCSVRow())
+}
+
+func (d *DualWriter) writeHeader() error {
+	if d.header {
+		return nil
+	}
+	if err := d.lcsv.Write(CSVHeader()); err != nil {
+		return err
+	}
+	if err := d.ecsv.Write(ExtendedCSVHeader()); err != nil {
+		return err
+	}
+	d.header = true
+	return nil
+}
+
+// Flush flushes buffered CSV output, writing the headers if nothing was
+// written yet, and reports any write error.
+func (d *DualWriter) Flush() error {
+	if d.format != FormatCSV {
+		return nil
+	}
+	if err := d.writeHeader(); err != nil {
+		return err
+	}
+	d.lcsv.Flush()
+	d.ecsv.Flush()
+	if err := d.lcsv.Error(); err != nil {
+		return err
+	}
+	return d.ecsv.Error()
+}
+
+// WriteDual writes results to both outputs with a DualWriter and flushes.
+func WriteDual(legacy, extended io.Writer, format Format, results []Result) error {
+	d := NewDualWriter(legacy, extended, format)
+	for _, r := range results {
+		if err := d.Write(r); err != nil {
+			return err
+		}
+	}
+	return d.Flush()
+}
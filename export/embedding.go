@@ -0,0 +1,88 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// Embedding is a record's position in the score's internal representation:
+// the seven vital deviations d_i in [0, 1] (0 if missing) and the linear
+// resource component, for clustering and phenotyping research.
+type Embedding struct {
+	ID        string     `json:"id,omitempty"`
+	Level     int        `json:"level"`
+	Deviation [7]float64 `json:"deviation"`
+	Resource  float64    `json:"resource"`
+}
+
+// Vector returns the embedding as an 8-element slice (deviations, then
+// resource), e.g. as a row for stats.PCA.
+func (e Embedding) Vector() []float64 {
+	out := make([]float64, 8)
+	copy(out, e.Deviation[:])
+	out[7] = e.Resource
+	return out
+}
+
+// Embed returns the embedding of each result under norms; maxResources is
+// Params.MaxResources.
+func Embed(results []Result, norms [7][2]float64, maxResources int) []Embedding {
+	out := make([]Embedding, len(results))
+	for i, r := range results {
+		out[i] = Embedding{
+			ID:        r.ID,
+			Level:     r.Level,
+			Deviation: score.Deviations(ResultToVitals(r), norms),
+			Resource:  score.ScaleResources(r.ResourceCount, maxResources, score.ResourceLinear),
+		}
+	}
+	return out
+}
+
+// EmbeddingHeader returns the CSV header for WriteEmbeddingCSV with
+// nComponents projection columns (pc1, pc2, ...).
+func EmbeddingHeader(nComponents int) []string {
+	h := []string{"id", "level", "d_hr", "d_rr", "d_sbp", "d_dbp", "d_temp", "d_spo2", "d_gcs", "resource"}
+	for c := 1; c <= nComponents; c++ {
+		h = append(h, "pc"+strconv.Itoa(c))
+	}
+	return h
+}
+
+// WriteEmbeddingCSV writes embeddings to w. proj is optional: if non-nil it
+// must hold one projection (e.g. from stats.PCAResult.Project) per
+// embedding, all of equal length, appended as pc columns.
+func WriteEmbeddingCSV(w io.Writer, es []Embedding, proj [][]float64) error {
+	nc := 0
+	if len(proj) > 0 {
+		nc = len(proj[0])
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(EmbeddingHeader(nc)); err != nil {
+		return err
+	}
+	for i, e := range es {
+		row := []string{e.ID, strconv.Itoa(e.Level)}
+		for _, x := range e.Vector() {
+			row = append(row, strconv.FormatFloat(x, 'f', -1, 64))
+		}
+		for c := 0; c < nc; c++ {
+			var x float64
+			if i < len(proj) && c < len(proj[i]) {
+				x = proj[i][c]
+			}
+			row = append(row, strconv.FormatFloat(x, 'f', -1, 64))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
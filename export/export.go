@@ -12,6 +12,7 @@
 //	| JSON   | APIs, logs, single record   |
 //	| CSV    | Batch export, spreadsheets  |
 //	| Row    | Tabular in-memory          |
+//	| Dual   | Legacy + extended schemas   |
 package export
 
 import (
@@ -35,6 +36,9 @@ type Result struct {
 	Temp float64 `json:"temp"`
 	SpO2 int     `json:"spo2"`
 	GCS  int     `json:"gcs"`
+	// Supplemental oxygen (JSON only; not part of the CSV schema)
+	OnOxygen bool    `json:"on_oxygen,omitempty"`
+	FiO2     float64 `json:"fio2,omitempty"`
 	// ResourceCount is the expected number of resources
 	ResourceCount int     `json:"resource_count"`
 	Acuity        float64 `json:"acuity"`
@@ -44,6 +48,48 @@ type Result struct {
 	Timestamp time.Time `json:"timestamp,omitempty"`
 	// ID is optional (e.g. encounter or record ID)
 	ID string `json:"id,omitempty"`
+	// Profile is the norm profile used for scoring, if any (JSON only)
+	Profile string `json:"profile,omitempty"`
+	// AgeYears, Pregnant, GestationalWeeks and Frailty are the patient
+	// context a profile-scored result was evaluated with, so that it can be
+	// re-evaluated against the same profile (JSON only)
+	AgeYears         float64 `json:"age_years,omitempty"`
+	Pregnant         bool    `json:"pregnant,omitempty"`
+	GestationalWeeks int     `json:"gestational_weeks,omitempty"`
+	Frailty          float64 `json:"frailty,omitempty"`
+	// Percentile is the score's percentile rank against a reference
+	// distribution, if one was configured (JSON only)
+	Percentile float64 `json:"percentile,omitempty"`
+	// Probability is the calibrated probability of a high-acuity outcome,
+	// if a calibration was configured (JSON only)
+	Probability float64 `json:"probability,omitempty"`
+	// Insufficient says why the vitals were too few to score, if they
+	// were; Acuity and Level are then 0 (JSON only)
+	Insufficient string `json:"insufficient,omitempty"`
+	// Flags are the feature flags the engine had enabled (JSON only)
+	Flags []string `json:"flags,omitempty"`
+	// Mode is the operating mode of the engine, e.g. a surge mode (JSON only)
+	Mode string `json:"mode,omitempty"`
+	// ParamsName, ParamsVersion and ParamsHash identify the parameter set
+	// that produced the result (JSON only)
+	ParamsName    string `json:"params_name,omitempty"`
+	ParamsVersion string `json:"params_version,omitempty"`
+	ParamsHash    string `json:"params_hash,omitempty"`
+	// FormulaVersion is the acuity formula version that produced the
+	// score, e.g. 1 (JSON only)
+	FormulaVersion int `json:"formula_version,omitempty"`
+	// Imputed names the vitals that were imputed rather than measured,
+	// e.g. "spo2" (JSON only)
+	Imputed []string `json:"imputed,omitempty"`
+	// Extended signs (see score.ExtendedVitals; JSON only)
+	Glucose      float64 `json:"glucose,omitempty"`
+	Lactate      float64 `json:"lactate,omitempty"`
+	EtCO2        float64 `json:"etco2,omitempty"`
+	CapRefill    float64 `json:"cap_refill,omitempty"`
+	Pain         int     `json:"pain,omitempty"`
+	PainRecorded bool    `json:"pain_recorded,omitempty"`
+	// Custom holds registered custom signals by name (JSON only)
+	Custom map[string]float64 `json:"custom,omitempty"`
 }
 
 // FromVitalsScoreLevel builds a Result from score.Vitals, acuity, level (1..5), and label.
@@ -55,7 +101,9 @@ func FromVitalsScoreLevel(v score.Vitals, resourceCount int, acuity float64, lev
 		DBP:           v.DBP,
 		Temp:          v.Temp,
 		SpO2:          v.SpO2,
-		GCS:           v.GCS,
+		GCS:           score.GCSTotal(v),
+		OnOxygen:      v.OnOxygen,
+		FiO2:          v.FiO2,
 		ResourceCount: resourceCount,
 		Acuity:        acuity,
 		Level:         level,
@@ -138,6 +186,9 @@ type ReportRow struct {
 	MeanAcuity float64
 	MinAcuity  float64
 	MaxAcuity  float64
+	// SuppressedBelow is non-zero if the cell was suppressed by
+	// SuppressLevelReport; it holds the threshold applied.
+	SuppressedBelow int
 }
 
 // LevelReport builds one ReportRow per level 1..5 from results.
@@ -200,8 +251,17 @@ func ReportRowHeader() []string {
 	return []string{"level", "level_label", "count", "pct", "mean_acuity", "min_acuity", "max_acuity"}
 }
 
-// ReportRowToCSV returns a string slice for one ReportRow.
+// ReportRowToCSV returns a string slice for one ReportRow. Suppressed rows
+// write the count as "<threshold" and leave the other numeric fields empty.
 func (r ReportRow) ReportRowToCSV() []string {
+	if r.SuppressedBelow > 0 {
+		return []string{
+			strconv.Itoa(r.Level),
+			r.LevelLabel,
+			"<" + strconv.Itoa(r.SuppressedBelow),
+			"", "", "", "",
+		}
+	}
 	return []string{
 		strconv.Itoa(r.Level),
 		r.LevelLabel,
@@ -215,7 +275,12 @@ func (r ReportRow) ReportRowToCSV() []string {
 
 // WriteLevelReportCSV writes LevelReport(results) as CSV to w.
 func WriteLevelReportCSV(w io.Writer, results []Result) error {
-	rows := LevelReport(results)
+	return WriteReportRowsCSV(w, LevelReport(results))
+}
+
+// WriteReportRowsCSV writes rows as CSV to w. Use with SuppressLevelReport
+// to publish a report with small cells suppressed.
+func WriteReportRowsCSV(w io.Writer, rows []ReportRow) error {
 	cw := csv.NewWriter(w)
 	if err := cw.Write(ReportRowHeader()); err != nil {
 		return err
@@ -255,6 +320,29 @@ func ResultToVitals(r Result) score.Vitals {
 		Temp: r.Temp,
 		SpO2: r.SpO2,
 		GCS:  r.GCS,
+
+		OnOxygen: r.OnOxygen,
+		FiO2:     r.FiO2,
+	}
+}
+
+// WithExtended returns r with the extended signs of x set.
+func (r Result) WithExtended(x score.ExtendedVitals) Result {
+	r.Glucose, r.Lactate, r.EtCO2, r.CapRefill = x.Glucose, x.Lactate, x.EtCO2, x.CapRefill
+	r.Pain, r.PainRecorded = x.Pain, x.PainRecorded
+	return r
+}
+
+// ResultToExtendedVitals returns the extended signs of r.
+func ResultToExtendedVitals(r Result) score.ExtendedVitals {
+	return score.ExtendedVitals{
+		Glucose:   r.Glucose,
+		Lactate:   r.Lactate,
+		EtCO2:     r.EtCO2,
+		CapRefill: r.CapRefill,
+		Pain:      r.Pain,
+
+		PainRecorded: r.PainRecorded,
 	}
 }
 
@@ -265,6 +353,8 @@ type Summary struct {
 	MinAcuity  float64 `json:"min_acuity"`
 	MaxAcuity  float64 `json:"max_acuity"`
 	LevelDist  [6]int  `json:"level_dist"` // index 0 unused; 1..5
+	// SuppressedLevels lists levels zeroed by SuppressSummary
+	SuppressedLevels []int `json:"suppressed_levels,omitempty"`
 }
 
 // ComputeSummary returns Summary from results.
@@ -3,6 +3,7 @@ package export
 import (
 	"bytes"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/olaflaitinen/triagegeist/score"
@@ -85,3 +86,114 @@ func TestWriteCSV(t *testing.T) {
 		t.Error("WriteCSV produced no output")
 	}
 }
+
+func TestSuppressLevelReport(t *testing.T) {
+	var results []Result
+	for i := 0; i < 10; i++ {
+		results = append(results, Result{Level: 3, Acuity: 0.5})
+	}
+	for i := 0; i < 6; i++ {
+		results = append(results, Result{Level: 4, Acuity: 0.2})
+	}
+	results = append(results, Result{Level: 1, Acuity: 0.9}, Result{Level: 1, Acuity: 0.95})
+	rows := SuppressLevelReport(LevelReport(results), DefaultSuppressionOptions())
+	if rows[0].SuppressedBelow != 5 || rows[0].Count != 0 {
+		t.Errorf("level 1 (n=2) should be suppressed: %+v", rows[0])
+	}
+	// complementary suppression of the next smallest cell (level 4, n=6)
+	if rows[3].SuppressedBelow != 5 || rows[2].SuppressedBelow != 0 {
+		t.Errorf("level 4 should be complementarily suppressed: %+v", rows)
+	}
+	if row := rows[0].ReportRowToCSV(); row[2] != "<5" {
+		t.Errorf("suppressed CSV count = %q, want <5", row[2])
+	}
+}
+
+func TestSuppressLevelReport_Bin(t *testing.T) {
+	results := []Result{
+		{Level: 1, Acuity: 0.9}, {Level: 2, Acuity: 0.7}, {Level: 2, Acuity: 0.7},
+		{Level: 2, Acuity: 0.7}, {Level: 2, Acuity: 0.7},
+	}
+	rows := SuppressLevelReport(LevelReport(results), SuppressionOptions{Threshold: 5, Mode: BinCells})
+	if rows[0].Count != 5 || rows[0].LevelLabel != "Resuscitation + Emergent" {
+		t.Errorf("levels 1 and 2 should be binned: %+v", rows[0])
+	}
+	for _, r := range rows {
+		if r.Count > 0 && r.Count < 5 {
+			t.Errorf("binned row below threshold: %+v", r)
+		}
+	}
+}
+
+func TestSuppressSummary(t *testing.T) {
+	s := Summary{N: 20, MeanAcuity: 0.4, LevelDist: [6]int{0, 1, 0, 12, 7, 0}}
+	out := SuppressSummary(s, DefaultSuppressionOptions())
+	if out.LevelDist[1] != 0 || out.LevelDist[4] != 0 || out.LevelDist[3] != 12 {
+		t.Errorf("SuppressSummary LevelDist = %v", out.LevelDist)
+	}
+	if len(out.SuppressedLevels) != 2 || out.SuppressedLevels[0] != 1 || out.SuppressedLevels[1] != 4 {
+		t.Errorf("SuppressedLevels = %v", out.SuppressedLevels)
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	rs := []Result{
+		{ID: "a", HR: 75, RR: 14, SBP: 120, SpO2: 98, ResourceCount: 1, Level: 5},
+		{ID: "b", HR: 140, SpO2: 85, ResourceCount: 10, Level: 1},
+	}
+	es := Embed(rs, score.DefaultNorms(), 5)
+	if es[0].Resource != 0.2 || es[0].Deviation[3] != 0 {
+		t.Errorf("normal embedding = %+v", es[0])
+	}
+	if es[1].Deviation[0] <= es[0].Deviation[0] || es[1].Deviation[2] != 0 || es[1].Resource != 1 {
+		t.Errorf("abnormal embedding = %+v", es[1])
+	}
+	var buf bytes.Buffer
+	if err := WriteEmbeddingCSV(&buf, es, [][]float64{{0.1, 0.2}, {0.3, 0.4}}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], "resource,pc1,pc2") || !strings.HasSuffix(lines[2], ",0.3,0.4") {
+		t.Errorf("csv = %q", buf.String())
+	}
+}
+
+func TestWriteDual(t *testing.T) {
+	r := FromVitalsScoreLevel(score.Vitals{HR: 110, RR: 22, SBP: 95, SpO2: 93, OnOxygen: true, FiO2: 0.4}, 2, 0.55, 3, "Urgent")
+	r.ID, r.Profile, r.Percentile, r.Flags = "a1", "adult", 87.5, []string{"x", "y"}
+	r.Imputed = []string{"dbp", "temp"}
+	r = r.WithExtended(score.ExtendedVitals{Lactate: 3.2, PainRecorded: true})
+
+	var legacy, extended bytes.Buffer
+	if err := WriteDual(&legacy, &extended, FormatCSV, []Result{r}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(legacy.String()), "\n")
+	if len(lines) != 2 || lines[0] != strings.Join(CSVHeader(), ",") || len(strings.Split(lines[1], ",")) != 13 {
+		t.Errorf("legacy CSV = %q", legacy.String())
+	}
+	lines = strings.Split(strings.TrimSpace(extended.String()), "\n")
+	if len(lines) != 2 || lines[0] != strings.Join(ExtendedCSVHeader(), ",") || !strings.HasSuffix(lines[1], ",true,0.4,adult,87.5,x;y,,,,dbp;temp,,3.2,,,0") {
+		t.Errorf("extended CSV = %q", extended.String())
+	}
+
+	legacy.Reset()
+	extended.Reset()
+	if err := WriteDual(&legacy, &extended, FormatJSONLines, []Result{r, r}); err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal([]byte(strings.Split(legacy.String(), "\n")[0]), &m); err != nil || len(m) != 13 {
+		t.Errorf("legacy JSON has %d fields (%v): %s", len(m), err, legacy.String())
+	}
+	got, err := ReadResultJSON(strings.NewReader(strings.Split(extended.String(), "\n")[1]))
+	if err != nil || got.Profile != "adult" || got.Percentile != 87.5 || len(got.Flags) != 2 {
+		t.Errorf("extended JSON = %+v, %v", got, err)
+	}
+
+	legacy.Reset()
+	extended.Reset()
+	if err := WriteDual(&legacy, &extended, FormatCSV, nil); err != nil || strings.Count(legacy.String(), "\n") != 1 {
+		t.Errorf("empty legacy CSV = %q, %v", legacy.String(), err)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package export
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultSuppressionThreshold is the minimum cell count that may be published
+// unmodified. Counts in [1, threshold) are suppressed or binned.
+const DefaultSuppressionThreshold = 5
+
+// SuppressionMode selects how small cells are handled.
+type SuppressionMode int
+
+const (
+	// SuppressCells blanks small cells. If that leaves exactly one suppressed
+	// cell, the next smallest non-zero cell is also suppressed so the hidden
+	// count cannot be recovered from the total.
+	SuppressCells SuppressionMode = iota
+	// BinCells merges each small level with its neighbours until every
+	// published row meets the threshold.
+	BinCells
+)
+
+// SuppressionOptions configures small-cell suppression.
+//
+//	| Field     | Default                     | Meaning                          |
+//	|-----------|-----------------------------|----------------------------------|
+//	| Threshold | DefaultSuppressionThreshold | Smallest count published as is   |
+//	| Mode      | SuppressCells               | Blank small cells or bin levels  |
+type SuppressionOptions struct {
+	Threshold int
+	Mode      SuppressionMode
+}
+
+// DefaultSuppressionOptions returns Threshold 5 with SuppressCells.
+func DefaultSuppressionOptions() SuppressionOptions {
+	return SuppressionOptions{Threshold: DefaultSuppressionThreshold, Mode: SuppressCells}
+}
+
+func (o SuppressionOptions) threshold() int {
+	if o.Threshold <= 0 {
+		return DefaultSuppressionThreshold
+	}
+	return o.Threshold
+}
+
+func isSmall(count, threshold int) bool {
+	return count > 0 && count < threshold
+}
+
+// SuppressLevelReport returns a copy of rows (as built by LevelReport) with
+// small cells suppressed or binned according to opts. Zero counts are
+// published as is. Suppressed rows have Count, Pct and acuity fields zeroed
+// and SuppressedBelow set to the threshold. The input is not modified.
+func SuppressLevelReport(rows []ReportRow, opts SuppressionOptions) []ReportRow {
+	th := opts.threshold()
+	if opts.Mode == BinCells {
+		return binLevelReport(rows, th)
+	}
+	out := make([]ReportRow, len(rows))
+	copy(out, rows)
+	var nSuppressed int
+	for i := range out {
+		if isSmall(out[i].Count, th) {
+			out[i] = suppressedRow(out[i], th)
+			nSuppressed++
+		}
+	}
+	if nSuppressed == 1 {
+		next := -1
+		for i := range out {
+			if out[i].SuppressedBelow == 0 && out[i].Count > 0 && (next < 0 || out[i].Count < out[next].Count) {
+				next = i
+			}
+		}
+		if next >= 0 {
+			out[next] = suppressedRow(out[next], th)
+		}
+	}
+	return out
+}
+
+func suppressedRow(r ReportRow, threshold int) ReportRow {
+	return ReportRow{Level: r.Level, LevelLabel: r.LevelLabel, SuppressedBelow: threshold}
+}
+
+// binLevelReport merges adjacent rows (in level order) until each bin reaches
+// threshold. A trailing small bin is merged into the previous one. Binned rows
+// carry the first level and a label joining the merged labels with " + ".
+func binLevelReport(rows []ReportRow, threshold int) []ReportRow {
+	var out []ReportRow
+	var cur []ReportRow
+	var curCount int
+	for _, r := range rows {
+		cur = append(cur, r)
+		curCount += r.Count
+		if curCount == 0 || curCount >= threshold {
+			out = append(out, mergeRows(cur))
+			cur, curCount = nil, 0
+		}
+	}
+	if len(cur) > 0 {
+		if len(out) > 0 {
+			last := out[len(out)-1]
+			out[len(out)-1] = mergeRows(append([]ReportRow{last}, cur...))
+		} else {
+			out = append(out, mergeRows(cur))
+		}
+	}
+	for i := range out {
+		if isSmall(out[i].Count, threshold) {
+			out[i] = suppressedRow(out[i], threshold)
+		}
+	}
+	return out
+}
+
+func mergeRows(rows []ReportRow) ReportRow {
+	if len(rows) == 1 {
+		return rows[0]
+	}
+	m := ReportRow{Level: rows[0].Level}
+	labels := make([]string, 0, len(rows))
+	var sum float64
+	first := true
+	for _, r := range rows {
+		labels = append(labels, r.LevelLabel)
+		m.Pct += r.Pct
+		if r.Count == 0 {
+			continue
+		}
+		m.Count += r.Count
+		sum += r.MeanAcuity * float64(r.Count)
+		if first || r.MinAcuity < m.MinAcuity {
+			m.MinAcuity = r.MinAcuity
+		}
+		if first || r.MaxAcuity > m.MaxAcuity {
+			m.MaxAcuity = r.MaxAcuity
+		}
+		first = false
+	}
+	m.LevelLabel = strings.Join(labels, " + ")
+	if m.Count > 0 {
+		m.MeanAcuity = sum / float64(m.Count)
+	}
+	return m
+}
+
+// SuppressSummary returns a copy of s with LevelDist cells in [1, threshold)
+// set to 0 and listed in SuppressedLevels. If exactly one cell is suppressed,
+// the next smallest non-zero cell is also suppressed. If N itself is below
+// the threshold, the acuity aggregates are zeroed as well. opts.Mode is
+// ignored; a Summary has no row structure to bin.
+func SuppressSummary(s Summary, opts SuppressionOptions) Summary {
+	th := opts.threshold()
+	out := s
+	out.SuppressedLevels = nil
+	for i := 1; i <= 5; i++ {
+		if isSmall(s.LevelDist[i], th) {
+			out.LevelDist[i] = 0
+			out.SuppressedLevels = append(out.SuppressedLevels, i)
+		}
+	}
+	if len(out.SuppressedLevels) == 1 {
+		next := 0
+		for i := 1; i <= 5; i++ {
+			if out.LevelDist[i] > 0 && (next == 0 || out.LevelDist[i] < out.LevelDist[next]) {
+				next = i
+			}
+		}
+		if next > 0 {
+			out.LevelDist[next] = 0
+			out.SuppressedLevels = append(out.SuppressedLevels, next)
+		}
+	}
+	sort.Ints(out.SuppressedLevels)
+	if isSmall(s.N, th) {
+		out.MeanAcuity, out.MinAcuity, out.MaxAcuity = 0, 0, 0
+	}
+	return out
+}
@@ -0,0 +1,182 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/olaflaitinen/triagegeist/norm"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// Feature flags with built-in behaviors. Other names are recorded but only
+// change scoring through hooks or code that branches on Engine.Enabled.
+//
+//	| Flag              | Behavior when enabled                                   |
+//	|-------------------|---------------------------------------------------------|
+//	| interaction_terms | Respiratory composite at InteractionRespiratoryWeight if Params.RespiratoryWeight is 0 |
+//	| asymmetric_norms  | Vitals scored against norm.DefaultAsymmetricRanges if Params sets no HalfWidths or Directions |
+//
+// Profile-scored evaluations keep the profile's ranges under
+// asymmetric_norms.
+const (
+	FlagInteractionTerms = "interaction_terms"
+	FlagAsymmetricNorms  = "asymmetric_norms"
+)
+
+// InteractionRespiratoryWeight is the respiratory composite weight that
+// FlagInteractionTerms applies.
+const InteractionRespiratoryWeight = 0.2
+
+// Flags is an immutable set of feature flags naming experimental scoring
+// behaviors (e.g. "interaction_terms"). Flags let a behavior be rolled out
+// per site and attributed in analyses: every evaluation records the engine's
+// flags in EvaluateResult.Flags, and ToExport carries them into
+// export.Result. The zero value is the empty set.
+type Flags struct {
+	names []string // sorted, unique, non-empty
+}
+
+// NewFlags returns the set of the given names. Names are trimmed and
+// lower-cased; empty names and duplicates are dropped.
+func NewFlags(names ...string) Flags {
+	var out []string
+	for _, n := range names {
+		if n = strings.ToLower(strings.TrimSpace(n)); n != "" {
+			out = append(out, n)
+		}
+	}
+	sort.Strings(out)
+	j := 0
+	for i, n := range out {
+		if i == 0 || n != out[j-1] {
+			out[j] = n
+			j++
+		}
+	}
+	return Flags{names: out[:j]}
+}
+
+// Enabled returns true if name is in f.
+func (f Flags) Enabled(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	i := sort.SearchStrings(f.names, name)
+	return i < len(f.names) && f.names[i] == name
+}
+
+// Names returns a sorted copy of the flag names, or nil if f is empty.
+func (f Flags) Names() []string {
+	if len(f.names) == 0 {
+		return nil
+	}
+	return append([]string(nil), f.names...)
+}
+
+// Len returns the number of flags in f.
+func (f Flags) Len() int {
+	return len(f.names)
+}
+
+// String returns the names joined by commas, e.g. "asymmetric_norms,interaction_terms".
+func (f Flags) String() string {
+	return strings.Join(f.names, ",")
+}
+
+// WithFlags returns a new Engine with the given feature flags enabled in
+// addition to any already set. The new engine has no Cache. The receiver is
+// unchanged. To change the flags of a running engine with an audit record,
+// use ManagedEngine.SetFlags.
+func (e *Engine) WithFlags(names ...string) *Engine {
+	c := *e
+	c.Flags = NewFlags(append(e.Flags.Names(), names...)...)
+	c.Cache = nil
+	return &c
+}
+
+// Enabled returns true if the engine has feature flag name set. Experimental
+// code paths and hooks branch on it.
+func (e *Engine) Enabled(name string) bool {
+	return e.Flags.Enabled(name)
+}
+
+// asymmetricNorms reports whether FlagAsymmetricNorms applies under p.
+func (e *Engine) asymmetricNorms(p Params) bool {
+	return e.Flags.Enabled(FlagAsymmetricNorms) && p.HalfWidths.IsZero() && p.Directions == ([7]score.Direction{})
+}
+
+// flagOptions applies the built-in behaviors of the engine's flags to o,
+// the score options of p.
+func (e *Engine) flagOptions(o score.Options, p Params) score.Options {
+	if e.Flags.Enabled(FlagInteractionTerms) && o.RespiratoryWeight == 0 {
+		o.RespiratoryWeight = InteractionRespiratoryWeight
+	}
+	if e.asymmetricNorms(p) {
+		a := score.AsymmetricOptions(norm.DefaultAsymmetricRanges())
+		o.Norms, o.HalfWidths, o.Directions = a.Norms, a.HalfWidths, a.Directions
+	}
+	return o
+}
+
+// withNorms returns o scoring against norms, e.g. a profile's ranges, in
+// place of the default asymmetric ranges of FlagAsymmetricNorms.
+func (e *Engine) withNorms(o score.Options, p Params, norms *[7][2]float64) score.Options {
+	if e.asymmetricNorms(p) {
+		o.HalfWidths, o.Directions = nil, nil
+	}
+	o.Norms = norms
+	return o
+}
+
+// FlagChange is the audit record of one change of a ManagedEngine's flags.
+type FlagChange struct {
+	From, To []string
+	At       time.Time
+}
+
+// SetFlags atomically replaces the engine's feature flags with names,
+// keeping its other settings, and records the change in FlagHistory unless
+// the flags are unchanged. If the current engine has a Cache, the new engine
+// gets an empty cache of the same size. It returns the change and whether
+// the flags changed.
+func (m *ManagedEngine) SetFlags(names ...string) (FlagChange, bool) {
+	f := NewFlags(names...)
+	for {
+		old := m.cur.Load()
+		if old.Flags.String() == f.String() {
+			return FlagChange{}, false
+		}
+		next := *old
+		next.Flags = f
+		if old.Cache != nil {
+			next.Cache = NewCache(old.Cache.Stats().Size)
+		}
+		if m.cur.CompareAndSwap(old, &next) {
+			ch := FlagChange{From: old.Flags.Names(), To: f.Names(), At: time.Now()}
+			m.mu.Lock()
+			m.flagLog = append(m.flagLog, ch)
+			m.mu.Unlock()
+			return ch, true
+		}
+	}
+}
+
+// FlagHistory returns a copy of all flag changes made with SetFlags, oldest
+// first.
+func (m *ManagedEngine) FlagHistory() []FlagChange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]FlagChange(nil), m.flagLog...)
+}
+
+// annotate records the engine-level annotations of r under p: its
+// percentile rank, calibrated probability, the engine's feature flags and
+// mode, and p's provenance.
+func (e *Engine) annotate(r EvaluateResult, p Params) EvaluateResult {
+	r = e.annotateProvenance(annotateProbability(e.percentile(r, p), p), p)
+	r.Flags = e.Flags.Names()
+	r.Mode = e.Mode
+	return r
+}
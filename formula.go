@@ -0,0 +1,47 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import "fmt"
+
+// FormulaVersion identifies the acuity formula a Params computes, so that
+// published results stay reproducible as the formula evolves: a parameter
+// file keeps scoring with the version it names, and every result records
+// it.
+//
+//	| Version   | Formula                                                    |
+//	|-----------|------------------------------------------------------------|
+//	| FormulaV1 | Weighted vital deviations plus resources, normalized by    |
+//	|           | the weight sum, with the score.Options extensions          |
+//
+// A later version is added here, and selected by Params.FormulaVersion;
+// FormulaV1 stays available unchanged.
+type FormulaVersion int
+
+const (
+	FormulaV1 FormulaVersion = 1
+
+	// LatestFormula is the newest formula version.
+	LatestFormula = FormulaV1
+)
+
+// String returns "v1", "v2", ...
+func (f FormulaVersion) String() string {
+	return fmt.Sprintf("v%d", int(f))
+}
+
+// Valid returns true if f is 0 (FormulaV1) or a defined version.
+func (f FormulaVersion) Valid() bool {
+	return f >= 0 && f <= LatestFormula
+}
+
+// Formula returns the formula version p computes: p.FormulaVersion, or
+// FormulaV1 if it is 0, so parameters written before versioning keep their
+// formula.
+func (p Params) Formula() FormulaVersion {
+	if p.FormulaVersion == 0 {
+		return FormulaV1
+	}
+	return p.FormulaVersion
+}
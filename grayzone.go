@@ -0,0 +1,59 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"math"
+
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// MaxGrayZone is the largest admissible Params.GrayZone.
+const MaxGrayZone = score.MaxThresholdMargin
+
+// GrayZoneCandidates reports whether score s lies within p.GrayZone of a
+// threshold (|s - Tk| < GrayZone). If so it returns the two levels on either
+// side of the nearest such threshold, more acute first, and deferred true;
+// otherwise it returns FromScore(s, p) twice and false.
+func (p Params) GrayZoneCandidates(s float64) (moreAcute, lessAcute Level, deferred bool) {
+	l := FromScore(s, p)
+	if p.GrayZone <= 0 {
+		return l, l, false
+	}
+	best := -1
+	bestD := p.GrayZone
+	for k, t := range p.Thresholds() {
+		if d := math.Abs(s - t); d < bestD {
+			best, bestD = k, d
+		}
+	}
+	if best < 0 {
+		return l, l, false
+	}
+	return Level(best + 1), Level(best + 2), true
+}
+
+// grayZone records the gray-zone decision for r under p. Outside the gray
+// zone both candidates are r.Level, which hysteresis may have held above
+// FromScore.
+func grayZone(r EvaluateResult, p Params) EvaluateResult {
+	a, b, d := p.GrayZoneCandidates(r.Acuity)
+	if !d {
+		a, b = r.Level, r.Level
+	}
+	r.Candidates = [2]Level{a, b}
+	r.Deferred = d
+	return r
+}
+
+// Deferred returns the results flagged for clinician review.
+func Deferred(results []EvaluateResult) []EvaluateResult {
+	var out []EvaluateResult
+	for _, r := range results {
+		if r.Deferred {
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import "github.com/olaflaitinen/triagegeist/score"
+
+// Hook is middleware around the Evaluate family (Evaluate, EvaluateWithContext,
+// EvaluateWithSources, and the batch and rescore methods built on them).
+// BeforeEvaluate may normalise the inputs; AfterEvaluate may log, audit or
+// annotate the result. Hooks must be safe for concurrent use if the engine is.
+type Hook interface {
+	BeforeEvaluate(v score.Vitals, resourceCount int) (score.Vitals, int)
+	AfterEvaluate(r EvaluateResult) EvaluateResult
+}
+
+// HookFuncs adapts a pair of functions to Hook. Either may be nil.
+type HookFuncs struct {
+	Before func(v score.Vitals, resourceCount int) (score.Vitals, int)
+	After  func(r EvaluateResult) EvaluateResult
+}
+
+// BeforeEvaluate calls h.Before, or returns the inputs unchanged if nil.
+func (h HookFuncs) BeforeEvaluate(v score.Vitals, resourceCount int) (score.Vitals, int) {
+	if h.Before == nil {
+		return v, resourceCount
+	}
+	return h.Before(v, resourceCount)
+}
+
+// AfterEvaluate calls h.After, or returns r unchanged if nil.
+func (h HookFuncs) AfterEvaluate(r EvaluateResult) EvaluateResult {
+	if h.After == nil {
+		return r
+	}
+	return h.After(r)
+}
+
+// WithHooks returns a new Engine with hooks appended to the receiver's.
+// BeforeEvaluate runs in registration order and AfterEvaluate in reverse, so
+// the first hook registered wraps all the others. The receiver is unchanged.
+func (e *Engine) WithHooks(hooks ...Hook) *Engine {
+	c := *e
+	c.Hooks = append(append([]Hook(nil), e.Hooks...), hooks...)
+	return &c
+}
+
+func (e *Engine) before(v score.Vitals, resourceCount int) (score.Vitals, int) {
+	for _, h := range e.Hooks {
+		v, resourceCount = h.BeforeEvaluate(v, resourceCount)
+	}
+	return v, resourceCount
+}
+
+func (e *Engine) after(r EvaluateResult) EvaluateResult {
+	for i := len(e.Hooks) - 1; i >= 0; i-- {
+		r = e.Hooks[i].AfterEvaluate(r)
+	}
+	return r
+}
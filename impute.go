@@ -0,0 +1,193 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"math"
+
+	"github.com/olaflaitinen/triagegeist/norm"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// Imputer fills vitals missing from v before the Evaluate family scores
+// them (see Engine.Imputer). The engine keeps every vital that was present
+// as given, whatever Impute returns, and records which vitals were filled
+// in EvaluateResult.Imputed. Imputers must be safe for concurrent use if
+// the engine is.
+//
+//	| Imputer             | Fills a missing vital with                       |
+//	|---------------------|--------------------------------------------------|
+//	| MidpointImputer     | The norm midpoint (scores as normal)             |
+//	| MeanImputer         | A cohort mean (see CohortMeanImputer)            |
+//	| CarryForwardImputer | The patient's previous measurement, if present   |
+type Imputer interface {
+	Impute(v score.Vitals) score.Vitals
+}
+
+// MidpointImputer fills missing vitals with the midpoints of Ranges, or of
+// norm.DefaultRanges if Ranges is the zero value. Under
+// Engine.EvaluateWithContext a zero Ranges means the selected profile's
+// ranges, so a missing infant blood pressure scores as normal for an infant.
+type MidpointImputer struct {
+	Ranges norm.Ranges
+}
+
+// Impute implements Imputer.
+func (m MidpointImputer) Impute(v score.Vitals) score.Vitals {
+	r := m.Ranges
+	if r == (norm.Ranges{}) {
+		r = norm.DefaultRanges()
+	}
+	var mid [7]float64
+	for i, n := range r.Array() {
+		mid[i] = n[0]
+	}
+	return fillVitals(v, mid)
+}
+
+// MeanImputer fills missing vitals with Means, in VitalWeights order; a
+// zero mean leaves that vital missing.
+type MeanImputer struct {
+	Means [7]float64
+}
+
+// CohortMeanImputer returns a MeanImputer holding the mean of each vital
+// over the cohort members in which it is present (GCS as the total).
+func CohortMeanImputer(cohort []score.Vitals) MeanImputer {
+	var sum [7]float64
+	var n [7]int
+	for _, v := range cohort {
+		x := score.VitalsToValues(v)
+		for i, ok := range score.Present(v) {
+			if ok {
+				sum[i] += x[i]
+				n[i]++
+			}
+		}
+	}
+	var m MeanImputer
+	for i := range sum {
+		if n[i] > 0 {
+			m.Means[i] = sum[i] / float64(n[i])
+		}
+	}
+	return m
+}
+
+// Impute implements Imputer.
+func (m MeanImputer) Impute(v score.Vitals) score.Vitals {
+	return fillVitals(v, m.Means)
+}
+
+// CarryForwardImputer fills missing vitals from Previous, the patient's
+// last measurement. Check its age before use (see StalenessPolicy), since
+// a carried-forward value is scored as if measured now.
+type CarryForwardImputer struct {
+	Previous score.Vitals
+}
+
+// Impute implements Imputer.
+func (c CarryForwardImputer) Impute(v score.Vitals) score.Vitals {
+	out := v
+	for f := FieldHR; f <= FieldGCS; f++ {
+		if !fieldPresent(v, f) {
+			copyField(&out, c.Previous, f)
+		}
+	}
+	return out
+}
+
+// fillVitals sets each vital missing from v to x[i], rounded for integer
+// vitals, unless x[i] would itself read as missing.
+func fillVitals(v score.Vitals, x [7]float64) score.Vitals {
+	out := v
+	p := score.Present(v)
+	n := func(i int) int { return int(math.Round(x[i])) }
+	for i := range x {
+		if p[i] {
+			continue
+		}
+		switch Field(i) {
+		case FieldHR:
+			out.HR = n(i)
+		case FieldRR:
+			out.RR = n(i)
+		case FieldSBP:
+			out.SBP = n(i)
+		case FieldDBP:
+			out.DBP = n(i)
+		case FieldTemp:
+			out.Temp = x[i]
+		case FieldSpO2:
+			out.SpO2 = n(i)
+		case FieldGCS:
+			out.GCS = n(i)
+		}
+	}
+	return out
+}
+
+// impute applies e.Imputer to v, keeping the vitals present in v, and
+// reports which vitals it filled.
+func (e *Engine) impute(v score.Vitals) (score.Vitals, [7]bool) {
+	return e.imputeWith(v, e.Imputer)
+}
+
+// imputeRanges is impute for a profile with ranges r: a MidpointImputer
+// with zero Ranges fills from r instead of norm.DefaultRanges.
+func (e *Engine) imputeRanges(v score.Vitals, r norm.Ranges) (score.Vitals, [7]bool) {
+	im := e.Imputer
+	if m, ok := im.(MidpointImputer); ok && m.Ranges == (norm.Ranges{}) {
+		im = MidpointImputer{Ranges: r}
+	}
+	return e.imputeWith(v, im)
+}
+
+func (e *Engine) imputeWith(v score.Vitals, im Imputer) (score.Vitals, [7]bool) {
+	var filled [7]bool
+	if im == nil {
+		return v, filled
+	}
+	out := im.Impute(v)
+	for f := FieldHR; f <= FieldGCS; f++ {
+		if fieldPresent(v, f) {
+			copyField(&out, v, f)
+		} else {
+			filled[f] = fieldPresent(out, f)
+		}
+	}
+	copyField(&out, v, FieldOxygen)
+	return out, filled
+}
+
+// measured returns r.Vitals without the vitals the engine imputed.
+func (r EvaluateResult) measured() score.Vitals {
+	v := r.Vitals
+	for i, imputed := range r.Imputed {
+		if imputed {
+			copyField(&v, score.Vitals{}, Field(i))
+		}
+	}
+	return v
+}
+
+// ImputedNames returns the lower-case names of the vitals that were imputed
+// (e.g. "spo2"), in VitalWeights order, or nil if none.
+func (r EvaluateResult) ImputedNames() []string {
+	var names []string
+	for i, imputed := range r.Imputed {
+		if imputed {
+			names = append(names, Field(i).String())
+		}
+	}
+	return names
+}
+
+// WithImputer returns a new Engine that fills missing vitals with im before
+// every evaluation of the Evaluate family. The receiver is unchanged.
+func (e *Engine) WithImputer(im Imputer) *Engine {
+	c := *e
+	c.Imputer = im
+	return &c
+}
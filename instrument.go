@@ -0,0 +1,312 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/olaflaitinen/triagegeist/stats"
+)
+
+// DefaultLatencyWindow is the number of recent latencies kept for percentiles.
+const DefaultLatencyWindow = 1024
+
+// Stage identifies a timed step of scoring.
+//
+//	| Stage         | Recorded by                                     |
+//	|---------------|-------------------------------------------------|
+//	| StageEvaluate | Engine, for every single evaluation             |
+//	| StageValidate | Callers validating input (e.g. pipeline.Run)    |
+//	| StageModel    | Callers invoking an external model              |
+type Stage int
+
+const (
+	StageEvaluate Stage = iota
+	StageValidate
+	StageModel
+	// NumStages is the number of stages.
+	NumStages
+)
+
+var stageNames = [NumStages]string{"evaluate", "validate", "model"}
+
+// String returns the stage name, e.g. "evaluate".
+func (s Stage) String() string {
+	if s < 0 || s >= NumStages {
+		return "unknown"
+	}
+	return stageNames[s]
+}
+
+// DefaultLatencyBuckets returns the histogram bucket upper bounds used by
+// NewInstruments: 100µs to 100ms, with 5ms (the scoring SLO) as a bound.
+func DefaultLatencyBuckets() []time.Duration {
+	return []time.Duration{
+		100 * time.Microsecond, 250 * time.Microsecond, 500 * time.Microsecond,
+		time.Millisecond, 2500 * time.Microsecond, 5 * time.Millisecond,
+		10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+		100 * time.Millisecond,
+	}
+}
+
+// SLO is a latency objective: the Percentile (e.g. 99) latency of Stage
+// over the recent window must not exceed Threshold. Every observation above
+// Threshold counts as a violation.
+type SLO struct {
+	Stage      Stage
+	Percentile float64
+	Threshold  time.Duration
+}
+
+// DefaultSLOs returns the scoring SLO: p99 evaluation latency <= 5ms.
+func DefaultSLOs() []SLO {
+	return []SLO{{Stage: StageEvaluate, Percentile: 99, Threshold: 5 * time.Millisecond}}
+}
+
+// stageStats holds the counters of one Stage.
+type stageStats struct {
+	count  uint64
+	total  time.Duration
+	window []float64 // nanoseconds, ring buffer
+	next   int
+	hist   []uint64 // one per bucket plus overflow
+}
+
+func (s *stageStats) add(d time.Duration, buckets []time.Duration) {
+	s.count++
+	s.total += d
+	if len(s.window) < cap(s.window) {
+		s.window = append(s.window, float64(d))
+	} else {
+		s.window[s.next] = float64(d)
+	}
+	s.next = (s.next + 1) % cap(s.window)
+	s.hist[sort.Search(len(buckets), func(i int) bool { return d <= buckets[i] })]++
+}
+
+func (s *stageStats) reset(buckets []time.Duration) {
+	s.count, s.total = 0, 0
+	s.window, s.next = s.window[:0], 0
+	s.hist = make([]uint64, len(buckets)+1)
+}
+
+// Instruments records latency per Stage (histogram, mean and percentiles),
+// SLO violations, evaluation count and batch sizes for an Engine. Latency
+// percentiles are computed over the most recent window of observations. A
+// nil *Instruments records nothing. Safe for concurrent use.
+type Instruments struct {
+	mu         sync.Mutex
+	stages     [NumStages]stageStats
+	buckets    []time.Duration
+	slos       []SLO
+	violations []uint64
+	batches    uint64
+	batchSum   uint64
+	batchMax   int
+	startedAt  time.Time
+}
+
+// LatencyHistogram counts observations per bucket: Counts[i] holds those
+// in (Bounds[i-1], Bounds[i]], and the last count those above every bound.
+type LatencyHistogram struct {
+	Bounds []time.Duration
+	Counts []uint64
+}
+
+// StageSnapshot is a point-in-time copy of one stage's latencies.
+type StageSnapshot struct {
+	Count         uint64
+	MeanLatency   time.Duration
+	P50, P90, P99 time.Duration
+	Histogram     LatencyHistogram
+}
+
+// SLOStatus reports one SLO: the observed percentile over the recent window,
+// the violation count since creation or Reset, and whether the objective is
+// currently met.
+type SLOStatus struct {
+	SLO
+	Observed   time.Duration
+	Violations uint64
+	Met        bool
+}
+
+// InstrumentsSnapshot is a point-in-time copy of the counters.
+//
+//	| Field        | Meaning                                          |
+//	|--------------|--------------------------------------------------|
+//	| Evaluations  | Single evaluations since creation or Reset       |
+//	| MeanLatency  | Mean latency over all evaluations                |
+//	| P50/P90/P99  | Latency percentiles over the recent window       |
+//	| Throughput   | Evaluations per second since creation or Reset   |
+//	| Batches      | Batch calls (each also counts its evaluations)   |
+//	| MeanBatch    | Mean batch size                                  |
+//	| MaxBatch     | Largest batch size                               |
+//	| Stages       | Latencies and histogram per Stage                |
+//	| SLOs         | Status of each configured SLO                    |
+type InstrumentsSnapshot struct {
+	Evaluations   uint64
+	MeanLatency   time.Duration
+	P50, P90, P99 time.Duration
+	Throughput    float64
+	Batches       uint64
+	MeanBatch     float64
+	MaxBatch      int
+	Stages        [NumStages]StageSnapshot
+	SLOs          []SLOStatus
+}
+
+// NewInstruments returns Instruments keeping the last window latencies per
+// stage (DefaultLatencyWindow if window <= 0), with DefaultLatencyBuckets
+// and DefaultSLOs.
+func NewInstruments(window int) *Instruments {
+	if window <= 0 {
+		window = DefaultLatencyWindow
+	}
+	in := &Instruments{buckets: DefaultLatencyBuckets(), startedAt: time.Now()}
+	for i := range in.stages {
+		in.stages[i].window = make([]float64, 0, window)
+		in.stages[i].hist = make([]uint64, len(in.buckets)+1)
+	}
+	in.slos = DefaultSLOs()
+	in.violations = make([]uint64, len(in.slos))
+	return in
+}
+
+// SetSLOs replaces the configured SLOs and zeroes their violation counters.
+// SLOs with an unknown Stage are ignored. It is a no-op on a nil receiver.
+func (in *Instruments) SetSLOs(slos ...SLO) {
+	if in == nil {
+		return
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.slos = in.slos[:0]
+	for _, s := range slos {
+		if s.Stage >= 0 && s.Stage < NumStages {
+			in.slos = append(in.slos, s)
+		}
+	}
+	in.violations = make([]uint64, len(in.slos))
+}
+
+// SetBuckets replaces the histogram bucket upper bounds (sorted ascending)
+// and zeroes the histograms. It is a no-op on a nil receiver.
+func (in *Instruments) SetBuckets(bounds []time.Duration) {
+	if in == nil {
+		return
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.buckets = append([]time.Duration(nil), bounds...)
+	sort.Slice(in.buckets, func(i, j int) bool { return in.buckets[i] < in.buckets[j] })
+	for i := range in.stages {
+		in.stages[i].hist = make([]uint64, len(in.buckets)+1)
+	}
+}
+
+// ObserveStage records one latency d for stage s. The Engine records
+// StageEvaluate itself; callers record the stages around it. It is a no-op
+// on a nil receiver or an unknown stage.
+func (in *Instruments) ObserveStage(s Stage, d time.Duration) {
+	if in == nil || s < 0 || s >= NumStages {
+		return
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.stages[s].add(d, in.buckets)
+	for i, slo := range in.slos {
+		if slo.Stage == s && d > slo.Threshold {
+			in.violations[i]++
+		}
+	}
+}
+
+// observe records one evaluation started at start.
+func (in *Instruments) observe(start time.Time) {
+	in.ObserveStage(StageEvaluate, time.Since(start))
+}
+
+// observeBatch records one batch call of size n.
+func (in *Instruments) observeBatch(n int) {
+	if in == nil {
+		return
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.batches++
+	in.batchSum += uint64(n)
+	if n > in.batchMax {
+		in.batchMax = n
+	}
+}
+
+// Snapshot returns the current counters, or a zero snapshot on a nil
+// receiver, so an engine without instruments can be polled like one with.
+func (in *Instruments) Snapshot() InstrumentsSnapshot {
+	if in == nil {
+		return InstrumentsSnapshot{}
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	var s InstrumentsSnapshot
+	for i := range in.stages {
+		st := &in.stages[i]
+		ss := StageSnapshot{
+			Count: st.count,
+			P50:   time.Duration(stats.Percentile(st.window, 50)),
+			P90:   time.Duration(stats.Percentile(st.window, 90)),
+			P99:   time.Duration(stats.Percentile(st.window, 99)),
+			Histogram: LatencyHistogram{
+				Bounds: append([]time.Duration(nil), in.buckets...),
+				Counts: append([]uint64(nil), st.hist...),
+			},
+		}
+		if st.count > 0 {
+			ss.MeanLatency = st.total / time.Duration(st.count)
+		}
+		s.Stages[i] = ss
+	}
+	ev := s.Stages[StageEvaluate]
+	s.Evaluations, s.MeanLatency = ev.Count, ev.MeanLatency
+	s.P50, s.P90, s.P99 = ev.P50, ev.P90, ev.P99
+	s.Batches, s.MaxBatch = in.batches, in.batchMax
+	if el := time.Since(in.startedAt).Seconds(); el > 0 {
+		s.Throughput = float64(ev.Count) / el
+	}
+	if in.batches > 0 {
+		s.MeanBatch = float64(in.batchSum) / float64(in.batches)
+	}
+	for i, slo := range in.slos {
+		obs := time.Duration(stats.Percentile(in.stages[slo.Stage].window, slo.Percentile))
+		s.SLOs = append(s.SLOs, SLOStatus{SLO: slo, Observed: obs, Violations: in.violations[i], Met: obs <= slo.Threshold})
+	}
+	return s
+}
+
+// Reset zeroes all counters and restarts the throughput clock. The SLOs and
+// buckets are kept. It is a no-op on a nil receiver.
+func (in *Instruments) Reset() {
+	if in == nil {
+		return
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for i := range in.stages {
+		in.stages[i].reset(in.buckets)
+	}
+	in.violations = make([]uint64, len(in.slos))
+	in.batches, in.batchSum, in.batchMax = 0, 0, 0
+	in.startedAt = time.Now()
+}
+
+// WithInstruments returns a new Engine recording into a fresh Instruments
+// (see NewInstruments). The receiver is unchanged.
+func (e *Engine) WithInstruments(window int) *Engine {
+	c := *e
+	c.Instruments = NewInstruments(window)
+	return &c
+}
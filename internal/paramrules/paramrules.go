@@ -0,0 +1,108 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+// Package paramrules holds the constraints on the core parameter fields.
+// triagegeist.Params and the deprecated validate.ParamsLike both check
+// their core fields here, so the two cannot drift apart.
+package paramrules
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// Core holds the parameter fields checked by Check. A zero optional field
+// (e.g. ResourceRate) is valid, so callers without it leave it zero.
+type Core struct {
+	VitalWeights   [7]float64
+	MaxResources   int
+	ResourceWeight float64
+	T1, T2, T3, T4 float64
+	MAPWeight      float64
+	ResourceScale  score.ResourceScale
+	ResourceRate   float64
+	GCSBanded      bool
+	GCSBands       score.GCSBands
+
+	RespiratoryWeight float64
+	QSOFABump         float64
+	Reliability       score.Reliability
+	GrayZone          float64
+	Hysteresis        float64
+	Asymmetric        bool
+	AsymmetricWeights score.AsymmetricWeights
+}
+
+// Check calls add once per constraint c violates, in field order.
+func Check(c Core, add func(field string, v float64, constraint string)) {
+	unit := func(field string, v float64) {
+		if !(v >= 0 && v <= 1) {
+			add(field, v, "must be in [0, 1]")
+		}
+	}
+	for i, w := range c.VitalWeights {
+		unit(fmt.Sprintf("VitalWeights[%d]", i), w)
+	}
+	if c.MaxResources < 0 {
+		add("MaxResources", float64(c.MaxResources), "must be >= 0")
+	}
+	if !(c.ResourceWeight >= 0) || math.IsInf(c.ResourceWeight, 0) {
+		add("ResourceWeight", c.ResourceWeight, "must be finite and >= 0")
+	}
+	if c.T1 > 1 {
+		add("T1", c.T1, "must be at most 1")
+	}
+	t := [4]float64{c.T1, c.T2, c.T3, c.T4}
+	for i := 1; i < 4; i++ {
+		if !(t[i-1] > t[i]) {
+			add(fmt.Sprintf("T%d", i+1), t[i], fmt.Sprintf("must be less than T%d (%v)", i, t[i-1]))
+		}
+	}
+	if !(c.T4 > 0) {
+		add("T4", c.T4, "must be greater than 0")
+	}
+	unit("MAPWeight", c.MAPWeight)
+	if !c.ResourceScale.Valid() {
+		add("ResourceScale", float64(c.ResourceScale), "is not a defined score.ResourceScale")
+	}
+	if r := c.ResourceRate; r != 0 && !(r > 0 && !math.IsInf(r, 0)) {
+		add("ResourceRate", r, "must be 0 or finite and > 0")
+	}
+	if c.GCSBanded {
+		b := c.GCSBands
+		bands := [4]float64{b.Normal, b.Mild, b.Moderate, b.Severe}
+		names := [4]string{"Normal", "Mild", "Moderate", "Severe"}
+		for i, x := range bands {
+			if !(x >= 0 && x <= 1) {
+				add("GCSBands."+names[i], x, "must be in [0, 1]")
+			} else if i > 0 && x < bands[i-1] {
+				add("GCSBands."+names[i], x, fmt.Sprintf("must be at least GCSBands.%s (%v)", names[i-1], bands[i-1]))
+			}
+		}
+	}
+	unit("RespiratoryWeight", c.RespiratoryWeight)
+	unit("QSOFABump", c.QSOFABump)
+	for s, row := range c.Reliability {
+		for i, f := range row {
+			if !(f >= 0 && f <= 1) || math.IsNaN(f) {
+				add(fmt.Sprintf("Reliability[%s][%d]", score.Source(s), i), f, "must be in [0, 1]")
+			}
+		}
+	}
+	if !(c.GrayZone >= 0 && c.GrayZone <= score.MaxThresholdMargin) {
+		add("GrayZone", c.GrayZone, fmt.Sprintf("must be in [0, %v]", score.MaxThresholdMargin))
+	}
+	if !(c.Hysteresis >= 0 && c.Hysteresis <= score.MaxThresholdMargin) {
+		add("Hysteresis", c.Hysteresis, fmt.Sprintf("must be in [0, %v]", score.MaxThresholdMargin))
+	}
+	if c.Asymmetric {
+		for i := range c.AsymmetricWeights.Low {
+			unit(fmt.Sprintf("AsymmetricWeights.Low[%d]", i), c.AsymmetricWeights.Low[i])
+		}
+		for i := range c.AsymmetricWeights.High {
+			unit(fmt.Sprintf("AsymmetricWeights.High[%d]", i), c.AsymmetricWeights.High[i])
+		}
+	}
+}
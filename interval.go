@@ -0,0 +1,132 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"math"
+
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// MeasurementError holds the absolute measurement error of each vital in
+// VitalWeights order (HR, RR, SBP, DBP, Temp, SpO2, GCS), in the vital's own
+// units (SpO2 in percentage points).
+type MeasurementError [7]float64
+
+// DefaultMeasurementError returns typical device and observer error:
+//
+//	| Vital | Error    |
+//	|-------|----------|
+//	| HR    | ±4 bpm   |
+//	| RR    | ±2 /min  |
+//	| SBP   | ±5 mmHg  |
+//	| DBP   | ±5 mmHg  |
+//	| Temp  | ±0.2 °C  |
+//	| SpO2  | ±2 %     |
+//	| GCS   | ±1       |
+func DefaultMeasurementError() MeasurementError {
+	return MeasurementError{4, 2, 5, 5, 0.2, 2, 1}
+}
+
+// Interval is an acuity score with the range it may take under measurement
+// error.
+type Interval struct {
+	Acuity float64
+	Low    float64
+	High   float64
+	Level  Level
+	// MostAcute and LeastAcute are the levels of High and Low.
+	MostAcute  Level
+	LeastAcute Level
+	// Straddles is true if the interval crosses a level threshold.
+	Straddles bool
+}
+
+// EvaluateInterval returns the acuity of v with the interval obtained by
+// moving every present vital within ±err toward (Low) or away from (High) its
+// norm midpoint. The bounds are exact for the base formula, where acuity
+// increases with each vital's deviation; with formula extensions that
+// combine vitals (MAP, respiratory composite, qSOFA) they are approximate.
+// Missing vitals stay missing.
+func (e *Engine) EvaluateInterval(v score.Vitals, resourceCount int, err MeasurementError) Interval {
+	a := e.Acuity(v, resourceCount)
+	lo := e.Acuity(shiftVitals(v, err, false), resourceCount)
+	hi := e.Acuity(shiftVitals(v, err, true), resourceCount)
+	lo, hi = math.Min(lo, a), math.Max(hi, a)
+	iv := Interval{
+		Acuity:     a,
+		Low:        lo,
+		High:       hi,
+		Level:      FromScore(a, e.P),
+		MostAcute:  FromScore(hi, e.P),
+		LeastAcute: FromScore(lo, e.P),
+	}
+	iv.Straddles = iv.MostAcute != iv.LeastAcute
+	return iv
+}
+
+// AcuityEstimate returns the acuity of v with its delta-method variance,
+// taking err as the standard error of each vital (see
+// score.AcuityWithVariance). Unlike EvaluateInterval it is analytic and
+// does not rescore; it bypasses the Cache.
+func (e *Engine) AcuityEstimate(v score.Vitals, resourceCount int, err MeasurementError) score.Estimate {
+	return score.AcuityWithVariance(v, resourceCount, e.P.MaxResources, e.P.VitalWeights, e.P.ResourceWeight, e.scoreOptions(e.P), err)
+}
+
+// shiftVitals moves each present vital by up to err toward the norm midpoint
+// (away=false) or away from it (away=true). GCS is shifted as a total.
+func shiftVitals(v score.Vitals, err MeasurementError, away bool) score.Vitals {
+	n := score.DefaultNorms()
+	out := v
+	out.HR = shiftInt(v.HR, err[0], n[0][0], away)
+	out.RR = shiftInt(v.RR, err[1], n[1][0], away)
+	out.SBP = shiftInt(v.SBP, err[2], n[2][0], away)
+	out.DBP = shiftInt(v.DBP, err[3], n[3][0], away)
+	if v.Temp != 0 {
+		out.Temp = shift(v.Temp, err[4], n[4][0], away)
+	}
+	out.SpO2 = shiftInt(v.SpO2, err[5], n[5][0], away)
+	if out.SpO2 > 100 {
+		out.SpO2 = 100
+	}
+	if g := score.GCSTotal(v); g > 0 {
+		out.GCS = shiftInt(g, err[6], n[6][0], away)
+		if out.GCS < 3 {
+			out.GCS = 3
+		}
+		if out.GCS > 15 {
+			out.GCS = 15
+		}
+	}
+	return out
+}
+
+func shift(x, e, mid float64, away bool) float64 {
+	e = math.Abs(e)
+	if away {
+		if x >= mid {
+			return x + e
+		}
+		return x - e
+	}
+	if math.Abs(x-mid) <= e {
+		return mid
+	}
+	if x > mid {
+		return x - e
+	}
+	return x + e
+}
+
+// shiftInt shifts a present integer vital, keeping it present (>= 1).
+func shiftInt(x int, e, mid float64, away bool) int {
+	if x <= 0 {
+		return x
+	}
+	s := int(math.Round(shift(float64(x), e, mid, away)))
+	if s < 1 {
+		return 1
+	}
+	return s
+}
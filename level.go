@@ -73,7 +73,7 @@ func FromScore(score float64, p Params) Level {
 }
 
 // WaitTimeMinutes returns a suggested maximum wait time in minutes for the level.
-// These are guidance only; institutional protocols override.
+// These are guidance only; institutional protocols override (see ActionPolicy).
 func (l Level) WaitTimeMinutes() int {
 	switch l {
 	case Level1Resuscitation:
@@ -248,7 +248,8 @@ func ParseLevel(s string) Level {
 }
 
 // RecommendedActions returns a short list of recommended actions for the level.
-// For display or decision support only; not a substitute for protocol.
+// For display or decision support only; not a substitute for protocol (see
+// ActionPolicy).
 func (l Level) RecommendedActions() []string {
 	switch l {
 	case Level1Resuscitation:
@@ -0,0 +1,109 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// ManagedEngine wraps an Engine whose parameters can be replaced while it is
+// in use. Each call loads the current Engine once, so an evaluation never
+// sees a mix of old and new parameters. Safe for concurrent use.
+type ManagedEngine struct {
+	cur atomic.Pointer[Engine]
+
+	mu      sync.Mutex
+	flagLog []FlagChange
+}
+
+// NewManagedEngine returns a ManagedEngine serving a copy of e.
+func NewManagedEngine(e *Engine) *ManagedEngine {
+	m := &ManagedEngine{}
+	c := *e
+	m.cur.Store(&c)
+	return m
+}
+
+// Engine returns the engine currently in use. Do not modify it; use
+// SwapParams or Swap instead.
+func (m *ManagedEngine) Engine() *Engine {
+	return m.cur.Load()
+}
+
+// SwapParams atomically replaces the parameters, keeping the engine's other
+// settings. If the current engine has a Cache, the new engine gets an empty
+// cache of the same size. A concurrent Swap or SwapParams is never lost:
+// the new engine is built from the current one and retried until it is
+// installed over the engine it was built from. Returns false and keeps the
+// current parameters if p is invalid.
+func (m *ManagedEngine) SwapParams(p Params) bool {
+	return m.swapParams(p, nil)
+}
+
+// swapParams is SwapParams that also applies set, if non-nil, to the new
+// engine before it is installed.
+func (m *ManagedEngine) swapParams(p Params, set func(*Engine)) bool {
+	if !p.Validate() {
+		return false
+	}
+	for {
+		old := m.cur.Load()
+		next := old.WithParams(p)
+		if old.Cache != nil {
+			next.Cache = NewCache(old.Cache.Stats().Size)
+		}
+		if set != nil {
+			set(next)
+		}
+		if m.cur.CompareAndSwap(old, next) {
+			return true
+		}
+	}
+}
+
+// Swap atomically replaces the whole engine with a copy of e and returns the
+// previous one.
+func (m *ManagedEngine) Swap(e *Engine) *Engine {
+	c := *e
+	return m.cur.Swap(&c)
+}
+
+// Params returns a copy of the current parameters.
+func (m *ManagedEngine) Params() Params {
+	return m.cur.Load().Params()
+}
+
+// Acuity is Engine.Acuity on the current engine.
+func (m *ManagedEngine) Acuity(v score.Vitals, resourceCount int) float64 {
+	return m.cur.Load().Acuity(v, resourceCount)
+}
+
+// Level is Engine.Level on the current engine.
+func (m *ManagedEngine) Level(v score.Vitals, resourceCount int) Level {
+	return m.cur.Load().Level(v, resourceCount)
+}
+
+// ScoreAndLevel is Engine.ScoreAndLevel on the current engine.
+func (m *ManagedEngine) ScoreAndLevel(v score.Vitals, resourceCount int) (float64, Level) {
+	return m.cur.Load().ScoreAndLevel(v, resourceCount)
+}
+
+// Evaluate is Engine.Evaluate on the current engine.
+func (m *ManagedEngine) Evaluate(v score.Vitals, resourceCount int) EvaluateResult {
+	return m.cur.Load().Evaluate(v, resourceCount)
+}
+
+// EvaluateWithContext is Engine.EvaluateWithContext on the current engine.
+func (m *ManagedEngine) EvaluateWithContext(v score.Vitals, resourceCount int, ctx PatientContext) EvaluateResult {
+	return m.cur.Load().EvaluateWithContext(v, resourceCount, ctx)
+}
+
+// BatchEvaluate is Engine.BatchEvaluate on the current engine; the whole
+// batch is scored with one parameter set.
+func (m *ManagedEngine) BatchEvaluate(vitals []score.Vitals, resourceCounts []int) []EvaluateResult {
+	return m.cur.Load().BatchEvaluate(vitals, resourceCounts)
+}
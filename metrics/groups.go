@@ -0,0 +1,86 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package metrics
+
+import "sort"
+
+// GroupAgreement summarises predicted-versus-reference agreement within one
+// group of records, e.g. a phenotype cluster from stats.KMeans.
+//
+//	| Field             | Meaning                                          |
+//	|-------------------|--------------------------------------------------|
+//	| ExactAgreement    | Share with pred == ref                           |
+//	| WithinOne         | Share with |pred - ref| <= 1                       |
+//	| UnderTriage       | Share with pred > ref (assigned less acute)      |
+//	| OverTriage        | Share with pred < ref (assigned more acute)      |
+//	| Bias              | Mean pred - ref; > 0 leans towards under-triage  |
+//	| WeightedKappa     | Linear weighted kappa within the group           |
+//	| UnderTriageExcess | UnderTriage minus the overall under-triage rate  |
+type GroupAgreement struct {
+	Group             int
+	N                 int
+	ExactAgreement    float64
+	WithinOne         float64
+	UnderTriage       float64
+	OverTriage        float64
+	Bias              float64
+	WeightedKappa     float64
+	UnderTriageExcess float64
+}
+
+// AgreementByGroup returns one GroupAgreement per distinct value of groups,
+// ordered by group. groups, pred and ref (levels 1..5) must have equal
+// length; otherwise nil is returned. Sort the result by UnderTriageExcess
+// to find the groups where the score misses acuity most often.
+func AgreementByGroup(groups, pred, ref []int) []GroupAgreement {
+	if len(groups) != len(pred) || len(pred) != len(ref) || len(pred) == 0 {
+		return nil
+	}
+	idx := make(map[int][]int)
+	for i, g := range groups {
+		idx[g] = append(idx[g], i)
+	}
+	overall := groupAgreement(0, pred, ref)
+	out := make([]GroupAgreement, 0, len(idx))
+	for g, rows := range idx {
+		p := make([]int, len(rows))
+		r := make([]int, len(rows))
+		for k, i := range rows {
+			p[k], r[k] = pred[i], ref[i]
+		}
+		ga := groupAgreement(g, p, r)
+		ga.UnderTriageExcess = ga.UnderTriage - overall.UnderTriage
+		out = append(out, ga)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Group < out[j].Group })
+	return out
+}
+
+func groupAgreement(g int, pred, ref []int) GroupAgreement {
+	ga := GroupAgreement{Group: g, N: len(pred)}
+	var exact, within, under, over, diff int
+	for i := range pred {
+		d := clampLevel(pred[i]) - clampLevel(ref[i])
+		diff += d
+		switch {
+		case d == 0:
+			exact++
+		case d > 0:
+			under++
+		default:
+			over++
+		}
+		if d >= -1 && d <= 1 {
+			within++
+		}
+	}
+	n := float64(len(pred))
+	ga.ExactAgreement = float64(exact) / n
+	ga.WithinOne = float64(within) / n
+	ga.UnderTriage = float64(under) / n
+	ga.OverTriage = float64(over) / n
+	ga.Bias = float64(diff) / n
+	ga.WeightedKappa = WeightedKappa(pred, ref)
+	return ga
+}
@@ -81,3 +81,24 @@ func TestWeightedKappa(t *testing.T) {
 		t.Errorf("perfect agreement: weighted kappa = %v", k)
 	}
 }
+
+func TestAgreementByGroup(t *testing.T) {
+	// Cluster 1 (e.g. isolated hypothermia) is systematically under-triaged.
+	groups := []int{0, 0, 0, 0, 1, 1, 1, 1}
+	pred := []int{2, 3, 3, 4, 4, 5, 4, 3}
+	ref := []int{2, 3, 3, 4, 2, 3, 3, 3}
+	rows := AgreementByGroup(groups, pred, ref)
+	if len(rows) != 2 || rows[0].Group != 0 || rows[1].Group != 1 {
+		t.Fatalf("rows = %+v", rows)
+	}
+	if rows[0].ExactAgreement != 1 || rows[0].UnderTriage != 0 || rows[0].UnderTriageExcess != -0.375 {
+		t.Errorf("group 0 = %+v", rows[0])
+	}
+	g := rows[1]
+	if g.N != 4 || g.ExactAgreement != 0.25 || g.UnderTriage != 0.75 || g.WithinOne != 0.5 || g.Bias != 1.25 || g.UnderTriageExcess != 0.375 {
+		t.Errorf("group 1 = %+v", g)
+	}
+	if AgreementByGroup(groups[:1], pred, ref) != nil {
+		t.Error("length mismatch should return nil")
+	}
+}
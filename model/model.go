@@ -0,0 +1,63 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+//
+// Package model defines the Predictor interface for learned triage models
+// and dependency-free reference implementations, so sites can compare the
+// parametric formula against simple learned baselines without external ML
+// runtimes.
+//
+// # Standard feature vector
+//
+//	| Index | Feature                                         |
+//	|-------|-------------------------------------------------|
+//	| 0..6  | Vital deviation d_i in [0, 1] (HR … GCS; 0 if missing) |
+//	| 7     | Resource ratio min(1, resourceCount/maxResources) |
+//
+// Deviations use the package norms of score, as score.Deviations does.
+package model
+
+import "github.com/olaflaitinen/triagegeist/score"
+
+// NumFeatures is the length of the standard feature vector.
+const NumFeatures = 8
+
+// Features is the standard feature vector.
+type Features [NumFeatures]float64
+
+// FeaturesOf returns the standard feature vector of v and resourceCount.
+func FeaturesOf(v score.Vitals, resourceCount, maxResources int) Features {
+	var f Features
+	d := score.Deviations(v, score.DefaultNorms())
+	copy(f[:7], d[:])
+	f[7] = score.ScaleResources(resourceCount, maxResources, score.ResourceLinear)
+	return f
+}
+
+// Predictor predicts a triage level distribution from features.
+// PredictProba returns probabilities for levels 1..5 (index 0 unused) that
+// sum to 1.
+type Predictor interface {
+	PredictProba(f Features) [6]float64
+}
+
+// PredictLevel returns the most probable level (1..5) under p; ties go to
+// the more acute level.
+func PredictLevel(p Predictor, f Features) int {
+	pr := p.PredictProba(f)
+	best := 1
+	for l := 2; l <= 5; l++ {
+		if pr[l] > pr[best] {
+			best = l
+		}
+	}
+	return best
+}
+
+// PredictLevels returns PredictLevel for each feature vector.
+func PredictLevels(p Predictor, xs []Features) []int {
+	out := make([]int, len(xs))
+	for i, f := range xs {
+		out[i] = PredictLevel(p, f)
+	}
+	return out
+}
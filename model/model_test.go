@@ -0,0 +1,101 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package model
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/olaflaitinen/triagegeist"
+	"github.com/olaflaitinen/triagegeist/metrics"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// cohort returns synthetic vitals labelled by the default engine.
+func cohort(n int, seed int64) ([]Features, []int) {
+	rng := rand.New(rand.NewSource(seed))
+	eng := triagegeist.NewDefaultEngine()
+	xs := make([]Features, n)
+	ys := make([]int, n)
+	for i := range xs {
+		v := score.Vitals{
+			HR:   50 + rng.Intn(110),
+			RR:   10 + rng.Intn(25),
+			SBP:  80 + rng.Intn(90),
+			SpO2: 84 + rng.Intn(16),
+		}
+		rc := rng.Intn(7)
+		xs[i] = FeaturesOf(v, rc, eng.P.MaxResources)
+		ys[i] = eng.Level(v, rc).Int()
+	}
+	return xs, ys
+}
+
+func TestOrdinalLogistic_Fit(t *testing.T) {
+	x, y := cohort(400, 1)
+	m, ok := FitOrdinalLogistic(x, y, DefaultFitOptions())
+	if !ok {
+		t.Fatal("fit failed")
+	}
+	for k := 1; k < 4; k++ {
+		if m.Cutpoints[k] <= m.Cutpoints[k-1] {
+			t.Fatalf("cutpoints not ordered: %v", m.Cutpoints)
+		}
+	}
+	if m.Coef[0] >= 0 || m.Coef[7] >= 0 {
+		t.Errorf("HR and resource coefficients should be negative (more acute): %v", m.Coef)
+	}
+	p := m.PredictProba(x[0])
+	var sum float64
+	for _, q := range p[1:] {
+		sum += q
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("probabilities sum to %v", sum)
+	}
+	tx, ty := cohort(200, 2)
+	cm := metrics.NewConfusionMatrix(PredictLevels(m, tx), ty)
+	if k := metrics.WeightedKappa(PredictLevels(m, tx), ty); k < 0.6 {
+		t.Errorf("held-out weighted kappa %v (accuracy %v) too low for a formula-labelled cohort", k, cm.OverallAccuracy())
+	}
+	if _, ok := FitOrdinalLogistic(x[:3], []int{2, 2, 2}, DefaultFitOptions()); ok {
+		t.Error("single-level data should not fit")
+	}
+}
+
+func TestBoostedStumps_Fit(t *testing.T) {
+	x, y := cohort(400, 3)
+	m, ok := FitBoostedStumps(x, y, DefaultBoostOptions())
+	if !ok {
+		t.Fatal("fit failed")
+	}
+	tx, ty := cohort(200, 4)
+	if k := metrics.WeightedKappa(PredictLevels(m, tx), ty); k < 0.6 {
+		t.Errorf("held-out weighted kappa %v too low", k)
+	}
+	p := m.PredictProba(tx[0])
+	var sum float64
+	for _, q := range p[1:] {
+		if q < 0 {
+			t.Fatalf("negative probability: %v", p)
+		}
+		sum += q
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("probabilities sum to %v", sum)
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back BoostedStumps
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.PredictProba(tx[0]) != p {
+		t.Error("JSON round trip changed predictions")
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package model
+
+import "math"
+
+// OrdinalLogistic is a proportional-odds (cumulative logit) model over the
+// standard feature vector:
+//
+//	P(level <= k | x) = σ(θ_k - βᵀx),  k = 1..4,  θ_1 < θ_2 < θ_3 < θ_4
+//
+// where σ is the logistic function. Level 1 is the most acute, so features
+// that raise acuity get negative coefficients.
+type OrdinalLogistic struct {
+	Coef      Features   `json:"coef"`
+	Cutpoints [4]float64 `json:"cutpoints"`
+}
+
+// FitOptions controls FitOrdinalLogistic.
+//
+//	| Field        | Default | Meaning                                |
+//	|--------------|---------|----------------------------------------|
+//	| Iterations   | 2000    | Full-batch gradient steps              |
+//	| LearningRate | 0.5     | Step size on the mean log-likelihood   |
+//	| L2           | 0.001   | Ridge penalty on Coef (not cutpoints)  |
+type FitOptions struct {
+	Iterations   int
+	LearningRate float64
+	L2           float64
+}
+
+// DefaultFitOptions returns the defaults above.
+func DefaultFitOptions() FitOptions {
+	return FitOptions{Iterations: 2000, LearningRate: 0.5, L2: 0.001}
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
+
+// cdf returns P(level <= k) for k = 0..5 (0 and 1 at the ends).
+func (m OrdinalLogistic) cdf(f Features) [6]float64 {
+	var eta float64
+	for i, c := range m.Coef {
+		eta += c * f[i]
+	}
+	c := [6]float64{0, 0, 0, 0, 0, 1}
+	for k := 1; k <= 4; k++ {
+		c[k] = sigmoid(m.Cutpoints[k-1] - eta)
+	}
+	return c
+}
+
+// PredictProba implements Predictor.
+func (m OrdinalLogistic) PredictProba(f Features) [6]float64 {
+	c := m.cdf(f)
+	var p [6]float64
+	for l := 1; l <= 5; l++ {
+		p[l] = math.Max(0, c[l]-c[l-1])
+	}
+	return p
+}
+
+// FitOrdinalLogistic fits an OrdinalLogistic to features x and levels y
+// (1..5) by full-batch gradient ascent on the penalised mean log-likelihood.
+// Cutpoints are parametrised as θ_1 and log-increments so they stay ordered.
+// Rows with a level outside 1..5 are skipped. Returns false if the lengths
+// differ or fewer than two distinct levels remain.
+func FitOrdinalLogistic(x []Features, y []int, opt FitOptions) (OrdinalLogistic, bool) {
+	var m OrdinalLogistic
+	if len(x) != len(y) {
+		return m, false
+	}
+	var xs []Features
+	var ys []int
+	var seen [6]bool
+	var distinct int
+	for i, l := range y {
+		if l < 1 || l > 5 {
+			continue
+		}
+		xs = append(xs, x[i])
+		ys = append(ys, l)
+		if !seen[l] {
+			seen[l] = true
+			distinct++
+		}
+	}
+	if distinct < 2 {
+		return m, false
+	}
+	if opt.Iterations <= 0 {
+		opt.Iterations = DefaultFitOptions().Iterations
+	}
+	if opt.LearningRate <= 0 {
+		opt.LearningRate = DefaultFitOptions().LearningRate
+	}
+
+	// θ_1 = u[0], θ_k = θ_{k-1} + exp(u[k-1]).
+	u := [4]float64{-1.5, 0, 0, 0}
+	n := float64(len(xs))
+	for it := 0; it < opt.Iterations; it++ {
+		m.Cutpoints = cutpoints(u)
+		var gCoef Features
+		var gTheta [4]float64
+		for i, f := range xs {
+			c := m.cdf(f)
+			l := ys[i]
+			p := math.Max(c[l]-c[l-1], 1e-12)
+			// density terms σ'(z) = σ(z)(1-σ(z)) at the upper and lower cutpoints
+			var fu, fl float64
+			if l <= 4 {
+				fu = c[l] * (1 - c[l])
+				gTheta[l-1] += fu / p
+			}
+			if l >= 2 {
+				fl = c[l-1] * (1 - c[l-1])
+				gTheta[l-2] -= fl / p
+			}
+			dEta := -(fu - fl) / p
+			for j := range gCoef {
+				gCoef[j] += dEta * f[j]
+			}
+		}
+		for j := range m.Coef {
+			m.Coef[j] += opt.LearningRate * (gCoef[j]/n - opt.L2*m.Coef[j])
+		}
+		// chain rule: θ_j depends on u[0] for all j, and on u[k] for j >= k.
+		var gu [4]float64
+		for k := 0; k < 4; k++ {
+			var s float64
+			for j := k; j < 4; j++ {
+				s += gTheta[j]
+			}
+			if k > 0 {
+				s *= math.Exp(u[k])
+			}
+			gu[k] = s / n
+		}
+		for k := range u {
+			u[k] += opt.LearningRate * gu[k]
+		}
+	}
+	m.Cutpoints = cutpoints(u)
+	return m, true
+}
+
+func cutpoints(u [4]float64) [4]float64 {
+	var t [4]float64
+	t[0] = u[0]
+	for k := 1; k < 4; k++ {
+		t[k] = t[k-1] + math.Exp(u[k])
+	}
+	return t
+}
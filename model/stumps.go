@@ -0,0 +1,220 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package model
+
+import (
+	"math"
+	"sort"
+)
+
+// Stump is a one-split regression tree: it returns Left if f[Feature] < Split
+// and Right otherwise.
+type Stump struct {
+	Feature int     `json:"feature"`
+	Split   float64 `json:"split"`
+	Left    float64 `json:"left"`
+	Right   float64 `json:"right"`
+}
+
+// Eval returns the stump's output for f.
+func (s Stump) Eval(f Features) float64 {
+	if s.Feature < 0 || s.Feature >= NumFeatures {
+		return 0
+	}
+	if f[s.Feature] < s.Split {
+		return s.Left
+	}
+	return s.Right
+}
+
+// StumpEnsemble is an additive logit model: Base plus the sum of its stumps.
+type StumpEnsemble struct {
+	Base   float64 `json:"base"`
+	Stumps []Stump `json:"stumps"`
+}
+
+// Logit returns the ensemble's log-odds for f.
+func (e StumpEnsemble) Logit(f Features) float64 {
+	z := e.Base
+	for _, s := range e.Stumps {
+		z += s.Eval(f)
+	}
+	return z
+}
+
+// BoostedStumps is a gradient-boosted stump model for ordinal levels: one
+// binary ensemble per cumulative split, Cumulative[k-1] estimating
+// P(level <= k) for k = 1..4. Cumulative probabilities are made
+// non-decreasing in k before differencing. The struct serialises with
+// encoding/json.
+type BoostedStumps struct {
+	Cumulative [4]StumpEnsemble `json:"cumulative"`
+}
+
+// PredictProba implements Predictor.
+func (m BoostedStumps) PredictProba(f Features) [6]float64 {
+	c := [6]float64{0, 0, 0, 0, 0, 1}
+	for k := 1; k <= 4; k++ {
+		c[k] = math.Max(c[k-1], sigmoid(m.Cumulative[k-1].Logit(f)))
+	}
+	var p [6]float64
+	for l := 1; l <= 5; l++ {
+		p[l] = math.Max(0, c[l]-c[l-1])
+	}
+	return p
+}
+
+// BoostOptions controls FitBoostedStumps.
+//
+//	| Field        | Default | Meaning                                  |
+//	|--------------|---------|------------------------------------------|
+//	| Rounds       | 100     | Stumps per cumulative ensemble           |
+//	| LearningRate | 0.1     | Shrinkage applied to each stump          |
+//	| MinLeaf      | 5       | Minimum rows on each side of a split     |
+type BoostOptions struct {
+	Rounds       int
+	LearningRate float64
+	MinLeaf      int
+}
+
+// DefaultBoostOptions returns the defaults above.
+func DefaultBoostOptions() BoostOptions {
+	return BoostOptions{Rounds: 100, LearningRate: 0.1, MinLeaf: 5}
+}
+
+// FitBoostedStumps fits a BoostedStumps model to features x and levels y
+// (1..5). Each cumulative ensemble is fitted by gradient boosting on the
+// logistic loss with Newton leaf values. Rows with a level outside 1..5 are
+// skipped. Returns false if the lengths differ or fewer than two distinct
+// levels remain.
+func FitBoostedStumps(x []Features, y []int, opt BoostOptions) (BoostedStumps, bool) {
+	var m BoostedStumps
+	if len(x) != len(y) {
+		return m, false
+	}
+	def := DefaultBoostOptions()
+	if opt.Rounds <= 0 {
+		opt.Rounds = def.Rounds
+	}
+	if opt.LearningRate <= 0 {
+		opt.LearningRate = def.LearningRate
+	}
+	if opt.MinLeaf <= 0 {
+		opt.MinLeaf = def.MinLeaf
+	}
+	var xs []Features
+	var ys []int
+	var seen [6]bool
+	var distinct int
+	for i, l := range y {
+		if l < 1 || l > 5 {
+			continue
+		}
+		xs = append(xs, x[i])
+		ys = append(ys, l)
+		if !seen[l] {
+			seen[l] = true
+			distinct++
+		}
+	}
+	if distinct < 2 {
+		return m, false
+	}
+	order := sortedByFeature(xs)
+	t := make([]float64, len(xs))
+	for k := 1; k <= 4; k++ {
+		for i, l := range ys {
+			t[i] = 0
+			if l <= k {
+				t[i] = 1
+			}
+		}
+		m.Cumulative[k-1] = boost(xs, t, order, opt)
+	}
+	return m, true
+}
+
+// sortedByFeature returns, per feature, the row indices in ascending order.
+func sortedByFeature(xs []Features) [NumFeatures][]int {
+	var order [NumFeatures][]int
+	for j := range order {
+		idx := make([]int, len(xs))
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.SliceStable(idx, func(a, b int) bool { return xs[idx[a]][j] < xs[idx[b]][j] })
+		order[j] = idx
+	}
+	return order
+}
+
+// boost fits one binary logistic ensemble to targets t in {0, 1}.
+func boost(xs []Features, t []float64, order [NumFeatures][]int, opt BoostOptions) StumpEnsemble {
+	n := len(xs)
+	var pos float64
+	for _, v := range t {
+		pos += v
+	}
+	p0 := math.Min(math.Max(pos/float64(n), 1e-6), 1-1e-6)
+	e := StumpEnsemble{Base: math.Log(p0 / (1 - p0))}
+	if pos == 0 || pos == float64(n) {
+		return e
+	}
+	z := make([]float64, n)
+	g := make([]float64, n) // gradient t - p
+	h := make([]float64, n) // hessian p(1-p)
+	for i := range z {
+		z[i] = e.Base
+	}
+	for r := 0; r < opt.Rounds; r++ {
+		var gSum, hSum float64
+		for i := range z {
+			p := sigmoid(z[i])
+			g[i], h[i] = t[i]-p, p*(1-p)
+			gSum += g[i]
+			hSum += h[i]
+		}
+		best, ok := bestStump(xs, g, h, gSum, hSum, order, opt.MinLeaf)
+		if !ok {
+			break
+		}
+		best.Left *= opt.LearningRate
+		best.Right *= opt.LearningRate
+		e.Stumps = append(e.Stumps, best)
+		for i, f := range xs {
+			z[i] += best.Eval(f)
+		}
+	}
+	return e
+}
+
+// bestStump returns the split maximising the Newton gain
+// G_L²/H_L + G_R²/H_R over all features and distinct-value boundaries.
+func bestStump(xs []Features, g, h []float64, gSum, hSum float64, order [NumFeatures][]int, minLeaf int) (Stump, bool) {
+	const eps = 1e-12
+	var best Stump
+	bestGain := gSum * gSum / (hSum + eps)
+	found := false
+	n := len(xs)
+	for j, idx := range order {
+		var gl, hl float64
+		for k := 0; k < n-1; k++ {
+			i := idx[k]
+			gl += g[i]
+			hl += h[i]
+			a, b := xs[i][j], xs[idx[k+1]][j]
+			if a == b || k+1 < minLeaf || n-k-1 < minLeaf {
+				continue
+			}
+			gr, hr := gSum-gl, hSum-hl
+			gain := gl*gl/(hl+eps) + gr*gr/(hr+eps)
+			if gain > bestGain+eps {
+				bestGain = gain
+				best = Stump{Feature: j, Split: (a + b) / 2, Left: gl / (hl + eps), Right: gr / (hr + eps)}
+				found = true
+			}
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,145 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package norm
+
+import "math"
+
+// AgeBand is one row of an age-banded reference table: Ranges applies from
+// MinMonths up to, but not including, MaxMonths. MaxMonths is +Inf for an
+// open-ended band.
+type AgeBand struct {
+	Name      string
+	MinMonths float64
+	MaxMonths float64
+	Ranges    Ranges
+}
+
+// AgeBands is an age-banded reference table, bands in ascending age order
+// with each band starting where the previous one ends.
+type AgeBands []AgeBand
+
+// NeonatalMonths is the end of the neonatal period, 28 days, in months.
+const NeonatalMonths = 28 * 12 / 365.25
+
+// AgeBandedRanges returns the standard age-banded reference table, from
+// neonates to older adults. A single PediatricRanges cannot cover both
+// a 2-month-old and a 14-year-old: resting HR and RR fall and blood
+// pressure rises steeply through childhood. The neonatal and infant bands
+// are the sourced NeonatalRanges and InfantRanges; the child bands keep
+// Temp, SpO2 and GCS as in DefaultRanges and are illustrative only;
+// calibrate to your own protocol.
+//
+//	| Band    | Age           | HR       | RR      | SBP      | DBP     |
+//	|---------|---------------|----------|---------|----------|---------|
+//	| 0-28 d  | 0 to 28 days  | NeonatalRanges                          |
+//	| 1-12 mo | 28 d to 12 mo | InfantRanges                            |
+//	| 1-3 y   | 1 to 3 y      | 115 ± 45 | 28 ± 14 | 95 ± 25  | 60 ± 20 |
+//	| 3-6 y   | 3 to 6 y      | 100 ± 40 | 24 ± 12 | 100 ± 25 | 62 ± 20 |
+//	| 6-12 y  | 6 to 12 y     | 90 ± 40  | 20 ± 10 | 105 ± 30 | 68 ± 22 |
+//	| 12-18 y | 12 to 18 y    | AdolescentRanges                        |
+//	| adult   | 18 to 65 y    | DefaultRanges                           |
+//	| 65+     | 65 y and over | GeriatricRanges                         |
+func AgeBandedRanges() AgeBands {
+	child := func(hr, rr, sbp, dbp [2]float64) Ranges {
+		r := DefaultRanges()
+		r.HR, r.RR, r.SBP, r.DBP = hr, rr, sbp, dbp
+		return r
+	}
+	return AgeBands{
+		{"0-28 d", 0, NeonatalMonths, NeonatalRanges()},
+		{"1-12 mo", NeonatalMonths, 12, InfantRanges()},
+		{"1-3 y", 12, 36, child([2]float64{115, 45}, [2]float64{28, 14}, [2]float64{95, 25}, [2]float64{60, 20})},
+		{"3-6 y", 36, 72, child([2]float64{100, 40}, [2]float64{24, 12}, [2]float64{100, 25}, [2]float64{62, 20})},
+		{"6-12 y", 72, 144, child([2]float64{90, 40}, [2]float64{20, 10}, [2]float64{105, 30}, [2]float64{68, 22})},
+		{"12-18 y", 144, 216, AdolescentRanges()},
+		{"adult", 216, 780, DefaultRanges()},
+		{"65+", 780, math.Inf(1), GeriatricRanges()},
+	}
+}
+
+// Valid returns true if b is non-empty, its bands are contiguous and
+// ascending with MinMonths < MaxMonths, the first starts at 0, and every
+// band's Ranges is valid.
+func (b AgeBands) Valid() bool {
+	if len(b) == 0 || b[0].MinMonths != 0 {
+		return false
+	}
+	for i, band := range b {
+		if !(band.MinMonths < band.MaxMonths) || !band.Ranges.Valid() {
+			return false
+		}
+		if i > 0 && band.MinMonths != b[i-1].MaxMonths {
+			return false
+		}
+	}
+	return true
+}
+
+// Band returns the index of the band containing ageMonths; ages past the
+// last band fall in the last band. It returns -1 if b is empty or
+// ageMonths is negative or NaN (unknown age).
+func (b AgeBands) Band(ageMonths float64) int {
+	if len(b) == 0 || !(ageMonths >= 0) {
+		return -1
+	}
+	for i, band := range b {
+		if ageMonths < band.MaxMonths {
+			return i
+		}
+	}
+	return len(b) - 1
+}
+
+// Lookup returns the ranges for ageMonths: the Ranges of its band, or
+// DefaultRanges if the age is unknown (see Band).
+//
+// With interpolate, ranges change smoothly across band boundaries instead
+// of stepping: at a boundary between two bands, midpoints and half-widths
+// blend linearly over a window of half the narrower band's width on either
+// side, so the 1-year boundary blends from 6.5 to 17.5 months. There is no
+// blending into an open-ended band.
+func (b AgeBands) Lookup(ageMonths float64, interpolate bool) Ranges {
+	i := b.Band(ageMonths)
+	if i < 0 {
+		return DefaultRanges()
+	}
+	r := b[i].Ranges
+	if !interpolate {
+		return r
+	}
+	// blend returns the ranges at ageMonths across the boundary between
+	// bands j and j+1, and whether ageMonths lies in its window.
+	blend := func(j int) (Ranges, bool) {
+		if j < 0 || j+1 >= len(b) {
+			return Ranges{}, false
+		}
+		lo, hi := b[j], b[j+1]
+		if !finite(hi.MaxMonths) {
+			return Ranges{}, false
+		}
+		h := math.Min(lo.MaxMonths-lo.MinMonths, hi.MaxMonths-hi.MinMonths) / 2
+		if !(h > 0) || math.Abs(ageMonths-lo.MaxMonths) >= h {
+			return Ranges{}, false
+		}
+		return lerpRanges(lo.Ranges, hi.Ranges, (ageMonths-lo.MaxMonths+h)/(2*h)), true
+	}
+	if x, ok := blend(i - 1); ok {
+		return x
+	}
+	if x, ok := blend(i); ok {
+		return x
+	}
+	return r
+}
+
+// lerpRanges returns a + t·(b − a) for each midpoint and half-width.
+func lerpRanges(a, b Ranges, t float64) Ranges {
+	var r Ranges
+	for i := 0; i < NumVitals; i++ {
+		am, ah := a.At(i)
+		bm, bh := b.At(i)
+		r.Set(i, am+t*(bm-am), ah+t*(bh-ah))
+	}
+	return r
+}
@@ -0,0 +1,61 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package norm
+
+// altitudeSpO2 is the expected fall in resting SpO2 (percentage points)
+// of healthy acclimatized adults by elevation in metres, interpolated
+// linearly between rows.
+var altitudeSpO2 = [][2]float64{
+	{0, 0},
+	{1000, 1},
+	{1500, 2},
+	{2000, 3},
+	{2500, 4.5},
+	{3000, 6},
+	{3500, 8},
+	{4000, 10},
+	{4500, 13},
+}
+
+// AltitudeSpO2Drop returns the expected fall in resting SpO2, in
+// percentage points, at elevationMetres above sea level: 0 at or below sea
+// level, rising with the falling partial pressure of inspired oxygen.
+// Elevations above the top of the table take its last value. These are
+// illustrative only; calibrate to your own population.
+//
+//	| Elevation (m) | 1000 | 1500 | 2000 | 2500 | 3000 | 3500 | 4000 | 4500 |
+//	|---------------|------|------|------|------|------|------|------|------|
+//	| SpO2 drop     | 1    | 2    | 3    | 4.5  | 6    | 8    | 10   | 13   |
+func AltitudeSpO2Drop(elevationMetres float64) float64 {
+	if !(elevationMetres > 0) {
+		return 0
+	}
+	for k := 1; k < len(altitudeSpO2); k++ {
+		hi := altitudeSpO2[k]
+		if elevationMetres <= hi[0] {
+			lo := altitudeSpO2[k-1]
+			return lo[1] + (elevationMetres-lo[0])/(hi[0]-lo[0])*(hi[1]-lo[1])
+		}
+	}
+	return altitudeSpO2[len(altitudeSpO2)-1][1]
+}
+
+// AltitudeAdjusted returns r as AsymmetricRanges with only the low side of
+// SpO2 adjusted for elevation: the midpoint is lowered by
+// AltitudeSpO2Drop(elevationMetres) and the low half-width widened by half
+// that drop, since saturation at altitude is both lower and more variable,
+// and the high half-width is 0, so saturation at or above the lowered
+// midpoint never deviates. At a 2,500 m clinic, DefaultRanges' SpO2 [98, 8]
+// becomes [93.5, 10.25, 0]: a physiologically normal 93% no longer scores
+// as a deviation of 0.625, and 98% scores 0 rather than rising above the
+// new midpoint. Score with score.AcuityWithAsymmetricRanges. Elevations at
+// or below sea level return r.Asymmetric() unchanged.
+func AltitudeAdjusted(r Ranges, elevationMetres float64) AsymmetricRanges {
+	a := r.Asymmetric()
+	d := AltitudeSpO2Drop(elevationMetres)
+	if d > 0 && r.SpO2[1] > 0 {
+		a.SpO2 = [3]float64{r.SpO2[0] - d, r.SpO2[1] + d/2, 0}
+	}
+	return a
+}
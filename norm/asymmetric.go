@@ -0,0 +1,113 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package norm
+
+import "math"
+
+// AsymmetricRanges holds (midpoint, low half-width, high half-width) for
+// each of the seven vitals, since most normal ranges are not symmetric
+// around their midpoint: a fall in SBP of 35 mmHg is as abnormal as a rise
+// of 60. The low half-width applies to values below the midpoint, the high
+// one to values at or above it. A zero half-width means that side does not
+// deviate; a vital with both zero is not used.
+type AsymmetricRanges struct {
+	HR   [3]float64 // [mid, lowHalfWidth, highHalfWidth]
+	RR   [3]float64
+	SBP  [3]float64
+	DBP  [3]float64
+	Temp [3]float64
+	SpO2 [3]float64
+	GCS  [3]float64
+}
+
+// DefaultAsymmetricRanges returns example adult ED ranges with separate
+// half-widths per side. SpO2 and GCS deviate only below the midpoint.
+// These are illustrative only; calibrate to your own protocol.
+//
+//	| Vital | Mid  | Low half-width | High half-width |
+//	|-------|------|----------------|-----------------|
+//	| HR    | 75   | 30             | 55              |
+//	| RR    | 16   | 8              | 14              |
+//	| SBP   | 120  | 35             | 60              |
+//	| DBP   | 78   | 25             | 40              |
+//	| Temp  | 37.0 | 2.0            | 2.5             |
+//	| SpO2  | 98   | 8              | 0               |
+//	| GCS   | 15   | 6              | 0               |
+func DefaultAsymmetricRanges() AsymmetricRanges {
+	return AsymmetricRanges{
+		HR:   [3]float64{75, 30, 55},
+		RR:   [3]float64{16, 8, 14},
+		SBP:  [3]float64{120, 35, 60},
+		DBP:  [3]float64{78, 25, 40},
+		Temp: [3]float64{37.0, 2.0, 2.5},
+		SpO2: [3]float64{98, 8, 0},
+		GCS:  [3]float64{15, 6, 0},
+	}
+}
+
+// Asymmetric returns r as AsymmetricRanges with both half-widths equal to
+// r's; it deviates exactly as r does.
+func (r Ranges) Asymmetric() AsymmetricRanges {
+	var a AsymmetricRanges
+	for i := 0; i < NumVitals; i++ {
+		m, hw := r.At(i)
+		a.Set(i, m, hw, hw)
+	}
+	return a
+}
+
+// DeviationAsym returns the normalised deviation of value from mid using
+// lowHW below mid and highHW at or above it:
+//
+//	d = min(1, |value - mid| / halfWidth)
+//
+// If the half-width of value's side is <= 0, returns 0. Result is in [0, 1].
+func DeviationAsym(value, mid, lowHW, highHW float64) float64 {
+	if value < mid {
+		return Deviation(value, mid, lowHW)
+	}
+	return Deviation(value, mid, highHW)
+}
+
+// At returns the midpoint and low and high half-widths for vital index i
+// (0..6). If i is out of range, returns zero values.
+func (r AsymmetricRanges) At(i int) (mid, lowHW, highHW float64) {
+	if i < 0 || i >= NumVitals {
+		return 0, 0, 0
+	}
+	a := r.Array()[i]
+	return a[0], a[1], a[2]
+}
+
+// Set sets the midpoint and half-widths for vital index i. No-op if i out
+// of range.
+func (r *AsymmetricRanges) Set(i int, mid, lowHW, highHW float64) {
+	p := [NumVitals]*[3]float64{&r.HR, &r.RR, &r.SBP, &r.DBP, &r.Temp, &r.SpO2, &r.GCS}
+	if i >= 0 && i < NumVitals {
+		*p[i] = [3]float64{mid, lowHW, highHW}
+	}
+}
+
+// Valid returns true if all midpoints are finite and all half-widths are
+// non-negative and finite.
+func (r AsymmetricRanges) Valid() bool {
+	for _, a := range r.Array() {
+		if !finite(a[0]) || !(a[1] >= 0) || !(a[2] >= 0) || math.IsInf(a[1], 0) || math.IsInf(a[2], 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// Deviation returns DeviationAsym of value for vital index i, or 0 if i is
+// out of range.
+func (r AsymmetricRanges) Deviation(i int, value float64) float64 {
+	m, lo, hi := r.At(i)
+	return DeviationAsym(value, m, lo, hi)
+}
+
+// Array returns r as [7][3]float64 in vital index order.
+func (r AsymmetricRanges) Array() [7][3]float64 {
+	return [7][3]float64{r.HR, r.RR, r.SBP, r.DBP, r.Temp, r.SpO2, r.GCS}
+}
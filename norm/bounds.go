@@ -0,0 +1,89 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package norm
+
+// BoundsSet holds the absolute [min, max] of each of the seven vitals:
+// values outside are implausible (entry or device errors) rather than
+// abnormal. Start from DefaultBounds and override per site; both norm
+// (FitRanges) and validate (VitalsWithBounds, ClampVitalsWithBounds)
+// consume a BoundsSet, so they cannot disagree.
+type BoundsSet struct {
+	HR   [2]float64 // [min, max]
+	RR   [2]float64
+	SBP  [2]float64
+	DBP  [2]float64
+	Temp [2]float64
+	SpO2 [2]float64
+	GCS  [2]float64
+}
+
+// DefaultBounds returns the conservative default bounds, those of
+// CriticalBounds and of package validate.
+//
+//	| Vital | Min  | Max   |
+//	|-------|------|-------|
+//	| HR    | 20   | 300   |
+//	| RR    | 0    | 60    |
+//	| SBP   | 40   | 300   |
+//	| DBP   | 20   | 200   |
+//	| Temp  | 30   | 45    |
+//	| SpO2  | 0    | 100   |
+//	| GCS   | 3    | 15    |
+func DefaultBounds() BoundsSet {
+	return BoundsSet{
+		HR:   [2]float64{20, 300},
+		RR:   [2]float64{0, 60},
+		SBP:  [2]float64{40, 300},
+		DBP:  [2]float64{20, 200},
+		Temp: [2]float64{30, 45},
+		SpO2: [2]float64{0, 100},
+		GCS:  [2]float64{3, 15},
+	}
+}
+
+// At returns the [min, max] of vital index i (0..6). If i is out of range,
+// returns zero values.
+func (b BoundsSet) At(i int) (min, max float64) {
+	if i < 0 || i >= NumVitals {
+		return 0, 0
+	}
+	a := b.Array()[i]
+	return a[0], a[1]
+}
+
+// Set sets the [min, max] of vital index i. No-op if i out of range.
+func (b *BoundsSet) Set(i int, min, max float64) {
+	p := [NumVitals]*[2]float64{&b.HR, &b.RR, &b.SBP, &b.DBP, &b.Temp, &b.SpO2, &b.GCS}
+	if i >= 0 && i < NumVitals {
+		*p[i] = [2]float64{min, max}
+	}
+}
+
+// Array returns b as [7][2]float64 in vital index order.
+func (b BoundsSet) Array() [7][2]float64 {
+	return [7][2]float64{b.HR, b.RR, b.SBP, b.DBP, b.Temp, b.SpO2, b.GCS}
+}
+
+// Valid returns true if every bound is finite with min <= max.
+func (b BoundsSet) Valid() bool {
+	for _, a := range b.Array() {
+		if !finite(a[0]) || !finite(a[1]) || a[0] > a[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// Within returns true if value lies in the bounds of vital index i.
+func (b BoundsSet) Within(i int, value float64) bool {
+	lo, hi := b.At(i)
+	return value >= lo && value <= hi
+}
+
+// Clamp returns value clamped to the bounds of vital index i (see
+// ClampToRange).
+func (b BoundsSet) Clamp(i int, value float64) float64 {
+	lo, hi := b.At(i)
+	return ClampToRange(value, lo, hi)
+}
@@ -0,0 +1,38 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package norm
+
+// Citation records where the values of a clinically sourced preset come
+// from, for audit and for reporting in research that uses it.
+type Citation struct {
+	Population string
+	Sources    []string
+	Note       string
+}
+
+const (
+	citeFleming = "Fleming S, Thompson M, Stevens R, et al. Normal ranges of heart rate and respiratory rate in children from birth to 18 years of age: a systematic review of observational studies. Lancet. 2011;377(9770):1011-1018."
+	citePALS    = "American Heart Association. Pediatric Advanced Life Support Provider Manual. 2020 (normal blood pressure by age)."
+	citeNICE    = "National Institute for Health and Care Excellence. Fever in under 5s: assessment and initial management (NG143). 2019."
+)
+
+// CitationFor returns the citation of the preset function named preset,
+// e.g. "NeonatalRanges", and false for presets that are illustrative only.
+func CitationFor(preset string) (Citation, bool) {
+	switch preset {
+	case "NeonatalRanges":
+		return Citation{
+			Population: "term neonates, birth to 28 days",
+			Sources:    []string{citeFleming, citePALS, citeNICE},
+			Note:       "HR and RR: median and 1st-99th centiles at birth; values rounded",
+		}, true
+	case "InfantRanges":
+		return Citation{
+			Population: "infants, 28 days to under 1 year",
+			Sources:    []string{citeFleming, citePALS, citeNICE},
+			Note:       "HR and RR: median and 1st-99th centiles over 1-12 months; values rounded",
+		}, true
+	}
+	return Citation{}, false
+}
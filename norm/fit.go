@@ -0,0 +1,188 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package norm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Defaults for FitOptions.
+const (
+	DefaultFitCoverage   = 0.95
+	DefaultFitFence      = 3.0
+	DefaultFitMinSamples = 30
+	// DefaultFitSaturation is the ratio of a DefaultRanges half-width to
+	// the half-spread of the central 95% of normal adults, e.g. HR 40
+	// against 60-100 bpm: deviation saturates at about twice the normal
+	// spread, not at its edge.
+	DefaultFitSaturation = 2.0
+)
+
+// FitOptions configures FitRanges. Zero fields take the defaults.
+//
+//	| Field      | Default              | Meaning                                   |
+//	|------------|----------------------|-------------------------------------------|
+//	| Coverage   | DefaultFitCoverage   | Central fraction spanned by the range     |
+//	| Fence      | DefaultFitFence      | Tukey fence, in IQRs beyond the quartiles |
+//	| MinSamples | DefaultFitMinSamples | Values a vital needs after trimming       |
+//	| Saturation | DefaultFitSaturation | Half-width in central half-spreads        |
+//	| Base       | DefaultRanges        | Ranges of vitals that are not fitted      |
+//	| Bounds     | DefaultBounds        | Implausible values outside are dropped    |
+type FitOptions struct {
+	Coverage   float64
+	Fence      float64
+	MinSamples int
+	Saturation float64
+	Base       Ranges
+	Bounds     BoundsSet
+}
+
+// VitalFit is the fit diagnostics of one vital: N values kept after
+// trimming Outliers, their Median and the Low and High percentiles that
+// bound the central Coverage. Fitted is false if the vital kept its base
+// range (fewer than MinSamples values, or no spread).
+type VitalFit struct {
+	N        int
+	Outliers int
+	Median   float64
+	Low      float64
+	High     float64
+	Fitted   bool
+}
+
+// RangeFit is the fit diagnostics of FitRanges, in vital index order.
+type RangeFit struct {
+	Coverage   float64
+	Saturation float64
+	Vitals     [NumVitals]VitalFit
+}
+
+// FitRanges estimates reference ranges from observed values, one row per
+// patient in vital index order with values <= 0 or NaN missing, for site
+// calibration. For each vital it drops values outside Bounds and
+// beyond the Tukey fences (Fence IQRs below the first or above the third
+// quartile), then takes the median as the midpoint and Saturation times
+// half the spread of the central Coverage as the half-width:
+//
+//	mid = P50,  halfWidth = s · (P(1+c)/2 − P(1−c)/2) / 2
+//
+// Deviation reaches 1 at the half-width, so with a Saturation of 1 a
+// normal patient at the edge of the central spread already scores as
+// maximally deviant; the default keeps fitted ranges on the scale of
+// DefaultRanges.
+//
+// Percentiles interpolate linearly between order statistics, as
+// stats.Percentile. Vitals with fewer than MinSamples values, or no spread,
+// keep their Base range. Fit on patients representative of the normal
+// presentation. Skewed and bounded vitals (SpO2, GCS) may fit better as
+// AsymmetricRanges (see RangeFit.Asymmetric).
+func FitRanges(samples [][NumVitals]float64, o FitOptions) (Ranges, RangeFit, error) {
+	if o.Coverage == 0 {
+		o.Coverage = DefaultFitCoverage
+	}
+	if o.Fence == 0 {
+		o.Fence = DefaultFitFence
+	}
+	if o.MinSamples == 0 {
+		o.MinSamples = DefaultFitMinSamples
+	}
+	if o.Saturation == 0 {
+		o.Saturation = DefaultFitSaturation
+	}
+	if o.Base == (Ranges{}) {
+		o.Base = DefaultRanges()
+	}
+	if o.Bounds == (BoundsSet{}) {
+		o.Bounds = DefaultBounds()
+	}
+	fit := RangeFit{Coverage: o.Coverage, Saturation: o.Saturation}
+	switch {
+	case len(samples) == 0:
+		return Ranges{}, fit, errors.New("norm: fit: no samples")
+	case !(o.Coverage > 0 && o.Coverage < 1):
+		return Ranges{}, fit, fmt.Errorf("norm: fit: coverage %v must be in (0, 1)", o.Coverage)
+	case !(o.Fence > 0) || math.IsInf(o.Fence, 0):
+		return Ranges{}, fit, fmt.Errorf("norm: fit: fence %v must be finite and > 0", o.Fence)
+	case !(o.Saturation > 0) || math.IsInf(o.Saturation, 0):
+		return Ranges{}, fit, fmt.Errorf("norm: fit: saturation %v must be finite and > 0", o.Saturation)
+	case o.MinSamples < 1:
+		return Ranges{}, fit, fmt.Errorf("norm: fit: min samples %d must be >= 1", o.MinSamples)
+	case !o.Base.Valid():
+		return Ranges{}, fit, errors.New("norm: fit: invalid base ranges")
+	case !o.Bounds.Valid():
+		return Ranges{}, fit, errors.New("norm: fit: invalid bounds")
+	}
+	r := o.Base
+	for i := 0; i < NumVitals; i++ {
+		var col []float64
+		outliers := 0
+		for _, s := range samples {
+			switch x := s[i]; {
+			case !(x > 0):
+			case !o.Bounds.Within(i, x):
+				outliers++
+			default:
+				col = append(col, x)
+			}
+		}
+		sort.Float64s(col)
+		if len(col) > 0 {
+			q1, q3 := percentile(col, 0.25), percentile(col, 0.75)
+			lo, hi := q1-o.Fence*(q3-q1), q3+o.Fence*(q3-q1)
+			kept := col[:0]
+			for _, x := range col {
+				if x >= lo && x <= hi {
+					kept = append(kept, x)
+				}
+			}
+			outliers += len(col) - len(kept)
+			col = kept
+		}
+		f := VitalFit{N: len(col), Outliers: outliers}
+		if len(col) > 0 {
+			f.Median = percentile(col, 0.5)
+			f.Low = percentile(col, (1-o.Coverage)/2)
+			f.High = percentile(col, (1+o.Coverage)/2)
+		}
+		if f.N >= o.MinSamples && f.High > f.Low {
+			f.Fitted = true
+			r.Set(i, f.Median, o.Saturation*(f.High-f.Low)/2)
+		}
+		fit.Vitals[i] = f
+	}
+	return r, fit, nil
+}
+
+// Asymmetric returns r.Asymmetric() with each fitted vital's midpoint at
+// its median and its half-widths Saturation times the distances from the
+// median down to Low and up to High, so that a skewed distribution keeps
+// its shape.
+func (f RangeFit) Asymmetric(r Ranges) AsymmetricRanges {
+	a := r.Asymmetric()
+	s := f.Saturation
+	if s == 0 {
+		s = 1
+	}
+	for i, v := range f.Vitals {
+		if v.Fitted {
+			a.Set(i, v.Median, s*(v.Median-v.Low), s*(v.High-v.Median))
+		}
+	}
+	return a
+}
+
+// percentile returns the p-quantile (0 <= p <= 1) of the sorted, non-empty
+// x, interpolating linearly between order statistics.
+func percentile(x []float64, p float64) float64 {
+	idx := p * float64(len(x)-1)
+	i := int(idx)
+	if i >= len(x)-1 {
+		return x[len(x)-1]
+	}
+	w := idx - float64(i)
+	return x[i]*(1-w) + x[i+1]*w
+}
@@ -0,0 +1,49 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package norm
+
+import "math"
+
+// FrailtyFunc adjusts ranges for a frailty factor in [0, 1], 0 being not
+// frail; it must return r unchanged for 0. It is the hook through which
+// the root package's geriatric profile applies frailty (see
+// triagegeist.ProfileSelectorWithFrailty); FrailtyAdjust is the default.
+type FrailtyFunc func(r Ranges, frailty float64) Ranges
+
+// Frailty adjustment at frailty 1; FrailtyAdjust scales each linearly.
+const (
+	FrailSBPShift      = 10.0 // mmHg added to the SBP midpoint
+	FrailSBPNarrowing  = 0.25 // fraction removed from the SBP half-width
+	FrailHRNarrowing   = 0.15 // fraction removed from the HR half-width
+	FrailTempNarrowing = 0.20 // fraction removed from the Temp half-width
+)
+
+// FrailtyAdjust is the default FrailtyFunc. Frail patients tolerate
+// hypotension poorly and mount blunted tachycardic and febrile responses,
+// so at frailty f (clamped to [0, 1]) it raises the SBP midpoint by
+// f·FrailSBPShift and narrows the SBP, HR and Temp half-widths by f times
+// their narrowing fractions: a frail patient's SBP of 100 deviates as a
+// robust patient's lower SBP would. These are illustrative only; calibrate
+// to your own protocol.
+func FrailtyAdjust(r Ranges, frailty float64) Ranges {
+	f := math.Max(0, math.Min(1, frailty))
+	if !(f > 0) {
+		return r
+	}
+	r.SBP[0] += f * FrailSBPShift
+	r.SBP[1] *= 1 - f*FrailSBPNarrowing
+	r.HR[1] *= 1 - f*FrailHRNarrowing
+	r.Temp[1] *= 1 - f*FrailTempNarrowing
+	return r
+}
+
+// FrailtyFromCFS returns the frailty factor for a Clinical Frailty Scale
+// score (1 very fit to 9 terminally ill): 0 up to 4 (vulnerable), then
+// rising by 0.2 per point to 1 at 9. Scores outside 1..9 (unknown) give 0.
+func FrailtyFromCFS(score int) float64 {
+	if score < 5 || score > 9 {
+		return 0
+	}
+	return float64(score-4) / 5
+}
@@ -0,0 +1,119 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package norm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// VitalKeys are the configuration file keys of the seven vitals, in vital
+// index order.
+var VitalKeys = [NumVitals]string{"hr", "rr", "sbp", "dbp", "temp", "spo2", "gcs"}
+
+// vitalsJSON is the file schema of the range types: one array per vital,
+// [mid, halfWidth] for Ranges and [mid, lowHalfWidth, highHalfWidth] for
+// AsymmetricRanges.
+//
+//	{"hr": [80, 40], "rr": [16, 10], "sbp": [120, 40], "dbp": [80, 30],
+//	 "temp": [37, 2], "spo2": [98, 8], "gcs": [15, 6]}
+type vitalsJSON struct {
+	HR   []float64 `json:"hr"`
+	RR   []float64 `json:"rr"`
+	SBP  []float64 `json:"sbp"`
+	DBP  []float64 `json:"dbp"`
+	Temp []float64 `json:"temp"`
+	SpO2 []float64 `json:"spo2"`
+	GCS  []float64 `json:"gcs"`
+}
+
+func marshalVitals(rows [NumVitals][]float64) ([]byte, error) {
+	return json.Marshal(vitalsJSON{rows[0], rows[1], rows[2], rows[3], rows[4], rows[5], rows[6]})
+}
+
+// unmarshalVitals decodes data into one row of n values per vital. Every
+// vital must be present, with exactly n values; unknown keys are errors.
+func unmarshalVitals(data []byte, n int, kind string) ([NumVitals][]float64, error) {
+	var rows [NumVitals][]float64
+	var m map[string][]float64
+	if err := json.Unmarshal(data, &m); err != nil {
+		return rows, fmt.Errorf("norm: %s: %w", kind, err)
+	}
+	for key, row := range m {
+		i := 0
+		for i < NumVitals && VitalKeys[i] != key {
+			i++
+		}
+		if i == NumVitals {
+			return rows, fmt.Errorf("norm: %s: unknown vital %q", kind, key)
+		}
+		if len(row) != n {
+			return rows, fmt.Errorf("norm: %s: %s has %d values, want %d", kind, key, len(row), n)
+		}
+		rows[i] = row
+	}
+	for i, row := range rows {
+		if row == nil {
+			return rows, fmt.Errorf("norm: %s: missing %s", kind, VitalKeys[i])
+		}
+	}
+	return rows, nil
+}
+
+// MarshalJSON encodes r as an object of [mid, halfWidth] arrays keyed by
+// VitalKeys.
+func (r Ranges) MarshalJSON() ([]byte, error) {
+	var rows [NumVitals][]float64
+	for i, a := range r.Array() {
+		rows[i] = []float64{a[0], a[1]}
+	}
+	return marshalVitals(rows)
+}
+
+// UnmarshalJSON decodes the MarshalJSON format. All seven vitals are
+// required, and the result must be Valid; a vital is switched off with a
+// half-width of 0.
+func (r *Ranges) UnmarshalJSON(data []byte) error {
+	rows, err := unmarshalVitals(data, 2, "ranges")
+	if err != nil {
+		return err
+	}
+	var q Ranges
+	for i, row := range rows {
+		q.Set(i, row[0], row[1])
+	}
+	if !q.Valid() {
+		return fmt.Errorf("norm: ranges: half-widths must be non-negative")
+	}
+	*r = q
+	return nil
+}
+
+// MarshalJSON encodes r as an object of [mid, lowHalfWidth, highHalfWidth]
+// arrays keyed by VitalKeys.
+func (r AsymmetricRanges) MarshalJSON() ([]byte, error) {
+	var rows [NumVitals][]float64
+	for i, a := range r.Array() {
+		rows[i] = []float64{a[0], a[1], a[2]}
+	}
+	return marshalVitals(rows)
+}
+
+// UnmarshalJSON decodes the MarshalJSON format, with the rules of
+// Ranges.UnmarshalJSON.
+func (r *AsymmetricRanges) UnmarshalJSON(data []byte) error {
+	rows, err := unmarshalVitals(data, 3, "asymmetric ranges")
+	if err != nil {
+		return err
+	}
+	var q AsymmetricRanges
+	for i, row := range rows {
+		q.Set(i, row[0], row[1], row[2])
+	}
+	if !q.Valid() {
+		return fmt.Errorf("norm: asymmetric ranges: half-widths must be non-negative")
+	}
+	*r = q
+	return nil
+}
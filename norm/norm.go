@@ -77,6 +77,162 @@ func PediatricRanges() Ranges {
 	}
 }
 
+// NeonatalRanges returns ranges for term neonates (birth to 28 days): HR
+// and RR from the birth centiles of Fleming et al. (median, with the
+// half-width reaching the 1st and 99th centiles), blood pressure from the
+// PALS neonatal normal values, and a narrower Temp half-width, since fever
+// in the first months is a high-risk sign. Values are rounded; see
+// CitationFor("NeonatalRanges") and verify against the sources before
+// clinical use.
+//
+//	| Vital | Mid | Half-width | Source                           |
+//	|-------|-----|------------|----------------------------------|
+//	| HR    | 127 | 37         | Fleming 2011, birth: 90-164      |
+//	| RR    | 44  | 21         | Fleming 2011, birth: 25-66       |
+//	| SBP   | 72  | 22         | PALS 2020, neonate               |
+//	| DBP   | 45  | 15         | PALS 2020, neonate               |
+//	| Temp  | 37  | 1.0        | NICE NG143, 38 °C under 3 months |
+func NeonatalRanges() Ranges {
+	return Ranges{
+		HR:   [2]float64{127, 37},
+		RR:   [2]float64{44, 21},
+		SBP:  [2]float64{72, 22},
+		DBP:  [2]float64{45, 15},
+		Temp: [2]float64{37.0, 1.0},
+		SpO2: [2]float64{97, 7},
+		GCS:  [2]float64{15, 6},
+	}
+}
+
+// InfantRanges returns ranges for infants (28 days to under 1 year): HR
+// and RR from the 1-12 month centiles of Fleming et al., blood pressure
+// from the PALS infant normal values. Values are rounded; see
+// CitationFor("InfantRanges") and verify against the sources before
+// clinical use.
+//
+//	| Vital | Mid | Half-width | Source                           |
+//	|-------|-----|------------|----------------------------------|
+//	| HR    | 135 | 40         | Fleming 2011, 1-12 mo: 95-175    |
+//	| RR    | 36  | 18         | Fleming 2011, 1-12 mo: 18-54     |
+//	| SBP   | 88  | 26         | PALS 2020, infant                |
+//	| DBP   | 50  | 20         | PALS 2020, infant                |
+//	| Temp  | 37  | 1.5        | NICE NG143                       |
+func InfantRanges() Ranges {
+	return Ranges{
+		HR:   [2]float64{135, 40},
+		RR:   [2]float64{36, 18},
+		SBP:  [2]float64{88, 26},
+		DBP:  [2]float64{50, 20},
+		Temp: [2]float64{37.0, 1.5},
+		SpO2: [2]float64{98, 8},
+		GCS:  [2]float64{15, 6},
+	}
+}
+
+// AdolescentRanges returns example ranges for adolescents (12 to 17 years).
+// These are illustrative only; calibrate to your own protocol.
+func AdolescentRanges() Ranges {
+	return Ranges{
+		HR:   [2]float64{85, 40},
+		RR:   [2]float64{18, 10},
+		SBP:  [2]float64{110, 35},
+		DBP:  [2]float64{70, 28},
+		Temp: [2]float64{37.0, 2.0},
+		SpO2: [2]float64{98, 8},
+		GCS:  [2]float64{15, 6},
+	}
+}
+
+// PediatricRangesForAge returns the paediatric ranges for the age band
+// containing ageYears: NeonatalRanges for the first 28 days, InfantRanges
+// below 1, PediatricRanges from 1 to under 12, AdolescentRanges from 12 to
+// under 18, and DefaultRanges otherwise.
+func PediatricRangesForAge(ageYears float64) Ranges {
+	switch {
+	case ageYears < NeonatalMonths/12:
+		return NeonatalRanges()
+	case ageYears < 1:
+		return InfantRanges()
+	case ageYears < 12:
+		return PediatricRanges()
+	case ageYears < 18:
+		return AdolescentRanges()
+	default:
+		return DefaultRanges()
+	}
+}
+
+// GeriatricRanges returns example ranges for older adults (65 years and
+// over): a higher SBP midpoint and narrower HR and Temp half-widths, since
+// older patients mount smaller tachycardic and febrile responses.
+// These are illustrative only; calibrate to your own protocol.
+func GeriatricRanges() Ranges {
+	return Ranges{
+		HR:   [2]float64{75, 35},
+		RR:   [2]float64{16, 10},
+		SBP:  [2]float64{130, 40},
+		DBP:  [2]float64{80, 30},
+		Temp: [2]float64{36.8, 1.5},
+		SpO2: [2]float64{96, 8},
+		GCS:  [2]float64{15, 6},
+	}
+}
+
+// ObstetricRanges returns example ranges for pregnancy: higher resting HR
+// and RR and lower blood pressure than the non-pregnant adult.
+// These are illustrative only; calibrate to your own protocol.
+func ObstetricRanges() Ranges {
+	return Ranges{
+		HR:   [2]float64{90, 40},
+		RR:   [2]float64{18, 10},
+		SBP:  [2]float64{110, 35},
+		DBP:  [2]float64{70, 25},
+		Temp: [2]float64{37.0, 2.0},
+		SpO2: [2]float64{98, 8},
+		GCS:  [2]float64{15, 6},
+	}
+}
+
+// Trimester returns the pregnancy trimester (1..3) for gestationalWeeks:
+// 1 below 14 weeks, 2 from 14 to under 28, 3 from 28. Returns 0 if
+// gestationalWeeks <= 0 (unknown).
+func Trimester(gestationalWeeks int) int {
+	switch {
+	case gestationalWeeks <= 0:
+		return 0
+	case gestationalWeeks < 14:
+		return 1
+	case gestationalWeeks < 28:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// ObstetricRangesForWeeks returns pregnancy ranges for the trimester of
+// gestationalWeeks. Resting HR rises steadily through pregnancy; blood
+// pressure falls to a nadir in the second trimester and recovers in the
+// third. Unknown gestation (<= 0) returns ObstetricRanges.
+// These are illustrative only; calibrate to your own protocol.
+//
+//	| Trimester | Weeks | HR mid | SBP mid | DBP mid |
+//	|-----------|-------|--------|---------|---------|
+//	| 1         | < 14  | 85     | 115     | 72      |
+//	| 2         | 14-27 | 90     | 108     | 65      |
+//	| 3         | >= 28 | 95     | 114     | 70      |
+func ObstetricRangesForWeeks(gestationalWeeks int) Ranges {
+	r := ObstetricRanges()
+	switch Trimester(gestationalWeeks) {
+	case 1:
+		r.HR[0], r.SBP[0], r.DBP[0] = 85, 115, 72
+	case 2:
+		r.HR[0], r.SBP[0], r.DBP[0] = 90, 108, 65
+	case 3:
+		r.HR[0], r.SBP[0], r.DBP[0] = 95, 114, 70
+	}
+	return r
+}
+
 // Deviation returns the normalised deviation of value from the reference:
 //
 //	d = min(1, |value - mid| / halfWidth)
@@ -219,6 +375,12 @@ func (r Ranges) DeviationSpO2(v float64) float64 { return Deviation(v, r.SpO2[0]
 // DeviationGCS returns Deviation(v, r.GCS[0], r.GCS[1]).
 func (r Ranges) DeviationGCS(v float64) float64 { return Deviation(v, r.GCS[0], r.GCS[1]) }
 
+// Array returns r as [7][2]float64 in vital index order, the layout used by
+// score.VitalComponentWithNorms and score.Options.Norms.
+func (r Ranges) Array() [7][2]float64 {
+	return [7][2]float64{r.HR, r.RR, r.SBP, r.DBP, r.Temp, r.SpO2, r.GCS}
+}
+
 // Copy returns a copy of r.
 func (r Ranges) Copy() Ranges {
 	return Ranges{
@@ -274,36 +436,10 @@ func (r *Ranges) ScaleHalfWidths(factor float64) {
 }
 
 // CriticalBounds returns recommended absolute bounds [min, max] for vital i
-// for use in validation (e.g. flag out-of-range inputs). Values are
-// conservative; i must be 0..6. Returns (0, 0) if i out of range.
-//
-//	| Vital | Min  | Max   |
-//	| HR    | 20   | 300   |
-//	| RR    | 0    | 60    |
-//	| SBP   | 40   | 300   |
-//	| DBP   | 20   | 200   |
-//	| Temp  | 30   | 45    |
-//	| SpO2  | 0    | 100   |
-//	| GCS   | 3    | 15    |
+// for use in validation (e.g. flag out-of-range inputs): DefaultBounds().At(i).
+// Returns (0, 0) if i out of range.
 func CriticalBounds(i int) (min, max float64) {
-	switch i {
-	case VitalHR:
-		return 20, 300
-	case VitalRR:
-		return 0, 60
-	case VitalSBP:
-		return 40, 300
-	case VitalDBP:
-		return 20, 200
-	case VitalTemp:
-		return 30, 45
-	case VitalSpO2:
-		return 0, 100
-	case VitalGCS:
-		return 3, 15
-	default:
-		return 0, 0
-	}
+	return DefaultBounds().At(i)
 }
 
 // IsWithinCriticalBounds returns true if value is within CriticalBounds(i).
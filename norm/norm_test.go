@@ -1,6 +1,12 @@
 package norm
 
-import "testing"
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
 
 func TestDefaultRanges(t *testing.T) {
 	r := DefaultRanges()
@@ -85,3 +91,316 @@ func TestRanges_WeightedDeviationSum(t *testing.T) {
 		t.Errorf("weight sum should be positive, got %v", wSum)
 	}
 }
+
+func TestPediatricRangesForAge(t *testing.T) {
+	if r := PediatricRangesForAge(0.05); r != NeonatalRanges() {
+		t.Errorf("PediatricRangesForAge(0.05) = %+v, want NeonatalRanges", r)
+	}
+	if r := PediatricRangesForAge(0.5); r != InfantRanges() {
+		t.Error("age 0.5 should select InfantRanges")
+	}
+	if r := PediatricRangesForAge(6); r != PediatricRanges() {
+		t.Error("age 6 should select PediatricRanges")
+	}
+	if r := PediatricRangesForAge(15); r != AdolescentRanges() {
+		t.Error("age 15 should select AdolescentRanges")
+	}
+	for _, r := range []Ranges{InfantRanges(), AdolescentRanges(), GeriatricRanges(), ObstetricRanges()} {
+		if !r.Valid() {
+			t.Errorf("preset ranges invalid: %+v", r)
+		}
+	}
+	if a := DefaultRanges().Array(); a[VitalSBP] != [2]float64{120, 40} {
+		t.Errorf("Array()[SBP] = %v", a[VitalSBP])
+	}
+}
+
+func TestObstetricRangesForWeeks(t *testing.T) {
+	if Trimester(0) != 0 || Trimester(10) != 1 || Trimester(20) != 2 || Trimester(36) != 3 {
+		t.Error("Trimester boundaries")
+	}
+	t1, t2, t3 := ObstetricRangesForWeeks(10), ObstetricRangesForWeeks(20), ObstetricRangesForWeeks(36)
+	if !(t1.HR[0] < t2.HR[0] && t2.HR[0] < t3.HR[0]) {
+		t.Errorf("HR midpoint should rise by trimester: %v %v %v", t1.HR[0], t2.HR[0], t3.HR[0])
+	}
+	if !(t2.SBP[0] < t1.SBP[0] && t2.SBP[0] < t3.SBP[0]) {
+		t.Errorf("SBP midpoint should be lowest in trimester 2")
+	}
+	if ObstetricRangesForWeeks(0) != ObstetricRanges() {
+		t.Error("unknown gestation should return ObstetricRanges")
+	}
+}
+
+func TestAgeBandedRanges(t *testing.T) {
+	b := AgeBandedRanges()
+	if !b.Valid() {
+		t.Fatal("AgeBandedRanges should be valid")
+	}
+	infant, teen := b.Lookup(2, false), b.Lookup(14*12, false)
+	if !(infant.HR[0] > teen.HR[0] && infant.RR[0] > teen.RR[0] && infant.SBP[0] < teen.SBP[0]) {
+		t.Errorf("2 months %+v vs 14 years %+v", infant, teen)
+	}
+	if teen != AdolescentRanges() || b.Lookup(30*12, false) != DefaultRanges() || b.Lookup(80*12, true) != GeriatricRanges() {
+		t.Error("adolescent, adult and 65+ bands")
+	}
+	if b.Band(-1) != -1 || b.Lookup(-1, true) != DefaultRanges() {
+		t.Error("unknown age should return DefaultRanges")
+	}
+	if b.Lookup(0.5, false) != NeonatalRanges() || b.Lookup(2, false) != InfantRanges() {
+		t.Error("neonatal and infant bands should use the sourced presets")
+	}
+	if b.Band(3) != 1 || b.Band(1e6) != len(b)-1 {
+		t.Errorf("Band(3) = %d, Band(1e6) = %d", b.Band(3), b.Band(1e6))
+	}
+	// The 1-year boundary blends over 6.5..17.5 months.
+	if b.Lookup(6, true) != b[1].Ranges || b.Lookup(18, true) != b[2].Ranges {
+		t.Error("interpolation outside the blend window")
+	}
+	if r := b.Lookup(12, true); r.HR[0] != 125 || r.SBP[0] != 91.5 {
+		t.Errorf("interpolated at boundary: HR %v SBP %v", r.HR[0], r.SBP[0])
+	}
+	// HR rises from birth into infancy, then falls steadily.
+	prev := b.Lookup(0, true).HR[0]
+	for m := 0.25; m < 20*12; m += 0.25 {
+		hr := b.Lookup(m, true).HR[0]
+		if math.Abs(hr-prev) > 3 || (m > 2 && hr > prev) {
+			t.Fatalf("HR midpoint at %v months: %v after %v", m, hr, prev)
+		}
+		prev = hr
+	}
+	if b.Lookup(64*12, true) != DefaultRanges() {
+		t.Error("no blending into the open-ended 65+ band")
+	}
+	if (AgeBands{}).Valid() || (AgeBands{{MinMonths: 0, MaxMonths: 3}, {MinMonths: 4, MaxMonths: 6}}).Valid() {
+		t.Error("empty or gapped bands should be invalid")
+	}
+}
+
+func TestAsymmetricRanges(t *testing.T) {
+	r := DefaultAsymmetricRanges()
+	if !r.Valid() {
+		t.Fatal("DefaultAsymmetricRanges should be valid")
+	}
+	if d := r.Deviation(VitalSBP, 85); d != 1 {
+		t.Errorf("SBP 85: %v", d)
+	}
+	if d := r.Deviation(VitalSBP, 150); d != 0.5 {
+		t.Errorf("SBP 150: %v", d)
+	}
+	if r.Deviation(VitalSpO2, 100) != 0 || r.Deviation(VitalSpO2, 94) != 0.5 {
+		t.Error("SpO2 should deviate only below the midpoint")
+	}
+	if DeviationAsym(70, 80, 0, 10) != 0 || DeviationAsym(80, 80, 5, 10) != 0 {
+		t.Error("DeviationAsym: zero low half-width or value at mid")
+	}
+	s := DefaultRanges()
+	a := s.Asymmetric()
+	for i := 0; i < NumVitals; i++ {
+		m, hw := s.At(i)
+		for _, x := range []float64{m - hw/2, m, m + hw/3, m + 2*hw} {
+			if a.Deviation(i, x) != Deviation(x, m, hw) {
+				t.Errorf("vital %d at %v: symmetric deviation differs", i, x)
+			}
+		}
+	}
+	if m, lo, hi := r.At(NumVitals); m != 0 || lo != 0 || hi != 0 {
+		t.Error("At out of range")
+	}
+	r.Set(VitalHR, 70, -1, 40)
+	if r.HR != [3]float64{70, -1, 40} || r.Valid() {
+		t.Error("Set or Valid with a negative half-width")
+	}
+}
+
+func TestRangesJSON(t *testing.T) {
+	data, err := json.Marshal(DefaultRanges())
+	if err != nil || !strings.HasPrefix(string(data), `{"hr":[80,40],"rr":[16,10]`) {
+		t.Fatalf("marshal: %s %v", data, err)
+	}
+	var r Ranges
+	if err := json.Unmarshal(data, &r); err != nil || r != DefaultRanges() {
+		t.Errorf("round trip: %+v %v", r, err)
+	}
+	a := DefaultAsymmetricRanges()
+	data, _ = json.Marshal(a)
+	var back AsymmetricRanges
+	if err := json.Unmarshal(data, &back); err != nil || back != a {
+		t.Errorf("asymmetric round trip: %+v %v", back, err)
+	}
+	for _, bad := range []string{
+		`{"hr":[80,40]}`,
+		`{"hr":[80,40,1],"rr":[16,10],"sbp":[120,40],"dbp":[80,30],"temp":[37,2],"spo2":[98,8],"gcs":[15,6]}`,
+		`{"hr":[80,40],"rr":[16,10],"sbp":[120,40],"dbp":[80,30],"temp":[37,2],"spo2":[98,8],"gcs":[15,6],"map":[90,20]}`,
+		`{"hr":[80,-40],"rr":[16,10],"sbp":[120,40],"dbp":[80,30],"temp":[37,2],"spo2":[98,8],"gcs":[15,6]}`,
+	} {
+		if err := json.Unmarshal([]byte(bad), &r); err == nil || !strings.HasPrefix(err.Error(), "norm: ranges: ") {
+			t.Errorf("%s: err = %v", bad, err)
+		}
+	}
+}
+
+func TestFitRanges(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	samples := make([][NumVitals]float64, 2000)
+	for k := range samples {
+		s := &samples[k]
+		s[VitalHR] = 85 + 12*rng.NormFloat64()
+		s[VitalSBP] = 125 + 15*rng.NormFloat64()
+		s[VitalSpO2] = 99 - 2*math.Abs(rng.NormFloat64())
+		s[VitalGCS] = 15
+		if k < 10 {
+			s[VitalRR] = 18
+		}
+	}
+	samples[0][VitalHR] = 400 // outside DefaultBounds
+	samples[1][VitalHR] = 240 // beyond the Tukey fence
+	r, fit, err := FitRanges(samples, FitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hr := fit.Vitals[VitalHR]
+	if !hr.Fitted || hr.Outliers != 2 || hr.N != 1998 {
+		t.Errorf("HR fit: %+v", hr)
+	}
+	if math.Abs(r.HR[0]-85) > 1 || math.Abs(r.HR[1]-2*1.96*12) > 3 {
+		t.Errorf("HR range %v, want about [85, 47]", r.HR)
+	}
+	if math.Abs(r.SBP[0]-125) > 1.5 || math.Abs(r.SBP[1]-2*1.96*15) > 4 {
+		t.Errorf("SBP range %v", r.SBP)
+	}
+	base := DefaultRanges()
+	if fit.Vitals[VitalRR].Fitted || r.RR != base.RR || fit.Vitals[VitalGCS].Fitted || r.GCS != base.GCS || r.Temp != base.Temp {
+		t.Error("vitals with too few values or no spread should keep the base range")
+	}
+	a := fit.Asymmetric(r)
+	if m, lo, hi := a.At(VitalSpO2); m != fit.Vitals[VitalSpO2].Median || !(lo > hi) {
+		t.Errorf("SpO2 should be skewed low: %v %v %v", m, lo, hi)
+	}
+	if _, _, err := FitRanges(nil, FitOptions{}); err == nil {
+		t.Error("no samples accepted")
+	}
+	if _, _, err := FitRanges(samples, FitOptions{Coverage: 1.5}); err == nil {
+		t.Error("coverage 1.5 accepted")
+	}
+	if r1, _, _ := FitRanges(samples, FitOptions{Saturation: 1}); math.Abs(r1.HR[1]-1.96*12) > 1.5 {
+		t.Errorf("HR range with saturation 1: %v", r1.HR)
+	}
+	if _, _, err := FitRanges(samples, FitOptions{Saturation: -1}); err == nil {
+		t.Error("negative saturation accepted")
+	}
+}
+
+func TestFitRanges_MatchesDefaultScale(t *testing.T) {
+	// A population centred on DefaultRanges with the normal adult spread
+	// (HR 60-100, SBP 100-140) fits ranges close to DefaultRanges, so
+	// fitted and default deviations agree.
+	rng := rand.New(rand.NewSource(2))
+	samples := make([][NumVitals]float64, 5000)
+	for k := range samples {
+		samples[k][VitalHR] = 80 + 10.2*rng.NormFloat64()
+		samples[k][VitalSBP] = 120 + 10.2*rng.NormFloat64()
+	}
+	r, _, err := FitRanges(samples, FitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := DefaultRanges()
+	for _, hr := range []float64{95, 110, 130} {
+		if got, want := r.DeviationHR(hr), d.DeviationHR(hr); math.Abs(got-want) > 0.05 {
+			t.Errorf("HR %v: fitted deviation %v, default %v", hr, got, want)
+		}
+	}
+	for _, sbp := range []float64{100, 85} {
+		if got, want := r.DeviationSBP(sbp), d.DeviationSBP(sbp); math.Abs(got-want) > 0.05 {
+			t.Errorf("SBP %v: fitted deviation %v, default %v", sbp, got, want)
+		}
+	}
+}
+
+func TestNeonatalRanges(t *testing.T) {
+	n, i := NeonatalRanges(), InfantRanges()
+	if !n.Valid() || !i.Valid() {
+		t.Fatal("presets should be valid")
+	}
+	if !(n.RR[0] > i.RR[0] && n.SBP[0] < i.SBP[0] && n.Temp[1] < i.Temp[1]) {
+		t.Errorf("neonatal %+v vs infant %+v", n, i)
+	}
+	for _, name := range []string{"NeonatalRanges", "InfantRanges"} {
+		if c, ok := CitationFor(name); !ok || len(c.Sources) == 0 || c.Population == "" {
+			t.Errorf("%s: citation %+v", name, c)
+		}
+	}
+	if _, ok := CitationFor("PediatricRanges"); ok {
+		t.Error("illustrative preset should have no citation")
+	}
+}
+
+func TestFrailtyAdjust(t *testing.T) {
+	g := GeriatricRanges()
+	if FrailtyAdjust(g, 0) != g || FrailtyAdjust(g, math.NaN()) != g {
+		t.Error("frailty 0 should leave ranges unchanged")
+	}
+	f := FrailtyAdjust(g, 1)
+	if f.SBP[0] != g.SBP[0]+FrailSBPShift || f.SBP[1] != g.SBP[1]*(1-FrailSBPNarrowing) || f.RR != g.RR {
+		t.Errorf("frailty 1: %+v", f)
+	}
+	if f.DeviationSBP(100) <= g.DeviationSBP(100) {
+		t.Error("hypotension should deviate more when frail")
+	}
+	if FrailtyAdjust(g, 2) != f {
+		t.Error("frailty should clamp at 1")
+	}
+	if FrailtyFromCFS(3) != 0 || FrailtyFromCFS(0) != 0 || FrailtyFromCFS(7) != 0.6 || FrailtyFromCFS(9) != 1 {
+		t.Error("FrailtyFromCFS")
+	}
+}
+
+func TestAltitudeAdjusted(t *testing.T) {
+	if AltitudeSpO2Drop(0) != 0 || AltitudeSpO2Drop(-50) != 0 || AltitudeSpO2Drop(1250) != 1.5 || AltitudeSpO2Drop(9000) != 13 {
+		t.Error("AltitudeSpO2Drop interpolation")
+	}
+	d := DefaultRanges()
+	if AltitudeAdjusted(d, 0) != d.Asymmetric() {
+		t.Error("sea level should leave ranges unchanged")
+	}
+	a := AltitudeAdjusted(d, 2500)
+	if a.SpO2 != [3]float64{93.5, 10.25, 0} || a.HR != d.Asymmetric().HR {
+		t.Errorf("2500 m: %v", a.SpO2)
+	}
+	alt := func(x float64) float64 { return a.Deviation(VitalSpO2, x) }
+	if !(alt(93) < d.DeviationSpO2(93)/5) {
+		t.Errorf("93%% at 2500 m: %v vs %v at sea level", alt(93), d.DeviationSpO2(93))
+	}
+	// At or above the sea-level midpoint, altitude never adds deviation.
+	for x := d.SpO2[0]; x <= 100; x++ {
+		if alt(x) != 0 || alt(x) > d.DeviationSpO2(x) {
+			t.Errorf("SpO2 %v at 2500 m deviates %v (sea level %v)", x, alt(x), d.DeviationSpO2(x))
+		}
+	}
+}
+
+func TestBoundsSet(t *testing.T) {
+	b := DefaultBounds()
+	if !b.Valid() || !b.Within(VitalTemp, 38) || b.Within(VitalHR, 10) || b.Clamp(VitalSpO2, 104) != 100 {
+		t.Error("DefaultBounds")
+	}
+	for i := 0; i < NumVitals; i++ {
+		lo, hi := CriticalBounds(i)
+		if blo, bhi := b.At(i); blo != lo || bhi != hi {
+			t.Errorf("vital %d: CriticalBounds and DefaultBounds disagree", i)
+		}
+	}
+	b.Set(VitalHR, 30, 250)
+	samples := make([][NumVitals]float64, 100)
+	for k := range samples {
+		samples[k][VitalHR] = 60 + float64(k%40)
+	}
+	samples[0][VitalHR] = 25
+	if _, fit, err := FitRanges(samples, FitOptions{Bounds: b}); err != nil || fit.Vitals[VitalHR].Outliers != 1 {
+		t.Errorf("FitRanges with site bounds: %+v %v", fit.Vitals[VitalHR], err)
+	}
+	b.Set(VitalRR, 60, 10)
+	if b.Valid() {
+		t.Error("min > max should be invalid")
+	}
+}
@@ -0,0 +1,369 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+//
+// Package notify renders escalation alerts (level, key deviations,
+// recommended actions) into localized messages, so each site does not have
+// to write its own formatter.
+//
+// # Formats
+//
+//	| Method                        | Output                                      |
+//	|-------------------------------|---------------------------------------------|
+//	| Renderer.Text                 | Plain text (text/template)                  |
+//	| Renderer.HTML                 | HTML fragment (html/template, auto-escaped) |
+//	| Renderer.CommunicationRequest | FHIR R4 CommunicationRequest JSON           |
+//
+// Wording comes from a Catalog of Messages per locale; DefaultCatalog
+// provides en, de, fi and sv, including the built-in recommended actions
+// (Level.RecommendedActions). Actions from a site's ActionPolicy are shown
+// as written. Templates are replaceable: set
+// Renderer.TextTemplate or HTMLTemplate to your own source, which receives
+// a View.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	htmltemplate "html/template"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/olaflaitinen/triagegeist"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// Deviation is one vital's contribution to an alert.
+type Deviation struct {
+	Vital        int     // Index in VitalWeights order (0 HR … 6 GCS)
+	Value        float64 // Measured value
+	Deviation    float64 // d_i in [0, 1]
+	Contribution float64 // Share of the normalized score
+}
+
+// Alert is the payload of one escalation.
+type Alert struct {
+	ID         string // Record or encounter ID
+	Subject    string // Optional FHIR reference, e.g. "Patient/123"
+	Level      triagegeist.Level
+	Acuity     float64
+	Deviations []Deviation // Largest contribution first
+	Actions    []string
+	Time       time.Time
+}
+
+// AlertFrom builds an Alert from r, taking the n vitals with the largest
+// contribution (from r.Breakdown, or eng.Breakdown(r)) and the actions
+// recommended by eng for r.Level. Vitals with zero contribution are left out.
+func AlertFrom(r triagegeist.EvaluateResult, eng *triagegeist.Engine, n int) Alert {
+	a := Alert{
+		ID:      r.ID,
+		Level:   r.Level,
+		Acuity:  r.Acuity,
+		Actions: eng.RecommendedActions(r.Level),
+		Time:    r.EvaluatedAt(),
+	}
+	var b triagegeist.Breakdown
+	if r.Breakdown != nil {
+		b = *r.Breakdown
+	} else {
+		b = eng.Breakdown(r)
+	}
+	vals := score.VitalsToValues(r.Vitals)
+	for i := range b.Contribution {
+		if b.Present[i] && b.Contribution[i] > 0 {
+			a.Deviations = append(a.Deviations, Deviation{Vital: i, Value: vals[i], Deviation: b.Deviation[i], Contribution: b.Contribution[i]})
+		}
+	}
+	sort.SliceStable(a.Deviations, func(i, j int) bool { return a.Deviations[i].Contribution > a.Deviations[j].Contribution })
+	if n >= 0 && len(a.Deviations) > n {
+		a.Deviations = a.Deviations[:n]
+	}
+	return a
+}
+
+// Messages holds the localized wording of one locale.
+type Messages struct {
+	Title      string    // e.g. "Triage escalation"
+	Levels     [6]string // Level names, index 1..5
+	Vitals     [7]string // Vital names in VitalWeights order
+	Acuity     string
+	Deviations string // Heading of the deviation list
+	Actions    string // Heading of the action list
+	Record     string // Label for the record ID
+	// LevelActions translates the built-in Level.RecommendedActions, index
+	// 1..5, item for item. Empty keeps them as given.
+	LevelActions [6][]string
+}
+
+// Catalog maps locale tags (e.g. "en", "de") to Messages.
+type Catalog map[string]Messages
+
+// DefaultCatalog returns English, German, Finnish and Swedish wording.
+func DefaultCatalog() Catalog {
+	return Catalog{
+		"en": {
+			Title:      "Triage escalation",
+			Levels:     [6]string{"", "Resuscitation", "Emergent", "Urgent", "Less urgent", "Non-urgent"},
+			Vitals:     [7]string{"Heart rate", "Respiratory rate", "Systolic BP", "Diastolic BP", "Temperature", "SpO2", "GCS"},
+			Acuity:     "Acuity",
+			Deviations: "Key deviations",
+			Actions:    "Recommended actions",
+			Record:     "Record",
+		},
+		"de": {
+			Title:      "Triage-Eskalation",
+			Levels:     [6]string{"", "Reanimation", "Notfall", "Dringend", "Weniger dringend", "Nicht dringend"},
+			Vitals:     [7]string{"Herzfrequenz", "Atemfrequenz", "Systolischer RR", "Diastolischer RR", "Temperatur", "SpO2", "GCS"},
+			Acuity:     "Dringlichkeit",
+			Deviations: "Wichtigste Abweichungen",
+			Actions:    "Empfohlene Maßnahmen",
+			Record:     "Datensatz",
+			LevelActions: [6][]string{
+				1: {"Sofortige Beurteilung", "Lebensrettende Maßnahmen nach Bedarf", "Kontinuierliche Überwachung"},
+				2: {"Rasche Beurteilung", "Stabilisierung", "Neubeurteilung innerhalb von 15 min"},
+				3: {"Beurteilung innerhalb von 60 min", "Routineüberwachung", "Neubeurteilung nach Bedarf"},
+				4: {"Beurteilung innerhalb von 120 min", "Routineversorgung", "Neubeurteilung bei Zustandsänderung"},
+				5: {"Beurteilung innerhalb von 240 min", "Routineversorgung", "Fast-Track nutzen, falls verfügbar"},
+			},
+		},
+		"fi": {
+			Title:      "Triage-hälytys",
+			Levels:     [6]string{"", "Elvytys", "Hätätilanne", "Kiireellinen", "Vähemmän kiireellinen", "Ei kiireellinen"},
+			Vitals:     [7]string{"Syke", "Hengitystaajuus", "Systolinen RR", "Diastolinen RR", "Lämpö", "SpO2", "GCS"},
+			Acuity:     "Kiireellisyys",
+			Deviations: "Keskeiset poikkeamat",
+			Actions:    "Suositellut toimenpiteet",
+			Record:     "Tietue",
+			LevelActions: [6][]string{
+				1: {"Välitön arvio", "Henkeä pelastavat toimenpiteet tarpeen mukaan", "Jatkuva monitorointi"},
+				2: {"Nopea arvio", "Stabilointi", "Uusi arvio 15 min kuluessa"},
+				3: {"Arvio 60 min kuluessa", "Rutiiniseuranta", "Uusi arvio tarvittaessa"},
+				4: {"Arvio 120 min kuluessa", "Tavanomainen hoito", "Uusi arvio, jos tila muuttuu"},
+				5: {"Arvio 240 min kuluessa", "Tavanomainen hoito", "Pikavastaanotto, jos käytettävissä"},
+			},
+		},
+		"sv": {
+			Title:      "Triageeskalering",
+			Levels:     [6]string{"", "Återupplivning", "Akut", "Brådskande", "Mindre brådskande", "Ej brådskande"},
+			Vitals:     [7]string{"Puls", "Andningsfrekvens", "Systoliskt BT", "Diastoliskt BT", "Temperatur", "SpO2", "GCS"},
+			Acuity:     "Angelägenhetsgrad",
+			Deviations: "Viktigaste avvikelser",
+			Actions:    "Rekommenderade åtgärder",
+			Record:     "Post",
+			LevelActions: [6][]string{
+				1: {"Omedelbar bedömning", "Livräddande åtgärder vid behov", "Kontinuerlig övervakning"},
+				2: {"Snabb bedömning", "Stabilisering", "Ny bedömning inom 15 min"},
+				3: {"Bedömning inom 60 min", "Rutinövervakning", "Ny bedömning vid behov"},
+				4: {"Bedömning inom 120 min", "Rutinvård", "Ny bedömning om tillståndet ändras"},
+				5: {"Bedömning inom 240 min", "Rutinvård", "Snabbspår kan användas om tillgängligt"},
+			},
+		},
+	}
+}
+
+// Lookup returns the Messages for locale, falling back from a region tag
+// ("de-AT") to its language ("de") and then to "en". The bool is false if
+// only the English fallback (or nothing) was found.
+func (c Catalog) Lookup(locale string) (Messages, bool) {
+	if m, ok := c[locale]; ok {
+		return m, true
+	}
+	if lang, _, found := strings.Cut(locale, "-"); found {
+		if m, ok := c[lang]; ok {
+			return m, true
+		}
+	}
+	return c["en"], false
+}
+
+// View is the data passed to the templates.
+type View struct {
+	Alert
+	M          Messages
+	LevelName  string
+	Deviations []DeviationView
+}
+
+// DeviationView is a Deviation with its localized vital name.
+type DeviationView struct {
+	Deviation
+	Name string
+}
+
+// DefaultTextTemplate is the plain-text template source.
+const DefaultTextTemplate = `{{.M.Title}}: {{.LevelName}} ({{.Level.Int}})
+{{- if .ID}}
+{{.M.Record}}: {{.ID}}{{end}}
+{{.M.Acuity}}: {{printf "%.2f" .Acuity}}
+{{- if .Deviations}}
+{{.M.Deviations}}:
+{{- range .Deviations}}
+- {{.Name}}: {{.Value}} ({{printf "%.2f" .Deviation}}){{end}}{{end}}
+{{- if .Actions}}
+{{.M.Actions}}:
+{{- range .Actions}}
+- {{.}}{{end}}{{end}}
+`
+
+// DefaultHTMLTemplate is the HTML template source.
+const DefaultHTMLTemplate = `<div class="triage-alert level-{{.Level.Int}}">
+<h2>{{.M.Title}}: {{.LevelName}} ({{.Level.Int}})</h2>
+{{- if .ID}}
+<p>{{.M.Record}}: {{.ID}}</p>{{end}}
+<p>{{.M.Acuity}}: {{printf "%.2f" .Acuity}}</p>
+{{- if .Deviations}}
+<h3>{{.M.Deviations}}</h3>
+<ul>{{range .Deviations}}<li>{{.Name}}: {{.Value}} ({{printf "%.2f" .Deviation}})</li>{{end}}</ul>{{end}}
+{{- if .Actions}}
+<h3>{{.M.Actions}}</h3>
+<ul>{{range .Actions}}<li>{{.}}</li>{{end}}</ul>{{end}}
+</div>
+`
+
+// Renderer renders Alerts in one locale. The zero value renders English
+// with the default templates.
+type Renderer struct {
+	Catalog      Catalog // nil means DefaultCatalog
+	Locale       string  // empty means "en"
+	TextTemplate string  // empty means DefaultTextTemplate
+	HTMLTemplate string  // empty means DefaultHTMLTemplate
+}
+
+// NewRenderer returns a Renderer for locale with the default catalog and
+// templates.
+func NewRenderer(locale string) Renderer {
+	return Renderer{Catalog: DefaultCatalog(), Locale: locale}
+}
+
+// View returns the template data for a. If a's actions are the built-in
+// ones for its level, they are replaced by the locale's LevelActions.
+func (r Renderer) View(a Alert) View {
+	c := r.Catalog
+	if c == nil {
+		c = DefaultCatalog()
+	}
+	loc := r.Locale
+	if loc == "" {
+		loc = "en"
+	}
+	m, _ := c.Lookup(loc)
+	v := View{Alert: a, M: m, LevelName: a.Level.String()}
+	if a.Level.Valid() && m.Levels[a.Level] != "" {
+		v.LevelName = m.Levels[a.Level]
+	}
+	if a.Level.Valid() && builtinActions(a.Level, a.Actions) && len(m.LevelActions[a.Level]) == len(a.Actions) {
+		v.Actions = append([]string(nil), m.LevelActions[a.Level]...)
+	}
+	for _, d := range a.Deviations {
+		name := ""
+		if d.Vital >= 0 && d.Vital < 7 {
+			name = m.Vitals[d.Vital]
+		}
+		v.Deviations = append(v.Deviations, DeviationView{Deviation: d, Name: name})
+	}
+	return v
+}
+
+// builtinActions reports whether actions are Level.RecommendedActions of l.
+func builtinActions(l triagegeist.Level, actions []string) bool {
+	b := l.RecommendedActions()
+	if len(b) != len(actions) {
+		return false
+	}
+	for i := range b {
+		if b[i] != actions[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Text renders a as plain text.
+func (r Renderer) Text(a Alert) (string, error) {
+	src := r.TextTemplate
+	if src == "" {
+		src = DefaultTextTemplate
+	}
+	t, err := texttemplate.New("text").Parse(src)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, r.View(a)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// HTML renders a as an HTML fragment. Alert fields are escaped.
+func (r Renderer) HTML(a Alert) (string, error) {
+	src := r.HTMLTemplate
+	if src == "" {
+		src = DefaultHTMLTemplate
+	}
+	t, err := htmltemplate.New("html").Parse(src)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, r.View(a)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// fhirPriority maps levels to FHIR request priority codes.
+var fhirPriority = [6]string{"routine", "stat", "asap", "urgent", "routine", "routine"}
+
+// CommunicationRequest renders a as a FHIR R4 CommunicationRequest with
+// the plain-text rendering as its payload. Level 1 maps to priority "stat",
+// level 2 to "asap", level 3 to "urgent" and levels 4-5 to "routine".
+func (r Renderer) CommunicationRequest(a Alert) ([]byte, error) {
+	text, err := r.Text(a)
+	if err != nil {
+		return nil, err
+	}
+	v := r.View(a)
+	type ref struct {
+		Reference string `json:"reference"`
+	}
+	type concept struct {
+		Text string `json:"text"`
+	}
+	type payload struct {
+		ContentString string `json:"contentString"`
+	}
+	req := struct {
+		ResourceType string    `json:"resourceType"`
+		Identifier   []any     `json:"identifier,omitempty"`
+		Status       string    `json:"status"`
+		Category     []concept `json:"category"`
+		Priority     string    `json:"priority"`
+		Subject      *ref      `json:"subject,omitempty"`
+		ReasonCode   []concept `json:"reasonCode"`
+		Payload      []payload `json:"payload"`
+		AuthoredOn   string    `json:"authoredOn,omitempty"`
+	}{
+		ResourceType: "CommunicationRequest",
+		Status:       "active",
+		Category:     []concept{{Text: v.M.Title}},
+		Priority:     "routine",
+		ReasonCode:   []concept{{Text: v.LevelName}},
+		Payload:      []payload{{ContentString: text}},
+	}
+	if a.Level.Valid() {
+		req.Priority = fhirPriority[a.Level]
+	}
+	if a.ID != "" {
+		req.Identifier = []any{map[string]string{"value": a.ID}}
+	}
+	if a.Subject != "" {
+		req.Subject = &ref{Reference: a.Subject}
+	}
+	if !a.Time.IsZero() {
+		req.AuthoredOn = a.Time.UTC().Format(time.RFC3339)
+	}
+	return json.Marshal(req)
+}
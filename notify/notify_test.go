@@ -0,0 +1,86 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package notify
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olaflaitinen/triagegeist"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+func TestRender(t *testing.T) {
+	eng := triagegeist.NewDefaultEngine()
+	r := eng.Evaluate(score.Vitals{HR: 150, RR: 34, SBP: 80, SpO2: 84, GCS: 15}, 5)
+	r.ID = "enc-<7>"
+	r.Time = time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)
+	a := AlertFrom(r, eng, 2)
+	if len(a.Deviations) != 2 || a.Deviations[0].Contribution < a.Deviations[1].Contribution || len(a.Actions) == 0 {
+		t.Fatalf("alert = %+v", a)
+	}
+
+	text, err := NewRenderer("en").Text(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(text, "Triage escalation: ") || !strings.Contains(text, "Record: enc-<7>") || !strings.Contains(text, "Recommended actions:\n- ") {
+		t.Errorf("text =\n%s", text)
+	}
+
+	de, _ := NewRenderer("de-AT").Text(a)
+	if !strings.HasPrefix(de, "Triage-Eskalation: ") || !strings.Contains(de, "Empfohlene Maßnahmen:") {
+		t.Errorf("de =\n%s", de)
+	}
+	for _, act := range a.Actions {
+		if strings.Contains(de, act) {
+			t.Errorf("built-in action %q not localized:\n%s", act, de)
+		}
+	}
+	for loc, m := range DefaultCatalog() {
+		for _, l := range triagegeist.AllLevels() {
+			if n := len(m.LevelActions[l]); n != 0 && n != len(l.RecommendedActions()) {
+				t.Errorf("%s: %d actions for %v", loc, n, l)
+			}
+		}
+	}
+	site := a
+	site.Actions = []string{"Page the trauma team"}
+	if v := NewRenderer("sv").View(site); len(v.Actions) != 1 || v.Actions[0] != "Page the trauma team" {
+		t.Errorf("site actions should be shown as written: %v", v.Actions)
+	}
+
+	html, err := NewRenderer("fi").HTML(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, "enc-&lt;7&gt;") || !strings.Contains(html, "<h3>Keskeiset poikkeamat</h3>") {
+		t.Errorf("html =\n%s", html)
+	}
+
+	a.Subject = "Patient/42"
+	b, err := Renderer{}.CommunicationRequest(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fhir map[string]any
+	if err := json.Unmarshal(b, &fhir); err != nil {
+		t.Fatal(err)
+	}
+	if fhir["resourceType"] != "CommunicationRequest" || fhir["authoredOn"] != "2026-03-01T08:30:00Z" || fhir["subject"].(map[string]any)["reference"] != "Patient/42" {
+		t.Errorf("fhir = %s", b)
+	}
+	if want := fhirPriority[a.Level]; fhir["priority"] != want {
+		t.Errorf("priority = %v, want %v", fhir["priority"], want)
+	}
+
+	if _, err := (Renderer{TextTemplate: "{{.Nope"}).Text(a); err == nil {
+		t.Error("bad template accepted")
+	}
+	if m, ok := DefaultCatalog().Lookup("xx"); ok || m.Title != "Triage escalation" {
+		t.Error("unknown locale should fall back to English")
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+//
+// Package ops holds small operational consumers of the triage level, such
+// as bed and zone placement.
+//
+// # Zone assignment
+//
+// AssignZone walks an ordered list of ZoneRules and returns the first zone
+// whose level range and complaint filter match. DefaultZoneRules encodes a
+// common four-zone layout; sites replace it with their own capability
+// matrix.
+//
+//	| Zone       | Levels | Complaints                                   |
+//	|------------|--------|----------------------------------------------|
+//	| resus      | 1      | any                                          |
+//	| majors     | 2-3    | any                                          |
+//	| fast-track | 4-5    | minor injury, wound, rash, eye, ENT          |
+//	| minors     | 4-5    | any except chest pain, abdominal pain        |
+//	| majors     | 4-5    | any (fallback)                               |
+package ops
+
+import (
+	"strings"
+
+	"github.com/olaflaitinen/triagegeist"
+)
+
+// Zone names used by DefaultZoneRules.
+const (
+	ZoneResus     = "resus"
+	ZoneMajors    = "majors"
+	ZoneMinors    = "minors"
+	ZoneFastTrack = "fast-track"
+)
+
+// ZoneRule is one row of a zone capability matrix. A rule matches a
+// patient whose level lies in [MostAcute, LeastAcute] and whose complaint
+// category is in Complaints (any if empty) and not in Excluded. Categories
+// compare case-insensitively.
+type ZoneRule struct {
+	Zone       string
+	MostAcute  triagegeist.Level
+	LeastAcute triagegeist.Level
+	Complaints []string
+	Excluded   []string
+}
+
+// Matches returns true if the rule accepts level and complaint.
+func (r ZoneRule) Matches(level triagegeist.Level, complaint string) bool {
+	if !level.Valid() || level < r.MostAcute || level > r.LeastAcute {
+		return false
+	}
+	if len(r.Complaints) > 0 && !containsFold(r.Complaints, complaint) {
+		return false
+	}
+	return !containsFold(r.Excluded, complaint)
+}
+
+func containsFold(list []string, s string) bool {
+	s = strings.TrimSpace(s)
+	for _, x := range list {
+		if strings.EqualFold(x, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultZoneRules returns the layout in the package table.
+func DefaultZoneRules() []ZoneRule {
+	return []ZoneRule{
+		{Zone: ZoneResus, MostAcute: triagegeist.Level1Resuscitation, LeastAcute: triagegeist.Level1Resuscitation},
+		{Zone: ZoneMajors, MostAcute: triagegeist.Level2Emergent, LeastAcute: triagegeist.Level3Urgent},
+		{Zone: ZoneFastTrack, MostAcute: triagegeist.Level4LessUrgent, LeastAcute: triagegeist.Level5NonUrgent,
+			Complaints: []string{"minor injury", "wound", "rash", "eye", "ENT"}},
+		{Zone: ZoneMinors, MostAcute: triagegeist.Level4LessUrgent, LeastAcute: triagegeist.Level5NonUrgent,
+			Excluded: []string{"chest pain", "abdominal pain"}},
+		{Zone: ZoneMajors, MostAcute: triagegeist.Level4LessUrgent, LeastAcute: triagegeist.Level5NonUrgent},
+	}
+}
+
+// AssignZone returns the zone of the first rule in rules that matches level
+// and complaintCategory. Returns ("", false) if none matches or level is
+// invalid; callers should then escalate to the nurse in charge.
+func AssignZone(level triagegeist.Level, complaintCategory string, rules []ZoneRule) (string, bool) {
+	for _, r := range rules {
+		if r.Matches(level, complaintCategory) {
+			return r.Zone, true
+		}
+	}
+	return "", false
+}
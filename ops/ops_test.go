@@ -0,0 +1,37 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package ops
+
+import (
+	"testing"
+
+	"github.com/olaflaitinen/triagegeist"
+)
+
+func TestAssignZone(t *testing.T) {
+	rules := DefaultZoneRules()
+	cases := []struct {
+		level     triagegeist.Level
+		complaint string
+		want      string
+	}{
+		{triagegeist.Level1Resuscitation, "chest pain", ZoneResus},
+		{triagegeist.Level3Urgent, "wound", ZoneMajors},
+		{triagegeist.Level5NonUrgent, "Minor Injury", ZoneFastTrack},
+		{triagegeist.Level4LessUrgent, "headache", ZoneMinors},
+		{triagegeist.Level4LessUrgent, "chest pain", ZoneMajors},
+	}
+	for _, c := range cases {
+		if got, ok := AssignZone(c.level, c.complaint, rules); !ok || got != c.want {
+			t.Errorf("AssignZone(%v, %q) = %q, %v; want %q", c.level, c.complaint, got, ok, c.want)
+		}
+	}
+	if _, ok := AssignZone(triagegeist.Level(0), "wound", rules); ok {
+		t.Error("invalid level assigned a zone")
+	}
+	custom := []ZoneRule{{Zone: "paeds", MostAcute: triagegeist.Level2Emergent, LeastAcute: triagegeist.Level5NonUrgent}}
+	if _, ok := AssignZone(triagegeist.Level1Resuscitation, "", custom); ok {
+		t.Error("level outside every rule assigned a zone")
+	}
+}
@@ -3,23 +3,196 @@
 
 package triagegeist
 
-import "math"
+import (
+	"fmt"
+	"math"
+
+	"github.com/olaflaitinen/triagegeist/internal/paramrules"
+	"github.com/olaflaitinen/triagegeist/score"
+	"github.com/olaflaitinen/triagegeist/validate"
+)
 
 // Params holds all tunable parameters for acuity scoring and level assignment.
 // Defaults are chosen for general emergency department use; override for
 // site-specific or research calibration.
 //
-//	| Field           | Type      | Valid range / note                          |
-//	|-----------------|-----------|---------------------------------------------|
-//	| VitalWeights    | [7]float64| Each in [0, 1]; order HR, RR, SBP, DBP, Temp, SpO2, GCS |
-//	| MaxResources    | int       | >= 0                                        |
-//	| ResourceWeight  | float64   | >= 0                                        |
-//	| T1, T2, T3, T4  | float64   | T1 > T2 > T3 > T4, all in (0, 1]           |
+//	| Field             | Type      | Valid range / note                          |
+//	|-------------------|-----------|---------------------------------------------|
+//	| VitalWeights      | [7]float64| Each in [0, 1]; order HR, RR, SBP, DBP, Temp, SpO2, GCS |
+//	| MaxResources      | int       | >= 0                                        |
+//	| ResourceWeight    | float64   | >= 0                                        |
+//	| T1, T2, T3, T4    | float64   | T1 > T2 > T3 > T4, all in (0, 1]           |
+//	| MAPWeight         | float64   | In [0, 1]; 0 disables derived MAP           |
+//	| ResourceScale     | enum      | Linear (default), log1p, sqrt, piecewise, exp |
+//	| ResourceRate      | float64   | 0 (default rate) or finite and > 0          |
+//	| GCSBanded         | bool      | Score GCS by band instead of linearly       |
+//	| GCSBands          | struct    | Band deviations in [0, 1], non-decreasing   |
+//	| RespiratoryWeight | float64   | In [0, 1]; 0 disables respiratory composite |
+//	| QSOFABump         | float64   | In [0, 1]; added to score if qSOFA >= 2     |
+//	| Reliability       | table     | Factors in [0, 1] per source and vital      |
+//	| GrayZone          | float64   | In [0, 0.25]; 0 disables deferral           |
+//	| Hysteresis        | float64   | In [0, 0.25]; 0 disables (Rescore only)     |
+//	| Reference         | pointer   | Nil, or a valid ReferenceDistribution       |
+//	| Calibration       | pointer   | Nil, or a valid ProbabilityCalibration      |
+//	| Asymmetric        | bool      | Per-side vital weights instead of VitalWeights |
+//	| AsymmetricWeights | struct    | Low and High weights, each in [0, 1]        |
+//	| Transform         | struct    | Deviation curve; Saturation 0 or >= 1       |
+//	| HalfWidths        | struct    | Per-side norm half-widths, each 0 or > 0    |
+//	| Directions        | [7]enum   | Side each vital deviates on (default both)  |
+//	| MissingPolicy     | enum      | See score.MissingPolicy; default ignore     |
+//	| MinPresentVitals  | int       | In [0, 7]; used by require_minimum          |
+//	| ExtendedWeights   | [5]float64| Each in [0, 1]; 0 ignores the sign          |
+//	| TrendWeights      | [7]float64| Each in [0, 1]; 0 disables the trend term   |
+//	| TrendScales       | [7]float64| Each 0 (default scale) or finite and > 0    |
+//	| DeviationCaps     | [7]float64| Each 0 (cap 1) or finite and >= 1           |
+//	| ZScores           | [7]struct | Each zero (off) or a valid score.ZNorm      |
+//	| Normalization     | enum      | Sum (default), max, softmax                 |
+//	| SoftmaxSharpness  | float64   | 0 (default β) or finite and > 0             |
+//	| MinimumData       | struct    | MinPresent in [0, 7]; zero disables the gate |
+//	| FormulaVersion    | enum      | 0 (v1) or up to LatestFormula               |
+//	| Provenance        | struct    | Audit metadata; does not affect scoring     |
 type Params struct {
 	VitalWeights   [7]float64
 	MaxResources   int
 	ResourceWeight float64
 	T1, T2, T3, T4 float64
+
+	// MAPWeight is the weight of the derived mean arterial pressure in the
+	// weighted mean that forms the vital component (see score.MAP and
+	// score.Options); it does not enter Divisor. Default 0.
+	MAPWeight float64
+
+	// ResourceScale maps resource count to [0, 1] before ResourceWeight is
+	// applied. Default score.ResourceLinear.
+	ResourceScale score.ResourceScale
+
+	// ResourceRate is the rate k of score.ResourceExp, so the first
+	// resources count for most of the resource component (see
+	// score.ScaleResourcesRate). Default 0, score.DefaultResourceRate.
+	ResourceRate float64
+
+	// GCSBanded scores GCS categorically (15, 13-14, 9-12, <=8) using
+	// GCSBands instead of the linear deviation. Default false.
+	GCSBanded bool
+	GCSBands  score.GCSBands
+
+	// RespiratoryWeight scales the respiratory-distress composite (see
+	// score.RespiratoryComposite) added to the acuity score after
+	// normalization, like QSOFABump. Default 0.
+	RespiratoryWeight float64
+
+	// QSOFABump is added to the acuity score when qSOFA >= 2 (see
+	// score.QSOFA). Default 0.
+	QSOFABump float64
+
+	// Reliability down-weights vitals by measurement source in
+	// Engine.EvaluateWithSources. Default score.DefaultReliability (wearable
+	// readings 0.7x); a source left all zero, as in the zero value, applies
+	// no down-weighting.
+	Reliability score.Reliability
+
+	// GrayZone is the half-width of the band around each threshold in which
+	// evaluations are deferred to clinician review (see
+	// Params.GrayZoneCandidates). Default 0 (off).
+	GrayZone float64
+
+	// Hysteresis is the margin by which a re-scored patient's score must
+	// fall below a threshold before Rescore steps the level down (see
+	// Params.LevelWithHysteresis); escalation is never delayed. Default 0
+	// (off).
+	Hysteresis float64
+
+	// Reference is the site's reference score distribution (see
+	// calibrate.FitReferenceDistribution), stored with the parameters it was
+	// fitted under. When set, evaluations report a percentile rank. Treat it
+	// as immutable once assigned. Default nil.
+	Reference *ReferenceDistribution
+
+	// Calibration maps the acuity score to the probability of a high-acuity
+	// outcome (see calibrate.FitPlatt and calibrate.FitIsotonic), stored with
+	// the parameters it was fitted under. When set, evaluations report the
+	// calibrated probability. Treat it as immutable once assigned. Default
+	// nil.
+	Calibration *ProbabilityCalibration
+
+	// Asymmetric weights each vital by AsymmetricWeights.Low when it is
+	// below its norm midpoint and by AsymmetricWeights.High otherwise,
+	// instead of by VitalWeights (see score.AsymmetricWeights). VitalWeights
+	// still form the divisor. Default false; SplitWeights enables it with
+	// both sides equal to VitalWeights, which scores identically.
+	Asymmetric        bool
+	AsymmetricWeights score.AsymmetricWeights
+
+	// Transform maps each vital's distance from its norm, in half-widths,
+	// to its deviation (see score.DeviationTransform): for example a power
+	// curve saturating at two half-widths makes a vital just outside its
+	// range count for little. Default the zero value, linear saturating at
+	// the half-width.
+	Transform score.DeviationTransform
+
+	// HalfWidths gives vitals separate lower and upper half-widths (see
+	// score.HalfWidths), e.g. so a fall in SpO2 counts quickly and a rise
+	// hardly at all. Zero entries keep the symmetric norm. Default the zero
+	// value.
+	HalfWidths score.HalfWidths
+
+	// Directions makes selected vitals deviate on one side of their norm
+	// only (see score.Direction), e.g. score.DirectionLow for SpO2 so a
+	// saturation above the midpoint is not penalized. Default both sides
+	// for every vital.
+	Directions [7]score.Direction
+
+	// MissingPolicy selects how absent vitals count in the vital component
+	// (see score.MissingPolicy); MinPresentVitals is the minimum for
+	// score.MissingRequireMinimum. Default score.MissingIgnore, the mean
+	// over present vitals.
+	MissingPolicy    score.MissingPolicy
+	MinPresentVitals int
+
+	// ExtendedWeights weights the extended signs (glucose, lactate, EtCO2,
+	// capillary refill, pain; see score.ExtendedVitals) in
+	// Engine.EvaluateExtended. Default all 0, which ignores them;
+	// score.DefaultExtendedWeights suggests values.
+	ExtendedWeights [score.NumExtended]float64
+
+	// TrendWeights and TrendScales weight each vital's rate of
+	// deterioration since the previous snapshot (see score.TrendComponent)
+	// in EvaluateTrend and in Rescore. The weighted trend deviations are
+	// added to the score. Default all 0, which disables the trend term; a
+	// TrendScales entry of 0 uses score.DefaultTrendScales.
+	TrendWeights [7]float64
+	TrendScales  [7]float64
+
+	// DeviationCaps lets each vital's deviation rise past 1 beyond the
+	// transform's saturation, up to the given cap (see score.Options.Caps),
+	// so that HR 190 outscores HR 125. Default all 0, the hard cap of 1.
+	DeviationCaps [7]float64
+
+	// ZScores scores each vital with a non-zero entry as a z-score against
+	// the site's population mean and SD (see score.ZNorm and
+	// calibrate.FitZNorms) instead of against the package norm. Default all
+	// zero, which keeps the norms.
+	ZScores [7]score.ZNorm
+
+	// Normalization selects how the vital deviations combine into the
+	// vital component (see score.Normalization), and SoftmaxSharpness is β
+	// of score.NormalizeSoftmax. Default score.NormalizeSum, the weighted
+	// mean; 0 sharpness uses score.DefaultSoftmaxSharpness.
+	Normalization    score.Normalization
+	SoftmaxSharpness float64
+
+	// MinimumData is the data an evaluation needs before it is scored (see
+	// DataRequirement and EvaluateResult.Insufficient). Default zero, which
+	// scores any input; DefaultDataRequirement suggests a value.
+	MinimumData DataRequirement
+
+	// FormulaVersion selects the acuity formula (see FormulaVersion).
+	// Default 0, which is FormulaV1; results record the version used.
+	FormulaVersion FormulaVersion
+
+	// Provenance names and versions this parameter set for audits. It is
+	// ignored by Equal and Hash.
+	Provenance Provenance
 }
 
 // DefaultParams returns parameters tuned for a typical five-level ED triage.
@@ -35,6 +208,8 @@ func DefaultParams() Params {
 		T2:             0.60,
 		T3:             0.35,
 		T4:             0.15,
+		GCSBands:       score.DefaultGCSBands(),
+		Reliability:    score.DefaultReliability(),
 	}
 }
 
@@ -54,6 +229,32 @@ func PresetLenient() Params {
 	return p
 }
 
+// PresetGeriatric returns parameters for older adults (65 years and over):
+// lower thresholds, since blunted physiological responses make the same
+// score more concerning, and more weight on HR and GCS. Use with
+// norm.GeriatricRanges (higher SBP midpoint, narrower HR and Temp bands),
+// as the geriatric profile of DefaultProfileSelector does.
+func PresetGeriatric() Params {
+	p := DefaultParams()
+	p.VitalWeights = [7]float64{0.20, 0.20, 0.16, 0.08, 0.08, 0.16, 0.12}
+	p.T1, p.T2, p.T3, p.T4 = 0.78, 0.52, 0.28, 0.11
+	return p
+}
+
+// PresetPediatric returns parameters for children under 18 years: more
+// weight on RR and SpO2, since respiratory illness dominates paediatric
+// acuity, and on Temp; less on blood pressure, since hypotension is a late
+// sign in children; and lower thresholds, since children compensate until
+// they decompensate abruptly. Use with the age-banded norm.InfantRanges,
+// norm.PediatricRanges or norm.AdolescentRanges, as the pediatric profiles
+// of DefaultProfileSelector do.
+func PresetPediatric() Params {
+	p := DefaultParams()
+	p.VitalWeights = [7]float64{0.20, 0.26, 0.08, 0.04, 0.12, 0.20, 0.10}
+	p.T1, p.T2, p.T3, p.T4 = 0.80, 0.55, 0.32, 0.13
+	return p
+}
+
 // PresetResearch returns parameters with equal level widths (0.2 each) for
 // balanced research cohorts.
 func PresetResearch() Params {
@@ -67,20 +268,199 @@ func PresetResearch() Params {
 		T2:             0.60,
 		T3:             0.40,
 		T4:             0.20,
+		GCSBands:       score.DefaultGCSBands(),
+		Reliability:    score.DefaultReliability(),
 	}
 }
 
-// Validate returns true if all fields are within admissible ranges.
+// Validate returns true if all fields are within admissible ranges, that
+// is, if ValidateDetailed reports no violation.
 func (p Params) Validate() bool {
-	if p.MaxResources < 0 || p.ResourceWeight < 0 {
-		return false
+	return len(p.ValidateDetailed()) == 0
+}
+
+// ParamError describes one constraint a Params field violates, e.g.
+// "T2 (0.7) must be less than T1 (0.65)". It is defined in the validate
+// package so validate.Params can group violations by field.
+type ParamError = validate.ParamError
+
+// ValidateDetailed returns one ParamError per violated constraint, in field
+// order, or nil if p is valid. It is the single rule list behind Validate.
+func (p Params) ValidateDetailed() []error {
+	var errs []error
+	add := func(field string, v float64, constraint string) {
+		errs = append(errs, ParamError{Field: field, Value: v, Constraint: constraint})
 	}
-	for _, w := range p.VitalWeights {
-		if w < 0 || w > 1 {
-			return false
+	unit := func(field string, v float64) {
+		if !(v >= 0 && v <= 1) {
+			add(field, v, "must be in [0, 1]")
+		}
+	}
+	paramrules.Check(p.core(), add)
+	if p.Reference != nil && !p.Reference.Valid() {
+		add("Reference.Quantiles", float64(len(p.Reference.Quantiles)), "must hold at least two finite, non-decreasing quantiles")
+	}
+	if p.Calibration != nil && !p.Calibration.Valid() {
+		add("Calibration", float64(len(p.Calibration.X)), "must be a valid platt or isotonic calibration")
+	}
+	tr := p.Transform
+	if !tr.Kind.Valid() {
+		add("Transform.Kind", float64(tr.Kind), "is not a defined score.TransformKind")
+	}
+	if tr.Saturation != 0 && !(tr.Saturation >= 1 && !math.IsInf(tr.Saturation, 0)) {
+		add("Transform.Saturation", tr.Saturation, "must be 0 or finite and >= 1")
+	}
+	if tr.Shape != 0 && !(tr.Shape > 0 && !math.IsInf(tr.Shape, 0)) {
+		add("Transform.Shape", tr.Shape, "must be 0 or finite and > 0")
+	}
+	hw := func(field string, v float64) {
+		if v != 0 && !(v > 0 && !math.IsInf(v, 0)) {
+			add(field, v, "must be 0 or finite and > 0")
+		}
+	}
+	for i := range p.HalfWidths.Low {
+		hw(fmt.Sprintf("HalfWidths.Low[%d]", i), p.HalfWidths.Low[i])
+	}
+	for i := range p.HalfWidths.High {
+		hw(fmt.Sprintf("HalfWidths.High[%d]", i), p.HalfWidths.High[i])
+	}
+	for i, d := range p.Directions {
+		if !d.Valid() {
+			add(fmt.Sprintf("Directions[%d]", i), float64(d), "is not a defined score.Direction")
 		}
 	}
-	return p.T1 > p.T2 && p.T2 > p.T3 && p.T3 > p.T4 && p.T4 > 0 && p.T1 <= 1
+	if !p.MissingPolicy.Valid() {
+		add("MissingPolicy", float64(p.MissingPolicy), "is not a defined score.MissingPolicy")
+	}
+	if p.MinPresentVitals < 0 || p.MinPresentVitals > 7 {
+		add("MinPresentVitals", float64(p.MinPresentVitals), "must be in [0, 7]")
+	}
+	for i, w := range p.ExtendedWeights {
+		unit(fmt.Sprintf("ExtendedWeights[%d]", i), w)
+	}
+	for i := range p.TrendWeights {
+		unit(fmt.Sprintf("TrendWeights[%d]", i), p.TrendWeights[i])
+		hw(fmt.Sprintf("TrendScales[%d]", i), p.TrendScales[i])
+	}
+	for i, c := range p.DeviationCaps {
+		if c != 0 && !(c >= 1 && !math.IsInf(c, 0)) {
+			add(fmt.Sprintf("DeviationCaps[%d]", i), c, "must be 0 or finite and >= 1")
+		}
+	}
+	for i, z := range p.ZScores {
+		if z.Valid() {
+			continue
+		}
+		if math.IsNaN(z.Mean) || math.IsInf(z.Mean, 0) {
+			add(fmt.Sprintf("ZScores[%d].Mean", i), z.Mean, "must be finite")
+		}
+		if !(z.SD > 0) || math.IsInf(z.SD, 0) {
+			add(fmt.Sprintf("ZScores[%d].SD", i), z.SD, "must be finite and > 0")
+		}
+		if s := z.Saturation; s != 0 && !(s > 0 && !math.IsInf(s, 0)) {
+			add(fmt.Sprintf("ZScores[%d].Saturation", i), s, "must be 0 or finite and > 0")
+		}
+	}
+	if !p.Normalization.Valid() {
+		add("Normalization", float64(p.Normalization), "is not a defined score.Normalization")
+	}
+	if b := p.SoftmaxSharpness; b != 0 && !(b > 0 && !math.IsInf(b, 0)) {
+		add("SoftmaxSharpness", b, "must be 0 or finite and > 0")
+	}
+	if !p.MinimumData.Valid() {
+		add("MinimumData.MinPresent", float64(p.MinimumData.MinPresent), "must be in [0, 7]")
+	}
+	if !p.FormulaVersion.Valid() {
+		add("FormulaVersion", float64(p.FormulaVersion), fmt.Sprintf("must be in [0, %d]", LatestFormula))
+	}
+	return errs
+}
+
+// core returns the fields of p checked by paramrules.Check.
+func (p Params) core() paramrules.Core {
+	return paramrules.Core{
+		VitalWeights:   p.VitalWeights,
+		MaxResources:   p.MaxResources,
+		ResourceWeight: p.ResourceWeight,
+		T1:             p.T1, T2: p.T2, T3: p.T3, T4: p.T4,
+		MAPWeight:     p.MAPWeight,
+		ResourceScale: p.ResourceScale,
+		ResourceRate:  p.ResourceRate,
+		GCSBanded:     p.GCSBanded,
+		GCSBands:      p.GCSBands,
+
+		RespiratoryWeight: p.RespiratoryWeight,
+		QSOFABump:         p.QSOFABump,
+		Reliability:       p.Reliability,
+		GrayZone:          p.GrayZone,
+		Hysteresis:        p.Hysteresis,
+		Asymmetric:        p.Asymmetric,
+		AsymmetricWeights: p.AsymmetricWeights,
+	}
+}
+
+// ScoreOptions returns the score.Options corresponding to p's formula
+// extensions (MAPWeight, ResourceScale, ResourceRate, GCS banding,
+// RespiratoryWeight, QSOFABump, asymmetric weights, Transform, HalfWidths,
+// Directions, MissingPolicy, ExtendedWeights, TrendWeights, TrendScales,
+// DeviationCaps, ZScores, Normalization). The trend term also needs Options.Previous and
+// TrendHours, which are per call.
+func (p Params) ScoreOptions() score.Options {
+	o := score.Options{
+		MAPWeight:     p.MAPWeight,
+		ResourceScale: p.ResourceScale,
+		GCSBanded:     p.GCSBanded,
+		GCSBands:      p.GCSBands,
+		ResourceRate:  p.ResourceRate,
+
+		RespiratoryWeight: p.RespiratoryWeight,
+		QSOFABump:         p.QSOFABump,
+		Transform:         p.Transform,
+		Missing:           p.MissingPolicy,
+		MinPresent:        p.MinPresentVitals,
+		ExtendedWeights:   p.ExtendedWeights,
+		TrendWeights:      p.TrendWeights,
+		TrendScales:       p.TrendScales,
+		Normalization:     p.Normalization,
+		SoftmaxSharpness:  p.SoftmaxSharpness,
+	}
+	if p.Asymmetric {
+		aw := p.AsymmetricWeights
+		o.Asymmetric = &aw
+	}
+	if !p.HalfWidths.IsZero() {
+		h := p.HalfWidths
+		o.HalfWidths = &h
+	}
+	if p.Directions != ([7]score.Direction{}) {
+		d := p.Directions
+		o.Directions = &d
+	}
+	if p.DeviationCaps != ([7]float64{}) {
+		c := p.DeviationCaps
+		o.Caps = &c
+	}
+	if p.ZScores != ([7]score.ZNorm{}) {
+		z := p.ZScores
+		o.ZScores = &z
+	}
+	return o
+}
+
+// SplitWeights enables Asymmetric with both sides set to VitalWeights, so
+// the score is unchanged until Low or High is adjusted.
+func (p *Params) SplitWeights() {
+	p.Asymmetric = true
+	p.AsymmetricWeights = score.Symmetric(p.VitalWeights)
+}
+
+// WeightsFor returns the vital weights that apply to v under the package
+// norms: VitalWeights, or the per-side selection if Asymmetric is set.
+func (p Params) WeightsFor(v score.Vitals) [7]float64 {
+	if !p.Asymmetric {
+		return p.VitalWeights
+	}
+	return p.AsymmetricWeights.Select(v, score.DefaultNorms())
 }
 
 // WeightSum returns the sum of VitalWeights (for normalisation divisor).
@@ -92,7 +472,9 @@ func (p Params) WeightSum() float64 {
 	return s
 }
 
-// Divisor returns WeightSum() + ResourceWeight (denominator for score normalisation).
+// Divisor returns WeightSum() + ResourceWeight (denominator for score
+// normalisation). Extension weights such as MAPWeight take part in the
+// weighted mean of the vital component only, not in the divisor.
 func (p Params) Divisor() float64 {
 	return p.WeightSum() + p.ResourceWeight
 }
@@ -136,33 +518,91 @@ func (p Params) ThresholdForLevel(L int) (low, high float64) {
 	}
 }
 
-// ScoreToLevelContinuous returns a continuous "level" in [1, 5] by linear
-// interpolation between thresholds. For display or smoothing only; discrete
-// level should use FromScore.
+// continuousKnots are the (score, level) points of the piecewise-linear
+// mapping between acuity and continuous level, most acute first.
+func (p Params) continuousKnots() [6][2]float64 {
+	return [6][2]float64{{1, 1}, {p.T1, 1.5}, {p.T2, 2.5}, {p.T3, 3.5}, {p.T4, 4.5}, {0, 5}}
+}
+
+// ScoreToLevelContinuous maps an acuity score s to a continuous level in
+// [1, 5], linear between the knots below; s outside [0, 1] is clamped.
+//
+//	| Score | 1 | T1  | T2  | T3  | T4  | 0 |
+//	|-------|---|-----|-----|-----|-----|---|
+//	| Level | 1 | 1.5 | 2.5 | 3.5 | 4.5 | 5 |
+//
+// Each discrete level L thus covers (L-0.5, L+0.5] (1 and 5 at the ends),
+// so rounding half down gives FromScore(s, p). The mapping is strictly
+// decreasing for valid p and LevelToScore is its inverse. For display,
+// smoothing and research; triage decisions should use FromScore.
 func (p Params) ScoreToLevelContinuous(s float64) float64 {
-	if s >= p.T1 {
-		return 1.0 + (1.0-s)/(1.0-p.T1)*0.5
-	}
-	if s >= p.T2 {
-		return 1.5 + (p.T1-s)/(p.T1-p.T2)*0.5
-	}
-	if s >= p.T3 {
-		return 2.0 + (p.T2-s)/(p.T2-p.T3)*0.5
+	s = math.Min(math.Max(s, 0), 1)
+	k := p.continuousKnots()
+	for i := 0; i < len(k)-1; i++ {
+		hi, lo := k[i], k[i+1]
+		if s >= lo[0] && hi[0] > lo[0] {
+			return lo[1] + (hi[1]-lo[1])*(s-lo[0])/(hi[0]-lo[0])
+		}
 	}
-	if s >= p.T4 {
-		return 2.5 + (p.T3-s)/(p.T3-p.T4)*0.5
+	return 5
+}
+
+// LevelToScore is the inverse of ScoreToLevelContinuous: it maps a
+// continuous level l (clamped to [1, 5]) to the acuity score that
+// ScoreToLevelContinuous maps to l. For valid p, LevelToScore(
+// ScoreToLevelContinuous(s)) == s for s in [0, 1], up to rounding; if T1 is
+// 1, every l in [1, 1.5] maps to 1.
+func (p Params) LevelToScore(l float64) float64 {
+	l = math.Min(math.Max(l, 1), 5)
+	k := p.continuousKnots()
+	for i := 0; i < len(k)-1; i++ {
+		hi, lo := k[i], k[i+1]
+		if l <= lo[1] {
+			return hi[0] + (lo[0]-hi[0])*(l-hi[1])/(lo[1]-hi[1])
+		}
 	}
-	return 3.0 + (p.T4-s)/p.T4*2.0
+	return 0
 }
 
-// Equal returns true if p and q have the same field values.
+// Equal returns true if p and q have the same field values, ignoring
+// Provenance.
 func (p Params) Equal(q Params) bool {
 	if p.MaxResources != q.MaxResources || p.ResourceWeight != q.ResourceWeight {
 		return false
 	}
+	if p.MAPWeight != q.MAPWeight || p.ResourceScale != q.ResourceScale || p.ResourceRate != q.ResourceRate {
+		return false
+	}
+	if p.GCSBanded != q.GCSBanded || p.GCSBands != q.GCSBands || p.RespiratoryWeight != q.RespiratoryWeight {
+		return false
+	}
+	if p.QSOFABump != q.QSOFABump || p.Reliability != q.Reliability || p.GrayZone != q.GrayZone || p.Hysteresis != q.Hysteresis {
+		return false
+	}
 	if p.T1 != q.T1 || p.T2 != q.T2 || p.T3 != q.T3 || p.T4 != q.T4 {
 		return false
 	}
+	if (p.Reference == nil) != (q.Reference == nil) || (p.Reference != nil && !p.Reference.Equal(*q.Reference)) {
+		return false
+	}
+	if (p.Calibration == nil) != (q.Calibration == nil) || (p.Calibration != nil && !p.Calibration.Equal(*q.Calibration)) {
+		return false
+	}
+	if p.Asymmetric != q.Asymmetric || p.AsymmetricWeights != q.AsymmetricWeights {
+		return false
+	}
+	if p.Transform != q.Transform || p.HalfWidths != q.HalfWidths || p.Directions != q.Directions {
+		return false
+	}
+	if p.MissingPolicy != q.MissingPolicy || p.MinPresentVitals != q.MinPresentVitals || p.ExtendedWeights != q.ExtendedWeights {
+		return false
+	}
+	if p.TrendWeights != q.TrendWeights || p.TrendScales != q.TrendScales || p.DeviationCaps != q.DeviationCaps {
+		return false
+	}
+	if p.ZScores != q.ZScores || p.Normalization != q.Normalization || p.SoftmaxSharpness != q.SoftmaxSharpness || p.MinimumData != q.MinimumData || p.Formula() != q.Formula() {
+		return false
+	}
 	for i := range p.VitalWeights {
 		if p.VitalWeights[i] != q.VitalWeights[i] {
 			return false
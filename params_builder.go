@@ -0,0 +1,236 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// ParamsBuilder constructs Params fluently, starting from DefaultParams or
+// a given base. Setters never fail; all errors are reported by Build:
+//
+//	p, err := triagegeist.NewParamsBuilder().
+//		Weights(0.2, 0.2, 0.15, 0.1, 0.1, 0.15, 0.1).
+//		Thresholds(0.8, 0.55, 0.3, 0.12).
+//		MaxResources(5).
+//		Build()
+//
+// A ParamsBuilder is not safe for concurrent use.
+type ParamsBuilder struct {
+	p    Params
+	errs []error
+}
+
+// NewParamsBuilder returns a builder starting from DefaultParams.
+func NewParamsBuilder() *ParamsBuilder {
+	return &ParamsBuilder{p: DefaultParams()}
+}
+
+// ParamsBuilderFrom returns a builder starting from base.
+func ParamsBuilderFrom(base Params) *ParamsBuilder {
+	return &ParamsBuilder{p: base}
+}
+
+// Weights sets VitalWeights in HR, RR, SBP, DBP, Temp, SpO2, GCS order.
+// Build fails unless exactly seven weights are given.
+func (b *ParamsBuilder) Weights(w ...float64) *ParamsBuilder {
+	if len(w) != 7 {
+		b.errs = append(b.errs, fmt.Errorf("Weights: got %d values, want 7", len(w)))
+		return b
+	}
+	copy(b.p.VitalWeights[:], w)
+	return b
+}
+
+// Weight sets VitalWeights[i]. Build fails if i is not in 0..6.
+func (b *ParamsBuilder) Weight(i int, w float64) *ParamsBuilder {
+	if i < 0 || i >= 7 {
+		b.errs = append(b.errs, fmt.Errorf("Weight: index %d not in 0..6", i))
+		return b
+	}
+	b.p.VitalWeights[i] = w
+	return b
+}
+
+// Thresholds sets T1..T4.
+func (b *ParamsBuilder) Thresholds(t1, t2, t3, t4 float64) *ParamsBuilder {
+	b.p.SetThresholds(t1, t2, t3, t4)
+	return b
+}
+
+// MaxResources sets MaxResources.
+func (b *ParamsBuilder) MaxResources(n int) *ParamsBuilder {
+	b.p.MaxResources = n
+	return b
+}
+
+// ResourceWeight sets ResourceWeight.
+func (b *ParamsBuilder) ResourceWeight(w float64) *ParamsBuilder {
+	b.p.ResourceWeight = w
+	return b
+}
+
+// ResourceScale sets ResourceScale.
+func (b *ParamsBuilder) ResourceScale(s score.ResourceScale) *ParamsBuilder {
+	b.p.ResourceScale = s
+	return b
+}
+
+// ResourceRate sets ResourceRate, the rate of score.ResourceExp.
+func (b *ParamsBuilder) ResourceRate(k float64) *ParamsBuilder {
+	b.p.ResourceRate = k
+	return b
+}
+
+// MAPWeight sets MAPWeight.
+func (b *ParamsBuilder) MAPWeight(w float64) *ParamsBuilder {
+	b.p.MAPWeight = w
+	return b
+}
+
+// GCSBands enables banded GCS scoring with the given bands.
+func (b *ParamsBuilder) GCSBands(bands score.GCSBands) *ParamsBuilder {
+	b.p.GCSBanded = true
+	b.p.GCSBands = bands
+	return b
+}
+
+// RespiratoryWeight sets RespiratoryWeight.
+func (b *ParamsBuilder) RespiratoryWeight(w float64) *ParamsBuilder {
+	b.p.RespiratoryWeight = w
+	return b
+}
+
+// QSOFABump sets QSOFABump.
+func (b *ParamsBuilder) QSOFABump(bump float64) *ParamsBuilder {
+	b.p.QSOFABump = bump
+	return b
+}
+
+// Reliability sets Reliability.
+func (b *ParamsBuilder) Reliability(r score.Reliability) *ParamsBuilder {
+	b.p.Reliability = r
+	return b
+}
+
+// GrayZone sets GrayZone.
+func (b *ParamsBuilder) GrayZone(w float64) *ParamsBuilder {
+	b.p.GrayZone = w
+	return b
+}
+
+// Hysteresis sets Hysteresis.
+func (b *ParamsBuilder) Hysteresis(m float64) *ParamsBuilder {
+	b.p.Hysteresis = m
+	return b
+}
+
+// Calibration sets Calibration to a copy of c.
+func (b *ParamsBuilder) Calibration(c ProbabilityCalibration) *ParamsBuilder {
+	c.X = append([]float64(nil), c.X...)
+	c.Y = append([]float64(nil), c.Y...)
+	b.p.Calibration = &c
+	return b
+}
+
+// Reference sets Reference to a copy of d.
+func (b *ParamsBuilder) Reference(d ReferenceDistribution) *ParamsBuilder {
+	d.Quantiles = append([]float64(nil), d.Quantiles...)
+	b.p.Reference = &d
+	return b
+}
+
+// AsymmetricWeights enables per-side vital weights (see
+// Params.Asymmetric).
+func (b *ParamsBuilder) AsymmetricWeights(low, high [7]float64) *ParamsBuilder {
+	b.p.Asymmetric = true
+	b.p.AsymmetricWeights = score.AsymmetricWeights{Low: low, High: high}
+	return b
+}
+
+// DeviationTransform sets the deviation curve (see Params.Transform).
+func (b *ParamsBuilder) DeviationTransform(t score.DeviationTransform) *ParamsBuilder {
+	b.p.Transform = t
+	return b
+}
+
+// HalfWidths sets per-side norm half-widths (see Params.HalfWidths).
+func (b *ParamsBuilder) HalfWidths(low, high [7]float64) *ParamsBuilder {
+	b.p.HalfWidths = score.HalfWidths{Low: low, High: high}
+	return b
+}
+
+// Direction sets Directions[i]. Build fails if i is not in 0..6.
+func (b *ParamsBuilder) Direction(i int, d score.Direction) *ParamsBuilder {
+	if i < 0 || i >= 7 {
+		b.errs = append(b.errs, fmt.Errorf("Direction: index %d not in 0..6", i))
+		return b
+	}
+	b.p.Directions[i] = d
+	return b
+}
+
+// MissingPolicy sets MissingPolicy and MinPresentVitals.
+func (b *ParamsBuilder) MissingPolicy(m score.MissingPolicy, minPresent int) *ParamsBuilder {
+	b.p.MissingPolicy = m
+	b.p.MinPresentVitals = minPresent
+	return b
+}
+
+// ExtendedWeights sets ExtendedWeights in score.ExtendedNames order.
+func (b *ParamsBuilder) ExtendedWeights(w [score.NumExtended]float64) *ParamsBuilder {
+	b.p.ExtendedWeights = w
+	return b
+}
+
+// Trend sets TrendWeights and TrendScales.
+func (b *ParamsBuilder) Trend(weights, scales [7]float64) *ParamsBuilder {
+	b.p.TrendWeights = weights
+	b.p.TrendScales = scales
+	return b
+}
+
+// DeviationCaps sets DeviationCaps.
+func (b *ParamsBuilder) DeviationCaps(caps [7]float64) *ParamsBuilder {
+	b.p.DeviationCaps = caps
+	return b
+}
+
+// Normalization sets Normalization and SoftmaxSharpness.
+func (b *ParamsBuilder) Normalization(n score.Normalization, sharpness float64) *ParamsBuilder {
+	b.p.Normalization = n
+	b.p.SoftmaxSharpness = sharpness
+	return b
+}
+
+// FormulaVersion sets FormulaVersion.
+func (b *ParamsBuilder) FormulaVersion(f FormulaVersion) *ParamsBuilder {
+	b.p.FormulaVersion = f
+	return b
+}
+
+// MinimumData sets MinimumData.
+func (b *ParamsBuilder) MinimumData(r DataRequirement) *ParamsBuilder {
+	b.p.MinimumData = r
+	return b
+}
+
+// ZScores sets ZScores.
+func (b *ParamsBuilder) ZScores(z [7]score.ZNorm) *ParamsBuilder {
+	b.p.ZScores = z
+	return b
+}
+
+// Build returns the constructed Params, or an error joining every setter
+// error and every ValidateDetailed error.
+func (b *ParamsBuilder) Build() (Params, error) {
+	errs := append(append([]error(nil), b.errs...), b.p.ValidateDetailed()...)
+	if len(errs) > 0 {
+		return Params{}, fmt.Errorf("triagegeist: params: %w", errors.Join(errs...))
+	}
+	return b.p, nil
+}
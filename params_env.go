@@ -0,0 +1,199 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix starts every variable read by LoadParamsFromEnv.
+const EnvPrefix = "TRIAGEGEIST_"
+
+// envVars maps variable names, without EnvPrefix, to setters on Params.
+var envVars = map[string]func(p *Params, v string) error{
+	"MAX_RESOURCES": func(p *Params, v string) error {
+		n, err := strconv.Atoi(v)
+		p.MaxResources = n
+		return err
+	},
+	"RESOURCE_WEIGHT": envFloat(func(p *Params) *float64 { return &p.ResourceWeight }),
+	"RESOURCE_SCALE": func(p *Params, v string) error {
+		s, ok := parseResourceScale(v)
+		if !ok {
+			return fmt.Errorf("unknown resource scale %q", v)
+		}
+		p.ResourceScale = s
+		return nil
+	},
+	"RESOURCE_RATE": envFloat(func(p *Params) *float64 { return &p.ResourceRate }),
+	"T1":            envFloat(func(p *Params) *float64 { return &p.T1 }),
+	"T2":            envFloat(func(p *Params) *float64 { return &p.T2 }),
+	"T3":            envFloat(func(p *Params) *float64 { return &p.T3 }),
+	"T4":            envFloat(func(p *Params) *float64 { return &p.T4 }),
+	"MAP_WEIGHT":    envFloat(func(p *Params) *float64 { return &p.MAPWeight }),
+	"GCS_BANDED": func(p *Params, v string) error {
+		b, err := strconv.ParseBool(v)
+		p.GCSBanded = b
+		return err
+	},
+	"RESPIRATORY_WEIGHT": envFloat(func(p *Params) *float64 { return &p.RespiratoryWeight }),
+	"QSOFA_BUMP":         envFloat(func(p *Params) *float64 { return &p.QSOFABump }),
+	"GRAY_ZONE":          envFloat(func(p *Params) *float64 { return &p.GrayZone }),
+	"HYSTERESIS":         envFloat(func(p *Params) *float64 { return &p.Hysteresis }),
+	"MISSING_POLICY": func(p *Params, v string) error {
+		m, ok := parseMissingPolicy(v)
+		if !ok {
+			return fmt.Errorf("unknown missing policy %q", v)
+		}
+		p.MissingPolicy = m
+		return nil
+	},
+	"NORMALIZATION": func(p *Params, v string) error {
+		n, ok := parseNormalization(v)
+		if !ok {
+			return fmt.Errorf("unknown normalization %q", v)
+		}
+		p.Normalization = n
+		return nil
+	},
+	"SOFTMAX_SHARPNESS": envFloat(func(p *Params) *float64 { return &p.SoftmaxSharpness }),
+	"MIN_PRESENT_VITALS": func(p *Params, v string) error {
+		n, err := strconv.Atoi(v)
+		p.MinPresentVitals = n
+		return err
+	},
+	"FORMULA_VERSION": func(p *Params, v string) error {
+		n, err := strconv.Atoi(strings.TrimPrefix(v, "v"))
+		p.FormulaVersion = FormulaVersion(n)
+		return err
+	},
+	"PARAMS_NAME":    func(p *Params, v string) error { p.Provenance.Name = v; return nil },
+	"PARAMS_VERSION": func(p *Params, v string) error { p.Provenance.Version = v; return nil },
+}
+
+func init() {
+	for i, name := range [7]string{"HR", "RR", "SBP", "DBP", "TEMP", "SPO2", "GCS"} {
+		i := i
+		envVars["WEIGHT_"+name] = envFloat(func(p *Params) *float64 { return &p.VitalWeights[i] })
+	}
+}
+
+func envFloat(field func(p *Params) *float64) func(p *Params, v string) error {
+	return func(p *Params, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		*field(p) = f
+		return err
+	}
+}
+
+// LoadParamsFromEnv returns Params configured by TRIAGEGEIST_* environment
+// variables (see ParamsFromEnv), for container deployments configured via
+// the environment only. Unknown TRIAGEGEIST_* variables are ignored and
+// returned (see UnknownEnvVars), so the caller can warn about a misspelt
+// override while a variable meant for another component sharing the prefix
+// does not stop the service.
+func LoadParamsFromEnv() (p Params, unknown []string, err error) {
+	p, err = ParamsFromEnv(os.LookupEnv)
+	if err != nil {
+		return Params{}, nil, err
+	}
+	return p, UnknownEnvVars(), nil
+}
+
+// UnknownEnvVars returns the sorted names of the TRIAGEGEIST_* environment
+// variables that ParamsFromEnv does not read, or nil if there are none.
+func UnknownEnvVars() []string {
+	var unknown []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if key, ok := strings.CutPrefix(name, EnvPrefix); ok && key != "CONFIG" && envVars[key] == nil {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// ParamsFromEnv layers variables read through lookup over DefaultParams, or
+// over the file named by TRIAGEGEIST_CONFIG if set:
+//
+//	| Variable                          | Field                                      |
+//	|-----------------------------------|--------------------------------------------|
+//	| TRIAGEGEIST_CONFIG                | Base file (see LoadParams)                 |
+//	| TRIAGEGEIST_WEIGHT_HR ... _GCS    | VitalWeights (HR RR SBP DBP TEMP SPO2 GCS) |
+//	| TRIAGEGEIST_MAX_RESOURCES         | MaxResources                               |
+//	| TRIAGEGEIST_RESOURCE_WEIGHT       | ResourceWeight                             |
+//	| TRIAGEGEIST_RESOURCE_SCALE        | ResourceScale (by name)                    |
+//	| TRIAGEGEIST_RESOURCE_RATE         | ResourceRate                               |
+//	| TRIAGEGEIST_T1 ... TRIAGEGEIST_T4 | T1..T4                                     |
+//	| TRIAGEGEIST_MAP_WEIGHT            | MAPWeight                                  |
+//	| TRIAGEGEIST_GCS_BANDED            | GCSBanded (true/false)                     |
+//	| TRIAGEGEIST_RESPIRATORY_WEIGHT    | RespiratoryWeight                          |
+//	| TRIAGEGEIST_QSOFA_BUMP            | QSOFABump                                  |
+//	| TRIAGEGEIST_GRAY_ZONE             | GrayZone                                   |
+//	| TRIAGEGEIST_HYSTERESIS            | Hysteresis                                 |
+//	| TRIAGEGEIST_MISSING_POLICY        | MissingPolicy (by name)                    |
+//	| TRIAGEGEIST_MIN_PRESENT_VITALS    | MinPresentVitals                           |
+//	| TRIAGEGEIST_NORMALIZATION         | Normalization (by name)                    |
+//	| TRIAGEGEIST_SOFTMAX_SHARPNESS     | SoftmaxSharpness                           |
+//	| TRIAGEGEIST_FORMULA_VERSION       | FormulaVersion (1 or v1, ...)              |
+//	| TRIAGEGEIST_PARAMS_NAME           | Provenance.Name                            |
+//	| TRIAGEGEIST_PARAMS_VERSION        | Provenance.Version                         |
+//
+// For example:
+//
+//	TRIAGEGEIST_CONFIG=/etc/triagegeist/site.yaml
+//	TRIAGEGEIST_T1=0.8
+//	TRIAGEGEIST_WEIGHT_SPO2=0.2
+//
+// Empty values are ignored. Every malformed value is reported, and the
+// result must pass Validate. Overriding any scoring field leaves the base
+// file's Provenance describing other parameters, so its Hash is recomputed
+// and its Version cleared unless TRIAGEGEIST_PARAMS_VERSION is set.
+func ParamsFromEnv(lookup func(string) (string, bool)) (Params, error) {
+	p := DefaultParams()
+	if path, ok := lookup(EnvPrefix + "CONFIG"); ok && path != "" {
+		var err error
+		if p, err = LoadParams(path); err != nil {
+			return Params{}, err
+		}
+	}
+	keys := make([]string, 0, len(envVars))
+	for k := range envVars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var errs []error
+	version, overridden := p.Provenance.Version, false
+	for _, k := range keys {
+		v, ok := lookup(EnvPrefix + k)
+		if !ok || strings.TrimSpace(v) == "" {
+			continue
+		}
+		if err := envVars[k](&p, strings.TrimSpace(v)); err != nil {
+			errs = append(errs, fmt.Errorf("%s%s=%q: %w", EnvPrefix, k, v, err))
+		}
+		overridden = overridden || (k != "PARAMS_NAME" && k != "PARAMS_VERSION")
+	}
+	if len(errs) > 0 {
+		return Params{}, fmt.Errorf("triagegeist: params: %w", errors.Join(errs...))
+	}
+	if overridden {
+		if p.Provenance.Version == version {
+			p.Provenance.Version = ""
+		}
+		if p.Provenance.Hash != "" {
+			p.Provenance.Hash = p.Hash()
+		}
+	}
+	if err := paramsError(p); err != nil {
+		return Params{}, err
+	}
+	return p, nil
+}
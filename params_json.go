@@ -0,0 +1,526 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// paramsJSON is the configuration file schema of Params. Keys are
+// snake_case; resource_scale is a name ("linear", "log1p", "sqrt",
+// "piecewise") and reliability maps source names to seven factors.
+// schema_version is ParamsSchemaVersion when written; a file without it is
+// version 1 (see MigrateParams).
+//
+//	{
+//	  "schema_version": 11,
+//	  "vital_weights": [0.18, 0.22, 0.16, 0.10, 0.08, 0.16, 0.10],
+//	  "max_resources": 6,
+//	  "resource_weight": 0.25,
+//	  "t1": 0.85, "t2": 0.60, "t3": 0.35, "t4": 0.15,
+//	  "gray_zone": 0.02
+//	}
+type paramsJSON struct {
+	SchemaVersion     int                     `json:"schema_version,omitempty"`
+	VitalWeights      []float64               `json:"vital_weights"`
+	MaxResources      int                     `json:"max_resources"`
+	ResourceWeight    float64                 `json:"resource_weight"`
+	T1                float64                 `json:"t1"`
+	T2                float64                 `json:"t2"`
+	T3                float64                 `json:"t3"`
+	T4                float64                 `json:"t4"`
+	MAPWeight         float64                 `json:"map_weight"`
+	ResourceScale     string                  `json:"resource_scale"`
+	ResourceRate      float64                 `json:"resource_rate,omitempty"`
+	GCSBanded         bool                    `json:"gcs_banded"`
+	GCSBands          gcsBandsJSON            `json:"gcs_bands"`
+	RespiratoryWeight float64                 `json:"respiratory_weight"`
+	QSOFABump         float64                 `json:"qsofa_bump"`
+	Reliability       map[string][]float64    `json:"reliability"`
+	GrayZone          float64                 `json:"gray_zone"`
+	Hysteresis        float64                 `json:"hysteresis"`
+	Reference         *ReferenceDistribution  `json:"reference,omitempty"`
+	Calibration       *ProbabilityCalibration `json:"probability_calibration,omitempty"`
+	AsymmetricWeights *asymmetricJSON         `json:"asymmetric_weights,omitempty"`
+	Transform         *transformJSON          `json:"deviation_transform,omitempty"`
+	HalfWidths        *asymmetricJSON         `json:"half_widths,omitempty"`
+	Directions        map[string]string       `json:"directions,omitempty"`
+	MissingPolicy     string                  `json:"missing_policy,omitempty"`
+	MinPresentVitals  int                     `json:"min_present_vitals,omitempty"`
+	ExtendedWeights   map[string]float64      `json:"extended_weights,omitempty"`
+	Trend             *trendJSON              `json:"trend,omitempty"`
+	DeviationCaps     []float64               `json:"deviation_caps,omitempty"`
+	ZScores           map[string]zNormJSON    `json:"z_scores,omitempty"`
+	Normalization     string                  `json:"normalization,omitempty"`
+	SoftmaxSharpness  float64                 `json:"softmax_sharpness,omitempty"`
+	MinimumData       *minimumDataJSON        `json:"minimum_data,omitempty"`
+	FormulaVersion    int                     `json:"formula_version,omitempty"`
+	Provenance        *provenanceJSON         `json:"provenance,omitempty"`
+}
+
+// asymmetricJSON holds per-side values: asymmetric_weights is present
+// exactly when Params.Asymmetric is set, half_widths when Params.HalfWidths
+// is not the zero value.
+type asymmetricJSON struct {
+	Low  []float64 `json:"low"`
+	High []float64 `json:"high"`
+}
+
+// zNormJSON is a z_scores entry, keyed by vital name; vitals with a zero
+// score.ZNorm are omitted.
+type zNormJSON struct {
+	Mean       float64 `json:"mean"`
+	SD         float64 `json:"sd"`
+	Saturation float64 `json:"saturation,omitempty"`
+}
+
+// minimumDataJSON is present exactly when Params.MinimumData is not the
+// zero value; any_of names vitals ("rr", "spo2", ...).
+type minimumDataJSON struct {
+	MinPresent int      `json:"min_present"`
+	AnyOf      []string `json:"any_of,omitempty"`
+}
+
+// transformJSON is present exactly when Params.Transform is not the zero
+// value; kind is a name ("linear", "sigmoid", "power").
+type transformJSON struct {
+	Kind       string  `json:"kind"`
+	Saturation float64 `json:"saturation,omitempty"`
+	Shape      float64 `json:"shape,omitempty"`
+}
+
+// trendJSON is present exactly when Params.TrendWeights or TrendScales is
+// not all 0.
+type trendJSON struct {
+	Weights []float64 `json:"weights"`
+	Scales  []float64 `json:"scales"`
+}
+
+type gcsBandsJSON struct {
+	Normal   float64 `json:"normal"`
+	Mild     float64 `json:"mild"`
+	Moderate float64 `json:"moderate"`
+	Severe   float64 `json:"severe"`
+}
+
+func toParamsJSON(p Params) paramsJSON {
+	w := paramsJSON{
+		SchemaVersion:  ParamsSchemaVersion,
+		VitalWeights:   append([]float64(nil), p.VitalWeights[:]...),
+		MaxResources:   p.MaxResources,
+		ResourceWeight: p.ResourceWeight,
+		T1:             p.T1, T2: p.T2, T3: p.T3, T4: p.T4,
+		MAPWeight:     p.MAPWeight,
+		ResourceScale: p.ResourceScale.String(),
+		ResourceRate:  p.ResourceRate,
+		GCSBanded:     p.GCSBanded,
+		GCSBands:      gcsBandsJSON(p.GCSBands),
+
+		RespiratoryWeight: p.RespiratoryWeight,
+		QSOFABump:         p.QSOFABump,
+		Reliability:       make(map[string][]float64, score.NumSources),
+		GrayZone:          p.GrayZone,
+		Hysteresis:        p.Hysteresis,
+		Reference:         p.Reference,
+		Calibration:       p.Calibration,
+	}
+	for s := score.Source(0); s < score.NumSources; s++ {
+		w.Reliability[s.String()] = append([]float64(nil), p.Reliability[s][:]...)
+	}
+	w.Provenance = toProvenanceJSON(p.Provenance)
+	if p.Asymmetric {
+		w.AsymmetricWeights = &asymmetricJSON{
+			Low:  append([]float64(nil), p.AsymmetricWeights.Low[:]...),
+			High: append([]float64(nil), p.AsymmetricWeights.High[:]...),
+		}
+	}
+	if h := p.HalfWidths; !h.IsZero() {
+		w.HalfWidths = &asymmetricJSON{
+			Low:  append([]float64(nil), h.Low[:]...),
+			High: append([]float64(nil), h.High[:]...),
+		}
+	}
+	if p.MissingPolicy != score.MissingIgnore {
+		w.MissingPolicy = p.MissingPolicy.String()
+	}
+	w.MinPresentVitals = p.MinPresentVitals
+	for i, x := range p.ExtendedWeights {
+		if x != 0 {
+			if w.ExtendedWeights == nil {
+				w.ExtendedWeights = make(map[string]float64)
+			}
+			w.ExtendedWeights[score.ExtendedNames[i]] = x
+		}
+	}
+	if p.TrendWeights != ([7]float64{}) || p.TrendScales != ([7]float64{}) {
+		w.Trend = &trendJSON{
+			Weights: append([]float64(nil), p.TrendWeights[:]...),
+			Scales:  append([]float64(nil), p.TrendScales[:]...),
+		}
+	}
+	if p.DeviationCaps != ([7]float64{}) {
+		w.DeviationCaps = append([]float64(nil), p.DeviationCaps[:]...)
+	}
+	if p.Normalization != score.NormalizeSum {
+		w.Normalization = p.Normalization.String()
+	}
+	w.SoftmaxSharpness = p.SoftmaxSharpness
+	w.FormulaVersion = int(p.FormulaVersion)
+	if r := p.MinimumData; !r.IsZero() {
+		w.MinimumData = &minimumDataJSON{MinPresent: r.MinPresent}
+		for i, ok := range r.AnyOf {
+			if ok {
+				w.MinimumData.AnyOf = append(w.MinimumData.AnyOf, Field(i).String())
+			}
+		}
+	}
+	for i, z := range p.ZScores {
+		if !z.IsZero() {
+			if w.ZScores == nil {
+				w.ZScores = make(map[string]zNormJSON)
+			}
+			w.ZScores[Field(i).String()] = zNormJSON(z)
+		}
+	}
+	for i, d := range p.Directions {
+		if d != score.DirectionBoth {
+			if w.Directions == nil {
+				w.Directions = make(map[string]string)
+			}
+			w.Directions[Field(i).String()] = d.String()
+		}
+	}
+	if t := p.Transform; !t.IsZero() {
+		w.Transform = &transformJSON{Kind: t.Kind.String(), Saturation: t.Saturation, Shape: t.Shape}
+	}
+	return w
+}
+
+// MarshalJSON encodes p in the configuration file schema.
+func (p Params) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toParamsJSON(p))
+}
+
+// UnmarshalJSON decodes p from the configuration file schema. Keys that are
+// absent keep their DefaultParams value, so a file need only list what it
+// overrides; reliability rows are likewise overridden per source. Unknown
+// keys, malformed values, a schema_version newer than ParamsSchemaVersion,
+// parameters that fail Validate and a provenance hash that does not match
+// the parameters are errors.
+func (p *Params) UnmarshalJSON(data []byte) error {
+	w := toParamsJSON(DefaultParams())
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&w); err != nil {
+		return fmt.Errorf("triagegeist: params: %w", err)
+	}
+	if w.SchemaVersion < 1 || w.SchemaVersion > ParamsSchemaVersion {
+		return fmt.Errorf("triagegeist: params: schema_version %d not in 1..%d", w.SchemaVersion, ParamsSchemaVersion)
+	}
+	q, err := w.params()
+	if err != nil {
+		return err
+	}
+	if err := paramsError(q); err != nil {
+		return err
+	}
+	if h := q.Provenance.Hash; h != "" && h != q.Hash() {
+		return fmt.Errorf("triagegeist: params: provenance hash %s does not match the parameters (%s); record a new version", h, q.Hash())
+	}
+	*p = q
+	return nil
+}
+
+func (w paramsJSON) params() (Params, error) {
+	p := Params{
+		MaxResources:   w.MaxResources,
+		ResourceWeight: w.ResourceWeight,
+		T1:             w.T1, T2: w.T2, T3: w.T3, T4: w.T4,
+		MAPWeight:    w.MAPWeight,
+		ResourceRate: w.ResourceRate,
+		GCSBanded:    w.GCSBanded,
+		GCSBands:     score.GCSBands(w.GCSBands),
+
+		RespiratoryWeight: w.RespiratoryWeight,
+		QSOFABump:         w.QSOFABump,
+		GrayZone:          w.GrayZone,
+		Hysteresis:        w.Hysteresis,
+		Reference:         w.Reference,
+		Calibration:       w.Calibration,
+		MinPresentVitals:  w.MinPresentVitals,
+		SoftmaxSharpness:  w.SoftmaxSharpness,
+		FormulaVersion:    FormulaVersion(w.FormulaVersion),
+	}
+	if len(w.VitalWeights) != 7 {
+		return Params{}, fmt.Errorf("triagegeist: params: vital_weights has %d values, want 7", len(w.VitalWeights))
+	}
+	copy(p.VitalWeights[:], w.VitalWeights)
+	scale, ok := parseResourceScale(w.ResourceScale)
+	if !ok {
+		return Params{}, fmt.Errorf("triagegeist: params: unknown resource_scale %q", w.ResourceScale)
+	}
+	p.ResourceScale = scale
+	for name, row := range w.Reliability {
+		src, ok := parseSource(name)
+		if !ok {
+			return Params{}, fmt.Errorf("triagegeist: params: unknown reliability source %q", name)
+		}
+		if len(row) != 7 {
+			return Params{}, fmt.Errorf("triagegeist: params: reliability.%s has %d values, want 7", name, len(row))
+		}
+		copy(p.Reliability[src][:], row)
+	}
+	if a := w.AsymmetricWeights; a != nil {
+		if len(a.Low) != 7 || len(a.High) != 7 {
+			return Params{}, fmt.Errorf("triagegeist: params: asymmetric_weights has %d low and %d high values, want 7 each", len(a.Low), len(a.High))
+		}
+		p.Asymmetric = true
+		copy(p.AsymmetricWeights.Low[:], a.Low)
+		copy(p.AsymmetricWeights.High[:], a.High)
+	}
+	if h := w.HalfWidths; h != nil {
+		if len(h.Low) != 7 || len(h.High) != 7 {
+			return Params{}, fmt.Errorf("triagegeist: params: half_widths has %d low and %d high values, want 7 each", len(h.Low), len(h.High))
+		}
+		copy(p.HalfWidths.Low[:], h.Low)
+		copy(p.HalfWidths.High[:], h.High)
+	}
+	if w.MissingPolicy != "" {
+		m, ok := parseMissingPolicy(w.MissingPolicy)
+		if !ok {
+			return Params{}, fmt.Errorf("triagegeist: params: unknown missing_policy %q", w.MissingPolicy)
+		}
+		p.MissingPolicy = m
+	}
+	for name, x := range w.ExtendedWeights {
+		i := 0
+		for i < score.NumExtended && score.ExtendedNames[i] != name {
+			i++
+		}
+		if i == score.NumExtended {
+			return Params{}, fmt.Errorf("triagegeist: params: unknown extended_weights sign %q", name)
+		}
+		p.ExtendedWeights[i] = x
+	}
+	if t := w.Trend; t != nil {
+		if len(t.Weights) != 7 || len(t.Scales) != 7 {
+			return Params{}, fmt.Errorf("triagegeist: params: trend has %d weights and %d scales, want 7 each", len(t.Weights), len(t.Scales))
+		}
+		copy(p.TrendWeights[:], t.Weights)
+		copy(p.TrendScales[:], t.Scales)
+	}
+	if c := w.DeviationCaps; c != nil {
+		if len(c) != 7 {
+			return Params{}, fmt.Errorf("triagegeist: params: deviation_caps has %d values, want 7", len(c))
+		}
+		copy(p.DeviationCaps[:], c)
+	}
+	if w.Normalization != "" {
+		n, ok := parseNormalization(w.Normalization)
+		if !ok {
+			return Params{}, fmt.Errorf("triagegeist: params: unknown normalization %q", w.Normalization)
+		}
+		p.Normalization = n
+	}
+	if r := w.MinimumData; r != nil {
+		p.MinimumData.MinPresent = r.MinPresent
+		for _, name := range r.AnyOf {
+			f := FieldHR
+			for f <= FieldGCS && f.String() != name {
+				f++
+			}
+			if f > FieldGCS {
+				return Params{}, fmt.Errorf("triagegeist: params: unknown minimum_data.any_of vital %q", name)
+			}
+			p.MinimumData.AnyOf[f] = true
+		}
+	}
+	for name, z := range w.ZScores {
+		f := FieldHR
+		for f <= FieldGCS && f.String() != name {
+			f++
+		}
+		if f > FieldGCS {
+			return Params{}, fmt.Errorf("triagegeist: params: unknown z_scores vital %q", name)
+		}
+		p.ZScores[f] = score.ZNorm(z)
+	}
+	for name, dir := range w.Directions {
+		f := FieldHR
+		for f <= FieldGCS && f.String() != name {
+			f++
+		}
+		if f > FieldGCS {
+			return Params{}, fmt.Errorf("triagegeist: params: unknown directions vital %q", name)
+		}
+		d, ok := parseDirection(dir)
+		if !ok {
+			return Params{}, fmt.Errorf("triagegeist: params: unknown directions.%s %q", name, dir)
+		}
+		p.Directions[f] = d
+	}
+	if t := w.Transform; t != nil {
+		kind, ok := parseTransformKind(t.Kind)
+		if !ok {
+			return Params{}, fmt.Errorf("triagegeist: params: unknown deviation_transform.kind %q", t.Kind)
+		}
+		p.Transform = score.DeviationTransform{Kind: kind, Saturation: t.Saturation, Shape: t.Shape}
+	}
+	prov, err := w.Provenance.provenance()
+	if err != nil {
+		return Params{}, fmt.Errorf("triagegeist: params: provenance.calibrated_at: %w", err)
+	}
+	p.Provenance = prov
+	return p, nil
+}
+
+func parseResourceScale(name string) (score.ResourceScale, bool) {
+	for s := score.ResourceLinear; s.Valid(); s++ {
+		if s.String() == name {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+func parseTransformKind(name string) (score.TransformKind, bool) {
+	for k := score.TransformLinear; k.Valid(); k++ {
+		if k.String() == name {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+func parseDirection(name string) (score.Direction, bool) {
+	for d := score.DirectionBoth; d.Valid(); d++ {
+		if d.String() == name {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+func parseMissingPolicy(name string) (score.MissingPolicy, bool) {
+	for m := score.MissingIgnore; m.Valid(); m++ {
+		if m.String() == name {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+func parseNormalization(name string) (score.Normalization, bool) {
+	for n := score.NormalizeSum; n.Valid(); n++ {
+		if n.String() == name {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+func parseSource(name string) (score.Source, bool) {
+	for s := score.Source(0); s < score.NumSources; s++ {
+		if s.String() == name {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// paramsError joins p.ValidateDetailed() into one error, or returns nil if
+// p is valid.
+func paramsError(p Params) error {
+	errs := p.ValidateDetailed()
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("triagegeist: params: %w", errors.Join(errs...))
+}
+
+// isYAMLPath reports whether path has a .yaml or .yml extension.
+func isYAMLPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// readConfig reads a configuration file and returns it as JSON, converting
+// YAML (.yaml, .yml) files with parseYAML.
+func readConfig(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return configJSON(path, data)
+}
+
+// configJSON returns the contents data of the configuration file path as
+// JSON, converting YAML by the extension of path as readConfig.
+func configJSON(path string, data []byte) ([]byte, error) {
+	if isYAMLPath(path) {
+		v, err := parseYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: triagegeist: %w", path, err)
+		}
+		return json.Marshal(v)
+	}
+	return data, nil
+}
+
+// LoadParams reads Params from a configuration file. Files ending in .yaml
+// or .yml are parsed as YAML (block mappings, block and flow sequences,
+// scalars and comments; anchors and multi-document streams are not
+// supported); anything else as JSON. The schema and defaulting are those of
+// UnmarshalJSON, and the result is validated.
+func LoadParams(path string) (Params, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Params{}, err
+	}
+	return parseParams(path, data)
+}
+
+// parseParams is LoadParams of data already read from path.
+func parseParams(path string, data []byte) (Params, error) {
+	data, err := configJSON(path, data)
+	if err != nil {
+		return Params{}, err
+	}
+	var p Params
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Params{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// SaveParams writes p to path as YAML (.yaml, .yml) or indented JSON. It
+// refuses to write parameters that fail Validate. If p has Provenance, its
+// Hash is set to p.Hash() in the file.
+func SaveParams(path string, p Params) error {
+	if err := paramsError(p); err != nil {
+		return err
+	}
+	if !p.Provenance.IsZero() {
+		p.Provenance.Hash = p.Hash()
+	}
+	var data []byte
+	if isYAMLPath(path) {
+		data = paramsYAML(toParamsJSON(p))
+	} else {
+		b, err := json.MarshalIndent(p, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append(b, '\n')
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,154 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ParamsSchemaVersion is the configuration file schema version written by
+// SaveParams and MarshalJSON.
+//
+//	| Version | Adds                                             |
+//	|---------|--------------------------------------------------|
+//	| 1       | Core fields and formula options (no version key) |
+//	| 2       | reference (percentile reference distribution)    |
+//	| 3       | asymmetric_weights                               |
+//	| 4       | provenance, schema_version                       |
+//	| 5       | deviation_transform                              |
+//	| 6       | half_widths                                      |
+//	| 7       | directions                                       |
+//	| 8       | missing_policy, min_present_vitals               |
+//	| 9       | extended_weights                                 |
+//	| 10      | trend                                            |
+//	| 11      | deviation_caps                                   |
+//	| 12      | resource_rate                                    |
+//	| 13      | probability_calibration                          |
+//	| 14      | z_scores                                         |
+//	| 15      | normalization, softmax_sharpness                 |
+//	| 16      | minimum_data                                     |
+//	| 17      | formula_version                                  |
+const ParamsSchemaVersion = 17
+
+// migrations[v-1] upgrades a decoded file from version v to v+1. Versions
+// so far only added keys, which default on load, so apply is nil; a later
+// change that renames or restructures keys sets it.
+var migrations = []struct {
+	note  string
+	apply func(m map[string]any)
+}{
+	{note: "v1 to v2: no reference distribution; percentiles are not reported"},
+	{note: "v2 to v3: no asymmetric_weights; VitalWeights apply on both sides"},
+	{note: "v3 to v4: no provenance recorded"},
+	{note: "v4 to v5: no deviation_transform; deviations are linear, saturating at the half-width"},
+	{note: "v5 to v6: no half_widths; norms are symmetric"},
+	{note: "v6 to v7: no directions; every vital deviates on both sides"},
+	{note: "v7 to v8: no missing_policy; absent vitals are ignored"},
+	{note: "v8 to v9: no extended_weights; extended signs are not scored"},
+	{note: "v9 to v10: no trend; deterioration rates are not scored"},
+	{note: "v10 to v11: no deviation_caps; every deviation is capped at 1"},
+	{note: "v11 to v12: no resource_rate; the exp resource scale uses the default rate"},
+	{note: "v12 to v13: no probability_calibration; probabilities are not reported"},
+	{note: "v13 to v14: no z_scores; every vital is scored against its norm"},
+	{note: "v14 to v15: no normalization; the vital component is the weighted mean"},
+	{note: "v15 to v16: no minimum_data; any input is scored"},
+	{note: "v16 to v17: no formula_version; the v1 formula applies"},
+}
+
+// MigrationReport describes what MigrateParams did.
+type MigrationReport struct {
+	From, To int
+	// Defaulted lists the top-level keys the file did not set, which now
+	// hold their DefaultParams value, sorted.
+	Defaulted []string
+	// Steps holds one note per version step applied.
+	Steps []string
+}
+
+// Migrated returns true if the file was older than ParamsSchemaVersion.
+func (r MigrationReport) Migrated() bool {
+	return r.From != r.To
+}
+
+// MigrateParams upgrades a serialized configuration (JSON, or YAML if yaml
+// is true) of any earlier schema version to the current one and returns
+// the resulting Params, validated as by UnmarshalJSON, with a report of the
+// steps applied and the keys filled from DefaultParams. A file without
+// schema_version is version 1. It is an error if the file is malformed,
+// from a newer version, or invalid after migration. Save the result with
+// SaveParams to write it in the current format.
+func MigrateParams(data []byte, yaml bool) (Params, MigrationReport, error) {
+	var v any
+	var err error
+	if yaml {
+		v, err = parseYAML(data)
+	} else {
+		err = json.Unmarshal(data, &v)
+	}
+	if err != nil {
+		return Params{}, MigrationReport{}, fmt.Errorf("triagegeist: params: %w", err)
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return Params{}, MigrationReport{}, fmt.Errorf("triagegeist: params: configuration is not a mapping")
+	}
+	r := MigrationReport{From: 1, To: ParamsSchemaVersion}
+	if sv, ok := m["schema_version"]; ok {
+		f, ok := sv.(float64)
+		if !ok || f != float64(int(f)) || f < 1 || int(f) > ParamsSchemaVersion {
+			return Params{}, MigrationReport{}, fmt.Errorf("triagegeist: params: schema_version %v not in 1..%d", sv, ParamsSchemaVersion)
+		}
+		r.From = int(f)
+	}
+	for ver := r.From; ver < ParamsSchemaVersion; ver++ {
+		step := migrations[ver-1]
+		if step.apply != nil {
+			step.apply(m)
+		}
+		r.Steps = append(r.Steps, step.note)
+	}
+	m["schema_version"] = ParamsSchemaVersion
+
+	var current map[string]any
+	b, _ := json.Marshal(toParamsJSON(DefaultParams()))
+	json.Unmarshal(b, &current)
+	for k := range current {
+		if _, ok := m[k]; !ok {
+			r.Defaulted = append(r.Defaulted, k)
+		}
+	}
+	sort.Strings(r.Defaulted)
+
+	if b, err = json.Marshal(m); err != nil {
+		return Params{}, MigrationReport{}, err
+	}
+	var p Params
+	if err := json.Unmarshal(b, &p); err != nil {
+		return Params{}, MigrationReport{}, err
+	}
+	return p, r, nil
+}
+
+// MigrateParamsFile migrates the configuration file at path with
+// MigrateParams and, if it was older than ParamsSchemaVersion, rewrites it
+// in the current format with SaveParams.
+func MigrateParamsFile(path string) (MigrationReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return MigrationReport{}, err
+	}
+	p, r, err := MigrateParams(data, isYAMLPath(path))
+	if err != nil {
+		return MigrationReport{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if r.Migrated() {
+		if err := SaveParams(path, p); err != nil {
+			return MigrationReport{}, err
+		}
+	}
+	return r, nil
+}
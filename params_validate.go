@@ -5,15 +5,9 @@ package triagegeist
 
 import "github.com/olaflaitinen/triagegeist/validate"
 
-// ValidateParamsExternal runs the validate package's Params check on p.
-// Use when you want the same validation logic as validate.Params without
-// constructing validate.ParamsLike manually.
+// ValidateParamsExternal runs the validate package's Params check on p. p is
+// passed as a validate.ParamsChecker, so the check always covers every
+// Params field; it agrees with p.Validate.
 func ValidateParamsExternal(p Params) bool {
-	pl := validate.ParamsLike{
-		VitalWeights:   p.VitalWeights,
-		MaxResources:   p.MaxResources,
-		ResourceWeight: p.ResourceWeight,
-		T1:             p.T1, T2: p.T2, T3: p.T3, T4: p.T4,
-	}
-	return validate.ParamsValid(pl)
+	return validate.ParamsValid(p)
 }
@@ -0,0 +1,135 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultReferencePoints is the number of quantiles NewReferenceDistribution
+// keeps when asked for fewer than two (every percentile, 0..100).
+const DefaultReferencePoints = 101
+
+// ReferenceDistribution summarises the acuity scores of a reference
+// population (e.g. one year of ED presentations at the site) as evenly
+// spaced quantiles, so a score can be reported as a percentile rank: "0.62"
+// becomes "93rd percentile of presentations here".
+type ReferenceDistribution struct {
+	// Quantiles[i] is the i/(len-1) quantile of the reference scores;
+	// non-decreasing, at least two points.
+	Quantiles []float64 `json:"quantiles"`
+	// N is the number of reference scores summarised.
+	N int `json:"n"`
+}
+
+// NewReferenceDistribution returns the distribution of scores summarised in
+// points quantiles (linear interpolation between order statistics, as
+// stats.Percentile). NaN scores are skipped. Returns a zero (invalid)
+// distribution if no scores remain.
+func NewReferenceDistribution(scores []float64, points int) ReferenceDistribution {
+	if points < 2 {
+		points = DefaultReferencePoints
+	}
+	s := make([]float64, 0, len(scores))
+	for _, x := range scores {
+		if !math.IsNaN(x) {
+			s = append(s, x)
+		}
+	}
+	if len(s) == 0 {
+		return ReferenceDistribution{}
+	}
+	sort.Float64s(s)
+	d := ReferenceDistribution{Quantiles: make([]float64, points), N: len(s)}
+	for i := range d.Quantiles {
+		pos := float64(i) / float64(points-1) * float64(len(s)-1)
+		lo := int(pos)
+		if lo >= len(s)-1 {
+			d.Quantiles[i] = s[len(s)-1]
+			continue
+		}
+		d.Quantiles[i] = s[lo] + (pos-float64(lo))*(s[lo+1]-s[lo])
+	}
+	return d
+}
+
+// Valid returns true if d has at least two non-decreasing, finite quantiles.
+func (d ReferenceDistribution) Valid() bool {
+	if len(d.Quantiles) < 2 {
+		return false
+	}
+	for i, q := range d.Quantiles {
+		if math.IsNaN(q) || math.IsInf(q, 0) || (i > 0 && q < d.Quantiles[i-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal returns true if d and o have the same N and quantiles.
+func (d ReferenceDistribution) Equal(o ReferenceDistribution) bool {
+	if d.N != o.N || len(d.Quantiles) != len(o.Quantiles) {
+		return false
+	}
+	for i := range d.Quantiles {
+		if d.Quantiles[i] != o.Quantiles[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// PercentileRank returns the percentile rank of s in [0, 100], interpolating
+// linearly between quantiles. A score equal to a run of tied quantiles gets
+// the middle of the run. Returns 0 if d is not Valid.
+func (d ReferenceDistribution) PercentileRank(s float64) float64 {
+	if !d.Valid() || math.IsNaN(s) {
+		return 0
+	}
+	q := d.Quantiles
+	m := len(q)
+	lo := sort.SearchFloat64s(q, s)
+	hi := lo
+	for hi < m && q[hi] == s {
+		hi++
+	}
+	var pos float64
+	switch {
+	case hi > lo:
+		pos = float64(lo+hi-1) / 2
+	case lo == 0:
+		return 0
+	case lo == m:
+		return 100
+	default:
+		pos = float64(lo-1) + (s-q[lo-1])/(q[lo]-q[lo-1])
+	}
+	return pos / float64(m-1) * 100
+}
+
+// WithReference returns a new Engine that reports each evaluation's
+// percentile rank against d in EvaluateResult.Percentile, overriding
+// Params.Reference. The receiver is unchanged. An invalid d leaves only
+// Params.Reference in effect.
+func (e *Engine) WithReference(d ReferenceDistribution) *Engine {
+	c := *e
+	c.Reference = nil
+	if d.Valid() {
+		c.Reference = &d
+	}
+	return &c
+}
+
+// percentile records r's percentile rank under the engine's reference
+// distribution, or else p's, if any.
+func (e *Engine) percentile(r EvaluateResult, p Params) EvaluateResult {
+	switch {
+	case e.Reference != nil:
+		r.Percentile = e.Reference.PercentileRank(r.Acuity)
+	case p.Reference != nil:
+		r.Percentile = p.Reference.PercentileRank(r.Acuity)
+	}
+	return r
+}
@@ -0,0 +1,306 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package pipeline
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// OverflowPolicy selects what a Buffer does with a record that arrives when
+// it is full.
+//
+//	| Policy     | On overflow                                                |
+//	|------------|------------------------------------------------------------|
+//	| Block      | Put waits until a consumer makes room or the buffer closes |
+//	| DropOldest | The oldest record is evicted and recorded as a Drop        |
+//	| Spill      | Records go to a spill file and are read back in order      |
+type OverflowPolicy int
+
+const (
+	Block OverflowPolicy = iota
+	DropOldest
+	Spill
+)
+
+// DefaultBufferCapacity is the in-memory capacity of a Buffer when
+// BufferOptions.Capacity is not positive.
+const DefaultBufferCapacity = 1024
+
+// BufferOptions configures NewBuffer.
+type BufferOptions struct {
+	Capacity int            // In-memory records; DefaultBufferCapacity if <= 0
+	Policy   OverflowPolicy // Default Block
+	// SpillPath is the file Spill appends overflow to, as JSON lines. It is
+	// created (truncated) by NewBuffer and removed once the closed buffer is
+	// drained. Required for Spill.
+	SpillPath string
+	// OnDrop, if non-nil, is called (with the buffer locked; do not call
+	// back into it) for every record DropOldest evicts, e.g. to write it to
+	// an audit log.
+	OnDrop func(Drop)
+}
+
+// Drop is the audit record of one evicted record.
+type Drop struct {
+	Record Record
+	At     time.Time
+}
+
+// BufferStats is a point-in-time copy of a Buffer's counters.
+type BufferStats struct {
+	Len      int    // Records in memory
+	Spilled  int    // Records waiting in the spill file
+	Accepted uint64 // Records accepted by Put
+	Dropped  uint64 // Evicted by DropOldest or unreadable from the spill file
+	Blocked  uint64 // Put calls that had to wait (Block)
+}
+
+// ErrBufferClosed is returned by Put after Close.
+var ErrBufferClosed = errors.New("pipeline: buffer closed")
+
+// Buffer is a bounded FIFO of Records between a streaming source (e.g. a
+// monitor feed or message queue consumer) and the scorer, so bursts never
+// exhaust memory or silently lose observations: overflow blocks the
+// producer, evicts with an audit record, or spills to disk, per
+// OverflowPolicy. Safe for concurrent use.
+type Buffer struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	opt      BufferOptions
+	q        []Record
+	closed   bool
+	stats    BufferStats
+	drops    []Drop
+
+	spillW  *os.File
+	spillR  *os.File
+	spillBR *bufio.Reader
+	// spillEnd is the size of the spill file up to its last complete line.
+	spillEnd int64
+}
+
+// NewBuffer returns an empty Buffer. It is an error to choose Spill without
+// a SpillPath, or if the spill file cannot be created.
+func NewBuffer(opt BufferOptions) (*Buffer, error) {
+	if opt.Capacity <= 0 {
+		opt.Capacity = DefaultBufferCapacity
+	}
+	b := &Buffer{opt: opt}
+	b.notEmpty = sync.NewCond(&b.mu)
+	b.notFull = sync.NewCond(&b.mu)
+	switch opt.Policy {
+	case Block, DropOldest:
+	case Spill:
+		if opt.SpillPath == "" {
+			return nil, errors.New("pipeline: Spill policy needs a SpillPath")
+		}
+		w, err := os.OpenFile(opt.SpillPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		r, err := os.Open(opt.SpillPath)
+		if err != nil {
+			w.Close()
+			return nil, err
+		}
+		b.spillW, b.spillR, b.spillBR = w, r, bufio.NewReader(r)
+	default:
+		return nil, fmt.Errorf("pipeline: unknown overflow policy %d", opt.Policy)
+	}
+	return b, nil
+}
+
+// Put adds r, applying the overflow policy when the buffer is full. It
+// returns ErrBufferClosed after Close, or the error of a failed spill write.
+func (b *Buffer) Put(r Record) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrBufferClosed
+	}
+	full := len(b.q) >= b.opt.Capacity
+	switch b.opt.Policy {
+	case Block:
+		if full {
+			b.stats.Blocked++
+		}
+		for len(b.q) >= b.opt.Capacity && !b.closed {
+			b.notFull.Wait()
+		}
+		if b.closed {
+			return ErrBufferClosed
+		}
+	case DropOldest:
+		if full {
+			d := Drop{Record: b.q[0], At: time.Now()}
+			b.q = b.q[1:]
+			if len(b.drops) == b.opt.Capacity {
+				b.drops = b.drops[1:]
+			}
+			b.drops = append(b.drops, d)
+			b.stats.Dropped++
+			if b.opt.OnDrop != nil {
+				b.opt.OnDrop(d)
+			}
+		}
+	case Spill:
+		// Once anything is on disk, later records follow it to keep order.
+		if full || b.stats.Spilled > 0 {
+			line, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			line = append(line, '\n')
+			if n, err := b.spillW.Write(line); err != nil {
+				// Cut off a partial line, or the next record would be
+				// appended to it and both would be unreadable.
+				if n > 0 {
+					if terr := b.spillW.Truncate(b.spillEnd); terr != nil {
+						err = errors.Join(err, terr)
+					}
+				}
+				return fmt.Errorf("pipeline: spill: %w", err)
+			}
+			b.spillEnd += int64(len(line))
+			b.stats.Spilled++
+			b.stats.Accepted++
+			b.notEmpty.Signal()
+			return nil
+		}
+	}
+	b.q = append(b.q, r)
+	b.stats.Accepted++
+	b.notEmpty.Signal()
+	return nil
+}
+
+// Get removes and returns the oldest record, waiting while the buffer is
+// empty. ok is false once the buffer is closed and drained.
+func (b *Buffer) Get() (r Record, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.q) == 0 && b.stats.Spilled == 0 && !b.closed {
+		b.notEmpty.Wait()
+	}
+	return b.take()
+}
+
+// TryGet is like Get but returns ok false at once if the buffer is empty.
+func (b *Buffer) TryGet() (r Record, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.take()
+}
+
+// take pops the oldest record, refilling memory from the spill file. The
+// caller holds b.mu.
+func (b *Buffer) take() (Record, bool) {
+	if len(b.q) == 0 {
+		b.refill()
+	}
+	if len(b.q) == 0 {
+		if b.closed {
+			b.releaseSpill()
+		}
+		return Record{}, false
+	}
+	r := b.q[0]
+	b.q = b.q[1:]
+	b.refill()
+	b.notFull.Signal()
+	return r, true
+}
+
+// refill moves spilled records back into memory while there is room, and
+// truncates the spill file once it is fully read.
+func (b *Buffer) refill() {
+	read := false
+	for b.stats.Spilled > 0 && len(b.q) < b.opt.Capacity {
+		line, err := b.spillBR.ReadBytes('\n')
+		var r Record
+		if err == nil {
+			err = json.Unmarshal(line, &r)
+		}
+		if err != nil {
+			// Unreadable spill data cannot be recovered; count it as dropped
+			// rather than stalling the consumer.
+			b.stats.Spilled--
+			b.stats.Dropped++
+			read = true
+			continue
+		}
+		b.q = append(b.q, r)
+		b.stats.Spilled--
+		read = true
+	}
+	if read && b.stats.Spilled == 0 {
+		if err := b.spillW.Truncate(0); err == nil {
+			b.spillEnd = 0
+			if _, err := b.spillR.Seek(0, io.SeekStart); err == nil {
+				b.spillBR.Reset(b.spillR)
+			}
+		}
+	}
+}
+
+// releaseSpill closes and removes the spill file. The caller holds b.mu.
+func (b *Buffer) releaseSpill() {
+	if b.spillW == nil {
+		return
+	}
+	b.spillW.Close()
+	b.spillR.Close()
+	os.Remove(b.opt.SpillPath)
+	b.spillW, b.spillR, b.spillBR = nil, nil, nil
+}
+
+// Close stops Put and wakes waiting producers and consumers. Records already
+// accepted (including spilled ones) can still be read with Get.
+func (b *Buffer) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.notEmpty.Broadcast()
+	b.notFull.Broadcast()
+}
+
+// Stats returns the current counters.
+func (b *Buffer) Stats() BufferStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := b.stats
+	s.Len = len(b.q)
+	return s
+}
+
+// Drops returns a copy of the audit records of the most recent evictions
+// (at most Capacity; use OnDrop to keep all of them).
+func (b *Buffer) Drops() []Drop {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Drop(nil), b.drops...)
+}
+
+// Source returns a Source that drains the records currently in b without
+// waiting, so a Pipeline can score what has arrived so far.
+func (b *Buffer) Source() Source {
+	return func() ([]Record, error) {
+		var out []Record
+		for {
+			r, ok := b.TryGet()
+			if !ok {
+				return out, nil
+			}
+			out = append(out, r)
+		}
+	}
+}
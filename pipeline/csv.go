@@ -0,0 +1,85 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package pipeline
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ReferenceColumn is the optional CSV column holding the reference level.
+const ReferenceColumn = "reference_level"
+
+// CSVSource returns a Source reading records from CSV with a header row.
+// Columns are matched by name as in export.CSVHeader (hr, rr, sbp, dbp, temp,
+// spo2, gcs, resource_count, id) plus ReferenceColumn; other columns are
+// ignored, and missing columns or blank cells read as 0 (missing). The CSV
+// written by export.WriteCSV can be read back.
+func CSVSource(r io.Reader) Source {
+	return func() ([]Record, error) {
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		header, err := cr.Read()
+		if err != nil {
+			return nil, fmt.Errorf("pipeline: csv header: %w", err)
+		}
+		col := make(map[string]int, len(header))
+		for i, h := range header {
+			col[strings.ToLower(strings.TrimSpace(h))] = i
+		}
+		var recs []Record
+		for line := 2; ; line++ {
+			row, err := cr.Read()
+			if err == io.EOF {
+				return recs, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("pipeline: csv line %d: %w", line, err)
+			}
+			rec, err := csvRecord(row, col)
+			if err != nil {
+				return nil, fmt.Errorf("pipeline: csv line %d: %w", line, err)
+			}
+			recs = append(recs, rec)
+		}
+	}
+}
+
+func csvRecord(row []string, col map[string]int) (Record, error) {
+	var rec Record
+	var err error
+	cell := func(name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	atoi := func(name string, dst *int) {
+		if s := cell(name); s != "" && err == nil {
+			*dst, err = strconv.Atoi(s)
+			if err != nil {
+				err = fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	atoi("hr", &rec.Vitals.HR)
+	atoi("rr", &rec.Vitals.RR)
+	atoi("sbp", &rec.Vitals.SBP)
+	atoi("dbp", &rec.Vitals.DBP)
+	atoi("spo2", &rec.Vitals.SpO2)
+	atoi("gcs", &rec.Vitals.GCS)
+	atoi("resource_count", &rec.ResourceCount)
+	atoi(ReferenceColumn, &rec.Reference)
+	if s := cell("temp"); s != "" && err == nil {
+		rec.Vitals.Temp, err = strconv.ParseFloat(s, 64)
+		if err != nil {
+			err = fmt.Errorf("temp: %w", err)
+		}
+	}
+	rec.ID = cell("id")
+	return rec, err
+}
@@ -0,0 +1,175 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// LOINC codes read by FHIRBundleSource.
+//
+//	| Code    | Vital                        |
+//	|---------|------------------------------|
+//	| 8867-4  | Heart rate                   |
+//	| 9279-1  | Respiratory rate             |
+//	| 8480-6  | Systolic BP (or component)   |
+//	| 8462-4  | Diastolic BP (or component)  |
+//	| 8310-5  | Body temperature             |
+//	| 59408-5 | SpO2 by pulse oximetry       |
+//	| 2708-6  | Arterial O2 saturation       |
+//	| 9269-2  | GCS total                    |
+//	| 9267-6  | GCS eye                      |
+//	| 9270-0  | GCS verbal                   |
+//	| 9268-4  | GCS motor                    |
+const (
+	LOINCHeartRate = "8867-4"
+	LOINCRespRate  = "9279-1"
+	LOINCSystolic  = "8480-6"
+	LOINCDiastolic = "8462-4"
+	LOINCBodyTemp  = "8310-5"
+	LOINCSpO2      = "59408-5"
+	LOINCSaO2      = "2708-6"
+	LOINCGCSTotal  = "9269-2"
+	LOINCGCSEye    = "9267-6"
+	LOINCGCSVerbal = "9270-0"
+	LOINCGCSMotor  = "9268-4"
+)
+
+const loincSystem = "http://loinc.org"
+
+type fhirBundle struct {
+	ResourceType string `json:"resourceType"`
+	Entry        []struct {
+		Resource json.RawMessage `json:"resource"`
+	} `json:"entry"`
+}
+
+type fhirCoding struct {
+	System string `json:"system"`
+	Code   string `json:"code"`
+}
+
+type fhirQuantity struct {
+	Value *float64 `json:"value"`
+	Code  string   `json:"code"`
+}
+
+type fhirObservation struct {
+	ResourceType string `json:"resourceType"`
+	Code         struct {
+		Coding []fhirCoding `json:"coding"`
+	} `json:"code"`
+	Subject       struct{ Reference string } `json:"subject"`
+	Encounter     struct{ Reference string } `json:"encounter"`
+	ValueQuantity *fhirQuantity              `json:"valueQuantity"`
+	Component     []struct {
+		Code struct {
+			Coding []fhirCoding `json:"coding"`
+		} `json:"code"`
+		ValueQuantity *fhirQuantity `json:"valueQuantity"`
+	} `json:"component"`
+}
+
+// FHIRBundleSource returns a Source reading a FHIR R4 JSON Bundle of vital
+// sign Observations. Observations are grouped into one Record per encounter
+// (or per subject if no encounter is referenced), in order of first
+// appearance; the Record ID is that reference. Values are read from
+// valueQuantity and from components (e.g. a blood pressure panel), matched by
+// the LOINC codes above. Temperatures in [degF] are converted to Celsius
+// and blood pressures in kPa to mmHg.
+// Other resources and codes are skipped. FHIR carries no resource count or
+// reference level, so both are 0.
+func FHIRBundleSource(r io.Reader) Source {
+	return func() ([]Record, error) {
+		var b fhirBundle
+		if err := json.NewDecoder(r).Decode(&b); err != nil {
+			return nil, fmt.Errorf("pipeline: fhir: %w", err)
+		}
+		if b.ResourceType != "Bundle" {
+			return nil, fmt.Errorf("pipeline: fhir: resourceType %q, want Bundle", b.ResourceType)
+		}
+		var recs []Record
+		idx := make(map[string]int)
+		for _, e := range b.Entry {
+			var obs fhirObservation
+			if err := json.Unmarshal(e.Resource, &obs); err != nil {
+				return nil, fmt.Errorf("pipeline: fhir: %w", err)
+			}
+			if obs.ResourceType != "Observation" {
+				continue
+			}
+			key := obs.Encounter.Reference
+			if key == "" {
+				key = obs.Subject.Reference
+			}
+			i, ok := idx[key]
+			if !ok {
+				i = len(recs)
+				idx[key] = i
+				recs = append(recs, Record{ID: key})
+			}
+			setLOINC(&recs[i], obs.Code.Coding, obs.ValueQuantity)
+			for _, c := range obs.Component {
+				setLOINC(&recs[i], c.Code.Coding, c.ValueQuantity)
+			}
+		}
+		return recs, nil
+	}
+}
+
+func setLOINC(rec *Record, codings []fhirCoding, q *fhirQuantity) {
+	if q == nil || q.Value == nil {
+		return
+	}
+	x := *q.Value
+	n := int(math.Round(x))
+	v := &rec.Vitals
+	for _, c := range codings {
+		if c.System != loincSystem {
+			continue
+		}
+		switch c.Code {
+		case LOINCHeartRate:
+			v.HR = n
+		case LOINCRespRate:
+			v.RR = n
+		case LOINCSystolic:
+			v.SBP = score.ToMMHg(x, pressureUnit(q.Code))
+		case LOINCDiastolic:
+			v.DBP = score.ToMMHg(x, pressureUnit(q.Code))
+		case LOINCBodyTemp:
+			unit := score.Celsius
+			if q.Code == "[degF]" {
+				unit = score.Fahrenheit
+			}
+			v.Temp = score.ToCelsius(x, unit)
+		case LOINCSpO2, LOINCSaO2:
+			v.SpO2 = n
+		case LOINCGCSTotal:
+			v.GCS = n
+		case LOINCGCSEye:
+			v.GCSEye = n
+		case LOINCGCSVerbal:
+			v.GCSVerbal = n
+		case LOINCGCSMotor:
+			v.GCSMotor = n
+		default:
+			continue
+		}
+		return
+	}
+}
+
+// pressureUnit returns the unit of a blood pressure with UCUM code code.
+func pressureUnit(code string) score.PressureUnit {
+	if code == "kPa" {
+		return score.KPa
+	}
+	return score.MMHg
+}
@@ -0,0 +1,171 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+//
+// Package pipeline wires ingestion, validation, scoring, metrics and report
+// generation behind one configurable Pipeline. Each stage is a function field;
+// leave it nil for the default or replace it with your own.
+//
+// # Stages
+//
+//	| Stage    | Field    | Default                                             |
+//	|----------|----------|-----------------------------------------------------|
+//	| Ingest   | Source   | (required) CSVSource, FHIRBundleSource, or your own |
+//	| Validate | Validate | ClampValidate: clamp implausible vitals, drop empty |
+//	| Score    | Score    | EngineScore: Engine.Evaluate as an export.Result    |
+//	| Report   | Report   | (none) called with the Output after metrics         |
+//
+// Metrics (score statistics, level report, summary, and a confusion matrix
+// when records carry a reference level) are always computed.
+//
+// Streaming producers feed a Buffer, a bounded queue whose OverflowPolicy
+// decides what bursts do (block, drop the oldest with an audit record, or
+// spill to disk); Buffer.Source drains it into a Pipeline.
+package pipeline
+
+import (
+	"errors"
+	"time"
+
+	"github.com/olaflaitinen/triagegeist"
+	"github.com/olaflaitinen/triagegeist/export"
+	"github.com/olaflaitinen/triagegeist/metrics"
+	"github.com/olaflaitinen/triagegeist/score"
+	"github.com/olaflaitinen/triagegeist/stats"
+	"github.com/olaflaitinen/triagegeist/validate"
+)
+
+// Record is one ingested encounter.
+type Record struct {
+	ID            string
+	Vitals        score.Vitals
+	ResourceCount int
+	// Reference is the reference (ground truth) level 1..5; 0 if unknown.
+	Reference int
+}
+
+// Source reads all records for one run.
+type Source func() ([]Record, error)
+
+// ValidateFunc checks and optionally repairs one record. Returning false
+// rejects the record with the given reason.
+type ValidateFunc func(r Record, p triagegeist.Params) (Record, bool, string)
+
+// ScoreFunc scores one validated record.
+type ScoreFunc func(eng *triagegeist.Engine, r Record) export.Result
+
+// ReportFunc consumes the finished Output (e.g. writes CSV files).
+type ReportFunc func(out Output) error
+
+// Rejected is a record dropped by validation.
+type Rejected struct {
+	Record Record
+	Reason string
+}
+
+// Output holds everything one run produced.
+type Output struct {
+	Results  []export.Result
+	Rejected []Rejected
+	Scores   stats.ScoreStats
+	Levels   []export.ReportRow
+	Summary  export.Summary
+	// Confusion and Kappa compare predicted levels with Record.Reference over
+	// the records that have one; Confusion is nil if none do.
+	Confusion *metrics.ConfusionMatrix
+	Kappa     float64
+}
+
+// Pipeline runs Source → Validate → Score → metrics → Report. If the engine
+// has Instruments, validation latency is recorded as StageValidate.
+type Pipeline struct {
+	Engine   *triagegeist.Engine // nil uses triagegeist.NewDefaultEngine
+	Source   Source
+	Validate ValidateFunc
+	Score    ScoreFunc
+	Report   ReportFunc
+}
+
+// ErrNoSource is returned by Run when Source is nil.
+var ErrNoSource = errors.New("pipeline: no source")
+
+// ClampValidate is the default validator: records with no vitals are
+// rejected, implausible vitals are clamped (see validate.ClampVitals), and
+// the resource count is clamped to [0, MaxResources].
+func ClampValidate(r Record, p triagegeist.Params) (Record, bool, string) {
+	if !validate.AtLeastOneVital(r.Vitals) {
+		return r, false, "no vitals"
+	}
+	if !validate.VitalsValid(r.Vitals) {
+		r.Vitals = validate.ClampVitals(r.Vitals)
+	}
+	r.ResourceCount = validate.ResourceCount(r.ResourceCount, p.MaxResources)
+	return r, true, ""
+}
+
+// EngineScore is the default scorer.
+func EngineScore(eng *triagegeist.Engine, r Record) export.Result {
+	e := eng.Evaluate(r.Vitals, r.ResourceCount)
+	res := export.FromVitalsScoreLevel(r.Vitals, r.ResourceCount, e.Acuity, e.Level.Int(), e.Level.String())
+	res.ID = r.ID
+	return res
+}
+
+// Run executes the pipeline once. It stops at the first error from Source or
+// Report.
+func (p *Pipeline) Run() (Output, error) {
+	var out Output
+	if p.Source == nil {
+		return out, ErrNoSource
+	}
+	eng := p.Engine
+	if eng == nil {
+		eng = triagegeist.NewDefaultEngine()
+	}
+	check := p.Validate
+	if check == nil {
+		check = ClampValidate
+	}
+	scoreFn := p.Score
+	if scoreFn == nil {
+		scoreFn = EngineScore
+	}
+
+	recs, err := p.Source()
+	if err != nil {
+		return out, err
+	}
+	var acuities []float64
+	var pred, ref []int
+	for _, r := range recs {
+		start := time.Now()
+		r, ok, reason := check(r, eng.P)
+		eng.Instruments.ObserveStage(triagegeist.StageValidate, time.Since(start))
+		if !ok {
+			out.Rejected = append(out.Rejected, Rejected{Record: r, Reason: reason})
+			continue
+		}
+		res := scoreFn(eng, r)
+		out.Results = append(out.Results, res)
+		acuities = append(acuities, res.Acuity)
+		if r.Reference >= 1 && r.Reference <= 5 {
+			pred = append(pred, res.Level)
+			ref = append(ref, r.Reference)
+		}
+	}
+
+	out.Scores = stats.ComputeScoreStats(acuities)
+	out.Levels = export.LevelReport(out.Results)
+	out.Summary = export.ComputeSummary(out.Results)
+	if len(ref) > 0 {
+		cm := metrics.NewConfusionMatrix(pred, ref)
+		out.Confusion = &cm
+		out.Kappa = cm.CohenKappa()
+	}
+
+	if p.Report != nil {
+		if err := p.Report(out); err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,220 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package pipeline
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olaflaitinen/triagegeist"
+	"github.com/olaflaitinen/triagegeist/export"
+)
+
+const sampleCSV = `id,hr,rr,sbp,dbp,temp,spo2,gcs,resource_count,reference_level
+a,130,28,85,,,88,,4,1
+b,80,16,120,80,37.0,98,15,1,4
+c,,,,,,,,2,3
+d,350,16,120,80,,98,,9,
+`
+
+func TestPipeline_CSV(t *testing.T) {
+	var reported bool
+	p := Pipeline{
+		Engine: triagegeist.NewDefaultEngine().WithInstruments(0),
+		Source: CSVSource(strings.NewReader(sampleCSV)),
+		Report: func(out Output) error { reported = true; return nil },
+	}
+	out, err := p.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Results) != 3 || len(out.Rejected) != 1 || out.Rejected[0].Record.ID != "c" {
+		t.Fatalf("results %d, rejected %+v", len(out.Results), out.Rejected)
+	}
+	if d := out.Results[2]; d.HR != 300 || d.ResourceCount != 6 {
+		t.Errorf("record d not clamped: %+v", d)
+	}
+	if out.Confusion == nil || out.Confusion.Total != 2 {
+		t.Errorf("confusion over referenced records: %+v", out.Confusion)
+	}
+	if out.Summary.N != 3 || out.Scores.N != 3 || !reported {
+		t.Errorf("summary %+v, scores %+v, reported %v", out.Summary, out.Scores, reported)
+	}
+	if s := p.Engine.Instruments.Snapshot(); s.Stages[triagegeist.StageValidate].Count != 4 || s.Evaluations != 3 {
+		t.Errorf("stages: validate %d, evaluate %d", s.Stages[triagegeist.StageValidate].Count, s.Evaluations)
+	}
+}
+
+func TestCSVSource_ReadsExportCSV(t *testing.T) {
+	out, err := (&Pipeline{Source: CSVSource(strings.NewReader(sampleCSV))}).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := export.WriteCSV(&buf, out.Results); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := CSVSource(&buf)()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != len(out.Results) || recs[0].ID != "a" || recs[0].Vitals.SpO2 != 88 || recs[0].ResourceCount != 4 {
+		t.Errorf("round trip = %+v", recs)
+	}
+}
+
+func TestPipeline_CustomStages(t *testing.T) {
+	p := Pipeline{
+		Source: func() ([]Record, error) { return []Record{{ID: "x"}}, nil },
+		Validate: func(r Record, _ triagegeist.Params) (Record, bool, string) {
+			return r, true, ""
+		},
+		Score: func(_ *triagegeist.Engine, r Record) export.Result {
+			return export.Result{ID: r.ID, Level: 5}
+		},
+	}
+	out, err := p.Run()
+	if err != nil || len(out.Results) != 1 || out.Results[0].ID != "x" {
+		t.Errorf("custom stages: %+v, %v", out.Results, err)
+	}
+	if _, err := (&Pipeline{}).Run(); !errors.Is(err, ErrNoSource) {
+		t.Errorf("err = %v, want ErrNoSource", err)
+	}
+	bad := Pipeline{Source: CSVSource(strings.NewReader("hr\nx\n"))}
+	if _, err := bad.Run(); err == nil {
+		t.Error("malformed CSV should fail")
+	}
+}
+
+const sampleBundle = `{
+  "resourceType": "Bundle",
+  "entry": [
+    {"resource": {"resourceType": "Patient", "id": "p1"}},
+    {"resource": {"resourceType": "Observation",
+      "code": {"coding": [{"system": "http://loinc.org", "code": "8867-4"}]},
+      "encounter": {"reference": "Encounter/1"},
+      "valueQuantity": {"value": 118, "code": "/min"}}},
+    {"resource": {"resourceType": "Observation",
+      "code": {"coding": [{"system": "http://loinc.org", "code": "85354-9"}]},
+      "encounter": {"reference": "Encounter/1"},
+      "component": [
+        {"code": {"coding": [{"system": "http://loinc.org", "code": "8480-6"}]}, "valueQuantity": {"value": 92}},
+        {"code": {"coding": [{"system": "http://loinc.org", "code": "8462-4"}]}, "valueQuantity": {"value": 58}}
+      ]}},
+    {"resource": {"resourceType": "Observation",
+      "code": {"coding": [{"system": "http://loinc.org", "code": "8310-5"}]},
+      "subject": {"reference": "Patient/p2"},
+      "valueQuantity": {"value": 102.2, "code": "[degF]"}}}
+  ]
+}`
+
+func TestFHIRBundleSource(t *testing.T) {
+	recs, err := FHIRBundleSource(strings.NewReader(sampleBundle))()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("got %d records, want 2", len(recs))
+	}
+	v := recs[0].Vitals
+	if recs[0].ID != "Encounter/1" || v.HR != 118 || v.SBP != 92 || v.DBP != 58 {
+		t.Errorf("encounter record = %+v", recs[0])
+	}
+	if got := recs[1].Vitals.Temp; got < 38.99 || got > 39.01 {
+		t.Errorf("Temp = %v, want 39 C", got)
+	}
+	kpa := strings.Replace(sampleBundle, `"valueQuantity": {"value": 92}`, `"valueQuantity": {"value": 12.3, "code": "kPa"}`, 1)
+	if recs, err := FHIRBundleSource(strings.NewReader(kpa))(); err != nil || recs[0].Vitals.SBP != 92 {
+		t.Errorf("SBP in kPa = %+v, %v; want 92 mmHg", recs, err)
+	}
+	if _, err := FHIRBundleSource(strings.NewReader(`{"resourceType":"Patient"}`))(); err == nil {
+		t.Error("non-Bundle should fail")
+	}
+}
+
+func TestBuffer_Policies(t *testing.T) {
+	rec := func(i int) Record { return Record{ID: strconv.Itoa(i), ResourceCount: i % 7} }
+	ids := func(b *Buffer) string {
+		var s []string
+		for {
+			r, ok := b.TryGet()
+			if !ok {
+				return strings.Join(s, ",")
+			}
+			s = append(s, r.ID)
+		}
+	}
+
+	var audited []string
+	d, err := NewBuffer(BufferOptions{Capacity: 3, Policy: DropOldest, OnDrop: func(d Drop) { audited = append(audited, d.Record.ID) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		d.Put(rec(i))
+	}
+	if got := ids(d); got != "2,3,4" || strings.Join(audited, ",") != "0,1" || len(d.Drops()) != 2 || d.Stats().Dropped != 2 {
+		t.Errorf("DropOldest: got %s, audited %v, stats %+v", got, audited, d.Stats())
+	}
+
+	path := filepath.Join(t.TempDir(), "spill.jsonl")
+	s, err := NewBuffer(BufferOptions{Capacity: 2, Policy: Spill, SpillPath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 6; i++ {
+		if err := s.Put(rec(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if st := s.Stats(); st.Len != 2 || st.Spilled != 4 || st.Accepted != 6 {
+		t.Errorf("Spill stats = %+v", st)
+	}
+	// A failed write is cut back to spillEnd, the end of the last
+	// complete line.
+	if fi, err := os.Stat(path); err != nil || fi.Size() != s.spillEnd {
+		t.Errorf("spill file %+v, complete lines end at %d: %v", fi, s.spillEnd, err)
+	}
+	if r, _ := s.Get(); r.ID != "0" {
+		t.Errorf("first = %+v", r)
+	}
+	s.Put(rec(6))
+	s.Close()
+	if err := s.Put(rec(7)); err != ErrBufferClosed {
+		t.Errorf("Put after Close: %v", err)
+	}
+	if got := ids(s); got != "1,2,3,4,5,6" {
+		t.Errorf("Spill order = %s", got)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("spill file not removed: %v", err)
+	}
+	if _, err := NewBuffer(BufferOptions{Policy: Spill}); err == nil {
+		t.Error("Spill without a path should fail")
+	}
+
+	b, _ := NewBuffer(BufferOptions{Capacity: 1})
+	b.Put(rec(0))
+	done := make(chan error)
+	go func() { done <- b.Put(rec(1)) }()
+	for b.Stats().Blocked == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if r, _ := b.Get(); r.ID != "0" {
+		t.Errorf("Block first = %+v", r)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	out, err := (&Pipeline{Source: b.Source()}).Run()
+	if err != nil || len(out.Rejected) != 1 || out.Rejected[0].Record.ID != "1" {
+		t.Errorf("Source: %+v, %v", out.Rejected, err)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ActionPolicy holds institution-specific wait-time targets and recommended
+// actions per level (index 1..5; index 0 unused). Start from
+// DefaultActionPolicy and override what your protocol changes.
+type ActionPolicy struct {
+	WaitMinutes [6]int
+	Actions     [6][]string
+}
+
+// DefaultActionPolicy returns the policy of Level.WaitTimeMinutes and
+// Level.RecommendedActions (0/15/60/120/240 minutes).
+func DefaultActionPolicy() ActionPolicy {
+	var ap ActionPolicy
+	for _, l := range AllLevels() {
+		ap.WaitMinutes[l] = l.WaitTimeMinutes()
+		ap.Actions[l] = l.RecommendedActions()
+	}
+	return ap
+}
+
+// Validate returns an error naming every negative wait target and every
+// target that is shorter than the one of the more acute level before it,
+// or nil if targets are >= 0 and do not decrease from level 1 to level 5.
+func (ap ActionPolicy) Validate() error {
+	var errs []error
+	for l := 1; l <= 5; l++ {
+		w := ap.WaitMinutes[l]
+		switch {
+		case w < 0:
+			errs = append(errs, fmt.Errorf("WaitMinutes[%d] = %d is negative", l, w))
+		case l > 1 && w < ap.WaitMinutes[l-1]:
+			errs = append(errs, fmt.Errorf("WaitMinutes[%d] = %d is below WaitMinutes[%d] = %d", l, w, l-1, ap.WaitMinutes[l-1]))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("triagegeist: action policy: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Valid returns true if Validate returns nil.
+func (ap ActionPolicy) Valid() bool {
+	return ap.Validate() == nil
+}
+
+// WaitTimeMinutes returns the wait target for l. Unknown levels get the
+// level 5 target, as Level.WaitTimeMinutes does; for a result, which may
+// have no level, use WaitTimeMinutesFor.
+func (ap ActionPolicy) WaitTimeMinutes(l Level) int {
+	if !l.Valid() {
+		return ap.WaitMinutes[Level5NonUrgent]
+	}
+	return ap.WaitMinutes[l]
+}
+
+// WaitTimeMinutesFor returns the wait target for r: 0 if r is Insufficient
+// (reassess now), the more acute candidate's target if r is Deferred, and
+// otherwise WaitTimeMinutes(r.Level).
+func (ap ActionPolicy) WaitTimeMinutesFor(r EvaluateResult) int {
+	if r.Insufficient != "" {
+		return 0
+	}
+	if r.Deferred && r.Candidates[0].Valid() {
+		return ap.WaitTimeMinutes(r.Candidates[0])
+	}
+	return ap.WaitTimeMinutes(r.Level)
+}
+
+// RecommendedActions returns a copy of the actions for l, or nil for an
+// unknown level.
+func (ap ActionPolicy) RecommendedActions(l Level) []string {
+	if !l.Valid() {
+		return nil
+	}
+	return append([]string(nil), ap.Actions[l]...)
+}
+
+// WithActionPolicy returns a new Engine using ap for WaitTimeMinutes and
+// RecommendedActions. The receiver is unchanged. It returns ap.Validate's
+// error, and no engine, if ap is invalid.
+func (e *Engine) WithActionPolicy(ap ActionPolicy) (*Engine, error) {
+	if err := ap.Validate(); err != nil {
+		return nil, err
+	}
+	c := *e
+	c.Actions = &ap
+	return &c, nil
+}
+
+// WaitTimeMinutes returns the wait target for l under the engine's
+// ActionPolicy, or Level.WaitTimeMinutes if none is set.
+func (e *Engine) WaitTimeMinutes(l Level) int {
+	if e.Actions == nil {
+		return l.WaitTimeMinutes()
+	}
+	return e.Actions.WaitTimeMinutes(l)
+}
+
+// WaitTimeMinutesFor returns ActionPolicy.WaitTimeMinutesFor(r) under the
+// engine's ActionPolicy, or DefaultActionPolicy if none is set.
+func (e *Engine) WaitTimeMinutesFor(r EvaluateResult) int {
+	ap := DefaultActionPolicy()
+	if e.Actions != nil {
+		ap = *e.Actions
+	}
+	return ap.WaitTimeMinutesFor(r)
+}
+
+// RecommendedActions returns the actions for l under the engine's
+// ActionPolicy, or Level.RecommendedActions if none is set.
+func (e *Engine) RecommendedActions(l Level) []string {
+	if e.Actions == nil {
+		return l.RecommendedActions()
+	}
+	return e.Actions.RecommendedActions(l)
+}
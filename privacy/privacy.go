@@ -0,0 +1,243 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+//
+// Package privacy provides differential privacy (DP) helpers for sharing
+// aggregate triage reports outside the originating site. Small cells (e.g.
+// n < 5 patients at one level) can identify individuals; adding calibrated
+// noise before release bounds what any single record reveals.
+//
+// # Mechanisms
+//
+//	| Mechanism | Noise                              | Guarantee | Sensitivity |
+//	|-----------|------------------------------------|-----------|-------------|
+//	| Laplace   | Lap(0, Δ/ε)                        | ε-DP      | L1          |
+//	| Gaussian  | N(0, σ²), σ = Δ·√(2 ln(1.25/δ))/ε  | (ε, δ)-DP | L2          |
+//
+// The classic Gaussian calibration only holds for ε < 1, so a Gaussian
+// Mechanism with ε >= 1 is invalid; use Laplace or split the release.
+//
+// # Accounting
+//
+// A Budget tracks total ε and δ spent under sequential composition. Each
+// release (NoisyLevelReport, NoisySummary) spends the mechanism's ε and δ
+// once; the release is refused if the budget would be exceeded.
+//
+// Noise is drawn from the caller's *rand.Rand so results are reproducible in
+// tests. For production releases seed the source from crypto/rand.
+package privacy
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/olaflaitinen/triagegeist/export"
+)
+
+// Budget tracks privacy loss under sequential composition. Not safe for
+// concurrent use; guard with a mutex if shared.
+type Budget struct {
+	Epsilon float64 // Total ε available
+	Delta   float64 // Total δ available
+	spentE  float64
+	spentD  float64
+}
+
+// NewBudget returns a Budget with the given total ε and δ.
+func NewBudget(epsilon, delta float64) *Budget {
+	return &Budget{Epsilon: epsilon, Delta: delta}
+}
+
+// Spend records a release costing (epsilon, delta). Returns false and
+// records nothing if the release would exceed the remaining budget.
+func (b *Budget) Spend(epsilon, delta float64) bool {
+	if epsilon < 0 || delta < 0 {
+		return false
+	}
+	if b.spentE+epsilon > b.Epsilon || b.spentD+delta > b.Delta {
+		return false
+	}
+	b.spentE += epsilon
+	b.spentD += delta
+	return true
+}
+
+// Spent returns the ε and δ spent so far.
+func (b *Budget) Spent() (epsilon, delta float64) {
+	return b.spentE, b.spentD
+}
+
+// Remaining returns the ε and δ still available.
+func (b *Budget) Remaining() (epsilon, delta float64) {
+	return b.Epsilon - b.spentE, b.Delta - b.spentD
+}
+
+// Laplace returns one draw from Lap(0, scale). Returns 0 if scale <= 0.
+// The draw is always finite.
+func Laplace(rng *rand.Rand, scale float64) float64 {
+	if scale <= 0 {
+		return 0
+	}
+	// u is in [-0.5, 0.5); keep it off both ends so the logarithm is finite.
+	u := math.Min(math.Max(rng.Float64()-0.5, math.Nextafter(-0.5, 0)), math.Nextafter(0.5, 0))
+	if u < 0 {
+		return scale * math.Log(1+2*u)
+	}
+	return -scale * math.Log(1-2*u)
+}
+
+// GaussianSigma returns the noise standard deviation for the classic
+// Gaussian mechanism: σ = Δ·sqrt(2·ln(1.25/δ))/ε. Valid for 0 < ε < 1;
+// returns 0 if epsilon, delta or sensitivity are out of range.
+func GaussianSigma(sensitivity, epsilon, delta float64) float64 {
+	if sensitivity <= 0 || epsilon <= 0 || epsilon >= 1 || delta <= 0 || delta >= 1 {
+		return 0
+	}
+	return sensitivity * math.Sqrt(2*math.Log(1.25/delta)) / epsilon
+}
+
+// Mechanism selects the noise distribution and its privacy parameters.
+// Delta is used only when Gaussian is true.
+type Mechanism struct {
+	Gaussian bool
+	Epsilon  float64
+	Delta    float64
+}
+
+// LaplaceMechanism returns an ε-DP Laplace mechanism.
+func LaplaceMechanism(epsilon float64) Mechanism {
+	return Mechanism{Epsilon: epsilon}
+}
+
+// GaussianMechanism returns an (ε, δ)-DP Gaussian mechanism.
+func GaussianMechanism(epsilon, delta float64) Mechanism {
+	return Mechanism{Gaussian: true, Epsilon: epsilon, Delta: delta}
+}
+
+// Valid returns true if the mechanism parameters are admissible: ε > 0 and
+// finite, and for Gaussian 0 < ε < 1 and 0 < δ < 1.
+func (m Mechanism) Valid() bool {
+	if m.Epsilon <= 0 || math.IsInf(m.Epsilon, 0) || math.IsNaN(m.Epsilon) {
+		return false
+	}
+	if m.Gaussian {
+		return m.Epsilon < 1 && m.Delta > 0 && m.Delta < 1
+	}
+	return true
+}
+
+// cost returns the (ε, δ) charged for one release.
+func (m Mechanism) cost() (float64, float64) {
+	if m.Gaussian {
+		return m.Epsilon, m.Delta
+	}
+	return m.Epsilon, 0
+}
+
+// Noise returns one noise draw for a query with the given sensitivity.
+func (m Mechanism) Noise(rng *rand.Rand, sensitivity float64) float64 {
+	if m.Gaussian {
+		return rng.NormFloat64() * GaussianSigma(sensitivity, m.Epsilon, m.Delta)
+	}
+	if m.Epsilon <= 0 {
+		return 0
+	}
+	return Laplace(rng, sensitivity/m.Epsilon)
+}
+
+// Add returns value plus one noise draw for the given sensitivity.
+func (m Mechanism) Add(rng *rand.Rand, value, sensitivity float64) float64 {
+	return value + m.Noise(rng, sensitivity)
+}
+
+// noisyCount adds noise to a count, rounds and clamps to >= 0.
+func noisyCount(m Mechanism, rng *rand.Rand, c int) int {
+	n := math.Round(m.Add(rng, float64(c), 1))
+	if n < 0 {
+		return 0
+	}
+	return int(n)
+}
+
+// noisyMean divides a noisy sum of acuities (each in [0, 1]) by a noisy count
+// and clamps to [0, 1].
+func noisyMean(m Mechanism, rng *rand.Rand, sum float64, count int) float64 {
+	if count <= 0 {
+		return 0
+	}
+	mean := m.Add(rng, sum, 1) / float64(count)
+	if mean < 0 {
+		return 0
+	}
+	if mean > 1 {
+		return 1
+	}
+	return mean
+}
+
+// split returns m with ε (and δ) divided evenly over k sub-queries.
+func (m Mechanism) split(k int) Mechanism {
+	q := m
+	q.Epsilon /= float64(k)
+	q.Delta /= float64(k)
+	return q
+}
+
+// NoisyLevelReport returns a differentially private copy of rows (as built by
+// export.LevelReport). Half the budget goes to per-level counts (a histogram,
+// sensitivity 1 under add/remove of one patient) and half to per-level acuity
+// sums (sensitivity 1, since acuity is in [0, 1]). Pct and MeanAcuity are
+// recomputed from the noisy values. MinAcuity and MaxAcuity have unbounded
+// sensitivity and are set to 0.
+//
+// Charges m's (ε, δ) to b once. Returns (nil, false) if m is invalid or the
+// budget is insufficient.
+func NoisyLevelReport(rows []export.ReportRow, m Mechanism, b *Budget, rng *rand.Rand) ([]export.ReportRow, bool) {
+	if !m.Valid() {
+		return nil, false
+	}
+	if e, d := m.cost(); !b.Spend(e, d) {
+		return nil, false
+	}
+	half := m.split(2)
+	out := make([]export.ReportRow, len(rows))
+	var total int
+	for i, r := range rows {
+		sum := r.MeanAcuity * float64(r.Count)
+		out[i] = export.ReportRow{
+			Level:      r.Level,
+			LevelLabel: r.LevelLabel,
+			Count:      noisyCount(half, rng, r.Count),
+		}
+		out[i].MeanAcuity = noisyMean(half, rng, sum, out[i].Count)
+		total += out[i].Count
+	}
+	if total > 0 {
+		for i := range out {
+			out[i].Pct = float64(out[i].Count) / float64(total) * 100
+		}
+	}
+	return out, true
+}
+
+// NoisySummary returns a differentially private copy of s (as built by
+// export.ComputeSummary). The budget is split evenly over N, the level
+// histogram, and the acuity sum. MinAcuity and MaxAcuity are set to 0.
+//
+// Charges m's (ε, δ) to b once. Returns (Summary{}, false) if m is invalid or
+// the budget is insufficient.
+func NoisySummary(s export.Summary, m Mechanism, b *Budget, rng *rand.Rand) (export.Summary, bool) {
+	if !m.Valid() {
+		return export.Summary{}, false
+	}
+	if e, d := m.cost(); !b.Spend(e, d) {
+		return export.Summary{}, false
+	}
+	third := m.split(3)
+	var out export.Summary
+	out.N = noisyCount(third, rng, s.N)
+	for i := 1; i <= 5; i++ {
+		out.LevelDist[i] = noisyCount(third, rng, s.LevelDist[i])
+	}
+	out.MeanAcuity = noisyMean(third, rng, s.MeanAcuity*float64(s.N), out.N)
+	return out, true
+}
@@ -0,0 +1,99 @@
+package privacy
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/olaflaitinen/triagegeist/export"
+)
+
+func TestBudget_Spend(t *testing.T) {
+	b := NewBudget(1.0, 1e-5)
+	if !b.Spend(0.6, 0) {
+		t.Fatal("first spend should succeed")
+	}
+	if b.Spend(0.6, 0) {
+		t.Error("second spend should exceed budget")
+	}
+	if e, _ := b.Remaining(); math.Abs(e-0.4) > 1e-12 {
+		t.Errorf("Remaining epsilon = %v, want 0.4", e)
+	}
+}
+
+func TestLaplace_Scale(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var sumAbs float64
+	const n = 20000
+	for i := 0; i < n; i++ {
+		sumAbs += math.Abs(Laplace(rng, 2))
+	}
+	// E|X| = scale for Laplace
+	if m := sumAbs / n; math.Abs(m-2) > 0.1 {
+		t.Errorf("mean |Laplace(2)| = %v, want ~2", m)
+	}
+	// A uniform draw of exactly 0 must not give -Inf.
+	if x := Laplace(rand.New(zeroSource{}), 2); math.IsInf(x, 0) || math.IsNaN(x) || x >= 0 {
+		t.Errorf("Laplace at u = 0: %v", x)
+	}
+}
+
+// zeroSource is a rand.Source whose every draw is 0.
+type zeroSource struct{}
+
+func (zeroSource) Int63() int64 { return 0 }
+func (zeroSource) Seed(int64)   {}
+
+func TestNoisyLevelReport(t *testing.T) {
+	rows := export.LevelReport([]export.Result{
+		{Level: 1, Acuity: 0.9}, {Level: 2, Acuity: 0.7}, {Level: 2, Acuity: 0.65},
+	})
+	b := NewBudget(1, 0)
+	rng := rand.New(rand.NewSource(7))
+	out, ok := NoisyLevelReport(rows, LaplaceMechanism(1), b, rng)
+	if !ok || len(out) != 5 {
+		t.Fatalf("NoisyLevelReport ok=%v len=%d", ok, len(out))
+	}
+	for _, r := range out {
+		if r.Count < 0 || r.MeanAcuity < 0 || r.MeanAcuity > 1 || r.MinAcuity != 0 {
+			t.Errorf("row out of range: %+v", r)
+		}
+	}
+	if _, ok := NoisyLevelReport(rows, LaplaceMechanism(1), b, rng); ok {
+		t.Error("second release should exceed budget")
+	}
+}
+
+func TestNoisySummary_Gaussian(t *testing.T) {
+	s := export.Summary{N: 100, MeanAcuity: 0.4}
+	s.LevelDist[3] = 100
+	b := NewBudget(1, 1e-5)
+	out, ok := NoisySummary(s, GaussianMechanism(0.9, 1e-6), b, rand.New(rand.NewSource(3)))
+	if !ok || out.N <= 0 {
+		t.Fatalf("NoisySummary ok=%v %+v", ok, out)
+	}
+	if _, ok := NoisySummary(s, GaussianMechanism(0.9, 0), NewBudget(1, 1), rand.New(rand.NewSource(3))); ok {
+		t.Error("Gaussian with delta 0 should be refused")
+	}
+}
+
+func TestMechanism_ValidGaussianEpsilon(t *testing.T) {
+	below := math.Nextafter(1, 0)
+	for _, c := range []struct {
+		eps  float64
+		want bool
+	}{{0, false}, {math.SmallestNonzeroFloat64, true}, {below, true}, {1, false}, {2, false}} {
+		if got := GaussianMechanism(c.eps, 1e-6).Valid(); got != c.want {
+			t.Errorf("GaussianMechanism(%v).Valid() = %v, want %v", c.eps, got, c.want)
+		}
+		if got := GaussianSigma(1, c.eps, 1e-6) > 0; got != c.want {
+			t.Errorf("GaussianSigma(1, %v, 1e-6) > 0 = %v, want %v", c.eps, got, c.want)
+		}
+	}
+	if !LaplaceMechanism(2).Valid() {
+		t.Error("Laplace is valid for any finite epsilon > 0")
+	}
+	if _, ok := NoisySummary(export.Summary{N: 1}, GaussianMechanism(1, 1e-6), NewBudget(2, 1), rand.New(rand.NewSource(3))); ok {
+		t.Error("Gaussian with epsilon 1 should be refused")
+	}
+}
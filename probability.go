@@ -0,0 +1,116 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"math"
+	"sort"
+)
+
+// CalibrationMethod names the mapping of a ProbabilityCalibration.
+type CalibrationMethod string
+
+const (
+	// CalibrationPlatt is Platt scaling: p = 1 / (1 + e^-(A·s + B)).
+	CalibrationPlatt CalibrationMethod = "platt"
+	// CalibrationIsotonic is a non-decreasing piecewise-linear map through
+	// the knots (X[i], Y[i]), constant beyond the first and last knot.
+	CalibrationIsotonic CalibrationMethod = "isotonic"
+)
+
+// ProbabilityCalibration maps an acuity score to the calibrated probability
+// of a high-acuity outcome (e.g. admission to critical care), as fitted to
+// the site's labeled outcomes by calibrate.FitPlatt or calibrate.FitIsotonic.
+// The score ranks patients; the calibrated probability can be read as a
+// risk: "0.62" becomes "31% risk of critical care".
+//
+//	| Method   | Fields | Valid when                                        |
+//	|----------|--------|---------------------------------------------------|
+//	| platt    | A, B   | A and B finite                                    |
+//	| isotonic | X, Y   | Equal length >= 1, X increasing, Y non-decreasing |
+//	|          |        | and in [0, 1]                                     |
+type ProbabilityCalibration struct {
+	Method CalibrationMethod `json:"method"`
+	A      float64           `json:"a,omitempty"`
+	B      float64           `json:"b,omitempty"`
+	X      []float64         `json:"x,omitempty"`
+	Y      []float64         `json:"y,omitempty"`
+	// N is the number of labeled records fitted.
+	N int `json:"n"`
+}
+
+// Valid returns true if c is a well-formed calibration of a known method.
+func (c ProbabilityCalibration) Valid() bool {
+	finite := func(x float64) bool { return !math.IsNaN(x) && !math.IsInf(x, 0) }
+	switch c.Method {
+	case CalibrationPlatt:
+		return finite(c.A) && finite(c.B)
+	case CalibrationIsotonic:
+		if len(c.X) == 0 || len(c.X) != len(c.Y) {
+			return false
+		}
+		for i := range c.X {
+			if !finite(c.X[i]) || !(c.Y[i] >= 0 && c.Y[i] <= 1) {
+				return false
+			}
+			if i > 0 && (c.X[i] <= c.X[i-1] || c.Y[i] < c.Y[i-1]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Equal returns true if c and o have the same method, coefficients, knots
+// and N.
+func (c ProbabilityCalibration) Equal(o ProbabilityCalibration) bool {
+	if c.Method != o.Method || c.A != o.A || c.B != o.B || c.N != o.N {
+		return false
+	}
+	if len(c.X) != len(o.X) || len(c.Y) != len(o.Y) {
+		return false
+	}
+	for i := range c.X {
+		if c.X[i] != o.X[i] {
+			return false
+		}
+	}
+	for i := range c.Y {
+		if c.Y[i] != o.Y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Probability returns the calibrated probability of a high-acuity outcome
+// at acuity s, in [0, 1]. Returns 0 if c is not Valid or s is NaN.
+func (c ProbabilityCalibration) Probability(s float64) float64 {
+	if !c.Valid() || math.IsNaN(s) {
+		return 0
+	}
+	if c.Method == CalibrationPlatt {
+		return 1 / (1 + math.Exp(-(c.A*s + c.B)))
+	}
+	n := len(c.X)
+	i := sort.SearchFloat64s(c.X, s)
+	switch {
+	case i == n:
+		return c.Y[n-1]
+	case c.X[i] == s || i == 0:
+		return c.Y[i]
+	}
+	t := (s - c.X[i-1]) / (c.X[i] - c.X[i-1])
+	return c.Y[i-1] + t*(c.Y[i]-c.Y[i-1])
+}
+
+// annotateProbability records r's calibrated probability under p, if p has
+// a calibration.
+func annotateProbability(r EvaluateResult, p Params) EvaluateResult {
+	if p.Calibration != nil {
+		r.Probability = p.Calibration.Probability(r.Acuity)
+	}
+	return r
+}
@@ -0,0 +1,193 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import "github.com/olaflaitinen/triagegeist/norm"
+
+// PatientContext carries patient attributes used to choose a norm profile
+// for an evaluation. The zero value selects the adult profile.
+type PatientContext struct {
+	AgeYears float64 // Age in years (fractional for infants); 0 = unknown
+	Pregnant bool
+	// GestationalWeeks selects trimester-specific obstetric ranges; a
+	// positive value implies Pregnant. 0 = unknown or not pregnant.
+	GestationalWeeks int
+	// Frailty in [0, 1] adjusts the geriatric ranges (see
+	// ProfileSelectorWithFrailty), e.g. norm.FrailtyFromCFS of the
+	// Clinical Frailty Scale score. 0 = not frail or unknown.
+	Frailty float64
+}
+
+// Profile names used by DefaultProfileSelector.
+const (
+	ProfileAdult      = "adult"
+	ProfileNeonate    = "pediatric-neonate"
+	ProfileInfant     = "pediatric-infant"
+	ProfileChild      = "pediatric-child"
+	ProfileAdolescent = "pediatric-adolescent"
+	ProfileGeriatric  = "geriatric"
+	// ProfileGeriatricFrail is the geriatric profile adjusted for
+	// PatientContext.Frailty.
+	ProfileGeriatricFrail = "geriatric-frail"
+	ProfileObstetric      = "obstetric"
+
+	ProfileObstetricT1 = "obstetric-t1"
+	ProfileObstetricT2 = "obstetric-t2"
+	ProfileObstetricT3 = "obstetric-t3"
+)
+
+// Profile is a named set of reference ranges used for deviation scoring,
+// with optional parameter and score adjustments.
+type Profile struct {
+	Name   string
+	Ranges norm.Ranges
+	// VitalWeights and Thresholds, when non-nil, replace those of the
+	// engine's Params for this profile; every other setting (resources,
+	// gray zone, hysteresis, extensions) is kept.
+	VitalWeights *[7]float64
+	Thresholds   *[4]float64
+	// Params replaces the engine's Params for this profile when non-nil,
+	// overriding VitalWeights and Thresholds. Use it only for a complete
+	// site calibration (e.g. calibrate.Bootstrap.Profile).
+	Params *Params
+	// ScoreFactor multiplies the acuity score (then clamped to 1) to
+	// compensate for blunted vital-sign responses. 0 means 1 (none).
+	ScoreFactor float64
+}
+
+// ParamsFor returns the Params to score this profile with on an engine
+// using base: *prof.Params if set, otherwise base with the profile's
+// VitalWeights and Thresholds overlaid.
+func (prof Profile) ParamsFor(base Params) Params {
+	if prof.Params != nil {
+		return *prof.Params
+	}
+	if prof.VitalWeights != nil {
+		base.VitalWeights = *prof.VitalWeights
+	}
+	if prof.Thresholds != nil {
+		base.T1, base.T2, base.T3, base.T4 = prof.Thresholds[0], prof.Thresholds[1], prof.Thresholds[2], prof.Thresholds[3]
+	}
+	return base
+}
+
+// presetOverlay sets prof's VitalWeights and Thresholds from p.
+func presetOverlay(prof Profile, p Params) Profile {
+	w, t := p.VitalWeights, p.Thresholds()
+	prof.VitalWeights, prof.Thresholds = &w, &t
+	return prof
+}
+
+// ProfileSelector chooses the Profile for one evaluation. Implementations
+// must be safe for concurrent use.
+type ProfileSelector interface {
+	Select(ctx PatientContext) Profile
+}
+
+// ProfileSelectorFunc adapts a function to ProfileSelector.
+type ProfileSelectorFunc func(ctx PatientContext) Profile
+
+// Select calls f(ctx).
+func (f ProfileSelectorFunc) Select(ctx PatientContext) Profile {
+	return f(ctx)
+}
+
+// GeriatricAgeYears is the age from which DefaultProfileSelector chooses
+// the geriatric profile.
+const GeriatricAgeYears = 65
+
+// Geriatric compensation: from CompensationAgeYears the score factor rises by
+// CompensationPerYear per year of age, up to MaxCompensation.
+const (
+	CompensationAgeYears = 75
+	CompensationPerYear  = 0.01
+	MaxCompensation      = 1.15
+)
+
+// GeriatricCompensation returns the score factor for ageYears: 1 below
+// CompensationAgeYears, then 1 + CompensationPerYear per year, capped at
+// MaxCompensation. Patients aged 75 and over are systematically under-triaged
+// by adult norms because tachycardia and fever are often absent.
+func GeriatricCompensation(ageYears float64) float64 {
+	if ageYears < CompensationAgeYears {
+		return 1
+	}
+	f := 1 + (ageYears-CompensationAgeYears)*CompensationPerYear
+	if f > MaxCompensation {
+		return MaxCompensation
+	}
+	return f
+}
+
+// DefaultProfileSelector returns the built-in selector:
+//
+//	| Condition               | Profile              | Ranges                       |
+//	|-------------------------|----------------------|------------------------------|
+//	| GestationalWeeks > 0    | obstetric-t1..t3     | norm.ObstetricRangesForWeeks |
+//	| Pregnant, weeks unknown | obstetric            | norm.ObstetricRanges         |
+//	| 0 < age < 28 days       | pediatric-neonate    | norm.NeonatalRanges          |
+//	| 28 days <= age < 1      | pediatric-infant     | norm.InfantRanges            |
+//	| 1 <= age < 12           | pediatric-child      | norm.PediatricRanges         |
+//	| 12 <= age < 18          | pediatric-adolescent | norm.AdolescentRanges        |
+//	| age >= 65               | geriatric            | norm.GeriatricRanges         |
+//	| age >= 65, Frailty > 0  | geriatric-frail      | norm.FrailtyAdjust of above  |
+//	| otherwise (age unknown) | adult                | norm.DefaultRanges           |
+//
+// The pediatric profiles also use the weights and thresholds of
+// PresetPediatric, and the geriatric profile those of PresetGeriatric and a
+// ScoreFactor of GeriatricCompensation(age); the engine's other Params are
+// kept (see Profile.ParamsFor).
+func DefaultProfileSelector() ProfileSelector {
+	return ProfileSelectorFunc(selectDefaultProfile)
+}
+
+// ProfileSelectorWithFrailty returns DefaultProfileSelector with f in place
+// of norm.FrailtyAdjust as the frailty hook: for a geriatric patient with
+// PatientContext.Frailty > 0 the profile is geriatric-frail and its ranges
+// are f(norm.GeriatricRanges(), Frailty). A nil f ignores frailty.
+func ProfileSelectorWithFrailty(f norm.FrailtyFunc) ProfileSelector {
+	return ProfileSelectorFunc(func(ctx PatientContext) Profile {
+		return selectProfile(ctx, f)
+	})
+}
+
+func selectDefaultProfile(ctx PatientContext) Profile {
+	return selectProfile(ctx, norm.FrailtyAdjust)
+}
+
+func selectProfile(ctx PatientContext, frailty norm.FrailtyFunc) Profile {
+	switch {
+	case ctx.GestationalWeeks > 0:
+		names := [4]string{ProfileObstetric, ProfileObstetricT1, ProfileObstetricT2, ProfileObstetricT3}
+		return Profile{
+			Name:   names[norm.Trimester(ctx.GestationalWeeks)],
+			Ranges: norm.ObstetricRangesForWeeks(ctx.GestationalWeeks),
+		}
+	case ctx.Pregnant:
+		return Profile{Name: ProfileObstetric, Ranges: norm.ObstetricRanges()}
+	case ctx.AgeYears <= 0:
+		return Profile{Name: ProfileAdult, Ranges: norm.DefaultRanges()}
+	case ctx.AgeYears < norm.NeonatalMonths/12:
+		return presetOverlay(Profile{Name: ProfileNeonate, Ranges: norm.NeonatalRanges()}, PresetPediatric())
+	case ctx.AgeYears < 1:
+		return presetOverlay(Profile{Name: ProfileInfant, Ranges: norm.InfantRanges()}, PresetPediatric())
+	case ctx.AgeYears < 12:
+		return presetOverlay(Profile{Name: ProfileChild, Ranges: norm.PediatricRanges()}, PresetPediatric())
+	case ctx.AgeYears < 18:
+		return presetOverlay(Profile{Name: ProfileAdolescent, Ranges: norm.AdolescentRanges()}, PresetPediatric())
+	case ctx.AgeYears >= GeriatricAgeYears:
+		prof := presetOverlay(Profile{
+			Name:        ProfileGeriatric,
+			Ranges:      norm.GeriatricRanges(),
+			ScoreFactor: GeriatricCompensation(ctx.AgeYears),
+		}, PresetGeriatric())
+		if frailty != nil && ctx.Frailty > 0 {
+			prof.Name = ProfileGeriatricFrail
+			prof.Ranges = frailty(prof.Ranges, ctx.Frailty)
+		}
+		return prof
+	default:
+		return Profile{Name: ProfileAdult, Ranges: norm.DefaultRanges()}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"sync"
+	"time"
+)
+
+// Provenance identifies a parameter set so audits can tie every score to
+// the exact calibration used. It is serialized with the configuration (see
+// SaveParams) and echoed into every EvaluateResult and export.Result. It
+// does not affect scoring, Equal or Hash.
+type Provenance struct {
+	Name          string    // e.g. "site-a-ed"
+	Version       string    // e.g. "2026.3"
+	CalibratedAt  time.Time // When the parameters were fitted
+	Author        string
+	SourceDataset string // Dataset the parameters were fitted on
+	// Hash is Params.Hash of the parameters at save time. SaveParams sets
+	// it, and loading fails if it no longer matches, e.g. after a
+	// threshold was edited without a new version.
+	Hash string
+}
+
+// IsZero returns true if no provenance field is set.
+func (v Provenance) IsZero() bool {
+	return v == Provenance{}
+}
+
+// provenanceJSON is the configuration file schema of Provenance.
+type provenanceJSON struct {
+	Name          string `json:"name,omitempty"`
+	Version       string `json:"version,omitempty"`
+	CalibratedAt  string `json:"calibrated_at,omitempty"` // RFC 3339
+	Author        string `json:"author,omitempty"`
+	SourceDataset string `json:"source_dataset,omitempty"`
+	Hash          string `json:"hash,omitempty"`
+}
+
+func toProvenanceJSON(v Provenance) *provenanceJSON {
+	if v.IsZero() {
+		return nil
+	}
+	w := &provenanceJSON{Name: v.Name, Version: v.Version, Author: v.Author, SourceDataset: v.SourceDataset, Hash: v.Hash}
+	if !v.CalibratedAt.IsZero() {
+		w.CalibratedAt = v.CalibratedAt.Format(time.RFC3339)
+	}
+	return w
+}
+
+func (w *provenanceJSON) provenance() (Provenance, error) {
+	if w == nil {
+		return Provenance{}, nil
+	}
+	v := Provenance{Name: w.Name, Version: w.Version, Author: w.Author, SourceDataset: w.SourceDataset, Hash: w.Hash}
+	if w.CalibratedAt != "" {
+		t, err := time.Parse(time.RFC3339, w.CalibratedAt)
+		if err != nil {
+			return Provenance{}, err
+		}
+		v.CalibratedAt = t
+	}
+	return v, nil
+}
+
+// annotateProvenance records p's provenance in r. ParamsHash is always the
+// computed Params.Hash of p, never the stored Provenance.Hash, which goes
+// stale once p is edited in code or derived for a profile.
+func (e *Engine) annotateProvenance(r EvaluateResult, p Params) EvaluateResult {
+	r.FormulaVersion = p.Formula()
+	r.ParamsName = p.Provenance.Name
+	r.ParamsVersion = p.Provenance.Version
+	r.ParamsHash = e.hashes.hash(p)
+	return r
+}
+
+// hashMemoSize bounds the parameter sets a hashMemo remembers; the base
+// params and the profile params derived from them fit easily.
+const hashMemoSize = 16
+
+// hashMemo memoizes Params.Hash, which costs several evaluations, per
+// parameter set. A nil *hashMemo computes the hash every time.
+type hashMemo struct {
+	mu sync.Mutex
+	m  map[Params]string
+}
+
+func (h *hashMemo) hash(p Params) string {
+	if h == nil {
+		return p.Hash()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, ok := h.m[p]; ok {
+		return s
+	}
+	if len(h.m) >= hashMemoSize {
+		h.m = nil
+	}
+	if h.m == nil {
+		h.m = make(map[Params]string, hashMemoSize)
+	}
+	s := p.Hash()
+	h.m[p] = s
+	return s
+}
@@ -0,0 +1,91 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"sort"
+	"time"
+)
+
+// WaitingPatient is one patient in the waiting room: the latest evaluation
+// and the arrival time from which the wait target runs.
+type WaitingPatient struct {
+	Result  EvaluateResult
+	Arrival time.Time
+}
+
+// QueueEntry is one position in a recommended see-next ordering.
+type QueueEntry struct {
+	WaitingPatient
+	// Level is the level used for the wait target: Result.Level, or the
+	// more acute candidate if the result is Deferred.
+	Level Level
+	// Reassess is true if the result is Insufficient: the patient has no
+	// level and needs more observations now, so the wait target is 0.
+	Reassess bool
+	Deadline time.Time     // Arrival plus the level's wait target
+	Waited   time.Duration // now minus Arrival
+	Slack    time.Duration // Deadline minus now; negative when overdue
+}
+
+// Overdue returns true if the patient has waited past the target.
+func (q QueueEntry) Overdue() bool {
+	return q.Slack < 0
+}
+
+// RecommendQueue returns patients in recommended see-next order under ap at
+// time now. Level 1 patients come first, then patients with an
+// Insufficient result, who need reassessment now; everyone else is ordered
+// by deadline (earliest first), so a long-waiting lower-acuity patient can
+// overtake a new arrival whose target is further away. Ties go to the more
+// acute level, then the higher acuity, then the earlier arrival. The input
+// is not modified.
+func RecommendQueue(patients []WaitingPatient, ap ActionPolicy, now time.Time) []QueueEntry {
+	out := make([]QueueEntry, len(patients))
+	for i, p := range patients {
+		l := p.Result.Level
+		if p.Result.Deferred && p.Result.Candidates[0].Valid() {
+			l = p.Result.Candidates[0]
+		}
+		deadline := p.Arrival.Add(time.Duration(ap.WaitTimeMinutesFor(p.Result)) * time.Minute)
+		out[i] = QueueEntry{
+			WaitingPatient: p,
+			Level:          l,
+			Reassess:       p.Result.Insufficient != "",
+			Deadline:       deadline,
+			Waited:         now.Sub(p.Arrival),
+			Slack:          deadline.Sub(now),
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if ia, ib := a.Level == Level1Resuscitation, b.Level == Level1Resuscitation; ia != ib {
+			return ia
+		}
+		if a.Reassess != b.Reassess {
+			return a.Reassess
+		}
+		if !a.Deadline.Equal(b.Deadline) {
+			return a.Deadline.Before(b.Deadline)
+		}
+		if a.Level != b.Level {
+			return a.Level < b.Level
+		}
+		if a.Result.Acuity != b.Result.Acuity {
+			return a.Result.Acuity > b.Result.Acuity
+		}
+		return a.Arrival.Before(b.Arrival)
+	})
+	return out
+}
+
+// RecommendQueue is RecommendQueue under the engine's ActionPolicy, or
+// DefaultActionPolicy if none is set.
+func (e *Engine) RecommendQueue(patients []WaitingPatient, now time.Time) []QueueEntry {
+	ap := DefaultActionPolicy()
+	if e.Actions != nil {
+		ap = *e.Actions
+	}
+	return RecommendQueue(patients, ap, now)
+}
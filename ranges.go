@@ -0,0 +1,100 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olaflaitinen/triagegeist/norm"
+)
+
+// LoadRanges reads site-specific reference ranges from a configuration
+// file, so they can live in reviewed files instead of Go code. Files ending
+// in .yaml or .yml are parsed as YAML, anything else as JSON; the schema is
+// that of norm.Ranges.UnmarshalJSON, one [mid, halfWidth] per vital:
+//
+//	# adult ED, site-calibrated 2026
+//	hr: [82, 38]
+//	rr: [16, 10]
+//	sbp: [125, 40]
+//	dbp: [80, 30]
+//	temp: [37, 2]
+//	spo2: [97, 8]
+//	gcs: [15, 6]
+func LoadRanges(path string) (norm.Ranges, error) {
+	var r norm.Ranges
+	err := loadRanges(path, &r)
+	return r, err
+}
+
+// LoadAsymmetricRanges is LoadRanges for norm.AsymmetricRanges, one
+// [mid, lowHalfWidth, highHalfWidth] per vital.
+func LoadAsymmetricRanges(path string) (norm.AsymmetricRanges, error) {
+	var r norm.AsymmetricRanges
+	err := loadRanges(path, &r)
+	return r, err
+}
+
+// SaveRanges writes r to path as YAML (.yaml, .yml) or indented JSON, in
+// the format LoadRanges reads. It refuses ranges that fail Valid.
+func SaveRanges(path string, r norm.Ranges) error {
+	if !r.Valid() {
+		return fmt.Errorf("triagegeist: ranges: half-widths must be non-negative and finite")
+	}
+	return saveRanges(path, r)
+}
+
+// SaveAsymmetricRanges is SaveRanges for norm.AsymmetricRanges.
+func SaveAsymmetricRanges(path string, r norm.AsymmetricRanges) error {
+	if !r.Valid() {
+		return fmt.Errorf("triagegeist: ranges: half-widths must be non-negative and finite")
+	}
+	return saveRanges(path, r)
+}
+
+func loadRanges(path string, r json.Unmarshaler) error {
+	data, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func saveRanges(path string, r json.Marshaler) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if isYAMLPath(path) {
+		var rows map[string][]float64
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return err
+		}
+		data = rangesYAML(rows)
+	} else {
+		data = append(data, '\n')
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// rangesYAML renders rows as YAML in vital order.
+func rangesYAML(rows map[string][]float64) []byte {
+	var b bytes.Buffer
+	for _, key := range norm.VitalKeys {
+		parts := make([]string, len(rows[key]))
+		for i, x := range rows[key] {
+			parts[i] = strconv.FormatFloat(x, 'g', -1, 64)
+		}
+		fmt.Fprintf(&b, "%s: [%s]\n", key, strings.Join(parts, ", "))
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,199 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"time"
+
+	"github.com/olaflaitinen/triagegeist/export"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// RescoreResult pairs an exported evaluation with its re-evaluation under a
+// new parameter set.
+type RescoreResult struct {
+	ID        string
+	OldAcuity float64
+	OldLevel  Level
+	NewAcuity float64
+	NewLevel  Level
+}
+
+// Changed returns true if the level differs between old and new.
+func (r RescoreResult) Changed() bool {
+	return r.OldLevel != r.NewLevel
+}
+
+// RescoreBatch converts each exported Result back to vitals (see
+// export.ResultToVitals), re-evaluates it with newParams, and returns the
+// old and new acuity and level in input order. As in Rescore, a
+// profile-scored Result is re-evaluated with EvaluateWithContext for its
+// recorded patient context, so it is scored against the same profile; the
+// others are scored with ScoreAndLevel. The receiver's other settings (e.g.
+// Profiles) are kept; the receiver itself is unchanged.
+func (e *Engine) RescoreBatch(results []export.Result, newParams Params) []RescoreResult {
+	ne := e.WithParams(newParams)
+	out := make([]RescoreResult, len(results))
+	for i, r := range results {
+		v := export.ResultToVitals(r)
+		var a float64
+		var l Level
+		if r.Profile != "" {
+			n := ne.EvaluateWithContext(v, r.ResourceCount, exportContext(r))
+			a, l = n.Acuity, n.Level
+		} else {
+			a, l = ne.ScoreAndLevel(v, r.ResourceCount)
+		}
+		out[i] = RescoreResult{
+			ID:        r.ID,
+			OldAcuity: r.Acuity,
+			OldLevel:  LevelFromInt(r.Level),
+			NewAcuity: a,
+			NewLevel:  l,
+		}
+	}
+	return out
+}
+
+// exportContext returns the patient context recorded in r.
+func exportContext(r export.Result) PatientContext {
+	return PatientContext{AgeYears: r.AgeYears, Pregnant: r.Pregnant, GestationalWeeks: r.GestationalWeeks, Frailty: r.Frailty}
+}
+
+// RescoreImpact summarises level changes across a RescoreBatch.
+type RescoreImpact struct {
+	N         int
+	Unchanged int
+	MoreAcute int // New level more acute than old (up-triaged)
+	LessAcute int // New level less acute than old (down-triaged)
+	// Transitions[old][new] counts level pairs; index 0 is unused/invalid.
+	Transitions [6][6]int
+}
+
+// ComputeRescoreImpact returns the RescoreImpact for rs.
+func ComputeRescoreImpact(rs []RescoreResult) RescoreImpact {
+	var im RescoreImpact
+	im.N = len(rs)
+	for _, r := range rs {
+		switch {
+		case r.NewLevel.MoreAcuteThan(r.OldLevel):
+			im.MoreAcute++
+		case r.NewLevel.LessAcuteThan(r.OldLevel):
+			im.LessAcute++
+		default:
+			im.Unchanged++
+		}
+		im.Transitions[r.OldLevel.Int()][r.NewLevel.Int()]++
+	}
+	return im
+}
+
+// StalenessPolicy controls how Rescore treats vitals carried over from a
+// prior evaluation.
+type StalenessPolicy struct {
+	// MaxAge is how long prior vitals remain usable. When the prior result
+	// was last evaluated (see EvaluateResult.EvaluatedAt) more than MaxAge
+	// before rescoring, only the changed vitals are used. 0 means prior
+	// vitals never expire.
+	MaxAge time.Duration
+}
+
+// Stale returns true if vitals taken at t are stale at now. A zero t or
+// MaxAge is never stale.
+func (sp StalenessPolicy) Stale(t, now time.Time) bool {
+	if sp.MaxAge <= 0 || t.IsZero() {
+		return false
+	}
+	return now.Sub(t) > sp.MaxAge
+}
+
+// Rescore merges newly measured vitals into the snapshot of prev (see
+// score.MergeVitals) and re-evaluates with prev's resource count, against
+// the profile selected for prev's Context as in EvaluateWithContext if prev
+// was profile-scored. Prev's extended signs, custom signals and measured
+// zeros (see EvaluateOpt) are kept, the latter until the vital is measured
+// again, whether or not prev was profile-scored. If Params.TrendWeights
+// is set and prev has a Time, the trend term since prev is added as in
+// EvaluateTrend. Prior vitals are dropped if prev.EvaluatedAt() is stale
+// under the engine's StalenessPolicy; the result's Time is then the current
+// time, since it no longer carries anything measured earlier.
+// If Params.Hysteresis is set, the level steps down from prev.Level only
+// when the score falls below the crossed threshold by that margin;
+// escalation is immediate. This is decided before the gray zone, the
+// minimum-data gate and the engine's hooks, so they all see the held level.
+// Otherwise the returned result keeps prev's Time. It keeps prev's ID; its
+// RescoredAt is the current time.
+func (e *Engine) Rescore(prev EvaluateResult, changed score.Vitals) EvaluateResult {
+	return e.RescoreAt(prev, changed, time.Now())
+}
+
+// RescoreAt is like Rescore with an explicit measurement time for changed,
+// recorded as the result's RescoredAt.
+func (e *Engine) RescoreAt(prev EvaluateResult, changed score.Vitals, now time.Time) EvaluateResult {
+	v := changed
+	in := evalExtras{extended: prev.Extended, custom: prev.Custom, prevLevel: prev.Level}
+	stale := e.Staleness.Stale(prev.EvaluatedAt(), now)
+	if !stale {
+		v = score.MergeVitals(prev.measured(), changed)
+		in.zero = prev.MeasuredZero
+		if last := prev.EvaluatedAt(); e.P.TrendWeights != ([7]float64{}) && !last.IsZero() {
+			pv := prev.measured()
+			in.previous, in.elapsed = &pv, now.Sub(last)
+		}
+	}
+	for i, ok := range score.Present(changed) {
+		in.zero[i] = in.zero[i] && !ok
+	}
+	p := e.P
+	if prev.Profile != "" {
+		prof := e.SelectProfile(prev.Context)
+		in.profile, in.ctx = &prof, prev.Context
+		p = prof.ParamsFor(e.P)
+	}
+	var r EvaluateResult
+	if in.profile != nil || !prev.Extended.IsZero() || in.zero != [7]bool{} || len(prev.Custom) > 0 || in.previous != nil || (p.Hysteresis > 0 && prev.Level.Valid()) {
+		r = e.evaluateOptions(v, prev.ResourceCount, in)
+	} else {
+		r = e.Evaluate(v, prev.ResourceCount)
+	}
+	r.Time, r.RescoredAt = prev.Time, now
+	if stale {
+		r.Time = now
+	}
+	r.ID = prev.ID
+	return r
+}
+
+// EvaluatedAt returns when r was last evaluated from new measurements: its
+// RescoredAt if it was rescored, and otherwise its Time.
+func (r EvaluateResult) EvaluatedAt() time.Time {
+	if !r.RescoredAt.IsZero() {
+		return r.RescoredAt
+	}
+	return r.Time
+}
+
+// MaxHysteresis is the largest admissible Params.Hysteresis.
+const MaxHysteresis = score.MaxThresholdMargin
+
+// LevelWithHysteresis returns the level for score s given the patient's
+// previous level. Escalation is immediate: if FromScore(s, p) is more acute
+// than prev, it is returned. The level moves one step less acute only while
+// s < T - Hysteresis for the threshold T being crossed, so a patient
+// hovering just below a threshold keeps the more acute level. With
+// Hysteresis 0 it equals FromScore(s, p). An invalid prev gives
+// FromScore(s, p).
+func (p Params) LevelWithHysteresis(s float64, prev Level) Level {
+	l := FromScore(s, p)
+	if !prev.Valid() || l <= prev {
+		return l
+	}
+	t := p.Thresholds()
+	h := p.Hysteresis
+	l = prev
+	for l < Level5NonUrgent && s < t[l-1]-h {
+		l++
+	}
+	return l
+}
@@ -0,0 +1,184 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/olaflaitinen/triagegeist/export"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// Breakdown splits an acuity score into per-vital, resource and extension
+// terms, in VitalWeights order, built from score.AcuityDecomposed with the
+// options the score was computed with. Contribution[i] is vital i's share
+// of the normalized score: the vital component V / divisor, apportioned by
+// each term's weighted deviation (w_i·d_i / (sum of counted weights) /
+// divisor under the default sum Normalization). Other is the share of the
+// extension terms of the weighted mean (MAP, extended signs, custom
+// signals), Resource is R / divisor, and Adjustment is what was added
+// after normalization: the respiratory composite, qSOFA bump and trend, a
+// profile's ScoreFactor, hooks and clamping. The terms sum to the score.
+// When Params.MissingPolicy counts absent vitals, their weights join the
+// sum, and under score.MissingPenalizeWorst their Deviation is 1.
+// Imputed, set by EvaluateDetailed, marks the present vitals that the
+// engine's Imputer filled.
+type Breakdown struct {
+	Present      [7]bool
+	Imputed      [7]bool
+	Deviation    [7]float64
+	Contribution [7]float64
+	Other        float64
+	Resource     float64
+	Adjustment   float64
+}
+
+// ComputeBreakdown returns the Breakdown of v and resourceCount under p,
+// using the package norms; it sums to Engine.Evaluate's Acuity under p.
+// For a result scored with a profile or formula extensions, use
+// Engine.Breakdown.
+func ComputeBreakdown(v score.Vitals, resourceCount int, p Params) Breakdown {
+	d := score.AcuityDecomposed(v, resourceCount, p.MaxResources, p.VitalWeights, p.ResourceWeight, p.ScoreOptions())
+	return breakdownFrom(v, d, d.Score)
+}
+
+// Breakdown returns the Breakdown of r as the engine scored it: against
+// the profile selected for r.Context if r is profile-scored, and with r's
+// extended signs, measured zeros, custom signals and source reliability.
+// Adjustment takes up any difference from r.Acuity, such as the trend term
+// or a hook's change, so the terms always sum to r.Acuity. An Insufficient
+// result has no score to break down: only Present, Imputed and Deviation
+// are set.
+func (e *Engine) Breakdown(r EvaluateResult) Breakdown {
+	if r.Insufficient != "" {
+		return Breakdown{Present: score.Present(r.Vitals), Imputed: r.Imputed, Deviation: score.DeviationsWithOptions(r.Vitals, e.scoreOptions(e.P))}
+	}
+	p := e.P
+	var norms [7][2]float64
+	if r.Profile != "" {
+		prof := e.SelectProfile(r.Context)
+		p, norms = prof.ParamsFor(e.P), prof.Ranges.Array()
+	}
+	o := e.scoreOptions(p)
+	if r.Profile != "" {
+		o = e.withNorms(o, p, &norms)
+	}
+	if !r.Extended.IsZero() {
+		x := r.Extended
+		o.Extended = &x
+	}
+	o.MeasuredZero = r.MeasuredZero
+	o.Custom, o.Registry = r.Custom, e.Registry
+	if r.Reliability != ([7]float64{}) {
+		f := r.Reliability
+		o.Reliability = &f
+	}
+	d := score.AcuityDecomposed(r.Vitals, r.ResourceCount, p.MaxResources, p.VitalWeights, p.ResourceWeight, o)
+	b := breakdownFrom(r.Vitals, d, r.Acuity)
+	b.Imputed = r.Imputed
+	return b
+}
+
+// breakdownFrom returns the Breakdown of d, with Adjustment making the
+// terms sum to total.
+func breakdownFrom(v score.Vitals, d score.Decomposition, total float64) Breakdown {
+	b := Breakdown{Present: score.Present(v), Deviation: d.Deviation}
+	if d.Divisor > 0 {
+		var terms float64
+		for _, c := range d.Contribution {
+			terms += c
+		}
+		if terms += d.Other; terms > 0 {
+			k := d.V / terms / d.Divisor
+			for i, c := range d.Contribution {
+				b.Contribution[i] = c * k
+			}
+			b.Other = d.Other * k
+		}
+		b.Resource = d.R / d.Divisor
+	}
+	b.Adjustment = total - b.Other - b.Resource
+	for _, c := range b.Contribution {
+		b.Adjustment -= c
+	}
+	return b
+}
+
+// Hash returns a short, stable fingerprint of p (the first 16 hex digits of
+// the SHA-256 of its JSON encoding without Provenance and schema_version),
+// for recording which calibration produced a result. Leaving the schema
+// version out keeps stored hashes valid across schema bumps. It returns ""
+// if p cannot be encoded (e.g. NaN values).
+func (p Params) Hash() string {
+	b, err := hashInput(p)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// hashInput returns the encoding of p that Hash fingerprints.
+func hashInput(p Params) ([]byte, error) {
+	p.Provenance = Provenance{}
+	w := toParamsJSON(p)
+	w.SchemaVersion = 0
+	return json.Marshal(w)
+}
+
+// EvaluateDetailed is like Evaluate and also records id, the measurement time
+// t, the Breakdown, and the hash of the engine's Params.
+func (e *Engine) EvaluateDetailed(id string, v score.Vitals, resourceCount int, t time.Time) EvaluateResult {
+	r := e.Evaluate(v, resourceCount)
+	r.ID = id
+	r.Time = t
+	b := e.Breakdown(r)
+	r.Breakdown = &b
+	return r
+}
+
+// ToExport converts r to an export.Result, carrying ID, Time, Profile and
+// the patient context of a profile-scored result, Percentile, Probability,
+// Insufficient, Flags, Mode, the parameter provenance, the imputed vitals,
+// the extended signs and the custom signals.
+func (r EvaluateResult) ToExport() export.Result {
+	res := export.FromVitalsScoreLevel(r.Vitals, r.ResourceCount, r.Acuity, r.Level.Int(), r.Level.String())
+	res.ID = r.ID
+	res.Timestamp = r.Time
+	res.Profile = r.Profile
+	if r.Profile != "" {
+		c := r.Context
+		res.AgeYears, res.Pregnant, res.GestationalWeeks, res.Frailty = c.AgeYears, c.Pregnant, c.GestationalWeeks, c.Frailty
+	}
+	res.Percentile = r.Percentile
+	res.Probability = r.Probability
+	res.Insufficient = r.Insufficient
+	res.Flags = append([]string(nil), r.Flags...)
+	res.Mode = r.Mode
+	res.ParamsName = r.ParamsName
+	res.ParamsVersion = r.ParamsVersion
+	res.ParamsHash = r.ParamsHash
+	res.FormulaVersion = int(r.FormulaVersion)
+	res.Imputed = r.ImputedNames()
+	res = res.WithExtended(r.Extended)
+	for name, x := range r.Custom {
+		if res.Custom == nil {
+			res.Custom = make(map[string]float64, len(r.Custom))
+		}
+		res.Custom[string(name)] = x
+	}
+	return res
+}
+
+// ResultsToExport converts each result with ToExport.
+func ResultsToExport(results []EvaluateResult) []export.Result {
+	out := make([]export.Result, len(results))
+	for i, r := range results {
+		out[i] = r.ToExport()
+	}
+	return out
+}
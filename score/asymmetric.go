@@ -0,0 +1,179 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import (
+	"math"
+
+	"github.com/olaflaitinen/triagegeist/norm"
+)
+
+// AsymmetricWeights holds separate vital weights, in VitalWeights order, for
+// values below the norm midpoint (Low) and at or above it (High), e.g. so
+// that low SBP counts more than high SBP. Symmetric(w) scores exactly like
+// the weights w.
+type AsymmetricWeights struct {
+	Low, High [7]float64
+}
+
+// Symmetric returns AsymmetricWeights with both sides equal to w.
+func Symmetric(w [7]float64) AsymmetricWeights {
+	return AsymmetricWeights{Low: w, High: w}
+}
+
+// Valid returns true if every weight is in [0, 1].
+func (a AsymmetricWeights) Valid() bool {
+	for i := range a.Low {
+		if !(a.Low[i] >= 0 && a.Low[i] <= 1) || !(a.High[i] >= 0 && a.High[i] <= 1) {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the weight that applies to each vital of v under norms:
+// Low[i] if the value is below norms[i][0] and High[i] otherwise.
+func (a AsymmetricWeights) Select(v Vitals, norms [7][2]float64) [7]float64 {
+	return a.selectValues(VitalsToValues(v), norms)
+}
+
+func (a AsymmetricWeights) selectValues(values [7]float64, norms [7][2]float64) [7]float64 {
+	w := a.High
+	for i, x := range values {
+		if x < norms[i][0] {
+			w[i] = a.Low[i]
+		}
+	}
+	return w
+}
+
+// HalfWidths holds separate half-widths, in VitalWeights order, for values
+// below the norm midpoint (Low) and at or above it (High), so that a vital
+// can deviate quickly on one side and slowly on the other: e.g. SpO2 with
+// Low 4 and High 20 scores a fall from 98 to 94 as fully abnormal while a
+// rise barely counts. A zero entry keeps the norm's own half-width, so the
+// zero value changes nothing. Entries are absolute, in the vital's unit, and
+// apply to whichever norms are in use.
+type HalfWidths struct {
+	Low, High [7]float64
+}
+
+// IsZero returns true if h overrides no half-width.
+func (h HalfWidths) IsZero() bool {
+	return h == HalfWidths{}
+}
+
+// Valid returns true if every entry is 0 or finite and positive.
+func (h HalfWidths) Valid() bool {
+	for i := range h.Low {
+		for _, x := range [2]float64{h.Low[i], h.High[i]} {
+			if x != 0 && !(x > 0 && !math.IsInf(x, 0)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Select returns norms with each vital's half-width replaced by Low[i] if
+// the value of v is below norms[i][0], or by High[i] otherwise, where that
+// entry is non-zero. Missing vitals, and vitals whose norm half-width is
+// <= 0 (skipped), keep their norm.
+func (h HalfWidths) Select(v Vitals, norms [7][2]float64) [7][2]float64 {
+	return h.selectValues(VitalsToValues(v), Present(v), norms)
+}
+
+func (h HalfWidths) selectValues(values [7]float64, p [7]bool, norms [7][2]float64) [7][2]float64 {
+	for i, x := range values {
+		if !p[i] || norms[i][1] <= 0 {
+			continue
+		}
+		hw := h.High[i]
+		if x < norms[i][0] {
+			hw = h.Low[i]
+		}
+		if hw != 0 {
+			norms[i][1] = hw
+		}
+	}
+	return norms
+}
+
+// AsymmetricOptions returns Options that score against the asymmetric
+// ranges r: Norms holds each midpoint with the larger half-width, HalfWidths
+// the half-width per side, and Directions makes a vital with one zero
+// half-width deviate only on the other side. Deviations then follow
+// norm.DeviationAsym; other fields can be set on the result.
+// With r = s.Asymmetric() it scores as AcuityWithRanges against s.
+func AsymmetricOptions(r norm.AsymmetricRanges) Options {
+	var norms [7][2]float64
+	var h HalfWidths
+	var dirs [7]Direction
+	oneSided := false
+	for i, a := range r.Array() {
+		norms[i] = [2]float64{a[0], math.Max(a[1], a[2])}
+		h.Low[i], h.High[i] = a[1], a[2]
+		switch {
+		case a[1] > 0 && a[2] <= 0:
+			dirs[i], oneSided = DirectionLow, true
+		case a[2] > 0 && a[1] <= 0:
+			dirs[i], oneSided = DirectionHigh, true
+		}
+	}
+	o := Options{Norms: &norms, HalfWidths: &h}
+	if oneSided {
+		o.Directions = &dirs
+	}
+	return o
+}
+
+// VitalComponentWithAsymmetricRanges is like VitalComponent against the
+// asymmetric ranges r (see AsymmetricOptions).
+func VitalComponentWithAsymmetricRanges(v Vitals, weights [7]float64, r norm.AsymmetricRanges) float64 {
+	return VitalComponentWithOptions(v, weights, AsymmetricOptions(r))
+}
+
+// AcuityWithAsymmetricRanges is like Acuity against the asymmetric ranges r
+// (see AsymmetricOptions), e.g. norm.DefaultAsymmetricRanges.
+func AcuityWithAsymmetricRanges(v Vitals, resourceCount, maxResources int, vitalWeights [7]float64, resourceWeight float64, r norm.AsymmetricRanges) float64 {
+	return AcuityWithOptions(v, resourceCount, maxResources, vitalWeights, resourceWeight, AsymmetricOptions(r))
+}
+
+// Direction selects which side of its norm midpoint a vital deviates on.
+// The zero value, DirectionBoth, scores both sides as Acuity does; a
+// one-sided direction scores values on the other side as normal (deviation
+// 0), e.g. DirectionLow for SpO2 so that saturation above the midpoint is
+// not penalized.
+type Direction int
+
+const (
+	DirectionBoth Direction = iota
+	DirectionLow            // only values below the midpoint deviate
+	DirectionHigh           // only values above the midpoint deviate
+)
+
+// String returns the direction name.
+func (d Direction) String() string {
+	switch d {
+	case DirectionBoth:
+		return "both"
+	case DirectionLow:
+		return "low"
+	case DirectionHigh:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// Valid returns true if d is one of the defined directions.
+func (d Direction) Valid() bool {
+	return d >= DirectionBoth && d <= DirectionHigh
+}
+
+// ignores returns true if value x is on the side of mid that d does not
+// score.
+func (d Direction) ignores(x, mid float64) bool {
+	return (d == DirectionLow && x > mid) || (d == DirectionHigh && x < mid)
+}
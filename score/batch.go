@@ -0,0 +1,59 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// minParallelChunk is the smallest number of records BatchAcuityParallel
+// gives a worker; smaller batches are scored on fewer workers, down to the
+// calling goroutine alone.
+const minParallelChunk = 512
+
+// BatchAcuityParallel writes AcuityWithOptions(vitals[i], resourceCounts[i],
+// maxResources, vitalWeights, resourceWeight, o) to out[i] for every i. The
+// batch is split into contiguous chunks across up to runtime.GOMAXPROCS(0)
+// goroutines, each reading its records in place and writing its own part
+// of out, so there is no per-record allocation and no sharing between
+// workers. It fails if resourceCounts or out is not as long as vitals.
+//
+// o is shared by the workers and must not be modified during the call.
+func BatchAcuityParallel(vitals []Vitals, resourceCounts []int, maxResources int, vitalWeights [7]float64, resourceWeight float64, o Options, out []float64) error {
+	n := len(vitals)
+	if len(resourceCounts) != n || len(out) != n {
+		return fmt.Errorf("score: batch: %d vitals, %d resource counts and %d outputs", n, len(resourceCounts), len(out))
+	}
+	score := func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			out[i] = AcuityWithOptions(vitals[i], resourceCounts[i], maxResources, vitalWeights, resourceWeight, o)
+		}
+	}
+	workers := runtime.GOMAXPROCS(0)
+	if w := n / minParallelChunk; w < workers {
+		workers = w
+	}
+	if workers <= 1 {
+		score(0, n)
+		return nil
+	}
+	chunk := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := chunk; lo < n; lo += chunk {
+		hi := lo + chunk
+		if hi > n {
+			hi = n
+		}
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			score(lo, hi)
+		}(lo, hi)
+	}
+	score(0, chunk)
+	wg.Wait()
+	return nil
+}
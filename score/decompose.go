@@ -0,0 +1,93 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+// Decomposition is the acuity formula evaluated step by step, as
+// AcuityWithOptions computes it, so explanation layers can show each term
+// without re-implementing the formula. Vital arrays are in VitalWeights
+// order.
+//
+//	| Field        | Meaning                                                 |
+//	|--------------|---------------------------------------------------------|
+//	| Deviation    | Deviation scored per vital (banded GCS, worst missing)  |
+//	| Weight       | Effective weight counted in WeightSum, 0 if not counted |
+//	| Contribution | Weighted deviation, Weight[i]·Deviation[i]              |
+//	| Other        | Weighted sum of MAP, extended and custom terms          |
+//	| WeightSum    | Total weight of the vital component's weighted mean     |
+//	| V            | Vital component, (Σ Contribution + Other) / WeightSum   |
+//	|              | capped to 1 (see Normalization for the others)          |
+//	| R            | Resource component                                      |
+//	| Raw          | V + R                                                   |
+//	| Divisor      | sum(vitalWeights) + resourceWeight                      |
+//	| Respiratory  | Respiratory composite bump added, else 0                |
+//	| QSOFABump    | Bump added because qSOFA is positive, else 0            |
+//	| Trend        | Trend term added (see Options.Previous)                 |
+//	| Score        | Normalize(Raw, Divisor), plus Respiratory, QSOFABump    |
+//	|              | and Trend, clamped to [0, 1]: AcuityWithOptions         |
+type Decomposition struct {
+	Deviation    [7]float64
+	Weight       [7]float64
+	Contribution [7]float64
+	Other        float64
+	WeightSum    float64
+
+	V       float64
+	R       float64
+	Raw     float64
+	Divisor float64
+
+	Respiratory float64
+	QSOFABump   float64
+	Trend       float64
+	Score       float64
+}
+
+// AcuityDecomposed returns the Decomposition of AcuityWithOptions for the
+// same arguments; its Score equals AcuityWithOptions.
+func AcuityDecomposed(v Vitals, resourceCount, maxResources int, vitalWeights [7]float64, resourceWeight float64, o Options) Decomposition {
+	t := o.vitalTerms(v, vitalWeights)
+	d := Decomposition{
+		Deviation:    DeviationsWithOptions(v, o),
+		Weight:       t.weight,
+		Contribution: t.term,
+		Other:        t.other,
+		WeightSum:    t.wSum,
+		R:            ResourceComponentWithOptions(resourceCount, maxResources, resourceWeight, o),
+		Divisor:      WeightSum(vitalWeights) + resourceWeight,
+	}
+	if o.GCSBanded {
+		d.Deviation[6] = 0
+		if g := GCSTotal(v); g > 0 {
+			d.Deviation[6] = o.GCSBands.Deviation(g)
+		}
+	}
+	if o.Missing == MissingPenalizeWorst {
+		for i, m := range t.missing {
+			if m {
+				d.Deviation[i] = 1
+			}
+		}
+	}
+	if t.wSum > 0 {
+		d.V, _ = o.combine(t)
+		if d.V > 1 {
+			d.V = 1
+		}
+	}
+	d.Raw = AcuityRaw(d.V, d.R)
+	d.Score = Normalize(d.Raw, d.Divisor)
+	if r := o.respiratory(v, vitalWeights); r > 0 {
+		d.Respiratory = r
+		d.Score = Normalize(d.Score+r, 1)
+	}
+	if o.QSOFABump > 0 && QSOFA(v).Positive {
+		d.QSOFABump = o.QSOFABump
+		d.Score = Normalize(d.Score+o.QSOFABump, 1)
+	}
+	if tr := o.trend(v); tr > 0 {
+		d.Trend = tr
+		d.Score = Normalize(d.Score+tr, 1)
+	}
+	return d
+}
@@ -0,0 +1,62 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DeviationFunc maps a vital's value x to its deviation, given the vital's
+// norm [mid, halfWidth]. Set per vital in Options.Deviations, it replaces
+// the distance from the norm for scales where a distance is a poor model,
+// such as the ordinal GCS. Results are clamped to [0, 1]; NaN counts as 0.
+type DeviationFunc func(x float64, norm [2]float64) float64
+
+// DeviationTable returns a DeviationFunc that looks x up in a table: the
+// deviation of the largest value in values that is <= x, or of the
+// smallest value if x is below them all. values must be strictly
+// increasing, finite and of the same length as devs, with each dev in
+// [0, 1]. The norm is ignored. For GCS, where 15 is normal, 14 mildly
+// abnormal and 8 or less coma:
+//
+//	f, _ := DeviationTable([]float64{8, 9, 13, 14, 15}, []float64{1, 0.8, 0.4, 0.2, 0})
+func DeviationTable(values, devs []float64) (DeviationFunc, error) {
+	if len(values) == 0 || len(values) != len(devs) {
+		return nil, fmt.Errorf("score: deviation table: %d values, %d deviations", len(values), len(devs))
+	}
+	for i, x := range values {
+		if math.IsNaN(x) || math.IsInf(x, 0) || (i > 0 && x <= values[i-1]) {
+			return nil, fmt.Errorf("score: deviation table: values not strictly increasing at %d", i)
+		}
+		if !(devs[i] >= 0 && devs[i] <= 1) {
+			return nil, fmt.Errorf("score: deviation table: deviation %v at %d not in [0, 1]", devs[i], i)
+		}
+	}
+	xs := append([]float64(nil), values...)
+	ds := append([]float64(nil), devs...)
+	return func(x float64, _ [2]float64) float64 {
+		i := sort.SearchFloat64s(xs, x)
+		if i < len(xs) && xs[i] == x {
+			return ds[i]
+		}
+		if i > 0 {
+			i--
+		}
+		return ds[i]
+	}, nil
+}
+
+// apply returns f(x, norm) clamped to [0, 1], or 0 if it is NaN.
+func (f DeviationFunc) apply(x float64, norm [2]float64) float64 {
+	d := f(x, norm)
+	switch {
+	case !(d > 0):
+		return 0
+	case d > 1:
+		return 1
+	}
+	return d
+}
@@ -0,0 +1,90 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+// ExtendedVitals holds additional signs recorded in richer ED datasets.
+// Use 0 for unknown, as in Vitals. A pain score of 0 is recorded by setting
+// PainRecorded, since 0 also means unknown.
+//
+//	| Sign      | Unit   | Norm mid | Half-width | Direction | Default weight |
+//	|-----------|--------|----------|------------|-----------|----------------|
+//	| Glucose   | mmol/L | 6        | 4          | both      | 0.08           |
+//	| Lactate   | mmol/L | 1        | 3          | high      | 0.15           |
+//	| EtCO2     | mmHg   | 40       | 15         | both      | 0.10           |
+//	| CapRefill | s      | 1        | 3          | high      | 0.10           |
+//	| Pain      | NRS    | 0        | 10         | high      | 0.05           |
+type ExtendedVitals struct {
+	Glucose   float64 // Blood glucose, mmol/L
+	Lactate   float64 // Blood lactate, mmol/L
+	EtCO2     float64 // End-tidal CO2, mmHg
+	CapRefill float64 // Capillary refill time, seconds
+	Pain      int     // Pain score, numeric rating scale 0-10
+
+	PainRecorded bool // Pain was assessed (needed to record a score of 0)
+}
+
+// NumExtended is the number of signs in ExtendedVitals.
+const NumExtended = 5
+
+// ExtendedNames are the lower-case names of the extended signs, in
+// ExtendedVitals order, as used in export columns and configuration keys.
+var ExtendedNames = [NumExtended]string{"glucose", "lactate", "etco2", "cap_refill", "pain"}
+
+// ExtendedNorms holds (mid, halfWidth) for each extended sign and
+// ExtendedDirections the side on which it deviates.
+var (
+	ExtendedNorms      = [NumExtended][2]float64{{6, 4}, {1, 3}, {40, 15}, {1, 3}, {0, 10}}
+	ExtendedDirections = [NumExtended]Direction{DirectionBoth, DirectionHigh, DirectionBoth, DirectionHigh, DirectionHigh}
+)
+
+// DefaultExtendedWeights returns the suggested extended sign weights. They
+// are not applied by default: Params.ExtendedWeights is zero unless set.
+func DefaultExtendedWeights() [NumExtended]float64 {
+	return [NumExtended]float64{0.08, 0.15, 0.10, 0.10, 0.05}
+}
+
+// Values returns x as an array in ExtendedVitals order.
+func (x ExtendedVitals) Values() [NumExtended]float64 {
+	return [NumExtended]float64{x.Glucose, x.Lactate, x.EtCO2, x.CapRefill, float64(x.Pain)}
+}
+
+// Present reports which extended signs are recorded.
+func (x ExtendedVitals) Present() [NumExtended]bool {
+	var p [NumExtended]bool
+	for i, val := range x.Values() {
+		p[i] = val > 0
+	}
+	p[4] = p[4] || x.PainRecorded
+	return p
+}
+
+// IsZero returns true if no extended sign is recorded.
+func (x ExtendedVitals) IsZero() bool {
+	return x.Present() == [NumExtended]bool{}
+}
+
+// ExtendedDeviations returns the deviation in [0, 1] of each extended sign
+// against ExtendedNorms and ExtendedDirections, mapped by t. Missing signs
+// have deviation 0.
+func ExtendedDeviations(x ExtendedVitals, t DeviationTransform) [NumExtended]float64 {
+	var d [NumExtended]float64
+	vals := x.Values()
+	for i, ok := range x.Present() {
+		if ok {
+			d[i] = curve{t: t, dir: ExtendedDirections[i]}.deviation(vals[i], ExtendedNorms[i])
+		}
+	}
+	return d
+}
+
+// addExtended adds the recorded extended signs of x with weights w.
+func addExtended(x ExtendedVitals, w [NumExtended]float64, t DeviationTransform, sum, wSum *float64) {
+	d := ExtendedDeviations(x, t)
+	for i, ok := range x.Present() {
+		if ok && w[i] > 0 {
+			*sum += w[i] * d[i]
+			*wSum += w[i]
+		}
+	}
+}
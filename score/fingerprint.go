@@ -0,0 +1,52 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+)
+
+// fingerprintVersion is hashed first, so that a change to the encoding
+// below changes every fingerprint instead of silently colliding with old
+// ones.
+const fingerprintVersion = 1
+
+// Fingerprint returns a stable 64-bit hash (FNV-1a) of v and resourceCount,
+// for caching, deduplication and joining results back to their inputs. It
+// is the same across processes, platforms and releases (unless the Vitals
+// fields change), and equal inputs give equal fingerprints; -0 and 0 °C,
+// and all NaN temperatures or FiO2 values, hash alike. It is not
+// anonymization: vitals have few plausible values, so a fingerprint can be
+// reversed by trying them all.
+func Fingerprint(v Vitals, resourceCount int) uint64 {
+	h := fnv.New64a()
+	var b [8]byte
+	put := func(x uint64) {
+		binary.LittleEndian.PutUint64(b[:], x)
+		h.Write(b[:])
+	}
+	float := func(x float64) {
+		switch {
+		case x == 0:
+			x = 0
+		case math.IsNaN(x):
+			x = math.NaN()
+		}
+		put(math.Float64bits(x))
+	}
+	put(fingerprintVersion)
+	for _, n := range [...]int{v.HR, v.RR, v.SBP, v.DBP, v.SpO2, v.GCS, v.GCSEye, v.GCSVerbal, v.GCSMotor, resourceCount} {
+		put(uint64(int64(n)))
+	}
+	float(v.Temp)
+	float(v.FiO2)
+	var o uint64
+	if v.OnOxygen {
+		o = 1
+	}
+	put(o)
+	return h.Sum64()
+}
@@ -0,0 +1,100 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import "math"
+
+// VitalsF is Vitals with fractional values, as device integrations deliver
+// them (an averaged RR of 17.5, a temperature-corrected HR of 92.4).
+// Scoring Vitals would truncate them at the API boundary; AcuityF scores
+// the fractions. Units and the meaning of 0 (unknown) follow Vitals. The
+// GCS components are whole-number categories, as in Vitals.
+type VitalsF struct {
+	HR   float64
+	RR   float64
+	SBP  float64
+	DBP  float64
+	Temp float64
+	SpO2 float64
+	GCS  float64
+
+	GCSEye    int
+	GCSVerbal int
+	GCSMotor  int
+
+	OnOxygen bool
+	FiO2     float64
+}
+
+// FloatVitals returns v as VitalsF.
+func FloatVitals(v Vitals) VitalsF {
+	return VitalsF{
+		HR:        float64(v.HR),
+		RR:        float64(v.RR),
+		SBP:       float64(v.SBP),
+		DBP:       float64(v.DBP),
+		Temp:      v.Temp,
+		SpO2:      float64(v.SpO2),
+		GCS:       float64(v.GCS),
+		GCSEye:    v.GCSEye,
+		GCSVerbal: v.GCSVerbal,
+		GCSMotor:  v.GCSMotor,
+		OnOxygen:  v.OnOxygen,
+		FiO2:      v.FiO2,
+	}
+}
+
+// Vitals returns f rounded to whole numbers, for the terms that read
+// Vitals. A positive value below 0.5 rounds to 1, not to 0, so a present
+// vital stays present; negative values become 0 (unknown).
+func (f VitalsF) Vitals() Vitals {
+	r := func(x float64) int {
+		if !(x > 0) {
+			return 0
+		}
+		return int(math.Max(1, math.Round(x)))
+	}
+	return Vitals{
+		HR:        r(f.HR),
+		RR:        r(f.RR),
+		SBP:       r(f.SBP),
+		DBP:       r(f.DBP),
+		Temp:      f.Temp,
+		SpO2:      r(f.SpO2),
+		GCS:       r(f.GCS),
+		GCSEye:    f.GCSEye,
+		GCSVerbal: f.GCSVerbal,
+		GCSMotor:  f.GCSMotor,
+		OnOxygen:  f.OnOxygen,
+		FiO2:      f.FiO2,
+	}
+}
+
+// Values returns the seven vitals of f in VitalWeights order, like
+// VitalsToValues; GCS is the total, from the components if GCS is 0.
+func (f VitalsF) Values() [7]float64 {
+	g := f.GCS
+	if !(g > 0) {
+		g = float64(GCSTotal(Vitals{GCSEye: f.GCSEye, GCSVerbal: f.GCSVerbal, GCSMotor: f.GCSMotor}))
+	}
+	return [7]float64{f.HR, f.RR, f.SBP, f.DBP, f.Temp, f.SpO2, g}
+}
+
+// AcuityF is AcuityWithOptions for fractional vitals: each vital's
+// deviation is taken at its exact value (Options.Exact), and the derived
+// terms at the rounded Vitals. Whole-number input scores exactly as
+// AcuityWithOptions does. It overrides o.Exact.
+func AcuityF(v VitalsF, resourceCount, maxResources int, vitalWeights [7]float64, resourceWeight float64, o Options) float64 {
+	x := v.Values()
+	o.Exact = &x
+	return AcuityWithOptions(v.Vitals(), resourceCount, maxResources, vitalWeights, resourceWeight, o)
+}
+
+// VitalComponentF is VitalComponentWithOptions for fractional vitals (see
+// AcuityF).
+func VitalComponentF(v VitalsF, weights [7]float64, o Options) float64 {
+	x := v.Values()
+	o.Exact = &x
+	return VitalComponentWithOptions(v.Vitals(), weights, o)
+}
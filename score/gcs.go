@@ -0,0 +1,57 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+// GCSBands holds the deviation assigned to each clinical GCS band when GCS
+// is scored categorically (Options.GCSBanded) rather than as |15 - GCS| / 6.
+//
+//	| Band     | GCS   | Default deviation | Linear equivalent |
+//	|----------|-------|-------------------|-------------------|
+//	| Normal   | 15    | 0.00              | 0.00              |
+//	| Mild     | 13-14 | 0.35              | 0.17-0.33         |
+//	| Moderate | 9-12  | 0.70              | 0.50-1.00         |
+//	| Severe   | 3-8   | 1.00              | 1.00              |
+type GCSBands struct {
+	Normal   float64
+	Mild     float64
+	Moderate float64
+	Severe   float64
+}
+
+// DefaultGCSBands returns the default band deviations (0, 0.35, 0.70, 1.0).
+func DefaultGCSBands() GCSBands {
+	return GCSBands{Normal: 0, Mild: 0.35, Moderate: 0.70, Severe: 1.0}
+}
+
+// Deviation returns the band deviation for total GCS g. Returns 0 for g <= 0
+// (missing); values above 15 are treated as 15.
+func (b GCSBands) Deviation(g int) float64 {
+	switch {
+	case g <= 0:
+		return 0
+	case g >= 15:
+		return b.Normal
+	case g >= 13:
+		return b.Mild
+	case g >= 9:
+		return b.Moderate
+	default:
+		return b.Severe
+	}
+}
+
+// Valid returns true if all band deviations are in [0, 1] and non-decreasing
+// with severity.
+func (b GCSBands) Valid() bool {
+	d := [4]float64{b.Normal, b.Mild, b.Moderate, b.Severe}
+	for i, x := range d {
+		if !(x >= 0 && x <= 1) {
+			return false
+		}
+		if i > 0 && x < d[i-1] {
+			return false
+		}
+	}
+	return true
+}
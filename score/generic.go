@@ -0,0 +1,227 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// VitalName names a signal in a map-based vitals input (see SplitMap), so
+// that device feeds can carry signals the Vitals struct does not know. The
+// built-in names map to Vitals and ExtendedVitals fields; any other name
+// must be registered in a Registry with its norm and weight.
+//
+//	| Names                                     | Maps to                          |
+//	|-------------------------------------------|----------------------------------|
+//	| hr, rr, sbp, dbp, temp, spo2, gcs         | The Vitals field (rounded)       |
+//	| gcs_eye, gcs_verbal, gcs_motor, fio2      | The Vitals field                 |
+//	| on_oxygen                                 | Vitals.OnOxygen (non-zero: true) |
+//	| glucose, lactate, etco2, cap_refill, pain | The ExtendedVitals field         |
+//	| Registered names                          | Custom signals (Options.Custom)  |
+type VitalName string
+
+// Built-in vital names.
+const (
+	NameHR        VitalName = "hr"
+	NameRR        VitalName = "rr"
+	NameSBP       VitalName = "sbp"
+	NameDBP       VitalName = "dbp"
+	NameTemp      VitalName = "temp"
+	NameSpO2      VitalName = "spo2"
+	NameGCS       VitalName = "gcs"
+	NameGCSEye    VitalName = "gcs_eye"
+	NameGCSVerbal VitalName = "gcs_verbal"
+	NameGCSMotor  VitalName = "gcs_motor"
+	NameFiO2      VitalName = "fio2"
+	NameOnOxygen  VitalName = "on_oxygen"
+)
+
+// Builtin returns true if name maps to a Vitals or ExtendedVitals field.
+func Builtin(name VitalName) bool {
+	switch name {
+	case NameHR, NameRR, NameSBP, NameDBP, NameTemp, NameSpO2, NameGCS,
+		NameGCSEye, NameGCSVerbal, NameGCSMotor, NameFiO2, NameOnOxygen:
+		return true
+	}
+	return extendedIndex(name) >= 0
+}
+
+// extendedIndex returns the index of name in ExtendedNames, or -1.
+func extendedIndex(name VitalName) int {
+	for i, n := range ExtendedNames {
+		if VitalName(n) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// SignalSpec describes how a registered signal is scored: its deviation
+// against Norm (mid, halfWidth) on the Direction side, under
+// Options.Transform, joins the vital component with Weight.
+type SignalSpec struct {
+	Norm      [2]float64
+	Weight    float64
+	Direction Direction
+}
+
+// Valid returns true if the norm is finite with a positive half-width, the
+// weight is in [0, 1] and the direction is defined.
+func (s SignalSpec) Valid() bool {
+	return !math.IsNaN(s.Norm[0]) && !math.IsInf(s.Norm[0], 0) &&
+		s.Norm[1] > 0 && !math.IsInf(s.Norm[1], 0) &&
+		s.Weight >= 0 && s.Weight <= 1 && s.Direction.Valid()
+}
+
+// Registry holds the custom signals accepted in map-based input. It is
+// safe for concurrent use, so signals can be registered at run time (e.g.
+// from configuration) while engines score with it.
+type Registry struct {
+	mu    sync.Mutex
+	specs map[VitalName]SignalSpec
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{specs: make(map[VitalName]SignalSpec)}
+}
+
+// Register adds or replaces the signal name. It fails for built-in and
+// empty names and for an invalid spec.
+func (r *Registry) Register(name VitalName, s SignalSpec) error {
+	switch {
+	case name == "":
+		return errors.New("score: register: empty signal name")
+	case Builtin(name):
+		return fmt.Errorf("score: register: %q is a built-in vital", name)
+	case !s.Valid():
+		return fmt.Errorf("score: register: invalid spec for %q", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.specs[name] = s
+	return nil
+}
+
+// Lookup returns the spec of a registered signal.
+func (r *Registry) Lookup(name VitalName) (SignalSpec, bool) {
+	if r == nil {
+		return SignalSpec{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.specs[name]
+	return s, ok
+}
+
+// Names returns the registered signal names, sorted.
+func (r *Registry) Names() []VitalName {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]VitalName, 0, len(r.specs))
+	for n := range r.specs {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// SplitMap splits a map-based input into Vitals, ExtendedVitals and the
+// custom signals registered in r (which may be nil). Integer vitals are
+// rounded, a pain entry sets PainRecorded, and on_oxygen is true if
+// non-zero. It fails on a name that is neither built in nor registered, and
+// on a NaN or infinite value.
+func SplitMap(m map[VitalName]float64, r *Registry) (Vitals, ExtendedVitals, map[VitalName]float64, error) {
+	var v Vitals
+	var x ExtendedVitals
+	var custom map[VitalName]float64
+	names := make([]VitalName, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	for _, name := range names {
+		val := m[name]
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return Vitals{}, ExtendedVitals{}, nil, fmt.Errorf("score: vital %q: value %v is not finite", name, val)
+		}
+		n := int(math.Round(val))
+		switch name {
+		case NameHR:
+			v.HR = n
+		case NameRR:
+			v.RR = n
+		case NameSBP:
+			v.SBP = n
+		case NameDBP:
+			v.DBP = n
+		case NameTemp:
+			v.Temp = val
+		case NameSpO2:
+			v.SpO2 = n
+		case NameGCS:
+			v.GCS = n
+		case NameGCSEye:
+			v.GCSEye = n
+		case NameGCSVerbal:
+			v.GCSVerbal = n
+		case NameGCSMotor:
+			v.GCSMotor = n
+		case NameFiO2:
+			v.FiO2 = val
+		case NameOnOxygen:
+			v.OnOxygen = val != 0
+		default:
+			switch extendedIndex(name) {
+			case 0:
+				x.Glucose = val
+			case 1:
+				x.Lactate = val
+			case 2:
+				x.EtCO2 = val
+			case 3:
+				x.CapRefill = val
+			case 4:
+				x.Pain, x.PainRecorded = n, true
+			default:
+				if _, ok := r.Lookup(name); !ok {
+					return Vitals{}, ExtendedVitals{}, nil, fmt.Errorf("score: unknown vital %q", name)
+				}
+				if custom == nil {
+					custom = make(map[VitalName]float64)
+				}
+				custom[name] = val
+			}
+		}
+	}
+	return v, x, custom, nil
+}
+
+// addCustom adds the custom signals registered in r, each with its spec's
+// weight, norm and direction, under t, in name order. Unregistered names
+// are skipped, and so are values <= 0 against a positive midpoint, which
+// read as missing as in Vitals.
+func addCustom(custom map[VitalName]float64, r *Registry, t DeviationTransform, sum, wSum *float64) {
+	names := make([]VitalName, 0, len(custom))
+	for n := range custom {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	for _, name := range names {
+		val := custom[name]
+		s, ok := r.Lookup(name)
+		if !ok || s.Weight <= 0 || (val <= 0 && s.Norm[0] > 0) {
+			continue
+		}
+		*sum += s.Weight * curve{t: t, dir: s.Direction}.deviation(val, s.Norm)
+		*wSum += s.Weight
+	}
+}
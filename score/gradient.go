@@ -0,0 +1,75 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import "math"
+
+// slope returns the derivative of c.deviation(v, norm) with respect to v.
+func (c curve) slope(v float64, norm [2]float64) float64 {
+	if c.fn != nil || norm[1] <= 0 || c.dir.ignores(v, norm[0]) {
+		return 0
+	}
+	d := c.t.Slope(ratio(v, norm[0], norm[1]), c.limit) / norm[1]
+	if v < norm[0] {
+		return -d
+	}
+	return d
+}
+
+// AcuityGradient returns ∂s/∂x_i for each vital at v, in VitalWeights order
+// and per unit of the vital (per bpm, per °C, ...), where s is
+// AcuityWithOptions with the same arguments. Vital i contributes
+//
+//	w_i · d_i'(x_i) / (W · divisor)
+//
+// with w_i its effective weight, d_i' the slope of its deviation curve (see
+// DeviationTransform.Slope) and W the total weight of the vital component;
+// under another Normalization, w_i / W is replaced by ∂V/∂d_i.
+// The derivative is analytic; it is 0 for missing vitals, banded GCS,
+// vitals scored by a DeviationFunc, a vital at its midpoint or past
+// saturation, and everywhere when the vital component or the score is
+// clamped at 1. Terms that combine vitals (MAP,
+// respiratory composite, qSOFA, trend) are held fixed.
+func AcuityGradient(v Vitals, resourceCount, maxResources int, vitalWeights [7]float64, resourceWeight float64, o Options) [7]float64 {
+	var g [7]float64
+	t := o.vitalTerms(v, vitalWeights)
+	div := WeightSum(vitalWeights) + resourceWeight
+	if t.wSum <= 0 || div <= 0 {
+		return g
+	}
+	vc, k := o.combine(t)
+	if vc >= 1 {
+		return g
+	}
+	if AcuityWithOptions(v, resourceCount, maxResources, vitalWeights, resourceWeight, o) >= 1 {
+		return g
+	}
+	_, norms := o.effective(v, vitalWeights)
+	x := o.values(v)
+	for i, ok := range Present(v) {
+		if !ok || (i == 6 && o.GCSBanded) {
+			continue
+		}
+		c := o.curve(i)
+		if i == 5 && c.deviation(x[i], norms[i])+OxygenDeviation(v) >= c.max() {
+			continue
+		}
+		g[i] = k[i] * c.slope(x[i], norms[i]) / div
+	}
+	return g
+}
+
+// MostInfluential returns the index of the vital whose change by step[i]
+// moves the score most, |g[i]|·step[i], and that change; steps put vitals
+// of different units on one scale, e.g. their half-widths or measurement
+// errors. It returns -1 if every change is 0.
+func MostInfluential(g, step [7]float64) (int, float64) {
+	best, most := -1, 0.0
+	for i := range g {
+		if d := math.Abs(g[i]) * step[i]; d > most {
+			best, most = i, d
+		}
+	}
+	return best, most
+}
@@ -0,0 +1,87 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+// MissingPolicy selects how vitals that are absent from Vitals enter the
+// vital component. Acuity averages over the vitals present only, which
+// lets one mildly abnormal vital, recorded alone, outscore a fully
+// documented stable patient; the other policies count the missing weight.
+//
+//	| Policy                 | Missing vital counts as                         |
+//	|------------------------|-------------------------------------------------|
+//	| MissingIgnore          | Nothing: mean over present vitals (default)     |
+//	| MissingPenalizeNeutral | Deviation 0 (normal)                            |
+//	| MissingPenalizeWorst   | Deviation 1 (maximally abnormal)                |
+//	| MissingRequireMinimum  | Deviation 0 while fewer than MinPresent present |
+type MissingPolicy int
+
+const (
+	MissingIgnore MissingPolicy = iota
+	MissingPenalizeNeutral
+	MissingPenalizeWorst
+	MissingRequireMinimum
+)
+
+// String returns the policy name.
+func (m MissingPolicy) String() string {
+	switch m {
+	case MissingIgnore:
+		return "ignore"
+	case MissingPenalizeNeutral:
+		return "neutral"
+	case MissingPenalizeWorst:
+		return "worst"
+	case MissingRequireMinimum:
+		return "require_minimum"
+	default:
+		return "unknown"
+	}
+}
+
+// Valid returns true if m is one of the defined policies.
+func (m MissingPolicy) Valid() bool {
+	return m >= MissingIgnore && m <= MissingRequireMinimum
+}
+
+// Counts returns true if m counts the vitals absent from v, given
+// minPresent for MissingRequireMinimum.
+func (m MissingPolicy) Counts(v Vitals, minPresent int) bool {
+	switch m {
+	case MissingPenalizeNeutral, MissingPenalizeWorst:
+		return true
+	case MissingRequireMinimum:
+		return PresentCount(v) < minPresent
+	default:
+		return false
+	}
+}
+
+// missingTerms returns what o.Missing adds to the weighted sum and to the
+// weight total for the vitals of v that are absent, given the effective
+// weights and norms. Vitals skipped by a half-width <= 0 in o.Norms, and
+// measured zeros (see Options.MeasuredZero), are not missing. counted marks
+// the vitals whose weight it adds.
+func missingTerms(v Vitals, weights [7]float64, norms [7][2]float64, o Options) (sum, wSum float64, counted [7]bool) {
+	zeros := o.zeros(v)
+	var nz int
+	for _, z := range zeros {
+		if z {
+			nz++
+		}
+	}
+	if !o.Missing.Counts(v, o.MinPresent-nz) {
+		return 0, 0, counted
+	}
+	var missing float64
+	for i, ok := range Present(v) {
+		if !ok && !zeros[i] && (o.Norms == nil || norms[i][1] > 0) {
+			missing += weights[i]
+			counted[i] = true
+		}
+	}
+	if o.Missing == MissingPenalizeWorst {
+		return missing, missing, counted
+	}
+	return 0, missing, counted
+}
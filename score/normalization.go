@@ -0,0 +1,116 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import "math"
+
+// Normalization selects how the weighted vital deviations combine into the
+// vital component. The weighted mean that Acuity uses dilutes a single
+// life-threatening deviation across many normal vitals; NormalizeMax lets
+// the worst vital dominate, and NormalizeSoftmax sits between the two.
+//
+//	| Normalization    | Vital component V, over counted terms j   | SpO2 60, rest normal |
+//	|------------------|-------------------------------------------|----------------------|
+//	| NormalizeSum     | Σ w_j d_j / Σ w_j (default)               | 0.16                 |
+//	| NormalizeMax     | max d_j                                   | 1                    |
+//	| NormalizeSoftmax | Σ w_j e^(β d_j) d_j / Σ w_j e^(β d_j)     | 0.97 (β 5)           |
+//
+// A term is a vital with non-zero weight (present, measured zero or counted
+// by Missing); the extension terms (MAP, extended, custom) enter as one
+// combined term. NormalizeSoftmax with β 0 is NormalizeSum
+// and tends to NormalizeMax as β grows.
+type Normalization int
+
+const (
+	NormalizeSum Normalization = iota
+	NormalizeMax
+	NormalizeSoftmax
+)
+
+// DefaultSoftmaxSharpness is β of NormalizeSoftmax when
+// Options.SoftmaxSharpness is 0.
+const DefaultSoftmaxSharpness = 5.0
+
+// String returns the normalization name.
+func (n Normalization) String() string {
+	switch n {
+	case NormalizeSum:
+		return "sum"
+	case NormalizeMax:
+		return "max"
+	case NormalizeSoftmax:
+		return "softmax"
+	default:
+		return "unknown"
+	}
+}
+
+// Valid returns true if n is one of the defined normalizations.
+func (n Normalization) Valid() bool {
+	return n >= NormalizeSum && n <= NormalizeSoftmax
+}
+
+// combine returns the vital component of t under o.Normalization, before
+// the cap at 1, and its sensitivity k[i] = ∂V/∂d_i to each vital's
+// deviation. t.wSum must be > 0.
+func (o Options) combine(t vitalTerms) (float64, [7]float64) {
+	var k [7]float64
+	switch o.Normalization {
+	case NormalizeMax:
+		best, v := -1, math.Inf(-1)
+		for i, w := range t.weight {
+			if w > 0 && t.term[i]/w > v {
+				best, v = i, t.term[i]/w
+			}
+		}
+		if t.otherW > 0 && t.other/t.otherW > v {
+			best, v = -1, t.other/t.otherW
+		}
+		if best >= 0 {
+			k[best] = 1
+		}
+		return v, k
+	case NormalizeSoftmax:
+		beta := o.SoftmaxSharpness
+		if beta == 0 {
+			beta = DefaultSoftmaxSharpness
+		}
+		var d [8]float64
+		var w [8]float64
+		for i := range t.weight {
+			if t.weight[i] > 0 {
+				d[i], w[i] = t.term[i]/t.weight[i], t.weight[i]
+			}
+		}
+		if t.otherW > 0 {
+			d[7], w[7] = t.other/t.otherW, t.otherW
+		}
+		// Shift by the largest deviation so the exponentials cannot overflow.
+		top := 0.0
+		for j := range d {
+			if w[j] > 0 {
+				top = math.Max(top, d[j])
+			}
+		}
+		var num, den float64
+		var e [8]float64
+		for j := range d {
+			if w[j] > 0 {
+				e[j] = w[j] * math.Exp(beta*(d[j]-top))
+				num += e[j] * d[j]
+				den += e[j]
+			}
+		}
+		v := num / den
+		for i := range k {
+			k[i] = e[i] / den * (1 + beta*(d[i]-v))
+		}
+		return v, k
+	default:
+		for i, w := range t.weight {
+			k[i] = w / t.wSum
+		}
+		return t.sum / t.wSum, k
+	}
+}
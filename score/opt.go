@@ -0,0 +1,161 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import "math"
+
+// VitalsOpt is Vitals with explicit presence: a nil field is not measured,
+// and a non-nil field holds the measured value, including 0. Vitals reads 0
+// as unknown, which silently drops the most critical readings there are: an
+// RR of 0 is apnea, an HR or SBP of 0 is arrest. Score a VitalsOpt with
+// Options.MeasuredZero set from MeasuredZero (or Engine.EvaluateOpt).
+//
+// A measured 0 is scored for HR, RR, SBP, DBP and SpO2. It is not a reading
+// of Temp or GCS (3-15); validate.VitalsOpt reports those as invalid and
+// scoring ignores them.
+type VitalsOpt struct {
+	HR   *int
+	RR   *int
+	SBP  *int
+	DBP  *int
+	Temp *float64
+	SpO2 *int
+	GCS  *int
+
+	GCSEye    *int
+	GCSVerbal *int
+	GCSMotor  *int
+
+	OnOxygen bool
+	FiO2     float64 // 0 = unknown, as in Vitals
+}
+
+// Int returns a pointer to n, for building a VitalsOpt literal.
+func Int(n int) *int { return &n }
+
+// Float returns a pointer to x, for building a VitalsOpt literal.
+func Float(x float64) *float64 { return &x }
+
+// ZeroScorable marks the vitals, in VitalWeights order, whose measured 0 is
+// scored (see VitalsOpt).
+var ZeroScorable = [7]bool{true, true, true, true, false, true, false}
+
+// OptFromVitals returns v as a VitalsOpt, leaving its zero (unknown) fields
+// nil.
+func OptFromVitals(v Vitals) VitalsOpt {
+	i := func(n int) *int {
+		if n == 0 {
+			return nil
+		}
+		return Int(n)
+	}
+	o := VitalsOpt{
+		HR:        i(v.HR),
+		RR:        i(v.RR),
+		SBP:       i(v.SBP),
+		DBP:       i(v.DBP),
+		SpO2:      i(v.SpO2),
+		GCS:       i(v.GCS),
+		GCSEye:    i(v.GCSEye),
+		GCSVerbal: i(v.GCSVerbal),
+		GCSMotor:  i(v.GCSMotor),
+		OnOxygen:  v.OnOxygen,
+		FiO2:      v.FiO2,
+	}
+	if v.Temp != 0 {
+		o.Temp = Float(v.Temp)
+	}
+	return o
+}
+
+// Vitals returns o as Vitals. Nil fields become 0, and so do measured
+// zeros: pass MeasuredZero alongside to keep them.
+func (o VitalsOpt) Vitals() Vitals {
+	i := func(p *int) int {
+		if p == nil {
+			return 0
+		}
+		return *p
+	}
+	v := Vitals{
+		HR:        i(o.HR),
+		RR:        i(o.RR),
+		SBP:       i(o.SBP),
+		DBP:       i(o.DBP),
+		SpO2:      i(o.SpO2),
+		GCS:       i(o.GCS),
+		GCSEye:    i(o.GCSEye),
+		GCSVerbal: i(o.GCSVerbal),
+		GCSMotor:  i(o.GCSMotor),
+		OnOxygen:  o.OnOxygen,
+		FiO2:      o.FiO2,
+	}
+	if o.Temp != nil {
+		v.Temp = *o.Temp
+	}
+	return v
+}
+
+// Measured reports which vitals of o are non-nil, in VitalWeights order.
+// GCS counts as measured if the total or all three components are.
+func (o VitalsOpt) Measured() [7]bool {
+	return [7]bool{
+		o.HR != nil,
+		o.RR != nil,
+		o.SBP != nil,
+		o.DBP != nil,
+		o.Temp != nil,
+		o.SpO2 != nil,
+		o.GCS != nil || (o.GCSEye != nil && o.GCSVerbal != nil && o.GCSMotor != nil),
+	}
+}
+
+// MeasuredZero reports which vitals of o were measured as 0, in
+// VitalWeights order, whether or not ZeroScorable.
+func (o VitalsOpt) MeasuredZero() [7]bool {
+	z := func(p *int) bool { return p != nil && *p == 0 }
+	return [7]bool{
+		z(o.HR),
+		z(o.RR),
+		z(o.SBP),
+		z(o.DBP),
+		o.Temp != nil && *o.Temp == 0,
+		z(o.SpO2),
+		z(o.GCS),
+	}
+}
+
+// zeros returns the vitals that o.MeasuredZero marks as a scored 0 in v:
+// ZeroScorable, and absent from v.
+func (o Options) zeros(v Vitals) [7]bool {
+	var z [7]bool
+	if o.MeasuredZero == ([7]bool{}) {
+		return z
+	}
+	p := Present(v)
+	for i := range z {
+		z[i] = o.MeasuredZero[i] && ZeroScorable[i] && !p[i]
+	}
+	return z
+}
+
+// zeroNorm returns the norm of vital i for a measured 0, with its Low
+// half-width from o.HalfWidths if set (0 is below every midpoint).
+func (o Options) zeroNorm(i int, norm [2]float64) [2]float64 {
+	if o.HalfWidths != nil && o.HalfWidths.Low[i] != 0 && norm[1] > 0 {
+		norm[1] = o.HalfWidths.Low[i]
+	}
+	return norm
+}
+
+// zeroDeviation returns the deviation of a measured 0 against norm.
+func (c curve) zeroDeviation(norm [2]float64) float64 {
+	if c.fn != nil {
+		return c.fn.apply(0, norm)
+	}
+	if norm[1] <= 0 || c.dir.ignores(0, norm[0]) {
+		return 0
+	}
+	return c.t.ApplyCapped(math.Abs(norm[0])/norm[1], c.limit)
+}
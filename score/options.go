@@ -0,0 +1,332 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import "math"
+
+// Options holds optional extensions to the acuity formula. The zero value
+// reproduces Acuity exactly.
+//
+//	| Field             | Zero value     | Effect                                         |
+//	|-------------------|----------------|------------------------------------------------|
+//	| MAPWeight         | 0 (off)        | Derived MAP joins the vital component          |
+//	| ResourceScale     | ResourceLinear | Mapping of resource count to [0, 1]            |
+//	| ResourceRate      | 0 (default k)  | Rate k of ResourceExp                          |
+//	| GCSBanded         | false          | GCS scored by GCSBands instead of linearly     |
+//	| RespiratoryWeight | 0 (off)        | Weighted RespiratoryComposite added to score   |
+//	| Norms             | nil            | Per-vital [mid, halfWidth] override            |
+//	| QSOFABump         | 0 (off)        | Added to the score when qSOFA is positive      |
+//	| Reliability       | nil            | Per-vital weight factor for the source         |
+//	| Asymmetric        | nil            | Per-side vital weights (below/above midpoint)  |
+//	| Transform         | linear         | Mapping of distance from the norm to deviation |
+//	| HalfWidths        | nil            | Per-side norm half-widths                      |
+//	| Directions        | nil            | Per-vital side that deviates (low, high, both) |
+//	| Missing           | MissingIgnore  | How absent vitals count (see MissingPolicy)    |
+//	| Extended          | nil            | ExtendedVitals join the vital component        |
+//	| MeasuredZero      | none           | Absent vitals scored as a measured 0           |
+//	| Custom            | nil            | Registered signals join the vital component    |
+//	| Previous          | nil            | Trend term for deterioration since Previous    |
+//	| Caps              | nil            | Per-vital deviation cap above 1                |
+//	| Exact             | nil            | Fractional vital values (see VitalsF)          |
+//	| Deviations        | nil            | Per-vital custom DeviationFunc                 |
+//	| ZScores           | nil            | Per-vital z-score norm (see ZNorm)             |
+//	| Normalization     | NormalizeSum   | How vital deviations combine into V            |
+type Options struct {
+	MAPWeight     float64
+	ResourceScale ResourceScale
+	GCSBanded     bool
+	GCSBands      GCSBands
+
+	// ResourceRate is the rate k of ResourceExp (see ScaleResourcesRate);
+	// 0 uses DefaultResourceRate.
+	ResourceRate float64
+
+	// RespiratoryWeight times the respiratory-distress composite (see
+	// RespiratoryComposite), against the effective RR and SpO2 norms, is
+	// added to the normalized score (then clamped to 1), like QSOFABump, so
+	// tachypnoea plus hypoxia can only raise the score.
+	RespiratoryWeight float64
+
+	// Norms overrides the package norms (see DefaultNorms), as in
+	// VitalComponentWithNorms: a vital whose half-width is <= 0 is skipped.
+	Norms *[7][2]float64
+
+	// QSOFABump is added to the normalized score (then clamped to 1) when
+	// QSOFA(v).Positive.
+	QSOFABump float64
+
+	// Reliability multiplies each vital weight in the weighted mean (see
+	// Reliability.Factors). The divisor in AcuityWithOptions is unchanged.
+	Reliability *[7]float64
+
+	// Asymmetric, if non-nil, replaces the weights passed to
+	// VitalComponentWithOptions per side of each vital's norm midpoint (see
+	// AsymmetricWeights.Select), before Reliability is applied. The divisor
+	// in AcuityWithOptions is unchanged.
+	Asymmetric *AsymmetricWeights
+
+	// Transform maps each vital's distance from its norm midpoint, in
+	// half-widths, to its deviation (see DeviationTransform). It applies to
+	// the seven vitals and MAP; banded GCS and the respiratory composite
+	// keep their own scales.
+	Transform DeviationTransform
+
+	// HalfWidths, if non-nil, replaces the half-width of each vital's norm
+	// per side of its midpoint (see HalfWidths.Select), including in the
+	// respiratory composite.
+	HalfWidths *HalfWidths
+
+	// Directions, if non-nil, makes vital i deviate only below
+	// (DirectionLow) or above (DirectionHigh) its norm midpoint; values on
+	// the other side score 0 but still count as present. It applies to the
+	// seven vitals and the respiratory composite, not to banded GCS or MAP.
+	Directions *[7]Direction
+
+	// Missing and MinPresent select how absent vitals count in the vital
+	// component (see MissingPolicy); MinPresent is used by
+	// MissingRequireMinimum only.
+	Missing    MissingPolicy
+	MinPresent int
+
+	// Extended, if non-nil, adds its recorded signs to the weighted mean
+	// that forms the vital component, each with its ExtendedWeights entry
+	// (0 leaves the sign out), against ExtendedNorms and under Transform.
+	// Like MAPWeight, ExtendedWeights do not enter the divisor of
+	// AcuityWithOptions, and absent extended signs are always ignored.
+	Extended        *ExtendedVitals
+	ExtendedWeights [NumExtended]float64
+
+	// MeasuredZero marks vitals, in VitalWeights order, that were measured
+	// as 0 (see VitalsOpt.MeasuredZero). Where such a vital reads 0 in
+	// Vitals it is scored as present at 0, with its Low asymmetric weight
+	// and half-width, instead of as missing. Only ZeroScorable vitals are
+	// affected; the respiratory composite and qSOFA still need RR > 0.
+	MeasuredZero [7]bool
+
+	// Custom holds signals from map-based input (see SplitMap), scored with
+	// their Registry spec and Transform. Like Extended they join the
+	// weighted mean only; names not registered in Registry are skipped.
+	Custom   map[VitalName]float64
+	Registry *Registry
+
+	// Previous, if non-nil, is the patient's earlier snapshot, taken
+	// TrendHours before v. The weighted trend deviations (see
+	// TrendComponent, with TrendWeights and TrendScales) are added to the
+	// normalized score, like QSOFABump, so rapid deterioration raises the
+	// acuity while absolute values are still borderline; improvement never
+	// lowers it.
+	Previous     *Vitals
+	TrendHours   float64
+	TrendWeights [7]float64
+	TrendScales  [7]float64
+
+	// Caps, if non-nil, lets vital i's deviation keep rising past 1, up to
+	// Caps[i] (see DeviationTransform.ApplyCapped); entries <= 1 keep the
+	// cap at 1. A deviation above 1 raises the weighted mean further, but
+	// the vital component itself is still capped to 1. It does not apply to
+	// banded GCS, MAP or the respiratory composite.
+	Caps *[7]float64
+
+	// Exact, if non-nil, holds the vitals' values in VitalWeights order,
+	// overriding the whole numbers of Vitals in each vital's own deviation
+	// term (see AcuityF); the derived terms (MAP, respiratory composite,
+	// qSOFA, trend, banded GCS) still read Vitals. Presence is taken from
+	// Vitals.
+	Exact *[7]float64
+
+	// Deviations, if non-nil, scores vital i with Deviations[i] where it is
+	// non-nil (see DeviationFunc), in place of Transform, Directions and
+	// Caps, against the vital's norm. It does not apply to banded GCS, MAP
+	// or the respiratory composite. Set Norms to the norms of
+	// VitalComponentWithNorms to score like it with custom functions.
+	Deviations *[7]DeviationFunc
+
+	// ZScores, if non-nil, scores each vital with a non-zero entry as a
+	// z-score against its population mean and SD (see ZNorm), in place of
+	// its entry in Norms or the package norms. Per-side weights and
+	// half-widths then split at the mean.
+	ZScores *[7]ZNorm
+
+	// Normalization selects how the weighted deviations combine into the
+	// vital component (see Normalization); SoftmaxSharpness is β of
+	// NormalizeSoftmax, 0 meaning DefaultSoftmaxSharpness.
+	Normalization    Normalization
+	SoftmaxSharpness float64
+}
+
+// curve returns the deviation curve of vital i under o.
+func (o Options) curve(i int) curve {
+	c := curve{t: o.Transform}
+	if o.Directions != nil {
+		c.dir = o.Directions[i]
+	}
+	if o.Caps != nil {
+		c.limit = o.Caps[i]
+	}
+	if o.Deviations != nil {
+		c.fn = o.Deviations[i]
+	}
+	return c
+}
+
+// values returns the values o scores the vitals of v at: o.Exact, or v's.
+func (o Options) values(v Vitals) [7]float64 {
+	if o.Exact != nil {
+		return *o.Exact
+	}
+	return VitalsToValues(v)
+}
+
+// VitalComponentWithOptions returns the vital component in [0, 1] like
+// VitalComponent, applying the formula extensions in o.
+func VitalComponentWithOptions(v Vitals, weights [7]float64, o Options) float64 {
+	t := o.vitalTerms(v, weights)
+	if t.wSum <= 0 {
+		return 0
+	}
+	raw, _ := o.combine(t)
+	if raw > 1 {
+		return 1
+	}
+	return raw
+}
+
+// effective returns the weights and norms that o scores the vitals of v
+// with: o.Norms or the package norms, ZScores, per-side weights and
+// half-widths, and Reliability.
+func (o Options) effective(v Vitals, weights [7]float64) ([7]float64, [7][2]float64) {
+	norms := o.baseNorms()
+	x := o.values(v)
+	if o.Asymmetric != nil {
+		weights = o.Asymmetric.selectValues(x, norms)
+	}
+	if o.HalfWidths != nil {
+		norms = o.HalfWidths.selectValues(x, Present(v), norms)
+	}
+	if o.Reliability != nil {
+		for i, f := range o.Reliability {
+			weights[i] *= f
+		}
+	}
+	return weights, norms
+}
+
+// vitalTerms holds the weighted mean that forms the vital component, and
+// each vital's share of it: term[i] is vital i's weighted deviation and
+// weight[i] the weight it adds to wSum (0 if it does not count); missing
+// marks the absent vitals counted by o.Missing, and other and otherW are
+// the weighted sum and weight of the extension terms (MAP, extended,
+// custom, respiratory).
+type vitalTerms struct {
+	sum, wSum     float64
+	term, weight  [7]float64
+	missing       [7]bool
+	other, otherW float64
+}
+
+// vitalTerms returns the terms of the vital component of v under o.
+func (o Options) vitalTerms(v Vitals, weights [7]float64) vitalTerms {
+	var t vitalTerms
+	weights, norms := o.effective(v, weights)
+	add := addVital
+	if o.Norms != nil {
+		add = addVitalNorm
+	}
+	// one adds the term f gives vital i to the sums and records it.
+	one := func(i int, f func(sum, wSum *float64)) {
+		var s, w float64
+		f(&s, &w)
+		t.sum += s
+		t.wSum += w
+		t.term[i] += s
+		t.weight[i] += w
+	}
+	x := o.values(v)
+	for i := 0; i < 5; i++ {
+		one(i, func(s, w *float64) { add(o.curve(i), x[i], weights[i], norms[i], s, w, i == 4) })
+	}
+	if o.Norms == nil || norms[5][1] > 0 {
+		one(5, func(s, w *float64) { addSpO2(o.curve(5), v, x[5], weights[5], norms[5], s, w) })
+	}
+	if o.GCSBanded {
+		if g := GCSTotal(v); g > 0 {
+			one(6, func(s, w *float64) { *s, *w = weights[6]*o.GCSBands.Deviation(g), weights[6] })
+		}
+	} else {
+		one(6, func(s, w *float64) { add(o.curve(6), x[6], weights[6], norms[6], s, w, false) })
+	}
+	for i, z := range o.zeros(v) {
+		if z && (o.Norms == nil || norms[i][1] > 0) {
+			d := o.curve(i).zeroDeviation(o.zeroNorm(i, norms[i]))
+			if i == 5 {
+				d = math.Min(o.curve(i).max(), d+OxygenDeviation(v))
+			}
+			one(i, func(s, w *float64) { *s, *w = weights[i]*d, weights[i] })
+		}
+	}
+	ms, mw, counted := missingTerms(v, weights, norms, o)
+	t.sum, t.wSum, t.missing = t.sum+ms, t.wSum+mw, counted
+	for i, c := range counted {
+		if c {
+			t.weight[i] += weights[i]
+			if o.Missing == MissingPenalizeWorst {
+				t.term[i] += weights[i]
+			}
+		}
+	}
+	// extra adds an extension term to the sums and to other.
+	extra := func(f func(sum, wSum *float64)) {
+		var s, w float64
+		f(&s, &w)
+		t.sum += s
+		t.wSum += w
+		t.other += s
+		t.otherW += w
+	}
+	if o.MAPWeight > 0 {
+		extra(func(s, w *float64) { addVital(curve{t: o.Transform}, MAP(v), o.MAPWeight, MAPNorm, s, w, false) })
+	}
+	if o.Extended != nil {
+		extra(func(s, w *float64) { addExtended(*o.Extended, o.ExtendedWeights, o.Transform, s, w) })
+	}
+	if len(o.Custom) > 0 {
+		extra(func(s, w *float64) { addCustom(o.Custom, o.Registry, o.Transform, s, w) })
+	}
+	return t
+}
+
+// respiratory returns the respiratory-composite bump of v under o:
+// RespiratoryWeight times the composite against the effective RR and SpO2
+// norms, or 0 if RR or SpO2 is absent.
+func (o Options) respiratory(v Vitals, weights [7]float64) float64 {
+	if o.RespiratoryWeight <= 0 || v.RR <= 0 || v.SpO2 <= 0 {
+		return 0
+	}
+	_, norms := o.effective(v, weights)
+	return o.RespiratoryWeight * respiratoryComposite(v, norms[1], norms[5], o.curve(1).dir, o.curve(5).dir)
+}
+
+// AcuityWithOptions returns the normalized acuity score in [0, 1] like Acuity,
+// applying the formula extensions in o. Extension weights (MAPWeight,
+// ExtendedWeights) take part in the weighted mean that forms the vital
+// component only; the divisor stays sum(vitalWeights) + resourceWeight.
+// The respiratory composite, the qSOFA bump and the trend term are added to
+// the normalized score.
+func AcuityWithOptions(v Vitals, resourceCount, maxResources int, vitalWeights [7]float64, resourceWeight float64, o Options) float64 {
+	vSum := VitalComponentWithOptions(v, vitalWeights, o)
+	rComp := ResourceComponentWithOptions(resourceCount, maxResources, resourceWeight, o)
+	raw := AcuityRaw(vSum, rComp)
+	div := WeightSum(vitalWeights) + resourceWeight
+	s := Normalize(raw, div)
+	if r := o.respiratory(v, vitalWeights); r > 0 {
+		s = Normalize(s+r, 1)
+	}
+	if o.QSOFABump > 0 && QSOFA(v).Positive {
+		s = Normalize(s+o.QSOFABump, 1)
+	}
+	if t := o.trend(v); t > 0 {
+		s = Normalize(s+t, 1)
+	}
+	return s
+}
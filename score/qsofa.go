@@ -0,0 +1,47 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+// qSOFA criteria (Sepsis-3). Each criterion met scores 1 point.
+//
+//	| Criterion          | Rule        |
+//	|--------------------|-------------|
+//	| Respiratory rate   | RR >= 22    |
+//	| Systolic BP        | SBP <= 100  |
+//	| Altered mentation  | GCS < 15    |
+const (
+	QSOFARRThreshold  = 22
+	QSOFASBPThreshold = 100
+	QSOFAPositiveAt   = 2
+)
+
+// QSOFAResult holds the qSOFA score and which criteria were met. Missing
+// vitals do not meet their criterion.
+type QSOFAResult struct {
+	Score     int // 0..3
+	RR        bool
+	SBP       bool
+	Mentation bool
+	Positive  bool // Score >= QSOFAPositiveAt
+}
+
+// QSOFA computes the quick SOFA score from v. GCS may be given as total or
+// components (see GCSTotal). For screening only; not a diagnosis of sepsis.
+func QSOFA(v Vitals) QSOFAResult {
+	var r QSOFAResult
+	if v.RR >= QSOFARRThreshold {
+		r.RR = true
+		r.Score++
+	}
+	if v.SBP > 0 && v.SBP <= QSOFASBPThreshold {
+		r.SBP = true
+		r.Score++
+	}
+	if g := GCSTotal(v); g > 0 && g < 15 {
+		r.Mentation = true
+		r.Score++
+	}
+	r.Positive = r.Score >= QSOFAPositiveAt
+	return r
+}
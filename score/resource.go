@@ -0,0 +1,113 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import "math"
+
+// ResourceScale selects how the resource ratio r = count / maxResources is
+// mapped to [0, 1] before weighting. All scales give 0 at count 0 and 1 at
+// count >= maxResources; the concave scales make each extra resource count
+// for less than the previous one. ResourceExp has a rate k (see
+// ScaleResourcesRate); the table uses DefaultResourceRate.
+//
+//	| Scale             | f(count)                                 | 1 vs 2 | 5 vs 6 (max 6) |
+//	|-------------------|------------------------------------------|--------|----------------|
+//	| ResourceLinear    | min(1, n/max)                            | 0.167  | 0.167          |
+//	| ResourceLog1p     | log(1+n) / log(1+max)                    | 0.208  | 0.079          |
+//	| ResourceSqrt      | sqrt(n/max)                              | 0.169  | 0.087          |
+//	| ResourcePiecewise | 4r/3 for r <= 1/2, then 2/3 + 2(r-1/2)/3 | 0.222  | 0.111          |
+//	| ResourceExp       | (1 - e^(-k·n)) / (1 - e^(-k·max))        | 0.251  | 0.034          |
+type ResourceScale int
+
+const (
+	ResourceLinear ResourceScale = iota
+	ResourceLog1p
+	ResourceSqrt
+	ResourcePiecewise
+	ResourceExp
+)
+
+// DefaultResourceRate is the rate k of ResourceExp when none is given.
+const DefaultResourceRate = 0.5
+
+// String returns the scale name.
+func (s ResourceScale) String() string {
+	switch s {
+	case ResourceLinear:
+		return "linear"
+	case ResourceLog1p:
+		return "log1p"
+	case ResourceSqrt:
+		return "sqrt"
+	case ResourcePiecewise:
+		return "piecewise"
+	case ResourceExp:
+		return "exp"
+	default:
+		return "unknown"
+	}
+}
+
+// Valid returns true if s is one of the defined scales.
+func (s ResourceScale) Valid() bool {
+	return s >= ResourceLinear && s <= ResourceExp
+}
+
+// ScaleResources returns f(resourceCount) in [0, 1] for the given scale.
+// Returns 0 if maxResources <= 0 or resourceCount <= 0. Unknown scales fall
+// back to ResourceLinear. ResourceExp uses DefaultResourceRate.
+func ScaleResources(resourceCount, maxResources int, scale ResourceScale) float64 {
+	return ScaleResourcesRate(resourceCount, maxResources, scale, 0)
+}
+
+// ScaleResourcesRate is like ScaleResources with rate k for ResourceExp,
+// where a larger k saturates sooner: at k = 1 and max 6, two resources
+// reach 0.87. A rate <= 0 uses DefaultResourceRate; other scales ignore it.
+func ScaleResourcesRate(resourceCount, maxResources int, scale ResourceScale, rate float64) float64 {
+	if maxResources <= 0 || resourceCount <= 0 {
+		return 0
+	}
+	n := float64(resourceCount)
+	max := float64(maxResources)
+	if n > max {
+		n = max
+	}
+	r := n / max
+	switch scale {
+	case ResourceLog1p:
+		return math.Log1p(n) / math.Log1p(max)
+	case ResourceSqrt:
+		return math.Sqrt(r)
+	case ResourcePiecewise:
+		if r <= 0.5 {
+			return r * 4 / 3
+		}
+		return 2.0/3 + (r-0.5)*2/3
+	case ResourceExp:
+		if rate <= 0 {
+			rate = DefaultResourceRate
+		}
+		return -math.Expm1(-rate*n) / -math.Expm1(-rate*max)
+	default:
+		return r
+	}
+}
+
+// ResourceComponentScaled is like ResourceComponent but maps the resource
+// count through scale. ResourceLinear gives the same result as ResourceComponent.
+func ResourceComponentScaled(resourceCount, maxResources int, weight float64, scale ResourceScale) float64 {
+	if weight <= 0 {
+		return 0
+	}
+	return weight * ScaleResources(resourceCount, maxResources, scale)
+}
+
+// ResourceComponentWithOptions is like ResourceComponentScaled with
+// o.ResourceScale and o.ResourceRate.
+func ResourceComponentWithOptions(resourceCount, maxResources int, weight float64, o Options) float64 {
+	if weight <= 0 {
+		return 0
+	}
+	return weight * ScaleResourcesRate(resourceCount, maxResources, o.ResourceScale, o.ResourceRate)
+}
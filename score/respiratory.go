@@ -0,0 +1,31 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+// RespiratoryComposite returns the respiratory-distress sub-score in [0, 1]:
+//
+//	resp = min(1, d_RR * d_SpO2 * (1 + OxygenDeviation(v)))
+//
+// where d_RR and d_SpO2 are the room-air deviations from the package RR and
+// SpO2 norms (see DefaultNorms).
+// The product is non-zero only when both tachypnoea (or bradypnoea) and
+// hypoxia are present, capturing the interaction that the independent linear
+// sum under-scores. Returns 0 unless both RR and SpO2 are present.
+func RespiratoryComposite(v Vitals) float64 {
+	n := DefaultNorms()
+	return respiratoryComposite(v, n[1], n[5], DirectionBoth, DirectionBoth)
+}
+
+func respiratoryComposite(v Vitals, rrNorm, spo2Norm [2]float64, rrDir, spo2Dir Direction) float64 {
+	if v.RR <= 0 || v.SpO2 <= 0 {
+		return 0
+	}
+	dRR := curve{dir: rrDir}.deviation(float64(v.RR), rrNorm)
+	dSpO2 := curve{dir: spo2Dir}.deviation(float64(v.SpO2), spo2Norm)
+	c := dRR * dSpO2 * (1 + OxygenDeviation(v))
+	if c > 1 {
+		return 1
+	}
+	return c
+}
@@ -7,11 +7,23 @@
 // normal ranges) and expected resource consumption, then normalized to [0, 1].
 package score
 
-import "math"
+import (
+	"math"
+
+	"github.com/olaflaitinen/triagegeist/norm"
+)
 
 // Vitals holds one set of vital signs. Units: HR (bpm), RR (per min),
 // SBP/DBP (mmHg), Temp (Celsius), SpO2 (%), GCS (3-15). Use 0 for unknown;
 // unknown values are excluded from the weighted sum.
+//
+// GCS may be given either as the total or as its eye/verbal/motor components
+// (E 1-4, V 1-5, M 1-6). When GCS is 0 and all three components are present,
+// the total is computed from the components; see GCSTotal.
+//
+// OnOxygen and FiO2 describe supplemental oxygen. An SpO2 reading achieved on
+// oxygen is scored as more severe than the same reading on room air (NEWS2
+// style); see OxygenDeviation.
 type Vitals struct {
 	HR   int     // Heart rate, beats per minute
 	RR   int     // Respiratory rate, per minute
@@ -20,64 +32,151 @@ type Vitals struct {
 	Temp float64 // Temperature, Celsius
 	SpO2 int     // Oxygen saturation, percent
 	GCS  int     // Glasgow Coma Scale, 3-15
+
+	GCSEye    int // GCS eye opening, 1-4
+	GCSVerbal int // GCS verbal response, 1-5
+	GCSMotor  int // GCS motor response, 1-6
+
+	OnOxygen bool    // Supplemental oxygen in use
+	FiO2     float64 // Fraction of inspired oxygen, 0.21-1.0 (0 = unknown)
+}
+
+// GCSTotal returns the Glasgow Coma Scale total for v. If GCS is set it is
+// returned as is; otherwise the sum of the E/V/M components is returned when
+// all three are present. Returns 0 (missing) if neither is available.
+func GCSTotal(v Vitals) int {
+	if v.GCS > 0 {
+		return v.GCS
+	}
+	if v.GCSEye > 0 && v.GCSVerbal > 0 && v.GCSMotor > 0 {
+		return v.GCSEye + v.GCSVerbal + v.GCSMotor
+	}
+	return 0
 }
 
 // VitalWeights is the default weight vector (HR, RR, SBP, DBP, Temp, SpO2, GCS).
 var VitalWeights = [7]float64{0.18, 0.22, 0.16, 0.10, 0.08, 0.16, 0.10}
 
-// Normal ranges (mid and half-width) for deviation calculation.
-// Deviation = |value - mid| / halfWidth, capped at 1.
+// Normal ranges (mid and half-width) of the seven vitals, copied from
+// norm.DefaultRanges, which is the single definition of the package norms
+// (see DefaultNorms).
+//
+// The legacy functions VitalComponent and Acuity score against these
+// variables, so existing code that adjusts them keeps working; every other
+// function, and the root engine, uses DefaultNorms.
+//
+// Deprecated: Use norm.DefaultRanges, or pass a norm.Ranges to
+// AcuityWithRanges.
 var (
-	HRNorm   = [2]float64{80, 40}
-	RRNorm   = [2]float64{16, 10}
-	SBPNorm  = [2]float64{120, 40}
-	DBPNorm  = [2]float64{80, 30}
-	TempNorm = [2]float64{37.0, 2.0}
-	SpO2Norm = [2]float64{98, 8}
-	GCSNorm  = [2]float64{15, 6}
+	HRNorm   = norm.DefaultRanges().HR
+	RRNorm   = norm.DefaultRanges().RR
+	SBPNorm  = norm.DefaultRanges().SBP
+	DBPNorm  = norm.DefaultRanges().DBP
+	TempNorm = norm.DefaultRanges().Temp
+	SpO2Norm = norm.DefaultRanges().SpO2
+	GCSNorm  = norm.DefaultRanges().GCS
 )
 
-// deviation returns |v - mid| / hw capped to 1. If hw <= 0 or v is "unknown", returns 0.
-func deviation(v float64, mid, hw float64) float64 {
-	if hw <= 0 {
+// MAPNorm is the normal range (mid and half-width) of the derived mean
+// arterial pressure (see MAP).
+var MAPNorm = [2]float64{93, 23}
+
+// MAP returns the mean arterial pressure (SBP + 2*DBP) / 3 in mmHg.
+// Returns 0 (missing) unless both SBP and DBP are present.
+func MAP(v Vitals) float64 {
+	if v.SBP <= 0 || v.DBP <= 0 {
 		return 0
 	}
-	if v <= 0 && mid > 0 {
+	return (float64(v.SBP) + 2*float64(v.DBP)) / 3
+}
+
+// SupplementalO2Deviation is the deviation added to SpO2 when the patient is
+// on supplemental oxygen at unknown or minimal FiO2. NEWS2 scores any
+// supplemental oxygen as +2 of a maximum 3 for SpO2.
+var SupplementalO2Deviation = 0.25
+
+// RoomAirFiO2 is the fraction of inspired oxygen on room air.
+const RoomAirFiO2 = 0.21
+
+// MaxThresholdMargin is the widest admissible margin around a level
+// threshold, in acuity score units: the bound of the root package's
+// GrayZone and Hysteresis parameters, shared with package validate.
+const MaxThresholdMargin = 0.25
+
+// OxygenDeviation returns the extra SpO2 deviation in [0, 1] for supplemental
+// oxygen. Returns 0 on room air (OnOxygen false and FiO2 <= RoomAirFiO2).
+// On oxygen, the extra is SupplementalO2Deviation, rising linearly to 1 as
+// FiO2 goes from RoomAirFiO2 to 1.0 (e.g. 15 L/min via non-rebreather).
+func OxygenDeviation(v Vitals) float64 {
+	if !v.OnOxygen && v.FiO2 <= RoomAirFiO2 {
 		return 0
 	}
-	d := math.Abs(v-mid) / hw
-	if d > 1 {
-		return 1
+	extra := SupplementalO2Deviation
+	if v.FiO2 > RoomAirFiO2 {
+		f := (v.FiO2 - RoomAirFiO2) / (1 - RoomAirFiO2)
+		if f > 1 {
+			f = 1
+		}
+		extra += (1 - extra) * f
 	}
-	return d
+	return extra
+}
+
+// curve maps a vital value to its deviation under Options.Transform and
+// the vital's Options.Directions and Options.Caps entries, or its
+// Options.Deviations function, which replaces them. The zero value gives
+// |v - mid| / hw capped to 1, or 0 if hw <= 0 or v is "unknown".
+type curve struct {
+	t     DeviationTransform
+	dir   Direction
+	limit float64
+	fn    DeviationFunc
+}
+
+func (c curve) deviation(v float64, norm [2]float64) float64 {
+	if c.fn != nil {
+		return c.fn.apply(v, norm)
+	}
+	if c.dir.ignores(v, norm[0]) {
+		return 0
+	}
+	return c.t.ApplyCapped(ratio(v, norm[0], norm[1]), c.limit)
+}
+
+// max returns the largest deviation c gives: its limit, or 1.
+func (c curve) max() float64 {
+	return math.Max(1, c.limit)
 }
 
-func addVital(v float64, w float64, norm [2]float64, sum *float64, wSum *float64, isTemp bool) {
+// addSpO2 adds the SpO2 term for saturation x, including the supplemental
+// oxygen adjustment of v.
+func addSpO2(c curve, v Vitals, x float64, w float64, norm [2]float64, sum *float64, wSum *float64) {
+	if v.SpO2 <= 0 {
+		return
+	}
+	d := math.Min(c.max(), c.deviation(x, norm)+OxygenDeviation(v))
+	*sum += w * d
+	*wSum += w
+}
+
+func addVital(c curve, v float64, w float64, norm [2]float64, sum *float64, wSum *float64, isTemp bool) {
 	if (!isTemp && v > 0) || (isTemp && v != 0) {
-		*sum += w * deviation(v, norm[0], norm[1])
+		*sum += w * c.deviation(v, norm)
 		*wSum += w
 	}
 }
 
-// VitalComponent returns the weighted sum of vital deviations in [0, 1].
-// Uses the package-level VitalWeights; pass a custom slice if needed via AcuityRaw.
+// VitalComponent returns the weighted sum of vital deviations in [0, 1]
+// against the deprecated HRNorm … GCSNorm variables, which equal
+// DefaultNorms unless changed. Pass custom weights if needed via AcuityRaw.
 func VitalComponent(v Vitals, weights [7]float64) float64 {
-	var sum, wSum float64
-	addVital(float64(v.HR), weights[0], HRNorm, &sum, &wSum, false)
-	addVital(float64(v.RR), weights[1], RRNorm, &sum, &wSum, false)
-	addVital(float64(v.SBP), weights[2], SBPNorm, &sum, &wSum, false)
-	addVital(float64(v.DBP), weights[3], DBPNorm, &sum, &wSum, false)
-	addVital(v.Temp, weights[4], TempNorm, &sum, &wSum, true)
-	addVital(float64(v.SpO2), weights[5], SpO2Norm, &sum, &wSum, false)
-	addVital(float64(v.GCS), weights[6], GCSNorm, &sum, &wSum, false)
-	if wSum <= 0 {
-		return 0
-	}
-	raw := sum / wSum
-	if raw > 1 {
-		return 1
-	}
-	return raw
+	n := legacyNorms()
+	return VitalComponentWithOptions(v, weights, Options{Norms: &n})
+}
+
+// legacyNorms returns HRNorm … GCSNorm in VitalWeights order.
+func legacyNorms() [7][2]float64 {
+	return [7][2]float64{HRNorm, RRNorm, SBPNorm, DBPNorm, TempNorm, SpO2Norm, GCSNorm}
 }
 
 // ResourceComponent returns the resource contribution in [0, 1] for
@@ -121,6 +220,7 @@ func Normalize(raw, divisor float64) float64 {
 // Acuity returns the normalized acuity score in [0, 1] for the given vitals,
 // resource count, and weights. It uses VitalWeights and the provided
 // maxResources and resourceWeight to compute the divisor for normalization.
+// Like VitalComponent it reads the deprecated HRNorm … GCSNorm variables.
 func Acuity(v Vitals, resourceCount, maxResources int, vitalWeights [7]float64, resourceWeight float64) float64 {
 	vSum := VitalComponent(v, vitalWeights)
 	var wSum float64
@@ -138,12 +238,65 @@ func Acuity(v Vitals, resourceCount, maxResources int, vitalWeights [7]float64,
 func VitalsToValues(v Vitals) [7]float64 {
 	return [7]float64{
 		float64(v.HR), float64(v.RR), float64(v.SBP), float64(v.DBP),
-		v.Temp, float64(v.SpO2), float64(v.GCS),
+		v.Temp, float64(v.SpO2), float64(GCSTotal(v)),
+	}
+}
+
+// Present reports which vitals are present, in VitalWeights order.
+func Present(v Vitals) [7]bool {
+	x := VitalsToValues(v)
+	var p [7]bool
+	for i, val := range x {
+		p[i] = val > 0 || (i == 4 && val != 0)
+	}
+	return p
+}
+
+// Deviations returns the deviation in [0, 1] of each vital against norms, in
+// VitalWeights order, as used by VitalComponentWithNorms (SpO2 includes the
+// supplemental oxygen adjustment). Missing vitals have deviation 0.
+func Deviations(v Vitals, norms [7][2]float64) [7]float64 {
+	return DeviationsWithTransform(v, norms, DeviationTransform{})
+}
+
+// DeviationsWithTransform is like Deviations with each deviation mapped by
+// t, as VitalComponentWithOptions does with Options.Transform.
+func DeviationsWithTransform(v Vitals, norms [7][2]float64, t DeviationTransform) [7]float64 {
+	return DeviationsWithOptions(v, Options{Norms: &norms, Transform: t})
+}
+
+// DeviationsWithOptions returns the deviations of v as
+// VitalComponentWithOptions scores them under o: against o.Norms (or the
+// package norms) and ZScores, with HalfWidths, Transform, Directions and
+// MeasuredZero applied. GCS is reported on the linear scale even if o.GCSBanded is set.
+func DeviationsWithOptions(v Vitals, o Options) [7]float64 {
+	norms := o.baseNorms()
+	x := o.values(v)
+	p := Present(v)
+	if o.HalfWidths != nil {
+		norms = o.HalfWidths.selectValues(x, p, norms)
+	}
+	var d [7]float64
+	for i := range x {
+		if !p[i] {
+			continue
+		}
+		d[i] = o.curve(i).deviation(x[i], norms[i])
+	}
+	for i, z := range o.zeros(v) {
+		if z {
+			d[i] = o.curve(i).zeroDeviation(o.zeroNorm(i, norms[i]))
+			p[i] = true
+		}
 	}
+	if p[5] {
+		d[5] = math.Min(o.curve(5).max(), d[5]+OxygenDeviation(v))
+	}
+	return d
 }
 
 // PresentCount returns the number of vitals that are present (non-zero).
-// Temp is present if != 0.
+// Temp is present if != 0. GCS counts once, whether given as total or components.
 func PresentCount(v Vitals) int {
 	var n int
 	if v.HR > 0 {
@@ -164,33 +317,37 @@ func PresentCount(v Vitals) int {
 	if v.SpO2 > 0 {
 		n++
 	}
-	if v.GCS > 0 {
+	if GCSTotal(v) > 0 {
 		n++
 	}
 	return n
 }
 
-func addVitalNorm(v float64, w float64, norm [2]float64, sum *float64, wSum *float64, isTemp bool) {
+func addVitalNorm(c curve, v float64, w float64, norm [2]float64, sum *float64, wSum *float64, isTemp bool) {
 	if norm[1] <= 0 {
 		return
 	}
 	if (!isTemp && v > 0) || (isTemp && v != 0) {
-		*sum += w * deviation(v, norm[0], norm[1])
+		*sum += w * c.deviation(v, norm)
 		*wSum += w
 	}
 }
 
 // VitalComponentWithNorms computes the vital component using custom norms (mid, halfWidth) per vital.
 // norms[i] = [mid, halfWidth] for vital i (0..6). If norms[i][1] <= 0, that vital is skipped.
+// For custom per-vital deviation functions, use VitalComponentWithOptions with
+// Norms and Deviations set.
 func VitalComponentWithNorms(v Vitals, weights [7]float64, norms [7][2]float64) float64 {
 	var sum, wSum float64
-	addVitalNorm(float64(v.HR), weights[0], norms[0], &sum, &wSum, false)
-	addVitalNorm(float64(v.RR), weights[1], norms[1], &sum, &wSum, false)
-	addVitalNorm(float64(v.SBP), weights[2], norms[2], &sum, &wSum, false)
-	addVitalNorm(float64(v.DBP), weights[3], norms[3], &sum, &wSum, false)
-	addVitalNorm(v.Temp, weights[4], norms[4], &sum, &wSum, true)
-	addVitalNorm(float64(v.SpO2), weights[5], norms[5], &sum, &wSum, false)
-	addVitalNorm(float64(v.GCS), weights[6], norms[6], &sum, &wSum, false)
+	addVitalNorm(curve{}, float64(v.HR), weights[0], norms[0], &sum, &wSum, false)
+	addVitalNorm(curve{}, float64(v.RR), weights[1], norms[1], &sum, &wSum, false)
+	addVitalNorm(curve{}, float64(v.SBP), weights[2], norms[2], &sum, &wSum, false)
+	addVitalNorm(curve{}, float64(v.DBP), weights[3], norms[3], &sum, &wSum, false)
+	addVitalNorm(curve{}, v.Temp, weights[4], norms[4], &sum, &wSum, true)
+	if norms[5][1] > 0 {
+		addSpO2(curve{}, v, float64(v.SpO2), weights[5], norms[5], &sum, &wSum)
+	}
+	addVitalNorm(curve{}, float64(GCSTotal(v)), weights[6], norms[6], &sum, &wSum, false)
 	if wSum <= 0 {
 		return 0
 	}
@@ -201,11 +358,25 @@ func VitalComponentWithNorms(v Vitals, weights [7]float64, norms [7][2]float64)
 	return raw
 }
 
-// DefaultNorms returns the package-level norm arrays as [7][2]float64 for use with VitalComponentWithNorms.
+// DefaultNorms returns the package norms, norm.DefaultRanges in
+// VitalWeights order, for use with VitalComponentWithNorms. Every scoring
+// function except the legacy Acuity and VitalComponent (see HRNorm) scores
+// against them.
 func DefaultNorms() [7][2]float64 {
-	return [7][2]float64{
-		HRNorm, RRNorm, SBPNorm, DBPNorm, TempNorm, SpO2Norm, GCSNorm,
-	}
+	return norm.DefaultRanges().Array()
+}
+
+// VitalComponentWithRanges is like VitalComponent against the ranges r
+// instead of the package norms.
+func VitalComponentWithRanges(v Vitals, weights [7]float64, r norm.Ranges) float64 {
+	return VitalComponentWithNorms(v, weights, r.Array())
+}
+
+// AcuityWithRanges is like Acuity against the ranges r instead of the
+// package norms, e.g. norm.PediatricRanges. With norm.DefaultRanges it
+// equals Acuity.
+func AcuityWithRanges(v Vitals, resourceCount, maxResources int, vitalWeights [7]float64, resourceWeight float64, r norm.Ranges) float64 {
+	return AcuityWithNorms(v, resourceCount, maxResources, vitalWeights, resourceWeight, r.Array())
 }
 
 // AcuityWithNorms is like Acuity but uses VitalComponentWithNorms with the given norms.
@@ -230,12 +401,50 @@ func WeightSum(w [7]float64) float64 {
 	return s
 }
 
+// MergeVitals returns base with every vital present in update (non-zero)
+// replacing the corresponding value in base. GCS is merged as a group: if
+// update carries a GCS total or any component, all four GCS fields come from
+// update. Oxygen fields are taken from update when update.OnOxygen is true or
+// update.FiO2 is set; a partial update cannot switch oxygen off.
+func MergeVitals(base, update Vitals) Vitals {
+	out := base
+	if update.HR > 0 {
+		out.HR = update.HR
+	}
+	if update.RR > 0 {
+		out.RR = update.RR
+	}
+	if update.SBP > 0 {
+		out.SBP = update.SBP
+	}
+	if update.DBP > 0 {
+		out.DBP = update.DBP
+	}
+	if update.Temp != 0 {
+		out.Temp = update.Temp
+	}
+	if update.SpO2 > 0 {
+		out.SpO2 = update.SpO2
+	}
+	if update.GCS > 0 || update.GCSEye > 0 || update.GCSVerbal > 0 || update.GCSMotor > 0 {
+		out.GCS, out.GCSEye, out.GCSVerbal, out.GCSMotor = update.GCS, update.GCSEye, update.GCSVerbal, update.GCSMotor
+	}
+	if update.OnOxygen || update.FiO2 != 0 {
+		out.OnOxygen, out.FiO2 = update.OnOxygen, update.FiO2
+	}
+	return out
+}
+
 // CloneVitals returns a copy of v.
+//
+// Deprecated: Vitals is a value type; assign it to copy.
 func CloneVitals(v Vitals) Vitals {
 	return v
 }
 
 // ZeroVitals returns a Vitals struct with all fields zero.
+//
+// Deprecated: Use Vitals{}.
 func ZeroVitals() Vitals {
 	return Vitals{}
 }
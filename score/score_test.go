@@ -1,7 +1,10 @@
 package score
 
 import (
+	"math"
 	"testing"
+
+	"github.com/olaflaitinen/triagegeist/norm"
 )
 
 func TestVitalComponent(t *testing.T) {
@@ -46,3 +49,997 @@ func BenchmarkScore_Acuity(b *testing.B) {
 		_ = Acuity(benchVitals, 3, 6, VitalWeights, 0.25)
 	}
 }
+
+func TestGCSTotal(t *testing.T) {
+	if g := GCSTotal(Vitals{GCSEye: 2, GCSVerbal: 3, GCSMotor: 5}); g != 10 {
+		t.Errorf("GCSTotal(E2 V3 M5) = %d, want 10", g)
+	}
+	if g := GCSTotal(Vitals{GCS: 14, GCSEye: 2, GCSVerbal: 3, GCSMotor: 5}); g != 14 {
+		t.Errorf("explicit GCS should win, got %d", g)
+	}
+	if g := GCSTotal(Vitals{GCSEye: 2, GCSMotor: 5}); g != 0 {
+		t.Errorf("partial components should be missing, got %d", g)
+	}
+	a := VitalComponent(Vitals{GCS: 9}, VitalWeights)
+	b := VitalComponent(Vitals{GCSEye: 2, GCSVerbal: 2, GCSMotor: 5}, VitalWeights)
+	if a != b {
+		t.Errorf("components should score as total: %v != %v", a, b)
+	}
+}
+
+func TestOxygenDeviation(t *testing.T) {
+	air := Vitals{SpO2: 95}
+	o2 := Vitals{SpO2: 95, OnOxygen: true}
+	highFlow := Vitals{SpO2: 95, OnOxygen: true, FiO2: 1.0}
+	if d := OxygenDeviation(air); d != 0 {
+		t.Errorf("room air OxygenDeviation = %v, want 0", d)
+	}
+	a, b, c := VitalComponent(air, VitalWeights), VitalComponent(o2, VitalWeights), VitalComponent(highFlow, VitalWeights)
+	if !(a < b && b < c) {
+		t.Errorf("SpO2 95 should score air < O2 < 15L: %v, %v, %v", a, b, c)
+	}
+	if c != 1 {
+		t.Errorf("SpO2 95 on FiO2 1.0 should reach full deviation, got %v", c)
+	}
+}
+
+func TestMAP(t *testing.T) {
+	if m := MAP(Vitals{SBP: 120, DBP: 60}); m != 80 {
+		t.Errorf("MAP(120/60) = %v, want 80", m)
+	}
+	if m := MAP(Vitals{SBP: 120}); m != 0 {
+		t.Errorf("MAP without DBP = %v, want 0", m)
+	}
+	v := Vitals{HR: 80, SBP: 80, DBP: 40}
+	if c := VitalComponentWithOptions(v, VitalWeights, Options{MAPWeight: 0.2}); c <= VitalComponent(v, VitalWeights) {
+		t.Errorf("low MAP should raise the vital component, got %v", c)
+	}
+	// MAP joins the weighted mean, not the divisor: normal vitals stay
+	// near 0 and fully deviant vitals still reach 1.
+	o := Options{MAPWeight: 0.3}
+	normal := Vitals{HR: 80, RR: 16, SBP: 120, DBP: 80, Temp: 37, SpO2: 98, GCS: 15}
+	if a := AcuityWithOptions(normal, 0, 6, VitalWeights, 0.25, o); a > 0.01 {
+		t.Errorf("normal vitals with MAP = %v, want about 0", a)
+	}
+	worst := Vitals{HR: 200, RR: 40, SBP: 60, DBP: 30, Temp: 41, SpO2: 80, GCS: 3}
+	if a, b := AcuityWithOptions(worst, 6, 6, VitalWeights, 0.25, o), Acuity(worst, 6, 6, VitalWeights, 0.25); a != 1 || b != 1 {
+		t.Errorf("fully deviant vitals with MAP = %v, without = %v, want 1", a, b)
+	}
+}
+
+func TestScaleResources(t *testing.T) {
+	for _, sc := range []ResourceScale{ResourceLinear, ResourceLog1p, ResourceSqrt, ResourcePiecewise, ResourceExp} {
+		if f := ScaleResources(0, 6, sc); f != 0 {
+			t.Errorf("%v: f(0) = %v, want 0", sc, f)
+		}
+		if f := ScaleResources(6, 6, sc); math.Abs(f-1) > 1e-12 {
+			t.Errorf("%v: f(max) = %v, want 1", sc, f)
+		}
+		if f := ScaleResources(9, 6, sc); math.Abs(f-1) > 1e-12 {
+			t.Errorf("%v: f(>max) = %v, want 1", sc, f)
+		}
+		if sc == ResourceLinear {
+			continue
+		}
+		low := ScaleResources(2, 6, sc) - ScaleResources(1, 6, sc)
+		high := ScaleResources(6, 6, sc) - ScaleResources(5, 6, sc)
+		if high >= low {
+			t.Errorf("%v: 5->6 step %v should be smaller than 1->2 step %v", sc, high, low)
+		}
+	}
+	if a, b := ResourceComponent(3, 6, 0.25), ResourceComponentScaled(3, 6, 0.25, ResourceLinear); a != b {
+		t.Errorf("linear scaled %v != ResourceComponent %v", b, a)
+	}
+}
+
+func TestGCSBands(t *testing.T) {
+	b := DefaultGCSBands()
+	if !b.Valid() {
+		t.Fatal("DefaultGCSBands should be valid")
+	}
+	cases := map[int]float64{15: 0, 14: 0.35, 13: 0.35, 12: 0.70, 9: 0.70, 8: 1, 3: 1, 0: 0}
+	for g, want := range cases {
+		if d := b.Deviation(g); d != want {
+			t.Errorf("Deviation(%d) = %v, want %v", g, d, want)
+		}
+	}
+	if (GCSBands{Normal: 0, Mild: 0.8, Moderate: 0.5, Severe: 1}).Valid() {
+		t.Error("decreasing bands should be invalid")
+	}
+	o := Options{GCSBanded: true, GCSBands: b}
+	if VitalComponentWithOptions(Vitals{GCS: 13}, VitalWeights, o) != VitalComponentWithOptions(Vitals{GCS: 14}, VitalWeights, o) {
+		t.Error("GCS 13 and 14 should score the same when banded")
+	}
+}
+
+func TestRespiratoryComposite(t *testing.T) {
+	if c := RespiratoryComposite(Vitals{RR: 30}); c != 0 {
+		t.Errorf("composite without SpO2 = %v, want 0", c)
+	}
+	air := RespiratoryComposite(Vitals{RR: 26, SpO2: 92})
+	o2 := RespiratoryComposite(Vitals{RR: 26, SpO2: 92, OnOxygen: true})
+	if air <= 0 || o2 <= air {
+		t.Errorf("composite air=%v o2=%v; want 0 < air < o2", air, o2)
+	}
+	v := Vitals{HR: 90, RR: 26, SpO2: 92}
+	off := AcuityWithOptions(v, 0, 6, VitalWeights, 0.25, Options{})
+	on := AcuityWithOptions(v, 0, 6, VitalWeights, 0.25, Options{RespiratoryWeight: 0.2})
+	if on <= off {
+		t.Errorf("respiratory composite should raise acuity for tachypnoea plus hypoxia: %v <= %v", on, off)
+	}
+	// The composite is a bump on top of the score: it never lowers it, and
+	// the score rises monotonically with the weight.
+	o := Options{}
+	for _, in := range []Vitals{v, {RR: 16, SpO2: 98}, {HR: 150, RR: 40, SBP: 70, SpO2: 80, GCS: 8}} {
+		prev := AcuityWithOptions(in, 2, 6, VitalWeights, 0.25, o)
+		for w := 0.1; w <= 1; w += 0.1 {
+			o.RespiratoryWeight = w
+			s := AcuityWithOptions(in, 2, 6, VitalWeights, 0.25, o)
+			if s < prev {
+				t.Errorf("%+v: weight %.1f lowers acuity %v -> %v", in, w, prev, s)
+			}
+			prev = s
+		}
+		o.RespiratoryWeight = 0
+	}
+	if got, want := on-off, 0.2*air; math.Abs(got-want) > 1e-12 {
+		t.Errorf("bump = %v, want 0.2 * composite %v", got, want)
+	}
+}
+
+func TestQSOFA(t *testing.T) {
+	r := QSOFA(Vitals{RR: 24, SBP: 95, GCS: 15})
+	if r.Score != 2 || !r.Positive || r.Mentation {
+		t.Errorf("QSOFA(RR24 SBP95 GCS15) = %+v", r)
+	}
+	if r := QSOFA(Vitals{}); r.Score != 0 || r.Positive {
+		t.Errorf("QSOFA(missing) = %+v, want 0", r)
+	}
+	if r := QSOFA(Vitals{RR: 22, SBP: 100, GCSEye: 3, GCSVerbal: 4, GCSMotor: 6}); r.Score != 3 {
+		t.Errorf("QSOFA at thresholds with GCS 13 = %+v, want 3", r)
+	}
+	v := Vitals{RR: 24, SBP: 95}
+	base := AcuityWithOptions(v, 0, 6, VitalWeights, 0.25, Options{})
+	bumped := AcuityWithOptions(v, 0, 6, VitalWeights, 0.25, Options{QSOFABump: 0.1})
+	if math.Abs(bumped-base-0.1) > 1e-12 {
+		t.Errorf("QSOFABump: base %v bumped %v", base, bumped)
+	}
+}
+
+func TestMergeVitals(t *testing.T) {
+	base := Vitals{HR: 90, RR: 18, GCS: 15, OnOxygen: true, FiO2: 0.4}
+	m := MergeVitals(base, Vitals{HR: 110, GCSEye: 3, GCSVerbal: 4, GCSMotor: 6})
+	if m.HR != 110 || m.RR != 18 {
+		t.Errorf("MergeVitals HR/RR = %d/%d", m.HR, m.RR)
+	}
+	if m.GCS != 0 || GCSTotal(m) != 13 {
+		t.Errorf("GCS should merge as a group, got total %d", GCSTotal(m))
+	}
+	if !m.OnOxygen || m.FiO2 != 0.4 {
+		t.Errorf("oxygen should be kept from base: %+v", m)
+	}
+}
+
+func TestReliability_Factors(t *testing.T) {
+	r := DefaultReliability()
+	if !r.Valid() {
+		t.Fatal("DefaultReliability should be valid")
+	}
+	src := Sources{SourceWearable, SourceMonitor, Source(9)}
+	f := r.Factors(src)
+	if f[0] != WearableReliability || f[1] != 1 || f[2] != 1 {
+		t.Errorf("Factors = %v", f)
+	}
+	if (Reliability{}).Factors(src) != [7]float64{1, 1, 1, 1, 1, 1, 1} {
+		t.Error("zero Reliability should apply no down-weighting")
+	}
+	// Only wearables configured: the other sources keep full weight, and a
+	// configured zero still drops the vital.
+	var wear Reliability
+	wear[SourceWearable] = [7]float64{0.5, 0, 0.5, 0.5, 0.5, 0.5, 0.5}
+	got := wear.Factors(Sources{SourceWearable, SourceWearable, SourceManual, SourceMonitor})
+	if got != [7]float64{0.5, 0, 1, 1, 1, 1, 1} {
+		t.Errorf("single-source Factors = %v", got)
+	}
+	r[SourceManual][0] = 1.5
+	if r.Valid() {
+		t.Error("factor > 1 should be invalid")
+	}
+	if SourceWearable.String() != "wearable" || Source(-1).String() != "invalid" {
+		t.Error("Source.String")
+	}
+}
+
+func TestDeviations(t *testing.T) {
+	v := Vitals{HR: 120, SpO2: 90, OnOxygen: true, GCSEye: 4, GCSVerbal: 5, GCSMotor: 6}
+	p := Present(v)
+	if !p[0] || p[1] || !p[5] || !p[6] {
+		t.Errorf("Present = %v", p)
+	}
+	d := Deviations(v, DefaultNorms())
+	if d[0] != 1 || d[1] != 0 || d[6] != 0 {
+		t.Errorf("Deviations = %v", d)
+	}
+	if want := math.Min(1, 8.0/8+SupplementalO2Deviation); d[5] != want {
+		t.Errorf("SpO2 deviation = %v, want %v", d[5], want)
+	}
+}
+
+func TestAsymmetricWeights(t *testing.T) {
+	w := [7]float64{0.18, 0.22, 0.16, 0.10, 0.08, 0.16, 0.10}
+	low := Vitals{HR: 80, SBP: 80}
+	high := Vitals{HR: 80, SBP: 160}
+	sym := Symmetric(w)
+	for _, v := range []Vitals{low, high} {
+		if got, want := AcuityWithOptions(v, 2, 6, w, 0.25, Options{Asymmetric: &sym}), AcuityWithOptions(v, 2, 6, w, 0.25, Options{}); got != want {
+			t.Errorf("symmetric: %v, want %v", got, want)
+		}
+	}
+	a := sym
+	a.Low[2], a.High[2] = 0.5, 0.05
+	if sel := a.Select(low, DefaultNorms()); sel[2] != 0.5 || sel[0] != w[0] {
+		t.Errorf("Select(low) = %v", sel)
+	}
+	if sel := a.Select(high, DefaultNorms()); sel[2] != 0.05 {
+		t.Errorf("Select(high) = %v", sel)
+	}
+	o := Options{Asymmetric: &a}
+	if l, h := VitalComponentWithOptions(low, w, o), VitalComponentWithOptions(high, w, o); l <= h {
+		t.Errorf("low SBP should outweigh high SBP: %v <= %v", l, h)
+	}
+	if !a.Valid() {
+		t.Error("valid weights reported invalid")
+	}
+	a.High[0] = -0.1
+	if a.Valid() {
+		t.Error("negative weight should be invalid")
+	}
+}
+
+func TestDeviationTransform(t *testing.T) {
+	var lin DeviationTransform
+	for _, r := range []float64{0, 0.3, 1, 2.5} {
+		if got := lin.Apply(r); got != math.Min(1, r) {
+			t.Errorf("linear Apply(%v) = %v", r, got)
+		}
+	}
+	for _, tr := range []DeviationTransform{
+		{Kind: TransformLinear, Saturation: 2},
+		{Kind: TransformPower, Saturation: 2},
+		{Kind: TransformPower, Shape: 3},
+		{Kind: TransformSigmoid, Saturation: 3, Shape: 6},
+	} {
+		if !tr.Valid() {
+			t.Errorf("%+v reported invalid", tr)
+		}
+		s := math.Max(1, tr.Saturation)
+		if tr.Apply(0) != 0 || math.Abs(tr.Apply(s)-1) > 1e-12 || tr.Apply(10*s) != tr.Apply(s) {
+			t.Errorf("%+v: f(0) = %v, f(s) = %v, f(10s) = %v", tr, tr.Apply(0), tr.Apply(s), tr.Apply(10*s))
+		}
+		prev := 0.0
+		for r := 0.1; r <= s; r += 0.1 {
+			if d := tr.Apply(r); d < prev {
+				t.Errorf("%+v: not non-decreasing at %v", tr, r)
+			} else {
+				prev = d
+			}
+		}
+	}
+	pow := DeviationTransform{Kind: TransformPower, Saturation: 2}
+	if got := pow.Apply(1.1); math.Abs(got-0.3025) > 1e-12 {
+		t.Errorf("power Apply(1.1) = %v, want 0.3025", got)
+	}
+	sig := DeviationTransform{Kind: TransformSigmoid, Saturation: 2}
+	if got := sig.Apply(1); math.Abs(got-0.5) > 1e-12 {
+		t.Errorf("sigmoid Apply(1) = %v, want 0.5", got)
+	}
+
+	v := Vitals{HR: 125}
+	if got := VitalComponentWithOptions(v, VitalWeights, Options{}); got != 1 {
+		t.Errorf("linear component = %v, want 1", got)
+	}
+	got := VitalComponentWithOptions(v, VitalWeights, Options{Transform: pow})
+	if want := pow.Apply(45.0 / 40); math.Abs(got-want) > 1e-12 {
+		t.Errorf("power component = %v, want %v", got, want)
+	}
+	if d := DeviationsWithTransform(v, DefaultNorms(), pow); d[0] != pow.Apply(45.0/40) {
+		t.Errorf("DeviationsWithTransform = %v", d)
+	}
+
+	for _, bad := range []DeviationTransform{
+		{Kind: TransformKind(9)}, {Saturation: 0.5}, {Saturation: math.Inf(1)}, {Shape: -1}, {Shape: math.NaN()},
+	} {
+		if bad.Valid() {
+			t.Errorf("%+v reported valid", bad)
+		}
+	}
+}
+
+func TestHalfWidths(t *testing.T) {
+	w := VitalWeights
+	var h HalfWidths
+	h.Low[5], h.High[5] = 4, 20
+	o := Options{HalfWidths: &h}
+	low, high := Vitals{SpO2: 94}, Vitals{SpO2: 100}
+	if got := VitalComponentWithOptions(low, w, o); got != 1 {
+		t.Errorf("SpO2 94 = %v, want 1", got)
+	}
+	if got := VitalComponentWithOptions(high, w, o); math.Abs(got-0.1) > 1e-12 {
+		t.Errorf("SpO2 100 = %v, want 0.1", got)
+	}
+	if got, want := VitalComponentWithOptions(Vitals{HR: 120, SpO2: 94}, w, Options{HalfWidths: &HalfWidths{}}), VitalComponent(Vitals{HR: 120, SpO2: 94}, w); got != want {
+		t.Errorf("zero HalfWidths: %v, want %v", got, want)
+	}
+	n := h.Select(Vitals{HR: 90}, DefaultNorms())
+	if n[5] != SpO2Norm || n[0] != HRNorm {
+		t.Errorf("Select changed missing or unset vitals: %v", n)
+	}
+	if !h.Valid() {
+		t.Error("valid half-widths reported invalid")
+	}
+	h.High[0] = -1
+	if h.Valid() {
+		t.Error("negative half-width should be invalid")
+	}
+}
+
+func TestDirections(t *testing.T) {
+	w := VitalWeights
+	dirs := [7]Direction{5: DirectionLow}
+	o := Options{Directions: &dirs}
+	high := Vitals{HR: 80, SpO2: 100}
+	if got := VitalComponentWithOptions(high, w, o); got != 0 {
+		t.Errorf("SpO2 100 with DirectionLow = %v, want 0", got)
+	}
+	if got, want := VitalComponentWithOptions(Vitals{HR: 80, SpO2: 90}, w, o), VitalComponent(Vitals{HR: 80, SpO2: 90}, w); got != want {
+		t.Errorf("SpO2 90 with DirectionLow = %v, want %v", got, want)
+	}
+	if d := DeviationsWithOptions(high, o); d[5] != 0 {
+		t.Errorf("DeviationsWithOptions = %v", d)
+	}
+	resp := Options{Directions: &dirs, RespiratoryWeight: 0.2}
+	if got := AcuityWithOptions(Vitals{RR: 30, SpO2: 100}, 0, 6, w, 0.25, resp); got != AcuityWithOptions(Vitals{RR: 30, SpO2: 98}, 0, 6, w, 0.25, resp) {
+		t.Errorf("respiratory composite penalizes high SpO2 under DirectionLow: %v", got)
+	}
+	dirs[0] = DirectionHigh
+	if got := VitalComponentWithOptions(Vitals{HR: 50}, w, o); got != 0 {
+		t.Errorf("HR 50 with DirectionHigh = %v, want 0", got)
+	}
+	if DirectionLow.String() != "low" || Direction(7).Valid() {
+		t.Error("Direction String/Valid")
+	}
+}
+
+func TestMissingPolicy(t *testing.T) {
+	w := VitalWeights
+	one := Vitals{HR: 100} // deviation 0.5
+	if got := VitalComponentWithOptions(one, w, Options{}); got != 0.5 {
+		t.Errorf("ignore = %v, want 0.5", got)
+	}
+	total := WeightSum(w)
+	if got, want := VitalComponentWithOptions(one, w, Options{Missing: MissingPenalizeNeutral}), 0.5*w[0]/total; math.Abs(got-want) > 1e-12 {
+		t.Errorf("neutral = %v, want %v", got, want)
+	}
+	if got, want := VitalComponentWithOptions(one, w, Options{Missing: MissingPenalizeWorst}), (0.5*w[0]+total-w[0])/total; math.Abs(got-want) > 1e-12 {
+		t.Errorf("worst = %v, want %v", got, want)
+	}
+	min := Options{Missing: MissingRequireMinimum, MinPresent: 2}
+	if got, want := VitalComponentWithOptions(one, w, min), VitalComponentWithOptions(one, w, Options{Missing: MissingPenalizeNeutral}); got != want {
+		t.Errorf("require_minimum below minimum = %v, want %v", got, want)
+	}
+	two := Vitals{HR: 100, RR: 16}
+	if got, want := VitalComponentWithOptions(two, w, min), VitalComponent(two, w); got != want {
+		t.Errorf("require_minimum at minimum = %v, want %v", got, want)
+	}
+	full := Vitals{HR: 80, RR: 16, SBP: 120, DBP: 80, Temp: 37, SpO2: 98, GCS: 15}
+	for m := MissingIgnore; m.Valid(); m++ {
+		if got := VitalComponentWithOptions(full, w, Options{Missing: m, MinPresent: 7}); got != 0 {
+			t.Errorf("%v on a complete normal set = %v", m, got)
+		}
+	}
+	if MissingPolicy(9).Valid() || MissingRequireMinimum.String() != "require_minimum" {
+		t.Error("MissingPolicy String/Valid")
+	}
+}
+
+func TestExtendedVitals(t *testing.T) {
+	x := ExtendedVitals{Lactate: 4, Glucose: 6, PainRecorded: true}
+	if got := x.Present(); got != [NumExtended]bool{true, true, false, false, true} {
+		t.Errorf("Present = %v", got)
+	}
+	d := ExtendedDeviations(x, DeviationTransform{})
+	if d != [NumExtended]float64{0, 1, 0, 0, 0} {
+		t.Errorf("ExtendedDeviations = %v", d)
+	}
+	if d := ExtendedDeviations(ExtendedVitals{Lactate: 0.5}, DeviationTransform{}); d[1] != 0 {
+		t.Errorf("low lactate deviation = %v, want 0 (high side only)", d[1])
+	}
+	v := Vitals{HR: 80, RR: 16}
+	base := AcuityWithOptions(v, 0, 5, VitalWeights, 0.3, Options{})
+	o := Options{Extended: &x}
+	if got := AcuityWithOptions(v, 0, 5, VitalWeights, 0.3, o); got != base {
+		t.Errorf("zero ExtendedWeights changed acuity: %v, want %v", got, base)
+	}
+	o.ExtendedWeights = DefaultExtendedWeights()
+	if got := AcuityWithOptions(v, 0, 5, VitalWeights, 0.3, o); !(got > base) {
+		t.Errorf("raised lactate did not raise acuity: %v <= %v", got, base)
+	}
+	if !(ExtendedVitals{}).IsZero() || (ExtendedVitals{PainRecorded: true}).IsZero() {
+		t.Error("IsZero")
+	}
+}
+
+func TestVitalsOpt(t *testing.T) {
+	o := VitalsOpt{HR: Int(110), RR: Int(0), SpO2: Int(90), GCS: Int(0)}
+	if got := o.MeasuredZero(); got != [7]bool{false, true, false, false, false, false, true} {
+		t.Errorf("MeasuredZero = %v", got)
+	}
+	if got := o.Measured(); got != [7]bool{true, true, false, false, false, true, true} {
+		t.Errorf("Measured = %v", got)
+	}
+	v := o.Vitals()
+	if v.RR != 0 || v.HR != 110 || Present(v)[1] {
+		t.Errorf("Vitals = %+v", v)
+	}
+	if OptFromVitals(v).Vitals() != v || OptFromVitals(v).RR != nil {
+		t.Error("OptFromVitals round trip")
+	}
+
+	missing := AcuityWithOptions(v, 0, 5, VitalWeights, 0.3, Options{})
+	apnea := AcuityWithOptions(v, 0, 5, VitalWeights, 0.3, Options{MeasuredZero: o.MeasuredZero()})
+	if !(apnea > missing) {
+		t.Errorf("apnea %v not above missing RR %v", apnea, missing)
+	}
+	d := DeviationsWithOptions(v, Options{MeasuredZero: o.MeasuredZero()})
+	if d[1] != 1 || d[6] != 0 {
+		t.Errorf("deviations = %v, want RR 1 and GCS ignored", d)
+	}
+	w := Options{Missing: MissingPenalizeNeutral, MeasuredZero: o.MeasuredZero()}
+	if got := VitalComponentWithOptions(Vitals{RR: 0}, VitalWeights, w); math.Abs(got-VitalWeights[1]/WeightSum(VitalWeights)) > 1e-12 {
+		t.Errorf("neutral with apnea only = %v", got)
+	}
+	// A measured zero is ignored when the vital reads non-zero in Vitals.
+	if got := VitalComponentWithOptions(Vitals{RR: 16}, VitalWeights, Options{MeasuredZero: o.MeasuredZero()}); got != 0 {
+		t.Errorf("present RR overridden: %v", got)
+	}
+}
+
+func TestSplitMap(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("icp", SignalSpec{Norm: [2]float64{10, 10}, Weight: 0.1, Direction: DirectionHigh}); err != nil {
+		t.Fatal(err)
+	}
+	if r.Register(NameHR, SignalSpec{Norm: [2]float64{80, 40}}) == nil || r.Register("x", SignalSpec{}) == nil {
+		t.Error("Register should reject built-in names and invalid specs")
+	}
+	v, x, custom, err := SplitMap(map[VitalName]float64{"hr": 101.6, "temp": 38.2, "on_oxygen": 1, "lactate": 3, "pain": 0, "icp": 25}, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.HR != 102 || v.Temp != 38.2 || !v.OnOxygen || x.Lactate != 3 || !x.PainRecorded || custom["icp"] != 25 || len(custom) != 1 {
+		t.Errorf("SplitMap = %+v, %+v, %v", v, x, custom)
+	}
+	if _, _, _, err := SplitMap(map[VitalName]float64{"icp": 25}, nil); err == nil {
+		t.Error("expected an error for an unregistered name")
+	}
+	if _, _, _, err := SplitMap(map[VitalName]float64{"hr": math.NaN()}, r); err == nil {
+		t.Error("expected an error for NaN")
+	}
+
+	base := VitalComponentWithOptions(v, VitalWeights, Options{})
+	o := Options{Custom: custom, Registry: r}
+	if got := VitalComponentWithOptions(v, VitalWeights, o); !(got > base) {
+		t.Errorf("raised icp: %v, want > %v", got, base)
+	}
+	o.Custom = map[VitalName]float64{"icp": 5}
+	if got, want := VitalComponentWithOptions(v, VitalWeights, o), VitalComponentWithOptions(v, VitalWeights, Options{}); !(got < want) {
+		t.Errorf("normal icp should dilute: %v, want < %v", got, want)
+	}
+	if names := r.Names(); len(names) != 1 || names[0] != "icp" {
+		t.Errorf("Names = %v", names)
+	}
+}
+
+func TestTrend(t *testing.T) {
+	prev := Vitals{HR: 90, RR: 18, SBP: 110, SpO2: 96, GCS: 15}
+	now := Vitals{HR: 105, RR: 18, SBP: 95, SpO2: 93, GCS: 15}
+	d := TrendDeviations(prev, now, 0.5, [7]float64{}, DefaultNorms())
+	// HR: 15 bpm further from the midpoint in half an hour = 30/h = 1 scale.
+	if d[0] != 1 || d[1] != 0 || d[6] != 0 {
+		t.Errorf("TrendDeviations = %v", d)
+	}
+	if d := TrendDeviations(now, prev, 0.5, [7]float64{}, DefaultNorms()); d != [7]float64{} {
+		t.Errorf("improvement = %v, want all 0", d)
+	}
+	if d := TrendDeviations(prev, now, 0, [7]float64{}, DefaultNorms()); d != [7]float64{} {
+		t.Errorf("hours 0 = %v, want all 0", d)
+	}
+	w := [7]float64{0.1, 0.1, 0.1, 0, 0, 0.1, 0.1}
+	base := AcuityWithOptions(now, 1, 5, VitalWeights, 0.3, Options{})
+	o := Options{Previous: &prev, TrendHours: 0.5, TrendWeights: w}
+	got := AcuityWithOptions(now, 1, 5, VitalWeights, 0.3, o)
+	if want := base + TrendComponent(prev, now, 0.5, w, [7]float64{}, DefaultNorms()); math.Abs(got-want) > 1e-12 || !(got > base) {
+		t.Errorf("with trend = %v, want %v (base %v)", got, want, base)
+	}
+	o.TrendHours = 24
+	if slow := AcuityWithOptions(now, 1, 5, VitalWeights, 0.3, o); !(slow < got) {
+		t.Errorf("slow change %v should add less than fast change %v", slow, got)
+	}
+}
+
+func TestDeviationCaps(t *testing.T) {
+	var tr DeviationTransform
+	if got := tr.ApplyCapped(2.5, 1); got != 1 {
+		t.Errorf("limit 1 = %v, want 1", got)
+	}
+	if got := tr.ApplyCapped(2.5, 2); got != 2 {
+		t.Errorf("limit 2 = %v, want 2", got)
+	}
+	if got := tr.ApplyCapped(0.5, 2); got != 0.5 {
+		t.Errorf("below saturation = %v, want 0.5", got)
+	}
+	if got := (DeviationTransform{Saturation: 2}).ApplyCapped(3, 4); got != 1.5 {
+		t.Errorf("saturation 2 = %v, want 1.5", got)
+	}
+
+	caps := [7]float64{3, 0, 0, 0, 0, 0, 0}
+	moderate, extreme := Vitals{HR: 125, RR: 16}, Vitals{HR: 190, RR: 16}
+	o := Options{Caps: &caps}
+	if a, b := VitalComponentWithOptions(moderate, VitalWeights, Options{}), VitalComponentWithOptions(extreme, VitalWeights, Options{}); a != b {
+		t.Errorf("hard cap: %v != %v", a, b)
+	}
+	if a, b := VitalComponentWithOptions(moderate, VitalWeights, o), VitalComponentWithOptions(extreme, VitalWeights, o); !(b > a) {
+		t.Errorf("HR 190 (%v) should outscore HR 125 (%v)", b, a)
+	}
+	if d := DeviationsWithOptions(extreme, o); d[0] <= 1 {
+		t.Errorf("deviation = %v, want > 1", d[0])
+	}
+	if got := VitalComponentWithOptions(Vitals{HR: 300}, VitalWeights, o); got != 1 {
+		t.Errorf("component = %v, want capped to 1", got)
+	}
+}
+
+func TestAcuityWithRanges(t *testing.T) {
+	if DefaultNorms() != norm.DefaultRanges().Array() {
+		t.Fatal("DefaultNorms drifted from norm.DefaultRanges")
+	}
+	v := Vitals{HR: 118, RR: 26, SBP: 96, Temp: 38.4, SpO2: 91, GCS: 14}
+	if got, want := AcuityWithRanges(v, 2, 5, VitalWeights, 0.3, norm.DefaultRanges()), Acuity(v, 2, 5, VitalWeights, 0.3); got != want {
+		t.Errorf("default ranges = %v, want Acuity %v", got, want)
+	}
+	child := Vitals{HR: 125, RR: 30}
+	if a, p := AcuityWithRanges(child, 0, 5, VitalWeights, 0.3, norm.DefaultRanges()), AcuityWithRanges(child, 0, 5, VitalWeights, 0.3, norm.PediatricRanges()); !(p < a) {
+		t.Errorf("paediatric ranges %v should score below adult %v", p, a)
+	}
+	if got, want := VitalComponentWithRanges(child, VitalWeights, norm.PediatricRanges()), VitalComponentWithNorms(child, VitalWeights, norm.PediatricRanges().Array()); got != want {
+		t.Errorf("VitalComponentWithRanges = %v, want %v", got, want)
+	}
+}
+
+func batchInput(n int) ([]Vitals, []int) {
+	vitals, rcs := make([]Vitals, n), make([]int, n)
+	for i := range vitals {
+		vitals[i] = Vitals{HR: 50 + i%100, RR: 8 + i%25, SBP: 70 + i%110, Temp: 35 + float64(i%50)/10, SpO2: 82 + i%18, GCS: 3 + i%13}
+		rcs[i] = i % 6
+	}
+	return vitals, rcs
+}
+
+func TestBatchAcuityParallel(t *testing.T) {
+	o := Options{MAPWeight: 0.1, RespiratoryWeight: 0.1}
+	for _, n := range []int{0, 1, 100, 5000} {
+		vitals, rcs := batchInput(n)
+		out := make([]float64, n)
+		if err := BatchAcuityParallel(vitals, rcs, 5, VitalWeights, 0.3, o, out); err != nil {
+			t.Fatal(err)
+		}
+		for i := range vitals {
+			if want := AcuityWithOptions(vitals[i], rcs[i], 5, VitalWeights, 0.3, o); out[i] != want {
+				t.Fatalf("n %d, record %d: %v, want %v", n, i, out[i], want)
+			}
+		}
+	}
+	vitals, rcs := batchInput(10)
+	if err := BatchAcuityParallel(vitals, rcs[:9], 5, VitalWeights, 0.3, o, make([]float64, 10)); err == nil {
+		t.Error("expected an error for mismatched lengths")
+	}
+}
+
+func BenchmarkBatchAcuity_Serial(b *testing.B) {
+	vitals, rcs := batchInput(1 << 16)
+	out := make([]float64, len(vitals))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range vitals {
+			out[j] = AcuityWithOptions(vitals[j], rcs[j], 6, VitalWeights, 0.25, Options{})
+		}
+	}
+}
+
+func BenchmarkBatchAcuity_Parallel(b *testing.B) {
+	vitals, rcs := batchInput(1 << 16)
+	out := make([]float64, len(vitals))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = BatchAcuityParallel(vitals, rcs, 6, VitalWeights, 0.25, Options{}, out)
+	}
+}
+
+func TestAcuityWithVariance(t *testing.T) {
+	v := Vitals{HR: 110, RR: 22, SBP: 100, Temp: 38.4, SpO2: 93, GCS: 14}
+	o := Options{}
+	se := [7]float64{4, 2, 5, 5, 0.2, 2, 1}
+	e := AcuityWithVariance(v, 2, 5, VitalWeights, 0.3, o, se)
+	if want := AcuityWithOptions(v, 2, 5, VitalWeights, 0.3, o); e.Acuity != want {
+		t.Errorf("Acuity = %v, want %v", e.Acuity, want)
+	}
+	// The Temp gradient matches a finite difference of the score.
+	const h = 1e-3
+	hi, lo := v, v
+	hi.Temp += h
+	lo.Temp -= h
+	fd := (AcuityWithOptions(hi, 2, 5, VitalWeights, 0.3, o) - AcuityWithOptions(lo, 2, 5, VitalWeights, 0.3, o)) / (2 * h)
+	if math.Abs(e.Gradient[4]-fd) > 1e-6 {
+		t.Errorf("Gradient[4] = %v, finite difference %v", e.Gradient[4], fd)
+	}
+	var want float64
+	for i, g := range e.Gradient {
+		want += g * g * se[i] * se[i]
+	}
+	if e.Variance <= 0 || math.Abs(e.Variance-want) > 1e-15 {
+		t.Errorf("Variance = %v, want %v", e.Variance, want)
+	}
+	if e.Gradient[3] != 0 {
+		t.Errorf("missing DBP has gradient %v", e.Gradient[3])
+	}
+	if z := AcuityWithVariance(v, 2, 5, VitalWeights, 0.3, o, [7]float64{}); z.Variance != 0 || z.SE() != 0 {
+		t.Errorf("zero error: %+v", z)
+	}
+	if s := (Estimate{Acuity: 0.624, Variance: 0.0016}).String(); s != "0.62 ± 0.04" {
+		t.Errorf("String = %q", s)
+	}
+}
+
+func TestScaleResourcesRate(t *testing.T) {
+	if a, b := ScaleResourcesRate(2, 6, ResourceExp, 0), ScaleResourcesRate(2, 6, ResourceExp, DefaultResourceRate); a != b {
+		t.Errorf("rate 0 = %v, want the default rate %v", a, b)
+	}
+	want := (1 - math.Exp(-2)) / (1 - math.Exp(-6))
+	if f := ScaleResourcesRate(2, 6, ResourceExp, 1); math.Abs(f-want) > 1e-12 {
+		t.Errorf("k = 1: f(2) = %v, want %v", f, want)
+	}
+	if ScaleResourcesRate(2, 6, ResourceExp, 2) <= ScaleResourcesRate(2, 6, ResourceExp, 0.2) {
+		t.Error("a larger rate should saturate sooner")
+	}
+	if a, b := ScaleResourcesRate(2, 6, ResourceSqrt, 3), ScaleResources(2, 6, ResourceSqrt); a != b {
+		t.Errorf("rate changed ResourceSqrt: %v, want %v", a, b)
+	}
+	o := Options{ResourceScale: ResourceExp, ResourceRate: 1}
+	if c := ResourceComponentWithOptions(2, 6, 0.25, o); math.Abs(c-0.25*want) > 1e-12 {
+		t.Errorf("ResourceComponentWithOptions = %v, want %v", c, 0.25*want)
+	}
+	if ResourceExp.String() != "exp" || !ResourceExp.Valid() || (ResourceExp + 1).Valid() {
+		t.Error("ResourceExp String or Valid")
+	}
+}
+
+func TestSmoother(t *testing.T) {
+	if _, err := NewSmoother(0); err == nil {
+		t.Error("expected an error for alpha 0")
+	}
+	if _, err := NewSmoother(1.5); err == nil {
+		t.Error("expected an error for alpha 1.5")
+	}
+	m, err := NewSmoother(0.3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Update(0.4); got != 0.4 {
+		t.Errorf("first score = %v, want 0.4", got)
+	}
+	// A single spurious spike moves the smoothed score by alpha times the jump.
+	if got := m.Update(0.9); math.Abs(got-0.55) > 1e-12 {
+		t.Errorf("spike = %v, want 0.55", got)
+	}
+	got := m.Update(0.4)
+	if want := EWMA(0.55, 0.4, 0.3); got != want {
+		t.Errorf("after spike = %v, want %v", got, want)
+	}
+	if v, n := m.Value(); v != got || n != 3 {
+		t.Errorf("Value = %v, %d", v, n)
+	}
+	m.Reset()
+	if v, n := m.Value(); v != 0 || n != 0 {
+		t.Errorf("after Reset: %v, %d", v, n)
+	}
+	h, err := NewSmootherHalfLife(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := math.Pow(1-h.Alpha(), 2); math.Abs(w-0.5) > 1e-12 {
+		t.Errorf("weight after half-life = %v, want 0.5", w)
+	}
+	if _, err := NewSmootherHalfLife(0); err == nil {
+		t.Error("expected an error for half-life 0")
+	}
+}
+
+func TestAcuityGradient(t *testing.T) {
+	v := Vitals{HR: 105, RR: 22, SBP: 100, Temp: 38.2, SpO2: 93, GCS: 14}
+	for _, tr := range []DeviationTransform{{}, {Kind: TransformPower, Saturation: 2}, {Kind: TransformSigmoid, Saturation: 3}} {
+		o := Options{Transform: tr}
+		g := AcuityGradient(v, 2, 5, VitalWeights, 0.3, o)
+		const h = 1e-5
+		hi, lo := v, v
+		hi.Temp += h
+		lo.Temp -= h
+		fd := (AcuityWithOptions(hi, 2, 5, VitalWeights, 0.3, o) - AcuityWithOptions(lo, 2, 5, VitalWeights, 0.3, o)) / (2 * h)
+		if math.Abs(g[4]-fd) > 1e-6 || g[4] <= 0 {
+			t.Errorf("%v: ∂s/∂Temp = %v, finite difference %v", tr.Kind, g[4], fd)
+		}
+		if g[2] >= 0 || g[0] <= 0 || g[3] != 0 {
+			t.Errorf("%v: HR %v, SBP %v, DBP %v: want positive, negative, 0", tr.Kind, g[0], g[2], g[3])
+		}
+	}
+	// HR past saturation does not move the score.
+	if g := AcuityGradient(Vitals{HR: 180, RR: 22}, 0, 5, VitalWeights, 0.3, Options{}); g[0] != 0 || g[1] == 0 {
+		t.Errorf("saturated HR: %v", g)
+	}
+	if g := AcuityGradient(v, 2, 5, VitalWeights, 0.3, Options{QSOFABump: 1}); g != ([7]float64{}) {
+		t.Errorf("clamped score: %v", g)
+	}
+	if i, _ := MostInfluential([7]float64{0.01, -0.05, 0, 0, 0.2, 0, 0}, [7]float64{10, 4, 10, 10, 0.5, 2, 1}); i != 1 {
+		t.Errorf("MostInfluential = %d, want 1 (RR)", i)
+	}
+	if i, _ := MostInfluential([7]float64{}, [7]float64{1, 1, 1, 1, 1, 1, 1}); i != -1 {
+		t.Errorf("MostInfluential of a zero gradient = %d, want -1", i)
+	}
+}
+
+func TestVitalsWithUnits(t *testing.T) {
+	u := VitalsWithUnits{HR: 90, SBP: 16, DBP: 10.7, Temp: 98.6, SpO2: 97, TempUnit: Fahrenheit, PressureUnit: KPa}
+	v, err := u.Vitals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.SBP != 120 || v.DBP != 80 || math.Abs(v.Temp-37) > 1e-9 || v.HR != 90 || v.SpO2 != 97 {
+		t.Errorf("converted = %+v", v)
+	}
+	if def, _ := (VitalsWithUnits{SBP: 120, Temp: 37}).Vitals(); def.SBP != 120 || def.Temp != 37 {
+		t.Errorf("default units changed the values: %+v", def)
+	}
+	if m, _ := (VitalsWithUnits{HR: 80, TempUnit: Fahrenheit, PressureUnit: KPa}).Vitals(); m.Temp != 0 || m.SBP != 0 {
+		t.Errorf("missing readings should stay 0: %+v", m)
+	}
+	if _, err := (VitalsWithUnits{TempUnit: 7}).Vitals(); err == nil {
+		t.Error("expected an error for an unknown temperature unit")
+	}
+	if _, err := (VitalsWithUnits{PressureUnit: 7}).Vitals(); err == nil {
+		t.Error("expected an error for an unknown pressure unit")
+	}
+	if Fahrenheit.String() != "°F" || KPa.String() != "kPa" {
+		t.Error("unit String")
+	}
+}
+
+func TestAcuityF(t *testing.T) {
+	w, o := VitalWeights, Options{}
+	v := Vitals{HR: 92, RR: 17, SBP: 118, DBP: 76, Temp: 37.8, SpO2: 95, GCS: 15}
+	if got, want := AcuityF(FloatVitals(v), 2, 10, w, 1, o), AcuityWithOptions(v, 2, 10, w, 1, o); got != want {
+		t.Errorf("AcuityF of whole numbers = %v, want %v", got, want)
+	}
+	lo, hi := v, v
+	lo.RR, hi.RR = 24, 25
+	f := FloatVitals(v)
+	f.RR = 24.5
+	a, b, c := AcuityWithOptions(lo, 2, 10, w, 1, o), AcuityF(f, 2, 10, w, 1, o), AcuityWithOptions(hi, 2, 10, w, 1, o)
+	if !(a < b && b < c) {
+		t.Errorf("RR 24.5 scores %v, want between %v and %v", b, a, c)
+	}
+	if got := (VitalsF{HR: 0.3, RR: -2, GCSEye: 4, GCSVerbal: 5, GCSMotor: 6}); got.Vitals().HR != 1 || got.Vitals().RR != 0 || got.Values()[6] != 15 {
+		t.Errorf("VitalsF{HR: 0.3, RR: -2} = %+v, values %v", got.Vitals(), got.Values())
+	}
+}
+
+func TestAcuityDecomposed(t *testing.T) {
+	w := VitalWeights
+	v := Vitals{HR: 118, RR: 24, SBP: 96, DBP: 60, Temp: 38.6, SpO2: 91, GCS: 14}
+	opts := []Options{
+		{},
+		{MAPWeight: 0.5, RespiratoryWeight: 0.5, QSOFABump: 0.1},
+		{GCSBanded: true, GCSBands: DefaultGCSBands(), Missing: MissingPenalizeWorst},
+		{Transform: DeviationTransform{Kind: TransformSigmoid}, ResourceScale: ResourceExp},
+	}
+	for k, o := range opts {
+		for _, in := range []Vitals{v, {HR: 125, SpO2: 93}} {
+			d := AcuityDecomposed(in, 3, 10, w, 1, o)
+			if want := AcuityWithOptions(in, 3, 10, w, 1, o); math.Abs(d.Score-want) > 1e-12 {
+				t.Errorf("opts %d: Score = %v, want AcuityWithOptions %v", k, d.Score, want)
+			}
+			sum := d.Other
+			for i := range d.Contribution {
+				sum += d.Contribution[i]
+				if math.Abs(d.Contribution[i]-d.Weight[i]*d.Deviation[i]) > 1e-12 {
+					t.Errorf("opts %d: vital %d contribution %v != %v·%v", k, i, d.Contribution[i], d.Weight[i], d.Deviation[i])
+				}
+			}
+			if v := math.Min(1, sum/d.WeightSum); math.Abs(v-d.V) > 1e-12 || math.Abs(d.Raw-(d.V+d.R)) > 1e-12 {
+				t.Errorf("opts %d: V = %v, want %v; Raw = %v", k, d.V, v, d.Raw)
+			}
+		}
+	}
+	if d := AcuityDecomposed(Vitals{HR: 125}, 0, 10, w, 1, Options{Missing: MissingPenalizeWorst}); d.Deviation[1] != 1 || d.Weight[1] != w[1] {
+		t.Errorf("missing RR under worst: deviation %v, weight %v", d.Deviation[1], d.Weight[1])
+	}
+}
+
+func TestDeviationTable(t *testing.T) {
+	gcs, err := DeviationTable([]float64{8, 9, 13, 14, 15}, []float64{1, 0.8, 0.4, 0.2, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x, want := range map[float64]float64{3: 1, 8: 1, 11: 0.8, 14: 0.2, 15: 0} {
+		if got := gcs(x, [2]float64{}); got != want {
+			t.Errorf("table(%v) = %v, want %v", x, got, want)
+		}
+	}
+	norms := DefaultNorms()
+	w := VitalWeights
+	fns := [7]DeviationFunc{6: gcs}
+	v := Vitals{HR: 80, RR: 16, SBP: 120, Temp: 37, SpO2: 98, GCS: 14}
+	o := Options{Norms: &norms, Deviations: &fns}
+	if got, want := DeviationsWithOptions(v, o)[6], 0.2; got != want {
+		t.Errorf("GCS 14 deviation = %v, want %v", got, want)
+	}
+	if got, want := VitalComponentWithOptions(v, w, Options{Norms: &norms}), VitalComponentWithNorms(v, w, norms); got != want {
+		t.Errorf("VitalComponentWithOptions with Norms = %v, want VitalComponentWithNorms %v", got, want)
+	}
+	if !(VitalComponentWithOptions(v, w, o) > VitalComponentWithNorms(v, w, norms)) {
+		t.Error("table GCS 14 should score above linear GCS 14")
+	}
+	clamp := [7]DeviationFunc{0: func(float64, [2]float64) float64 { return 3 }, 1: func(float64, [2]float64) float64 { return math.NaN() }}
+	if d := DeviationsWithOptions(v, Options{Deviations: &clamp}); d[0] != 1 || d[1] != 0 {
+		t.Errorf("clamped deviations = %v", d[:2])
+	}
+	for _, bad := range [][2][]float64{{nil, nil}, {{1, 2}, {0}}, {{2, 1}, {0, 0}}, {{1, 2}, {0, 1.5}}} {
+		if _, err := DeviationTable(bad[0], bad[1]); err == nil {
+			t.Errorf("DeviationTable(%v, %v): expected an error", bad[0], bad[1])
+		}
+	}
+}
+
+func TestZScores(t *testing.T) {
+	z := ZNorm{Mean: 85, SD: 12}
+	if got := z.Norm(); got != [2]float64{85, 36} {
+		t.Errorf("Norm = %v, want [85 36]", got)
+	}
+	if got := z.Z(109); got != 2 {
+		t.Errorf("Z(109) = %v, want 2", got)
+	}
+	zs := [7]ZNorm{0: z}
+	v := Vitals{HR: 109, RR: 16, SBP: 120, Temp: 37, SpO2: 98, GCS: 15}
+	d := DeviationsWithOptions(v, Options{ZScores: &zs})
+	if want := 2.0 / DefaultZSaturation; math.Abs(d[0]-want) > 1e-12 {
+		t.Errorf("HR deviation = %v, want %v", d[0], want)
+	}
+	if def := DeviationsWithOptions(v, Options{}); d[1] != def[1] {
+		t.Errorf("RR without a ZNorm changed: %v, want %v", d[1], def[1])
+	}
+	norms := DefaultNorms()
+	norms[0] = z.Norm()
+	w := VitalWeights
+	if got, want := AcuityWithOptions(v, 1, 6, w, 0.25, Options{ZScores: &zs}), AcuityWithNorms(v, 1, 6, w, 0.25, norms); math.Abs(got-want) > 1e-12 {
+		t.Errorf("z-scored acuity = %v, want %v", got, want)
+	}
+	for _, bad := range []ZNorm{{Mean: 80}, {Mean: math.NaN(), SD: 1}, {SD: 1, Saturation: -1}} {
+		if bad.Valid() {
+			t.Errorf("%+v should not be valid", bad)
+		}
+	}
+	if !(ZNorm{}).Valid() || !(ZNorm{Mean: 37, SD: 0.4, Saturation: 2.5}).Valid() {
+		t.Error("zero and well-formed ZNorm should be valid")
+	}
+}
+
+func TestNormalization(t *testing.T) {
+	w := VitalWeights
+	v := Vitals{HR: 80, RR: 16, SBP: 120, DBP: 80, Temp: 37, SpO2: 60, GCS: 15}
+	for _, c := range []struct {
+		n    Normalization
+		want float64
+	}{{NormalizeSum, 0.16}, {NormalizeMax, 1}, {NormalizeSoftmax, 0.9658}} {
+		if got := VitalComponentWithOptions(v, w, Options{Normalization: c.n}); math.Abs(got-c.want) > 5e-5 {
+			t.Errorf("%s: V = %v, want %v", c.n, got, c.want)
+		}
+	}
+	// β near 0 reduces softmax to the weighted mean.
+	u := Vitals{HR: 112, RR: 23, SBP: 104, Temp: 38.2, SpO2: 94, GCS: 14}
+	sum := VitalComponentWithOptions(u, w, Options{})
+	if got := VitalComponentWithOptions(u, w, Options{Normalization: NormalizeSoftmax, SoftmaxSharpness: 1e-9}); math.Abs(got-sum) > 1e-6 {
+		t.Errorf("softmax β→0 = %v, want %v", got, sum)
+	}
+	// The analytic gradient matches a finite difference under softmax.
+	o := Options{Normalization: NormalizeSoftmax}
+	g := AcuityGradient(u, 1, 6, w, 0.25, o)
+	f := FloatVitals(u)
+	f.HR += 1e-4
+	up := AcuityF(f, 1, 6, w, 0.25, o)
+	f.HR -= 2e-4
+	down := AcuityF(f, 1, 6, w, 0.25, o)
+	if fd := (up - down) / 2e-4; math.Abs(g[0]-fd) > 1e-6 {
+		t.Errorf("∂s/∂HR = %v, finite difference %v", g[0], fd)
+	}
+	if d := AcuityDecomposed(v, 0, 6, w, 0.25, Options{Normalization: NormalizeMax}); d.V != 1 {
+		t.Errorf("decomposed max V = %v, want 1", d.V)
+	}
+	if Normalization(9).Valid() || NormalizeSoftmax.String() != "softmax" {
+		t.Error("Normalization Valid/String")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	v := Vitals{HR: 98, RR: 18, SBP: 124, DBP: 78, Temp: 37.2, SpO2: 96, GCS: 15}
+	a := Fingerprint(v, 2)
+	if a != Fingerprint(CloneVitals(v), 2) {
+		t.Error("equal inputs should give equal fingerprints")
+	}
+	// Pinned so that a change of encoding is noticed: stored fingerprints
+	// would no longer join.
+	if a != 0xf1ae50a68105b238 {
+		t.Errorf("Fingerprint = %#x, want 0xf1ae50a68105b238", a)
+	}
+	seen := map[uint64]bool{a: true}
+	for _, w := range []Vitals{{HR: 99, RR: 18, SBP: 124, DBP: 78, Temp: 37.2, SpO2: 96, GCS: 15}, {HR: 98, RR: 18, SBP: 124, DBP: 78, Temp: 37.2, SpO2: 96, GCS: 15, OnOxygen: true}, {HR: 18, RR: 98, SBP: 124, DBP: 78, Temp: 37.2, SpO2: 96, GCS: 15}} {
+		f := Fingerprint(w, 2)
+		if seen[f] {
+			t.Errorf("collision for %+v", w)
+		}
+		seen[f] = true
+	}
+	if Fingerprint(v, 3) == a {
+		t.Error("resource count should change the fingerprint")
+	}
+	if Fingerprint(Vitals{Temp: math.Copysign(0, -1)}, 0) != Fingerprint(Vitals{}, 0) {
+		t.Error("-0 and 0 should hash alike")
+	}
+}
+
+func TestAcuityWithAsymmetricRanges(t *testing.T) {
+	v := Vitals{HR: 112, RR: 22, SBP: 96, DBP: 58, Temp: 38.4, SpO2: 93, GCS: 14}
+	for _, r := range []norm.Ranges{norm.DefaultRanges(), norm.PediatricRanges()} {
+		if got, want := AcuityWithAsymmetricRanges(v, 2, 5, VitalWeights, 0.3, r.Asymmetric()), AcuityWithRanges(v, 2, 5, VitalWeights, 0.3, r); math.Abs(got-want) > 1e-12 {
+			t.Errorf("symmetric ranges: %v, want %v", got, want)
+		}
+	}
+	r := norm.DefaultAsymmetricRanges()
+	d := DeviationsWithOptions(v, AsymmetricOptions(r))
+	for i, x := range VitalsToValues(v) {
+		if want := r.Deviation(i, x); math.Abs(d[i]-want) > 1e-12 {
+			t.Errorf("vital %d: deviation %v, want DeviationAsym %v", i, d[i], want)
+		}
+	}
+	// SpO2 and GCS deviate only below the midpoint.
+	if VitalComponentWithAsymmetricRanges(Vitals{SpO2: 100, GCS: 15}, VitalWeights, r) != 0 {
+		t.Error("high SpO2 should not deviate")
+	}
+}
+
+func TestLegacyNorms(t *testing.T) {
+	v := Vitals{HR: 120, SpO2: 94}
+	if VitalComponent(v, VitalWeights) != VitalComponentWithNorms(v, VitalWeights, DefaultNorms()) {
+		t.Error("unchanged legacy norms should equal DefaultNorms")
+	}
+	saved := HRNorm
+	defer func() { HRNorm = saved }()
+	HRNorm = [2]float64{120, 30}
+	if got := VitalComponent(Vitals{HR: 120}, VitalWeights); got != 0 {
+		t.Errorf("VitalComponent ignored HRNorm: %v", got)
+	}
+	if Acuity(Vitals{HR: 120}, 0, 6, VitalWeights, 0.25) != 0 {
+		t.Error("Acuity ignored HRNorm")
+	}
+	if AcuityWithOptions(Vitals{HR: 120}, 0, 6, VitalWeights, 0.25, Options{}) == 0 {
+		t.Error("Options scoring should keep DefaultNorms")
+	}
+}
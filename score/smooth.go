@@ -0,0 +1,75 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import (
+	"fmt"
+	"math"
+)
+
+// EWMA blends a new acuity score s into the previous smoothed score prev:
+//
+//	alpha·s + (1 - alpha)·prev
+//
+// alpha is the weight of the new score, in (0, 1]: 1 keeps only s, and a
+// smaller alpha lets a single spurious reading move the result less. The
+// result stays in [0, 1] when prev and s do.
+func EWMA(prev, s, alpha float64) float64 {
+	return alpha*s + (1-alpha)*prev
+}
+
+// Smoother holds the exponentially weighted moving average of successive
+// acuity scores for one patient (see EWMA). The first score is taken as
+// is. A Smoother is not safe for concurrent use; keep one per patient.
+type Smoother struct {
+	alpha float64
+	value float64
+	n     int
+}
+
+// NewSmoother returns a Smoother that gives each new score weight alpha,
+// which must be in (0, 1]. With alpha 0.3 a one-off jump of the score by d
+// moves the smoothed score by 0.3d, and the effect decays by 0.7 per score.
+func NewSmoother(alpha float64) (*Smoother, error) {
+	if !(alpha > 0 && alpha <= 1) {
+		return nil, fmt.Errorf("score: smoother: alpha %v must be in (0, 1]", alpha)
+	}
+	return &Smoother{alpha: alpha}, nil
+}
+
+// NewSmootherHalfLife returns a Smoother whose scores lose half their
+// weight after n further scores (n > 0): alpha = 1 - 2^(-1/n).
+func NewSmootherHalfLife(n float64) (*Smoother, error) {
+	if !(n > 0) || math.IsInf(n, 0) {
+		return nil, fmt.Errorf("score: smoother: half-life %v must be finite and > 0", n)
+	}
+	return NewSmoother(-math.Expm1(-math.Ln2 / n))
+}
+
+// Alpha returns the weight of each new score.
+func (m *Smoother) Alpha() float64 {
+	return m.alpha
+}
+
+// Update blends s into the average and returns the smoothed score.
+func (m *Smoother) Update(s float64) float64 {
+	if m.n == 0 {
+		m.value = s
+	} else {
+		m.value = EWMA(m.value, s, m.alpha)
+	}
+	m.n++
+	return m.value
+}
+
+// Value returns the smoothed score and the number of scores seen; the
+// score is 0 if none has been.
+func (m *Smoother) Value() (float64, int) {
+	return m.value, m.n
+}
+
+// Reset forgets all scores, e.g. when the patient is discharged.
+func (m *Smoother) Reset() {
+	m.value, m.n = 0, 0
+}
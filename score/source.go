@@ -0,0 +1,87 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import "math"
+
+// Source identifies the device or method a vital was measured with.
+type Source int
+
+const (
+	SourceUnknown  Source = iota // Not recorded; treated as fully reliable
+	SourceManual                 // Measured by staff
+	SourceMonitor                // Bedside monitor
+	SourceWearable               // Consumer wearable
+	NumSources
+)
+
+var sourceNames = [NumSources]string{"unknown", "manual", "monitor", "wearable"}
+
+// String returns the lower-case source name, or "invalid".
+func (s Source) String() string {
+	if !s.Valid() {
+		return "invalid"
+	}
+	return sourceNames[s]
+}
+
+// Valid returns true if s is a defined source.
+func (s Source) Valid() bool {
+	return s >= 0 && s < NumSources
+}
+
+// Sources holds the source of each vital in VitalWeights order
+// (HR, RR, SBP, DBP, Temp, SpO2, GCS). The zero value is all SourceUnknown.
+type Sources [7]Source
+
+// Reliability holds a weight factor in [0, 1] per source and vital. The
+// factor multiplies the vital's weight in the weighted mean that forms the
+// vital component, so a less reliable reading has less influence relative to
+// the other vitals. A source whose row is all zero is not configured and
+// gets factor 1, so the zero value applies no down-weighting and a table
+// that sets only, say, SourceWearable leaves the other sources alone.
+type Reliability [NumSources][7]float64
+
+// WearableReliability is the default weight factor for wearable readings.
+const WearableReliability = 0.7
+
+// DefaultReliability returns factor 1 for every source except
+// SourceWearable, which gets WearableReliability for every vital.
+func DefaultReliability() Reliability {
+	var r Reliability
+	for s := range r {
+		f := 1.0
+		if Source(s) == SourceWearable {
+			f = WearableReliability
+		}
+		for i := range r[s] {
+			r[s][i] = f
+		}
+	}
+	return r
+}
+
+// Valid returns true if every factor is in [0, 1].
+func (r Reliability) Valid() bool {
+	for _, row := range r {
+		for _, f := range row {
+			if !(f >= 0 && f <= 1) || math.IsNaN(f) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Factors returns the weight factor of each vital given its source.
+// Invalid sources and sources with an all-zero row give factor 1.
+func (r Reliability) Factors(src Sources) [7]float64 {
+	f := [7]float64{1, 1, 1, 1, 1, 1, 1}
+	for i, s := range src {
+		if s.Valid() && r[s] != ([7]float64{}) {
+			f[i] = r[s][i]
+		}
+	}
+	return f
+}
@@ -0,0 +1,175 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import "math"
+
+// TransformKind selects the shape of a DeviationTransform.
+type TransformKind int
+
+const (
+	TransformLinear TransformKind = iota
+	TransformSigmoid
+	TransformPower
+)
+
+// Default shapes used when DeviationTransform.Shape is 0.
+const (
+	DefaultSigmoidSteepness = 8.0
+	DefaultPowerExponent    = 2.0
+)
+
+// String returns the transform name.
+func (k TransformKind) String() string {
+	switch k {
+	case TransformLinear:
+		return "linear"
+	case TransformSigmoid:
+		return "sigmoid"
+	case TransformPower:
+		return "power"
+	default:
+		return "unknown"
+	}
+}
+
+// Valid returns true if k is one of the defined kinds.
+func (k TransformKind) Valid() bool {
+	return k >= TransformLinear && k <= TransformPower
+}
+
+// DeviationTransform maps the distance ratio r = |v - mid| / halfWidth of a
+// vital to its deviation in [0, 1]. Every kind gives 0 at the midpoint and
+// 1 from r = Saturation on; the zero value is the linear min(1, r) that
+// Acuity uses. With Saturation above 1, a vital just outside its half-width
+// no longer scores as extreme, and a power or sigmoid curve additionally
+// keeps small deviations small.
+//
+//	| Kind             | f(r), x = min(r, s) / s, k = Shape       | f(1), s = 2 |
+//	|------------------|------------------------------------------|-------------|
+//	| TransformLinear  | x                                        | 0.50        |
+//	| TransformPower   | x^k (default k 2)                        | 0.25        |
+//	| TransformSigmoid | logistic(k(x - 1/2)) rescaled to [0, 1]  | 0.50        |
+//	|                  | (default k 8)                            |             |
+type DeviationTransform struct {
+	Kind TransformKind
+	// Saturation is the ratio at which the deviation reaches 1; 0 means 1.
+	Saturation float64
+	// Shape is the exponent (TransformPower) or steepness (TransformSigmoid);
+	// 0 means the kind's default. Unused by TransformLinear.
+	Shape float64
+}
+
+// IsZero returns true if t is the zero value (linear, saturating at the
+// half-width).
+func (t DeviationTransform) IsZero() bool {
+	return t == DeviationTransform{}
+}
+
+// Valid returns true if Kind is defined, Saturation is 0 or a finite value
+// >= 1, and Shape is 0 or finite and positive.
+func (t DeviationTransform) Valid() bool {
+	if !t.Kind.Valid() {
+		return false
+	}
+	if t.Saturation != 0 && !(t.Saturation >= 1 && !math.IsInf(t.Saturation, 0)) {
+		return false
+	}
+	return t.Shape == 0 || (t.Shape > 0 && !math.IsInf(t.Shape, 0))
+}
+
+// Apply returns the deviation in [0, 1] for distance ratio r >= 0.
+// Negative or NaN r gives 0; unknown kinds fall back to TransformLinear.
+func (t DeviationTransform) Apply(r float64) float64 {
+	if !(r > 0) {
+		return 0
+	}
+	s := t.Saturation
+	if s <= 0 {
+		s = 1
+	}
+	x := math.Min(r, s) / s
+	switch t.Kind {
+	case TransformPower:
+		k := t.Shape
+		if k <= 0 {
+			k = DefaultPowerExponent
+		}
+		return math.Pow(x, k)
+	case TransformSigmoid:
+		k := t.Shape
+		if k <= 0 {
+			k = DefaultSigmoidSteepness
+		}
+		g := func(x float64) float64 { return 1 / (1 + math.Exp(-k*(x-0.5))) }
+		g0, g1 := g(0), g(1)
+		return (g(x) - g0) / (g1 - g0)
+	default:
+		return x
+	}
+}
+
+// ratio returns |v - mid| / hw, or 0 if hw <= 0 or v is a missing reading
+// (<= 0 with a positive midpoint).
+func ratio(v float64, mid, hw float64) float64 {
+	if hw <= 0 {
+		return 0
+	}
+	if v <= 0 && mid > 0 {
+		return 0
+	}
+	return math.Abs(v-mid) / hw
+}
+
+// ApplyCapped is like Apply but, with limit > 1, keeps the deviation rising
+// past saturation as r / Saturation, up to limit, so that an extreme
+// derangement outscores a borderline one. A limit <= 1 gives Apply.
+func (t DeviationTransform) ApplyCapped(r, limit float64) float64 {
+	s := t.Saturation
+	if s <= 0 {
+		s = 1
+	}
+	if !(limit > 1) || !(r > s) {
+		return t.Apply(r)
+	}
+	return math.Min(limit, r/s)
+}
+
+// Slope returns the derivative of ApplyCapped(r, limit) with respect to r,
+// for r > 0. It is 0 where the deviation is flat (at or past the cap) and
+// at r <= 0, the midpoint, where the curve has a kink.
+func (t DeviationTransform) Slope(r, limit float64) float64 {
+	if !(r > 0) {
+		return 0
+	}
+	s := t.Saturation
+	if s <= 0 {
+		s = 1
+	}
+	if r >= s {
+		if limit > 1 && r/s < limit {
+			return 1 / s
+		}
+		return 0
+	}
+	x := r / s
+	switch t.Kind {
+	case TransformPower:
+		k := t.Shape
+		if k <= 0 {
+			k = DefaultPowerExponent
+		}
+		return k * math.Pow(x, k-1) / s
+	case TransformSigmoid:
+		k := t.Shape
+		if k <= 0 {
+			k = DefaultSigmoidSteepness
+		}
+		g := func(x float64) float64 { return 1 / (1 + math.Exp(-k*(x-0.5))) }
+		gx := g(x)
+		return k * gx * (1 - gx) / (g(1) - g(0)) / s
+	default:
+		return 1 / s
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import "math"
+
+// DefaultTrendScales returns, in VitalWeights order, the rate of
+// deterioration per hour at which each vital's trend deviation saturates at
+// 1. Deterioration is movement away from the norm midpoint, so a fall in
+// GCS or SpO2 and a rise or fall in HR both count.
+//
+//	| Vital | Scale per hour |
+//	|-------|----------------|
+//	| HR    | 30 bpm         |
+//	| RR    | 10 /min        |
+//	| SBP   | 40 mmHg        |
+//	| DBP   | 30 mmHg        |
+//	| Temp  | 1.5 C          |
+//	| SpO2  | 6 %            |
+//	| GCS   | 3 points       |
+func DefaultTrendScales() [7]float64 {
+	return [7]float64{30, 10, 40, 30, 1.5, 6, 3}
+}
+
+// TrendDeviations returns, in VitalWeights order, the trend deviation in
+// [0, 1] of each vital from prev to v over hours: the increase in its
+// distance from the norm midpoint per hour, divided by scales[i] (0 uses
+// DefaultTrendScales), capped to 1. Improving or stable vitals, vitals
+// missing from either snapshot and hours <= 0 give 0.
+func TrendDeviations(prev, v Vitals, hours float64, scales [7]float64, norms [7][2]float64) [7]float64 {
+	var d [7]float64
+	if !(hours > 0) {
+		return d
+	}
+	def := DefaultTrendScales()
+	a, b := VitalsToValues(prev), VitalsToValues(v)
+	pa, pb := Present(prev), Present(v)
+	for i := range d {
+		if !pa[i] || !pb[i] {
+			continue
+		}
+		s := scales[i]
+		if s <= 0 {
+			s = def[i]
+		}
+		mid := norms[i][0]
+		worse := math.Abs(b[i]-mid) - math.Abs(a[i]-mid)
+		if worse > 0 {
+			d[i] = math.Min(1, worse/hours/s)
+		}
+	}
+	return d
+}
+
+// TrendComponent returns the weighted sum of the trend deviations of
+// TrendDeviations, capped to 1. It is added to the normalized score by
+// AcuityWithOptions when Options.Previous is set.
+func TrendComponent(prev, v Vitals, hours float64, weights, scales [7]float64, norms [7][2]float64) float64 {
+	var sum float64
+	for i, d := range TrendDeviations(prev, v, hours, scales, norms) {
+		sum += weights[i] * d
+	}
+	return math.Min(1, sum)
+}
+
+// trend returns the trend term of o for v, or 0 if o.Previous is nil.
+func (o Options) trend(v Vitals) float64 {
+	if o.Previous == nil {
+		return 0
+	}
+	norms := o.baseNorms()
+	return TrendComponent(*o.Previous, v, o.TrendHours, o.TrendWeights, o.TrendScales, norms)
+}
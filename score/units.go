@@ -0,0 +1,131 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import (
+	"fmt"
+	"math"
+)
+
+// TempUnit is the unit of a temperature reading.
+type TempUnit int
+
+const (
+	Celsius TempUnit = iota
+	Fahrenheit
+)
+
+// PressureUnit is the unit of a blood pressure reading.
+type PressureUnit int
+
+const (
+	MMHg PressureUnit = iota
+	KPa
+)
+
+// MMHgPerKPa converts kilopascals to millimetres of mercury.
+const MMHgPerKPa = 7.50062
+
+// String returns the unit symbol.
+func (u TempUnit) String() string {
+	switch u {
+	case Celsius:
+		return "°C"
+	case Fahrenheit:
+		return "°F"
+	default:
+		return "unknown"
+	}
+}
+
+// Valid returns true if u is one of the defined units.
+func (u TempUnit) Valid() bool {
+	return u == Celsius || u == Fahrenheit
+}
+
+// String returns the unit symbol.
+func (u PressureUnit) String() string {
+	switch u {
+	case MMHg:
+		return "mmHg"
+	case KPa:
+		return "kPa"
+	default:
+		return "unknown"
+	}
+}
+
+// Valid returns true if u is one of the defined units.
+func (u PressureUnit) Valid() bool {
+	return u == MMHg || u == KPa
+}
+
+// ToCelsius converts temperature x in unit u to Celsius. 0 (unknown) stays
+// 0. Unknown units are returned unchanged.
+func ToCelsius(x float64, u TempUnit) float64 {
+	if x == 0 || u != Fahrenheit {
+		return x
+	}
+	return (x - 32) * 5 / 9
+}
+
+// ToMMHg converts pressure x in unit u to mmHg, rounded to the nearest
+// integer as Vitals holds it. 0 (unknown) stays 0. Unknown units are
+// treated as mmHg.
+func ToMMHg(x float64, u PressureUnit) int {
+	if u == KPa {
+		x *= MMHgPerKPa
+	}
+	return int(math.Round(x))
+}
+
+// VitalsWithUnits is Vitals with temperature and blood pressure in the
+// units the source reports, so integrators need not convert before scoring
+// (a 98.6 taken as °C scores as extreme hyperthermia). The zero units are
+// those of Vitals: Celsius and mmHg. Convert with Vitals.
+type VitalsWithUnits struct {
+	HR   int
+	RR   int
+	SBP  float64 // In PressureUnit
+	DBP  float64 // In PressureUnit
+	Temp float64 // In TempUnit
+	SpO2 int
+	GCS  int
+
+	GCSEye    int
+	GCSVerbal int
+	GCSMotor  int
+
+	OnOxygen bool
+	FiO2     float64
+
+	TempUnit     TempUnit
+	PressureUnit PressureUnit
+}
+
+// Vitals returns u converted to Vitals (Celsius, whole mmHg). It fails if
+// a unit is not defined, so a misconfigured feed is not silently scored in
+// the wrong unit.
+func (u VitalsWithUnits) Vitals() (Vitals, error) {
+	if !u.TempUnit.Valid() {
+		return Vitals{}, fmt.Errorf("score: units: unknown temperature unit %d", u.TempUnit)
+	}
+	if !u.PressureUnit.Valid() {
+		return Vitals{}, fmt.Errorf("score: units: unknown pressure unit %d", u.PressureUnit)
+	}
+	return Vitals{
+		HR:        u.HR,
+		RR:        u.RR,
+		SBP:       ToMMHg(u.SBP, u.PressureUnit),
+		DBP:       ToMMHg(u.DBP, u.PressureUnit),
+		Temp:      ToCelsius(u.Temp, u.TempUnit),
+		SpO2:      u.SpO2,
+		GCS:       u.GCS,
+		GCSEye:    u.GCSEye,
+		GCSVerbal: u.GCSVerbal,
+		GCSMotor:  u.GCSMotor,
+		OnOxygen:  u.OnOxygen,
+		FiO2:      u.FiO2,
+	}, nil
+}
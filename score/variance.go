@@ -0,0 +1,50 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import (
+	"fmt"
+	"math"
+)
+
+// Estimate is an acuity score with its standard error under a measurement
+// error model (see AcuityWithVariance).
+type Estimate struct {
+	Acuity   float64
+	Variance float64
+	// Gradient is the derivative of the acuity with respect to each vital
+	// (see AcuityGradient).
+	Gradient [7]float64
+}
+
+// SE returns the standard error, the square root of Variance.
+func (e Estimate) SE() float64 {
+	return math.Sqrt(e.Variance)
+}
+
+// String formats e as "0.62 ± 0.04".
+func (e Estimate) String() string {
+	return fmt.Sprintf("%.2f ± %.2f", e.Acuity, e.SE())
+}
+
+// AcuityWithVariance returns AcuityWithOptions with its delta-method
+// variance under independent per-vital measurement errors with standard
+// errors se, in VitalWeights order and the vitals' units:
+//
+//	Var(s) ≈ Σ (∂s/∂x_i)² se_i²
+//
+// with the derivatives of AcuityGradient, so missing vitals, banded GCS and
+// saturated deviations contribute no variance, and the variance is
+// approximate when terms that combine vitals (MAP, respiratory composite,
+// qSOFA, trend) are in use.
+func AcuityWithVariance(v Vitals, resourceCount, maxResources int, vitalWeights [7]float64, resourceWeight float64, o Options, se [7]float64) Estimate {
+	e := Estimate{
+		Acuity:   AcuityWithOptions(v, resourceCount, maxResources, vitalWeights, resourceWeight, o),
+		Gradient: AcuityGradient(v, resourceCount, maxResources, vitalWeights, resourceWeight, o),
+	}
+	for i, g := range e.Gradient {
+		e.Variance += g * g * se[i] * se[i]
+	}
+	return e
+}
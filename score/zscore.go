@@ -0,0 +1,76 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package score
+
+import "math"
+
+// DefaultZSaturation is the |z| at which a z-score deviation reaches 1 when
+// ZNorm.Saturation is 0.
+const DefaultZSaturation = 3.0
+
+// ZNorm is a vital's population distribution, for scoring its deviation as
+// a z-score, |x - Mean| / SD, instead of against a [mid, halfWidth] norm:
+// sites that calibrate from their own distributions set the mean and
+// standard deviation they measured. The deviation reaches 1 at |z| =
+// Saturation, so ZNorm is the norm [Mean, Saturation·SD], under which
+// Transform, Directions and Caps apply as usual. The zero value is off.
+type ZNorm struct {
+	Mean float64
+	SD   float64
+	// Saturation is the |z| at which the deviation reaches 1; 0 means
+	// DefaultZSaturation.
+	Saturation float64
+}
+
+// IsZero returns true if z is the zero value (z-scoring off).
+func (z ZNorm) IsZero() bool {
+	return z == ZNorm{}
+}
+
+// Valid returns true if z is the zero value, or Mean is finite, SD finite
+// and > 0, and Saturation 0 or finite and > 0.
+func (z ZNorm) Valid() bool {
+	if z.IsZero() {
+		return true
+	}
+	finite := func(x float64) bool { return !math.IsNaN(x) && !math.IsInf(x, 0) }
+	return finite(z.Mean) && z.SD > 0 && finite(z.SD) &&
+		(z.Saturation == 0 || (z.Saturation > 0 && finite(z.Saturation)))
+}
+
+// Z returns the z-score of x, (x - Mean) / SD, or 0 if SD <= 0.
+func (z ZNorm) Z(x float64) float64 {
+	if !(z.SD > 0) {
+		return 0
+	}
+	return (x - z.Mean) / z.SD
+}
+
+// Norm returns the [mid, halfWidth] norm equivalent to z: [Mean,
+// Saturation·SD].
+func (z ZNorm) Norm() [2]float64 {
+	s := z.Saturation
+	if s == 0 {
+		s = DefaultZSaturation
+	}
+	return [2]float64{z.Mean, s * z.SD}
+}
+
+// baseNorms returns the norms o scores against before per-side
+// adjustments: o.Norms or the package norms, with the vitals that have a
+// non-zero ZScores entry replaced by its Norm.
+func (o Options) baseNorms() [7][2]float64 {
+	norms := DefaultNorms()
+	if o.Norms != nil {
+		norms = *o.Norms
+	}
+	if o.ZScores != nil {
+		for i, z := range o.ZScores {
+			if !z.IsZero() {
+				norms[i] = z.Norm()
+			}
+		}
+	}
+	return norms
+}
@@ -0,0 +1,116 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+//
+// Package similar retrieves the past cases closest to a new patient, giving
+// clinicians precedent-based context alongside the score.
+//
+// Cases are indexed in the standard feature space of the model package: the
+// seven vital deviations in [0, 1] plus the linear resource ratio. Distance is
+// Euclidean over those eight coordinates, so a missing vital counts as being
+// within its normal range. The index is a linear scan; it is intended for
+// site cohorts of up to a few hundred thousand records.
+package similar
+
+import (
+	"math"
+	"sort"
+
+	"github.com/olaflaitinen/triagegeist/model"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// Case is one historical presentation.
+type Case struct {
+	ID            string
+	Vitals        score.Vitals
+	ResourceCount int
+	Level         int    // Assigned level (1..5)
+	Outcome       string // Free-text or coded outcome, e.g. "admitted", "ICU"
+}
+
+// Neighbor is a retrieved case and its distance from the query.
+type Neighbor struct {
+	Case
+	Distance float64
+}
+
+// Index holds a cohort in feature space. Not safe for concurrent Add and
+// Query; concurrent queries are safe.
+type Index struct {
+	maxResources int
+	cases        []Case
+	vecs         []model.Features
+}
+
+// NewIndex returns an Index over cases. maxResources is the resource count
+// mapped to ratio 1 (Params.MaxResources); values <= 0 use 5.
+func NewIndex(cases []Case, maxResources int) *Index {
+	if maxResources <= 0 {
+		maxResources = 5
+	}
+	ix := &Index{maxResources: maxResources}
+	for _, c := range cases {
+		ix.Add(c)
+	}
+	return ix
+}
+
+// Add appends one case to the index.
+func (ix *Index) Add(c Case) {
+	ix.cases = append(ix.cases, c)
+	ix.vecs = append(ix.vecs, model.FeaturesOf(c.Vitals, c.ResourceCount, ix.maxResources))
+}
+
+// Len returns the number of indexed cases.
+func (ix *Index) Len() int {
+	return len(ix.cases)
+}
+
+// Query returns up to k cases closest to v and resourceCount, nearest first.
+// Ties keep index order. Returns nil if k <= 0 or the index is empty.
+func (ix *Index) Query(v score.Vitals, resourceCount, k int) []Neighbor {
+	if k <= 0 || len(ix.cases) == 0 {
+		return nil
+	}
+	q := model.FeaturesOf(v, resourceCount, ix.maxResources)
+	out := make([]Neighbor, len(ix.cases))
+	for i, f := range ix.vecs {
+		out[i] = Neighbor{Case: ix.cases[i], Distance: Distance(q, f)}
+	}
+	sort.SliceStable(out, func(a, b int) bool { return out[a].Distance < out[b].Distance })
+	if k < len(out) {
+		out = out[:k]
+	}
+	return out
+}
+
+// Distance returns the Euclidean distance between two feature vectors.
+func Distance(a, b model.Features) float64 {
+	var s float64
+	for i := range a {
+		d := a[i] - b[i]
+		s += d * d
+	}
+	return math.Sqrt(s)
+}
+
+// LevelVotes returns the neighbour count per level (index 0 unused). Levels
+// outside 1..5 are ignored.
+func LevelVotes(ns []Neighbor) [6]int {
+	var votes [6]int
+	for _, n := range ns {
+		if n.Level >= 1 && n.Level <= 5 {
+			votes[n.Level]++
+		}
+	}
+	return votes
+}
+
+// Outcomes returns how often each outcome occurs among ns.
+func Outcomes(ns []Neighbor) map[string]int {
+	m := make(map[string]int)
+	for _, n := range ns {
+		m[n.Outcome]++
+	}
+	return m
+}
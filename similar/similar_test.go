@@ -0,0 +1,43 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package similar
+
+import (
+	"testing"
+
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+func TestIndex_Query(t *testing.T) {
+	cases := []Case{
+		{ID: "normal", Vitals: score.Vitals{HR: 75, RR: 14, SBP: 120, SpO2: 98}, ResourceCount: 1, Level: 5, Outcome: "discharged"},
+		{ID: "hypoxic", Vitals: score.Vitals{HR: 110, RR: 28, SBP: 115, SpO2: 86}, ResourceCount: 3, Level: 2, Outcome: "admitted"},
+		{ID: "shock", Vitals: score.Vitals{HR: 140, RR: 30, SBP: 70, SpO2: 90}, ResourceCount: 5, Level: 1, Outcome: "ICU"},
+		{ID: "mild", Vitals: score.Vitals{HR: 95, RR: 18, SBP: 125, SpO2: 96}, ResourceCount: 2, Level: 4, Outcome: "discharged"},
+	}
+	ix := NewIndex(cases, 5)
+	if ix.Len() != 4 {
+		t.Fatalf("Len = %d", ix.Len())
+	}
+	ns := ix.Query(score.Vitals{HR: 112, RR: 27, SBP: 118, SpO2: 87}, 3, 2)
+	if len(ns) != 2 || ns[0].ID != "hypoxic" {
+		t.Fatalf("nearest = %+v", ns)
+	}
+	if ns[0].Distance > ns[1].Distance {
+		t.Error("neighbours not sorted by distance")
+	}
+	if got := ix.Query(score.Vitals{HR: 75}, 1, 10); len(got) != 4 {
+		t.Errorf("k > Len returned %d cases", len(got))
+	}
+	if ix.Query(score.Vitals{}, 0, 0) != nil {
+		t.Error("k = 0 should return nil")
+	}
+	votes := LevelVotes(ix.Query(score.Vitals{HR: 80, RR: 15, SBP: 120, SpO2: 98}, 1, 2))
+	if votes[5] != 1 || votes[4] != 1 {
+		t.Errorf("votes = %v", votes)
+	}
+	if o := Outcomes(ns); o["admitted"] != 1 {
+		t.Errorf("outcomes = %v", o)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// NoiseDist is the distribution of a vital's measurement noise.
+type NoiseDist int
+
+const (
+	// NoiseNormal is Gaussian noise with standard deviation Scale.
+	NoiseNormal NoiseDist = iota
+	// NoiseUniform is uniform noise on [-Scale, Scale].
+	NoiseUniform
+)
+
+// VitalNoise is the noise added to one vital when resampling. A zero Scale
+// leaves the vital unchanged.
+type VitalNoise struct {
+	Dist  NoiseDist
+	Scale float64
+}
+
+// NoiseModel holds the noise of each vital in VitalWeights order (HR, RR,
+// SBP, DBP, Temp, SpO2, GCS), in the vital's own units.
+type NoiseModel [7]VitalNoise
+
+// GaussianNoise returns a NoiseModel with normal noise whose standard
+// deviations are err, e.g. GaussianNoise(DefaultMeasurementError()).
+func GaussianNoise(err MeasurementError) NoiseModel {
+	var m NoiseModel
+	for i, s := range err {
+		m[i] = VitalNoise{Dist: NoiseNormal, Scale: s}
+	}
+	return m
+}
+
+// Simulation is the distribution of the acuity score under resampled
+// measurement noise (see Engine.Simulate).
+type Simulation struct {
+	// Acuity and Level are for the vitals as measured.
+	Acuity float64
+	Level  Level
+	// Scores holds the simulated scores in ascending order.
+	Scores []float64
+	Mean   float64
+	SD     float64
+	// LevelProb[l-1] is the fraction of simulated scores at Level l.
+	LevelProb [5]float64
+}
+
+// Quantile returns the q-quantile of the simulated scores (0 <= q <= 1),
+// by the nearest-rank method. It returns 0 if there are no scores.
+func (s Simulation) Quantile(q float64) float64 {
+	if len(s.Scores) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(q*float64(len(s.Scores)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(s.Scores) {
+		i = len(s.Scores) - 1
+	}
+	return s.Scores[i]
+}
+
+// Prob returns the simulated probability of level l.
+func (s Simulation) Prob(l Level) float64 {
+	if l < Level1Resuscitation || l > Level5NonUrgent {
+		return 0
+	}
+	return s.LevelProb[l-1]
+}
+
+// Simulate resamples the vitals of v n times, adding independent noise from
+// noise to each present vital, re-scores each sample and returns the score
+// distribution and the probability of each level. Samples are rounded and
+// kept in range like EvaluateInterval's bounds: integer vitals stay
+// integers and present, SpO2 at most 100 and GCS (as a total) in 3 to 15.
+// Missing vitals stay missing. The same seed gives the same result.
+//
+// It scores like Acuity but bypasses the Cache and per-call Instruments.
+func (e *Engine) Simulate(v score.Vitals, resourceCount int, noise NoiseModel, n int, seed int64) (Simulation, error) {
+	if n <= 0 {
+		return Simulation{}, errors.New("triagegeist: simulate: n must be positive")
+	}
+	for i, vn := range noise {
+		if vn.Scale < 0 || math.IsNaN(vn.Scale) || math.IsInf(vn.Scale, 0) {
+			return Simulation{}, fmt.Errorf("triagegeist: simulate: noise[%d] scale %v must be finite and >= 0", i, vn.Scale)
+		}
+		if vn.Dist != NoiseNormal && vn.Dist != NoiseUniform {
+			return Simulation{}, fmt.Errorf("triagegeist: simulate: noise[%d] has unknown distribution %d", i, vn.Dist)
+		}
+	}
+	o := e.scoreOptions(e.P)
+	acuity := func(v score.Vitals) float64 {
+		return score.AcuityWithOptions(v, resourceCount, e.P.MaxResources, e.P.VitalWeights, e.P.ResourceWeight, o)
+	}
+	a := acuity(v)
+	sim := Simulation{Acuity: a, Level: FromScore(a, e.P), Scores: make([]float64, n)}
+	rng := rand.New(rand.NewSource(seed))
+	var sum float64
+	for k := range sim.Scores {
+		s := acuity(perturbVitals(v, noise, rng))
+		sim.Scores[k] = s
+		sim.LevelProb[FromScore(s, e.P)-1]++
+		sum += s
+	}
+	sim.Mean = sum / float64(n)
+	var ss float64
+	for _, s := range sim.Scores {
+		ss += (s - sim.Mean) * (s - sim.Mean)
+	}
+	sim.SD = math.Sqrt(ss / float64(n))
+	for i := range sim.LevelProb {
+		sim.LevelProb[i] /= float64(n)
+	}
+	sort.Float64s(sim.Scores)
+	return sim, nil
+}
+
+// perturbVitals returns v with noise drawn from rng added to each present
+// vital.
+func perturbVitals(v score.Vitals, noise NoiseModel, rng *rand.Rand) score.Vitals {
+	out := v
+	out.HR = perturbInt(v.HR, noise[0], rng)
+	out.RR = perturbInt(v.RR, noise[1], rng)
+	out.SBP = perturbInt(v.SBP, noise[2], rng)
+	out.DBP = perturbInt(v.DBP, noise[3], rng)
+	if v.Temp != 0 {
+		out.Temp = v.Temp + noise[4].draw(rng)
+	}
+	out.SpO2 = perturbInt(v.SpO2, noise[5], rng)
+	if out.SpO2 > 100 {
+		out.SpO2 = 100
+	}
+	if g := score.GCSTotal(v); g > 0 {
+		out.GCS = perturbInt(g, noise[6], rng)
+		if out.GCS < 3 {
+			out.GCS = 3
+		}
+		if out.GCS > 15 {
+			out.GCS = 15
+		}
+	}
+	return out
+}
+
+// perturbInt perturbs a present integer vital, keeping it present (>= 1).
+func perturbInt(x int, n VitalNoise, rng *rand.Rand) int {
+	if x <= 0 {
+		return x
+	}
+	s := int(math.Round(float64(x) + n.draw(rng)))
+	if s < 1 {
+		return 1
+	}
+	return s
+}
+
+func (n VitalNoise) draw(rng *rand.Rand) float64 {
+	if n.Scale == 0 {
+		return 0
+	}
+	if n.Dist == NoiseUniform {
+		return (2*rng.Float64() - 1) * n.Scale
+	}
+	return rng.NormFloat64() * n.Scale
+}
@@ -0,0 +1,223 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package stats
+
+import (
+	"math"
+	"math/rand"
+)
+
+// KMeansResult holds a k-means clustering.
+type KMeansResult struct {
+	Centroids  [][]float64
+	Labels     []int   // Cluster index of each row
+	Sizes      []int   // Rows per cluster
+	Inertia    float64 // Sum of squared distances to the assigned centroid
+	Iterations int
+}
+
+// KMeans clusters the rows of x (e.g. export.Embedding vectors) into k
+// groups with Lloyd's algorithm and k-means++ seeding drawn from seed, so
+// the same inputs give the same clustering. It stops when no label changes
+// or after maxIter iterations (<= 0 means 100). An empty cluster is
+// re-seeded with the row farthest from its centroid. Returns false if x has
+// fewer than k rows, ragged rows or k <= 0.
+func KMeans(x [][]float64, k int, seed int64, maxIter int) (KMeansResult, bool) {
+	n := len(x)
+	if k <= 0 || n < k || len(x[0]) == 0 {
+		return KMeansResult{}, false
+	}
+	d := len(x[0])
+	for _, row := range x {
+		if len(row) != d {
+			return KMeansResult{}, false
+		}
+	}
+	if maxIter <= 0 {
+		maxIter = 100
+	}
+	rng := rand.New(rand.NewSource(seed))
+	cent := kmeansPlusPlus(x, k, rng)
+	labels := make([]int, n)
+	for i := range labels {
+		labels[i] = -1
+	}
+	res := KMeansResult{Labels: labels}
+	for it := 1; it <= maxIter; it++ {
+		res.Iterations = it
+		changed := false
+		for i, row := range x {
+			c, _ := nearest(row, cent)
+			if c != labels[i] {
+				labels[i] = c
+				changed = true
+			}
+		}
+		sizes := make([]int, k)
+		next := make([][]float64, k)
+		for c := range next {
+			next[c] = make([]float64, d)
+		}
+		for i, row := range x {
+			sizes[labels[i]]++
+			for j, v := range row {
+				next[labels[i]][j] += v
+			}
+		}
+		for c := range next {
+			if sizes[c] == 0 {
+				far, farD := 0, -1.0
+				for i, row := range x {
+					if dd := sqDist(row, cent[labels[i]]); dd > farD {
+						far, farD = i, dd
+					}
+				}
+				copy(next[c], x[far])
+				changed = true
+				continue
+			}
+			for j := range next[c] {
+				next[c][j] /= float64(sizes[c])
+			}
+		}
+		cent = next
+		if !changed {
+			break
+		}
+	}
+	res.Centroids = cent
+	res.Sizes = make([]int, k)
+	for i, row := range x {
+		res.Sizes[labels[i]]++
+		res.Inertia += sqDist(row, cent[labels[i]])
+	}
+	return res, true
+}
+
+// kmeansPlusPlus picks k initial centroids, each with probability
+// proportional to its squared distance from those already chosen.
+func kmeansPlusPlus(x [][]float64, k int, rng *rand.Rand) [][]float64 {
+	cent := [][]float64{append([]float64(nil), x[rng.Intn(len(x))]...)}
+	dist := make([]float64, len(x))
+	for len(cent) < k {
+		var total float64
+		for i, row := range x {
+			_, dist[i] = nearest(row, cent)
+			total += dist[i]
+		}
+		pick := 0
+		if total > 0 {
+			r := rng.Float64() * total
+			for i, dd := range dist {
+				r -= dd
+				if r <= 0 {
+					pick = i
+					break
+				}
+			}
+		} else {
+			pick = rng.Intn(len(x))
+		}
+		cent = append(cent, append([]float64(nil), x[pick]...))
+	}
+	return cent
+}
+
+// nearest returns the index of the centroid closest to row and the squared
+// distance to it.
+func nearest(row []float64, cent [][]float64) (int, float64) {
+	best, bestD := 0, math.Inf(1)
+	for c, m := range cent {
+		if dd := sqDist(row, m); dd < bestD {
+			best, bestD = c, dd
+		}
+	}
+	return best, bestD
+}
+
+func sqDist(a, b []float64) float64 {
+	var s float64
+	for i := range a {
+		d := a[i] - b[i]
+		s += d * d
+	}
+	return s
+}
+
+// Silhouette returns the mean silhouette coefficient of labels over x, in
+// [-1, 1]; higher means tighter, better separated clusters. Rows in
+// singleton clusters score 0. Runs in O(n²). Returns 0 if fewer than two
+// clusters are present or the lengths differ.
+func Silhouette(x [][]float64, labels []int) float64 {
+	n := len(x)
+	if n != len(labels) || n == 0 {
+		return 0
+	}
+	k := 0
+	for _, l := range labels {
+		if l < 0 {
+			return 0
+		}
+		if l+1 > k {
+			k = l + 1
+		}
+	}
+	size := make([]int, k)
+	for _, l := range labels {
+		size[l]++
+	}
+	nonEmpty := 0
+	for _, s := range size {
+		if s > 0 {
+			nonEmpty++
+		}
+	}
+	if nonEmpty < 2 {
+		return 0
+	}
+	var total float64
+	sum := make([]float64, k)
+	for i := range x {
+		for c := range sum {
+			sum[c] = 0
+		}
+		for j := range x {
+			if i != j {
+				sum[labels[j]] += math.Sqrt(sqDist(x[i], x[j]))
+			}
+		}
+		own := labels[i]
+		if size[own] < 2 {
+			continue
+		}
+		a := sum[own] / float64(size[own]-1)
+		b := math.Inf(1)
+		for c := range sum {
+			if c != own && size[c] > 0 {
+				b = math.Min(b, sum[c]/float64(size[c]))
+			}
+		}
+		if m := math.Max(a, b); m > 0 {
+			total += (b - a) / m
+		}
+	}
+	return total / float64(n)
+}
+
+// ClusterLevelTable cross-tabulates cluster labels against triage levels:
+// row c holds the level counts (index 1..5; 0 unused) of cluster c. Rows
+// with a level outside 1..5 or a negative label are skipped; k is the
+// number of clusters.
+func ClusterLevelTable(labels, levels []int, k int) [][6]int {
+	out := make([][6]int, k)
+	for i, c := range labels {
+		if i >= len(levels) || c < 0 || c >= k {
+			continue
+		}
+		if l := levels[i]; l >= 1 && l <= 5 {
+			out[c][l]++
+		}
+	}
+	return out
+}
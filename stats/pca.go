@@ -0,0 +1,175 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// PCAResult holds a principal component decomposition.
+type PCAResult struct {
+	Mean       []float64   // Column means subtracted before projection
+	Components [][]float64 // Unit loadings, one row per component, by decreasing variance
+	Variance   []float64   // Sample variance explained by each component
+	Total      float64     // Total sample variance (trace of the covariance)
+}
+
+// PCA computes the first k principal components of x (rows are observations,
+// all of equal length) from the sample covariance matrix, using Jacobi
+// eigendecomposition. Component signs are fixed so the largest-magnitude
+// loading is positive. k is capped at the column count. Returns false if
+// x has fewer than two rows, ragged rows or k <= 0.
+func PCA(x [][]float64, k int) (PCAResult, bool) {
+	n := len(x)
+	if n < 2 || k <= 0 || len(x[0]) == 0 {
+		return PCAResult{}, false
+	}
+	d := len(x[0])
+	for _, row := range x {
+		if len(row) != d {
+			return PCAResult{}, false
+		}
+	}
+	if k > d {
+		k = d
+	}
+	mean := make([]float64, d)
+	for _, row := range x {
+		for j, v := range row {
+			mean[j] += v
+		}
+	}
+	for j := range mean {
+		mean[j] /= float64(n)
+	}
+	cov := make([][]float64, d)
+	for i := range cov {
+		cov[i] = make([]float64, d)
+	}
+	for _, row := range x {
+		for i := 0; i < d; i++ {
+			di := row[i] - mean[i]
+			for j := i; j < d; j++ {
+				cov[i][j] += di * (row[j] - mean[j])
+			}
+		}
+	}
+	var total float64
+	for i := 0; i < d; i++ {
+		for j := i; j < d; j++ {
+			cov[i][j] /= float64(n - 1)
+			cov[j][i] = cov[i][j]
+		}
+		total += cov[i][i]
+	}
+	vals, vecs := jacobiEigen(cov)
+	idx := make([]int, d)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return vals[idx[a]] > vals[idx[b]] })
+	res := PCAResult{Mean: mean, Total: total}
+	for _, c := range idx[:k] {
+		comp := make([]float64, d)
+		big := 0
+		for i := 0; i < d; i++ {
+			comp[i] = vecs[i][c]
+			if math.Abs(comp[i]) > math.Abs(comp[big]) {
+				big = i
+			}
+		}
+		if comp[big] < 0 {
+			for i := range comp {
+				comp[i] = -comp[i]
+			}
+		}
+		res.Components = append(res.Components, comp)
+		res.Variance = append(res.Variance, math.Max(0, vals[c]))
+	}
+	return res, true
+}
+
+// Project returns the component scores of one observation. Returns nil if
+// len(row) does not match the fitted dimension.
+func (p PCAResult) Project(row []float64) []float64 {
+	if len(row) != len(p.Mean) {
+		return nil
+	}
+	out := make([]float64, len(p.Components))
+	for c, comp := range p.Components {
+		for j, v := range row {
+			out[c] += (v - p.Mean[j]) * comp[j]
+		}
+	}
+	return out
+}
+
+// ExplainedRatio returns each component's share of the total variance.
+func (p PCAResult) ExplainedRatio() []float64 {
+	out := make([]float64, len(p.Variance))
+	if p.Total <= 0 {
+		return out
+	}
+	for i, v := range p.Variance {
+		out[i] = v / p.Total
+	}
+	return out
+}
+
+// jacobiEigen returns the eigenvalues and eigenvectors (as columns) of the
+// symmetric matrix a, which is overwritten.
+func jacobiEigen(a [][]float64) ([]float64, [][]float64) {
+	d := len(a)
+	v := make([][]float64, d)
+	for i := range v {
+		v[i] = make([]float64, d)
+		v[i][i] = 1
+	}
+	for sweep := 0; sweep < 100; sweep++ {
+		var off float64
+		for i := 0; i < d; i++ {
+			for j := i + 1; j < d; j++ {
+				off += a[i][j] * a[i][j]
+			}
+		}
+		if off < 1e-22 {
+			break
+		}
+		for p := 0; p < d; p++ {
+			for q := p + 1; q < d; q++ {
+				if a[p][q] == 0 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < d; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p] = c*akp - s*akq
+					a[k][q] = s*akp + c*akq
+				}
+				for k := 0; k < d; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k] = c*apk - s*aqk
+					a[q][k] = s*apk + c*aqk
+				}
+				for k := 0; k < d; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p] = c*vkp - s*vkq
+					v[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+	vals := make([]float64, d)
+	for i := range vals {
+		vals[i] = a[i][i]
+	}
+	return vals, v
+}
@@ -14,6 +14,9 @@
 // 95% CI: [μ - 1.96*SE, μ + 1.96*SE]  (normal approximation)
 //
 // Percentile: linear interpolation between order statistics.
+//
+// Survival: Kaplan-Meier with Greenwood SE, and the k-group log-rank test
+// (see survival.go), for time-to-escalation analysis by initial level.
 package stats
 
 import (
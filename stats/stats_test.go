@@ -1,6 +1,9 @@
 package stats
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestMean(t *testing.T) {
 	x := []float64{1, 2, 3, 4, 5}
@@ -79,3 +82,109 @@ func TestRMSE(t *testing.T) {
 		t.Errorf("RMSE = %v", r)
 	}
 }
+
+func TestKaplanMeier(t *testing.T) {
+	// Classic example: events at 1, 3, 3, 6; censored at 2 and 5.
+	obs := []SurvivalObs{{1, true}, {2, false}, {3, true}, {3, true}, {5, false}, {6, true}}
+	km := KaplanMeier(obs)
+	if len(km) != 3 {
+		t.Fatalf("got %d steps, want 3", len(km))
+	}
+	want := []float64{5.0 / 6, 5.0 / 6 * 2 / 4, 5.0 / 6 * 2 / 4 * 0}
+	for i, p := range km {
+		if math.Abs(p.Survival-want[i]) > 1e-12 {
+			t.Errorf("S(%v) = %v, want %v", p.Time, p.Survival, want[i])
+		}
+	}
+	if km[1].AtRisk != 4 || km[1].Censored != 1 {
+		t.Errorf("step 2 = %+v", km[1])
+	}
+	if got := KMSurvivalAt(km, 4); math.Abs(got-want[1]) > 1e-12 {
+		t.Errorf("KMSurvivalAt(4) = %v", got)
+	}
+	if m, ok := KMMedian(km); !ok || m != 3 {
+		t.Errorf("KMMedian = %v, %v", m, ok)
+	}
+}
+
+func TestLogRank(t *testing.T) {
+	fast := []SurvivalObs{{1, true}, {2, true}, {3, true}, {4, true}, {5, true}, {6, false}}
+	slow := []SurvivalObs{{8, true}, {9, true}, {10, true}, {11, true}, {12, false}, {13, true}}
+	r := LogRank([][]SurvivalObs{fast, slow})
+	if r.DF != 1 || r.P > 0.01 || r.Observed[0] != 5 {
+		t.Errorf("LogRank = %+v", r)
+	}
+	if same := LogRank([][]SurvivalObs{fast, fast}); same.ChiSq > 1e-12 || math.Abs(same.P-1) > 1e-12 {
+		t.Errorf("identical groups: %+v", same)
+	}
+	g := GroupByLevel([]int{2, 3, 2}, []SurvivalObs{{1, true}, {2, true}, {3, false}})
+	if len(g[2]) != 2 || len(g[3]) != 1 {
+		t.Errorf("GroupByLevel = %v", g)
+	}
+	if p := ChiSquareSF(3.841458820694124, 1); math.Abs(p-0.05) > 1e-6 {
+		t.Errorf("ChiSquareSF(3.84, 1) = %v, want 0.05", p)
+	}
+	if p := ChiSquareSF(5.991464547107979, 2); math.Abs(p-0.05) > 1e-6 {
+		t.Errorf("ChiSquareSF(5.99, 2) = %v, want 0.05", p)
+	}
+}
+
+func TestPCA(t *testing.T) {
+	// Points along y = 2x with small orthogonal jitter.
+	var x [][]float64
+	for i := 0; i < 20; i++ {
+		j := 0.01 * float64(i%3-1)
+		x = append(x, []float64{float64(i) - 2*j, 2*float64(i) + j})
+	}
+	p, ok := PCA(x, 2)
+	if !ok {
+		t.Fatal("PCA failed")
+	}
+	c := p.Components[0]
+	if math.Abs(c[0]-1/math.Sqrt(5)) > 1e-3 || math.Abs(c[1]-2/math.Sqrt(5)) > 1e-3 {
+		t.Errorf("first component = %v", c)
+	}
+	if r := p.ExplainedRatio(); r[0] < 0.999 || math.Abs(r[0]+r[1]-1) > 1e-9 {
+		t.Errorf("explained ratio = %v", r)
+	}
+	if got := p.Project(p.Mean); math.Abs(got[0]) > 1e-12 {
+		t.Errorf("mean projects to %v", got)
+	}
+	if _, ok := PCA(x[:1], 1); ok {
+		t.Error("single row should fail")
+	}
+}
+
+func TestKMeans(t *testing.T) {
+	var x [][]float64
+	var levels []int
+	for i := 0; i < 30; i++ {
+		j := 0.02 * float64(i%5)
+		x = append(x, []float64{j, 0.8 + j}) // hypoxic-like
+		levels = append(levels, 2)
+		x = append(x, []float64{0.9 - j, j}) // tachycardic-like
+		levels = append(levels, 3)
+	}
+	r, ok := KMeans(x, 2, 7, 0)
+	if !ok {
+		t.Fatal("KMeans failed")
+	}
+	if r.Sizes[0] != 30 || r.Sizes[1] != 30 {
+		t.Errorf("sizes = %v", r.Sizes)
+	}
+	again, _ := KMeans(x, 2, 7, 0)
+	if again.Inertia != r.Inertia || again.Labels[0] != r.Labels[0] {
+		t.Error("same seed gave a different clustering")
+	}
+	if s := Silhouette(x, r.Labels); s < 0.9 {
+		t.Errorf("silhouette = %v", s)
+	}
+	tab := ClusterLevelTable(r.Labels, levels, 2)
+	c := r.Labels[0]
+	if tab[c][2] != 30 || tab[1-c][3] != 30 {
+		t.Errorf("table = %v", tab)
+	}
+	if _, ok := KMeans(x[:1], 2, 1, 0); ok {
+		t.Error("fewer rows than k should fail")
+	}
+}
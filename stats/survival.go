@@ -0,0 +1,311 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// SurvivalObs is one time-to-event observation, e.g. minutes from triage to
+// escalation or to first physician contact. Event false means the
+// observation was censored at Time (the patient left or the study ended
+// before the event).
+type SurvivalObs struct {
+	Time  float64
+	Event bool
+}
+
+// KMPoint is one step of a Kaplan-Meier curve, at a time with at least one
+// event. SE is Greenwood's standard error of Survival.
+type KMPoint struct {
+	Time     float64
+	AtRisk   int
+	Events   int
+	Censored int // censored in (previous step, Time]
+	Survival float64
+	SE       float64
+}
+
+// KaplanMeier returns the Kaplan-Meier survival curve of obs:
+// S(t) = Π_{t_j <= t} (1 - d_j / n_j), with Greenwood variance
+// Var S(t) = S(t)² Σ d_j / (n_j (n_j - d_j)). Censored observations at an
+// event time are counted at risk at that time. Observations with negative or
+// NaN Time are skipped. Returns nil if there are no events.
+func KaplanMeier(obs []SurvivalObs) []KMPoint {
+	xs := sortedObs(obs)
+	var out []KMPoint
+	s, gw := 1.0, 0.0
+	n := len(xs)
+	var censored int
+	for i := 0; i < len(xs); {
+		t := xs[i].Time
+		var d, c int
+		j := i
+		for ; j < len(xs) && xs[j].Time == t; j++ {
+			if xs[j].Event {
+				d++
+			} else {
+				c++
+			}
+		}
+		if d > 0 {
+			s *= 1 - float64(d)/float64(n)
+			if n > d {
+				gw += float64(d) / (float64(n) * float64(n-d))
+			}
+			out = append(out, KMPoint{Time: t, AtRisk: n, Events: d, Censored: censored, Survival: s, SE: s * math.Sqrt(gw)})
+			censored = 0
+		}
+		censored += c
+		n -= j - i
+		i = j
+	}
+	return out
+}
+
+func sortedObs(obs []SurvivalObs) []SurvivalObs {
+	xs := make([]SurvivalObs, 0, len(obs))
+	for _, o := range obs {
+		if o.Time >= 0 {
+			xs = append(xs, o)
+		}
+	}
+	sort.SliceStable(xs, func(a, b int) bool { return xs[a].Time < xs[b].Time })
+	return xs
+}
+
+// KMSurvivalAt returns S(t) from a curve built by KaplanMeier (1 before the
+// first event).
+func KMSurvivalAt(curve []KMPoint, t float64) float64 {
+	s := 1.0
+	for _, p := range curve {
+		if p.Time > t {
+			break
+		}
+		s = p.Survival
+	}
+	return s
+}
+
+// KMMedian returns the median survival time, the first time S(t) <= 0.5.
+// Returns (0, false) if the curve never reaches 0.5.
+func KMMedian(curve []KMPoint) (float64, bool) {
+	for _, p := range curve {
+		if p.Survival <= 0.5 {
+			return p.Time, true
+		}
+	}
+	return 0, false
+}
+
+// GroupByLevel splits obs by the level (1..5) at the same index in levels,
+// e.g. the initial triage level. Index 0 is unused; other levels are skipped.
+// Returns the zero value if the lengths differ.
+func GroupByLevel(levels []int, obs []SurvivalObs) [6][]SurvivalObs {
+	var g [6][]SurvivalObs
+	if len(levels) != len(obs) {
+		return g
+	}
+	for i, L := range levels {
+		if L >= 1 && L <= 5 {
+			g[L] = append(g[L], obs[i])
+		}
+	}
+	return g
+}
+
+// LogRankResult holds the k-group log-rank test statistic. Observed and
+// Expected are per input group; empty groups have 0 and do not count in DF.
+type LogRankResult struct {
+	ChiSq    float64
+	DF       int
+	P        float64
+	Observed []float64
+	Expected []float64
+}
+
+// LogRank tests whether the survival curves of groups differ. At each
+// distinct event time with n at risk and d events, group g contributes
+// E_g = d·n_g/n, and V_gh = d(n-d)/(n-1) · (n_g/n)(δ_gh - n_h/n). The
+// statistic U'V⁻¹U over the first k-1 non-empty groups (U = O - E) is
+// chi-square with k-1 degrees of freedom. Returns DF 0 and P 1 if fewer than
+// two groups are non-empty or the covariance is singular.
+func LogRank(groups [][]SurvivalObs) LogRankResult {
+	r := LogRankResult{
+		Observed: make([]float64, len(groups)),
+		Expected: make([]float64, len(groups)),
+		P:        1,
+	}
+	var active []int
+	sorted := make([][]SurvivalObs, len(groups))
+	var times []float64
+	for g, obs := range groups {
+		sorted[g] = sortedObs(obs)
+		if len(sorted[g]) > 0 {
+			active = append(active, g)
+		}
+		for _, o := range sorted[g] {
+			if o.Event {
+				times = append(times, o.Time)
+			}
+		}
+	}
+	k := len(active)
+	if k < 2 {
+		return r
+	}
+	sort.Float64s(times)
+
+	v := make([][]float64, k)
+	for i := range v {
+		v[i] = make([]float64, k)
+	}
+	pos := make([]int, len(groups)) // index of the first obs with Time >= t
+	nAt := make([]float64, k)
+	dAt := make([]float64, k)
+	for i := 0; i < len(times); {
+		t := times[i]
+		for i < len(times) && times[i] == t {
+			i++
+		}
+		var n, d float64
+		for a, g := range active {
+			xs := sorted[g]
+			for pos[g] < len(xs) && xs[pos[g]].Time < t {
+				pos[g]++
+			}
+			nAt[a] = float64(len(xs) - pos[g])
+			dAt[a] = 0
+			for j := pos[g]; j < len(xs) && xs[j].Time == t; j++ {
+				if xs[j].Event {
+					dAt[a]++
+				}
+			}
+			n += nAt[a]
+			d += dAt[a]
+		}
+		for a, g := range active {
+			r.Observed[g] += dAt[a]
+			r.Expected[g] += d * nAt[a] / n
+		}
+		if n > 1 {
+			f := d * (n - d) / (n - 1)
+			for a := range active {
+				for b := range active {
+					delta := 0.0
+					if a == b {
+						delta = 1
+					}
+					v[a][b] += f * nAt[a] / n * (delta - nAt[b]/n)
+				}
+			}
+		}
+	}
+
+	m := k - 1
+	u := make([]float64, m)
+	vm := make([][]float64, m)
+	for a := 0; a < m; a++ {
+		g := active[a]
+		u[a] = r.Observed[g] - r.Expected[g]
+		vm[a] = append([]float64(nil), v[a][:m]...)
+	}
+	x, ok := solve(vm, append([]float64(nil), u...))
+	if !ok {
+		return r
+	}
+	for a := range u {
+		r.ChiSq += u[a] * x[a]
+	}
+	r.DF = m
+	r.P = ChiSquareSF(r.ChiSq, m)
+	return r
+}
+
+// solve solves a·x = b by Gaussian elimination with partial pivoting.
+// a and b are overwritten. Returns false if a is singular.
+func solve(a [][]float64, b []float64) ([]float64, bool) {
+	n := len(b)
+	for c := 0; c < n; c++ {
+		p := c
+		for r := c + 1; r < n; r++ {
+			if math.Abs(a[r][c]) > math.Abs(a[p][c]) {
+				p = r
+			}
+		}
+		if math.Abs(a[p][c]) < 1e-12 {
+			return nil, false
+		}
+		a[c], a[p] = a[p], a[c]
+		b[c], b[p] = b[p], b[c]
+		for r := c + 1; r < n; r++ {
+			f := a[r][c] / a[c][c]
+			for j := c; j < n; j++ {
+				a[r][j] -= f * a[c][j]
+			}
+			b[r] -= f * b[c]
+		}
+	}
+	x := make([]float64, n)
+	for r := n - 1; r >= 0; r-- {
+		s := b[r]
+		for j := r + 1; j < n; j++ {
+			s -= a[r][j] * x[j]
+		}
+		x[r] = s / a[r][r]
+	}
+	return x, true
+}
+
+// ChiSquareSF returns P(X > x) for X ~ chi-square with df degrees of freedom
+// (the upper regularized incomplete gamma Q(df/2, x/2)). Returns 1 if
+// x <= 0 or df <= 0.
+func ChiSquareSF(x float64, df int) float64 {
+	if x <= 0 || df <= 0 {
+		return 1
+	}
+	return gammaQ(float64(df)/2, x/2)
+}
+
+// gammaQ is the upper regularized incomplete gamma function, by series for
+// x < a+1 and by continued fraction otherwise.
+func gammaQ(a, x float64) float64 {
+	lg, _ := math.Lgamma(a)
+	if x < a+1 {
+		sum, term := 1/a, 1/a
+		for n := 1; n < 500; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*1e-15 {
+				break
+			}
+		}
+		return 1 - sum*math.Exp(-x+a*math.Log(x)-lg)
+	}
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1; i < 500; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < 1e-15 {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lg) * h
+}
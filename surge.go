@@ -0,0 +1,176 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// SurgeModeNormal is the name of a SurgeScheduler's base parameters.
+const SurgeModeNormal = "normal"
+
+// SurgeMode is a named parameter set a SurgeScheduler switches to while its
+// conditions hold. A mode with neither a window nor an EnterSignal never
+// activates.
+type SurgeMode struct {
+	Name   string
+	Params Params
+	// Start and End bound a daily window [Start, End) as offsets from local
+	// midnight; End before Start wraps past midnight. Both zero means any
+	// time of day.
+	Start, End time.Duration
+	// EnterSignal activates the mode when the crowding signal (e.g. NEDOCS)
+	// reaches it; 0 means the signal is not used.
+	EnterSignal float64
+	// ExitSignal keeps an active mode until the signal falls below it, so a
+	// value oscillating around EnterSignal does not flap. 0 (or anything
+	// above EnterSignal) means EnterSignal.
+	ExitSignal float64
+}
+
+// windowed returns true if m has a time-of-day window.
+func (m SurgeMode) windowed() bool {
+	return m.Start != 0 || m.End != 0
+}
+
+// inWindow returns true if t lies in m's daily window.
+func (m SurgeMode) inWindow(t time.Time) bool {
+	y, mo, d := t.Date()
+	off := t.Sub(time.Date(y, mo, d, 0, 0, 0, 0, t.Location()))
+	if m.Start <= m.End {
+		return off >= m.Start && off < m.End
+	}
+	return off >= m.Start || off < m.End
+}
+
+// matches returns true if m applies at t with the given signal; active
+// selects the exit threshold.
+func (m SurgeMode) matches(t time.Time, signal float64, active bool) bool {
+	if m.windowed() && !m.inWindow(t) {
+		return false
+	}
+	if m.EnterSignal == 0 {
+		return m.windowed()
+	}
+	limit := m.EnterSignal
+	if active && m.ExitSignal > 0 && m.ExitSignal < limit {
+		limit = m.ExitSignal
+	}
+	return signal >= limit
+}
+
+// SurgeSwitch is the audit record of one change of the active mode.
+type SurgeSwitch struct {
+	From, To string
+	At       time.Time
+	Signal   float64 // Crowding signal at the switch; NaN if never set
+}
+
+// SurgeScheduler switches a ManagedEngine between named parameter sets by
+// time of day or an external crowding signal, so thresholds can be relaxed
+// or tightened automatically during surges. Modes are checked in order and
+// the first that matches is active; if none matches, the base parameters
+// ("normal") are. Every switch is recorded. The engine's Mode is set to
+// the active mode's name together with its parameters, so every
+// EvaluateResult shows which mode scored it while its provenance still
+// names the parameter set. Safe for concurrent use.
+type SurgeScheduler struct {
+	engine   *ManagedEngine
+	base     SurgeMode
+	modes    []SurgeMode
+	onSwitch func(SurgeSwitch)
+
+	mu      sync.Mutex
+	signal  float64
+	active  string
+	history []SurgeSwitch
+}
+
+// NewSurgeScheduler returns a scheduler of m with base as the normal
+// parameters, initially active. onSwitch, if non-nil, is called after every
+// switch. It is an error if any parameters are invalid, or a mode is
+// unnamed, named SurgeModeNormal, or named twice.
+func NewSurgeScheduler(m *ManagedEngine, base Params, onSwitch func(SurgeSwitch), modes ...SurgeMode) (*SurgeScheduler, error) {
+	if err := paramsError(base); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{SurgeModeNormal: true}
+	s := &SurgeScheduler{
+		engine:   m,
+		base:     SurgeMode{Name: SurgeModeNormal, Params: base},
+		onSwitch: onSwitch,
+		signal:   math.NaN(),
+	}
+	for _, md := range modes {
+		if md.Name == "" || seen[md.Name] {
+			return nil, fmt.Errorf("triagegeist: surge: mode name %q is empty or not unique", md.Name)
+		}
+		seen[md.Name] = true
+		if err := paramsError(md.Params); err != nil {
+			return nil, fmt.Errorf("triagegeist: surge: mode %q: %w", md.Name, err)
+		}
+		s.modes = append(s.modes, md)
+	}
+	s.active = SurgeModeNormal
+	s.install(s.base)
+	return s, nil
+}
+
+// install swaps md's parameters and name into the engine together.
+func (s *SurgeScheduler) install(md SurgeMode) {
+	s.engine.swapParams(md.Params, func(e *Engine) { e.Mode = md.Name })
+}
+
+// SetSignal records the current crowding signal (e.g. a NEDOCS score) and
+// re-evaluates the active mode at now. It returns the switch made, if any.
+func (s *SurgeScheduler) SetSignal(v float64, now time.Time) (SurgeSwitch, bool) {
+	s.mu.Lock()
+	s.signal = v
+	s.mu.Unlock()
+	return s.Update(now)
+}
+
+// Update re-evaluates the active mode at now (e.g. from a ticker, for time
+// windows) and swaps the engine's parameters if it changed. It returns the
+// switch made, if any.
+func (s *SurgeScheduler) Update(now time.Time) (SurgeSwitch, bool) {
+	s.mu.Lock()
+	next := s.base
+	for _, m := range s.modes {
+		if m.matches(now, s.signal, m.Name == s.active) {
+			next = m
+			break
+		}
+	}
+	if next.Name == s.active {
+		s.mu.Unlock()
+		return SurgeSwitch{}, false
+	}
+	sw := SurgeSwitch{From: s.active, To: next.Name, At: now, Signal: s.signal}
+	s.install(next)
+	s.active = next.Name
+	s.history = append(s.history, sw)
+	s.mu.Unlock()
+	if s.onSwitch != nil {
+		s.onSwitch(sw)
+	}
+	return sw, true
+}
+
+// Active returns the name of the active mode.
+func (s *SurgeScheduler) Active() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+// History returns a copy of all switches, oldest first.
+func (s *SurgeScheduler) History() []SurgeSwitch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SurgeSwitch(nil), s.history...)
+}
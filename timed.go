@@ -0,0 +1,157 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"time"
+
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// Field identifies one vital in TimedVitals. GCS (total and components) and
+// oxygen (OnOxygen and FiO2) are each one field, matching score.MergeVitals.
+type Field int
+
+const (
+	FieldHR Field = iota
+	FieldRR
+	FieldSBP
+	FieldDBP
+	FieldTemp
+	FieldSpO2
+	FieldGCS
+	FieldOxygen
+	NumFields
+)
+
+var fieldNames = [NumFields]string{"hr", "rr", "sbp", "dbp", "temp", "spo2", "gcs", "oxygen"}
+
+// String returns the lower-case field name, or "unknown".
+func (f Field) String() string {
+	if f < 0 || f >= NumFields {
+		return "unknown"
+	}
+	return fieldNames[f]
+}
+
+// TimedVitals carries vitals with a measurement time per field, for patients
+// whose observations arrive piecemeal from different devices. A zero time
+// means unknown and sorts before any known time.
+type TimedVitals struct {
+	Vitals score.Vitals
+	At     [NumFields]time.Time
+}
+
+// NewTimedVitals returns v with every present field stamped at t. Oxygen is
+// always stamped, so a snapshot on room air records that observation.
+func NewTimedVitals(v score.Vitals, t time.Time) TimedVitals {
+	tv := TimedVitals{Vitals: v}
+	for f := Field(0); f < NumFields; f++ {
+		if f == FieldOxygen || fieldPresent(v, f) {
+			tv.At[f] = t
+		}
+	}
+	return tv
+}
+
+func fieldPresent(v score.Vitals, f Field) bool {
+	switch f {
+	case FieldHR:
+		return v.HR > 0
+	case FieldRR:
+		return v.RR > 0
+	case FieldSBP:
+		return v.SBP > 0
+	case FieldDBP:
+		return v.DBP > 0
+	case FieldTemp:
+		return v.Temp != 0
+	case FieldSpO2:
+		return v.SpO2 > 0
+	case FieldGCS:
+		return v.GCS > 0 || v.GCSEye > 0 || v.GCSVerbal > 0 || v.GCSMotor > 0
+	case FieldOxygen:
+		return v.OnOxygen || v.FiO2 != 0
+	}
+	return false
+}
+
+// copyField sets field f of dst from src.
+func copyField(dst *score.Vitals, src score.Vitals, f Field) {
+	switch f {
+	case FieldHR:
+		dst.HR = src.HR
+	case FieldRR:
+		dst.RR = src.RR
+	case FieldSBP:
+		dst.SBP = src.SBP
+	case FieldDBP:
+		dst.DBP = src.DBP
+	case FieldTemp:
+		dst.Temp = src.Temp
+	case FieldSpO2:
+		dst.SpO2 = src.SpO2
+	case FieldGCS:
+		dst.GCS, dst.GCSEye, dst.GCSVerbal, dst.GCSMotor = src.GCS, src.GCSEye, src.GCSVerbal, src.GCSMotor
+	case FieldOxygen:
+		dst.OnOxygen, dst.FiO2 = src.OnOxygen, src.FiO2
+	}
+}
+
+// observed returns true if tv carries an observation for f: the value is
+// present, or (oxygen only) the field is stamped, so "off oxygen" can be
+// recorded explicitly.
+func (tv TimedVitals) observed(f Field) bool {
+	if f == FieldOxygen && !tv.At[f].IsZero() {
+		return true
+	}
+	return fieldPresent(tv.Vitals, f)
+}
+
+// MergeTimedVitals merges update into base field by field, latest wins: a
+// field observed in update replaces base's when update's time is not before
+// base's. Fields update does not observe are kept from base. Unlike
+// score.MergeVitals, a stamped oxygen field can switch oxygen off.
+func MergeTimedVitals(base, update TimedVitals) TimedVitals {
+	out := base
+	for f := Field(0); f < NumFields; f++ {
+		if !update.observed(f) || update.At[f].Before(base.At[f]) {
+			continue
+		}
+		copyField(&out.Vitals, update.Vitals, f)
+		out.At[f] = update.At[f]
+	}
+	return out
+}
+
+// Snapshot returns the vitals of tv with every field that is stale at now
+// under sp cleared (treated as missing; oxygen reverts to room air).
+func (tv TimedVitals) Snapshot(now time.Time, sp StalenessPolicy) score.Vitals {
+	v := tv.Vitals
+	for f := Field(0); f < NumFields; f++ {
+		if sp.Stale(tv.At[f], now) {
+			copyField(&v, score.Vitals{}, f)
+		}
+	}
+	return v
+}
+
+// Latest returns the most recent measurement time in tv, or zero if none.
+func (tv TimedVitals) Latest() time.Time {
+	var t time.Time
+	for _, at := range tv.At {
+		if at.After(t) {
+			t = at
+		}
+	}
+	return t
+}
+
+// EvaluateTimed evaluates tv.Snapshot(now, e.Staleness). The result's Time is
+// now.
+func (e *Engine) EvaluateTimed(tv TimedVitals, resourceCount int, now time.Time) EvaluateResult {
+	r := e.Evaluate(tv.Snapshot(now, e.Staleness), resourceCount)
+	r.Time = now
+	return r
+}
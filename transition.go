@@ -0,0 +1,132 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// TransitionMatrix counts level changes between consecutive re-triage events
+// in patient sessions. Counts[from][to] uses levels 1..5 (index 0 unused).
+type TransitionMatrix struct {
+	Counts  [6][6]int     `json:"counts"`
+	Horizon time.Duration `json:"horizon"`
+}
+
+// TransitionEdge is one non-empty cell of a TransitionMatrix.
+type TransitionEdge struct {
+	From, To    Level
+	Count       int
+	Probability float64
+}
+
+// ComputeTransitions builds a TransitionMatrix from sessions, each the
+// evaluation history of one patient (e.g. from Evaluate followed by Rescore).
+// Each session is ordered by EvaluatedAt; every consecutive pair of valid
+// levels at most horizon apart counts one transition, including from a level
+// to itself. horizon 0 counts every consecutive pair; otherwise pairs with
+// a zero EvaluatedAt are skipped. The input is not modified.
+func ComputeTransitions(sessions [][]EvaluateResult, horizon time.Duration) TransitionMatrix {
+	m := TransitionMatrix{Horizon: horizon}
+	for _, s := range sessions {
+		idx := make([]int, len(s))
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.SliceStable(idx, func(a, b int) bool { return s[idx[a]].EvaluatedAt().Before(s[idx[b]].EvaluatedAt()) })
+		for k := 1; k < len(idx); k++ {
+			prev, next := s[idx[k-1]], s[idx[k]]
+			if !prev.Level.Valid() || !next.Level.Valid() {
+				continue
+			}
+			if horizon > 0 {
+				pt, nt := prev.EvaluatedAt(), next.EvaluatedAt()
+				if pt.IsZero() || nt.IsZero() || nt.Sub(pt) > horizon {
+					continue
+				}
+			}
+			m.Counts[prev.Level][next.Level]++
+		}
+	}
+	return m
+}
+
+// Total returns the number of transitions out of level from.
+func (m TransitionMatrix) Total(from Level) int {
+	if !from.Valid() {
+		return 0
+	}
+	var n int
+	for to := 1; to <= 5; to++ {
+		n += m.Counts[from][to]
+	}
+	return n
+}
+
+// Probability returns the estimated probability that a patient at level from
+// is at level to at the next re-triage. Returns 0 if from has no transitions.
+func (m TransitionMatrix) Probability(from, to Level) float64 {
+	n := m.Total(from)
+	if n == 0 || !to.Valid() {
+		return 0
+	}
+	return float64(m.Counts[from][to]) / float64(n)
+}
+
+// Deterioration returns the probability of moving to a more acute level
+// (lower number) than from at the next re-triage.
+func (m TransitionMatrix) Deterioration(from Level) float64 {
+	var p float64
+	for to := Level(1); to < from; to++ {
+		p += m.Probability(from, to)
+	}
+	return p
+}
+
+// Improvement returns the probability of moving to a less acute level.
+func (m TransitionMatrix) Improvement(from Level) float64 {
+	var p float64
+	for to := from + 1; to <= 5; to++ {
+		p += m.Probability(from, to)
+	}
+	return p
+}
+
+// Edges returns the non-empty cells ordered by from, then to.
+func (m TransitionMatrix) Edges() []TransitionEdge {
+	var out []TransitionEdge
+	for from := Level(1); from <= 5; from++ {
+		for to := Level(1); to <= 5; to++ {
+			if c := m.Counts[from][to]; c > 0 {
+				out = append(out, TransitionEdge{From: from, To: to, Count: c, Probability: m.Probability(from, to)})
+			}
+		}
+	}
+	return out
+}
+
+// WriteCSV writes Edges as CSV with header from,to,count,probability.
+func (m TransitionMatrix) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"from", "to", "count", "probability"}); err != nil {
+		return err
+	}
+	for _, e := range m.Edges() {
+		row := []string{
+			strconv.Itoa(e.From.Int()),
+			strconv.Itoa(e.To.Int()),
+			strconv.Itoa(e.Count),
+			strconv.FormatFloat(e.Probability, 'f', -1, 64),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
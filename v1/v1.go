@@ -0,0 +1,268 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+//
+// Package v1 is the stable core API of triagegeist: the types, constructors
+// and scoring interface an integration needs, frozen so that the root
+// package's options and subsystems can evolve without breaking it.
+//
+// # Surface
+//
+//	| Name             | Kind      | Root counterpart                    |
+//	|------------------|-----------|-------------------------------------|
+//	| Engine           | struct    | *triagegeist.Engine, ManagedEngine  |
+//	| Params           | struct    | triagegeist.Params (core fields)    |
+//	| Level            | int       | triagegeist.Level                   |
+//	| Level1 … Level5  | constants | triagegeist.Level1Resuscitation …   |
+//	| Vitals           | struct    | score.Vitals (core fields)          |
+//	| EvaluateResult   | struct    | triagegeist.EvaluateResult          |
+//	| Result           | struct    | export.Result (core columns)        |
+//	| Scorer           | interface | Acuity, Level, ScoreAndLevel, Evaluate |
+//	| NewEngine        | func      | triagegeist.NewEngine               |
+//	| DefaultParams    | func      | triagegeist.DefaultParams           |
+//	| FromScore        | func      | triagegeist.FromScore               |
+//	| ToResult         | func      | EvaluateResult.ToExport             |
+//
+// The types are defined in this package, not aliased, so fields added to
+// the root types never appear here. Each has a conversion to its root
+// counterpart (Root, or Export for Result) and one back (ParamsFrom,
+// VitalsFrom, LevelFrom, EvaluateResultFrom, ResultFrom); use them, or Wrap
+// and WrapManaged, to combine v1 with root features. The names, fields and
+// signatures of this package do not change within v1; v1_test.go pins them.
+package v1
+
+import (
+	"time"
+
+	"github.com/olaflaitinen/triagegeist"
+	"github.com/olaflaitinen/triagegeist/export"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// Level is a triage level, 1 (most acute) to 5; 0 is invalid.
+type Level int
+
+const (
+	Level1 Level = Level(triagegeist.Level1Resuscitation)
+	Level2 Level = Level(triagegeist.Level2Emergent)
+	Level3 Level = Level(triagegeist.Level3Urgent)
+	Level4 Level = Level(triagegeist.Level4LessUrgent)
+	Level5 Level = Level(triagegeist.Level5NonUrgent)
+)
+
+// LevelFrom converts a root level.
+func LevelFrom(l triagegeist.Level) Level {
+	return Level(l)
+}
+
+// Root returns l as a root level.
+func (l Level) Root() triagegeist.Level {
+	return triagegeist.Level(l)
+}
+
+// Int returns the level as int (1..5), or 0 if l is invalid.
+func (l Level) Int() int {
+	return l.Root().Int()
+}
+
+// Valid returns true if l is 1..5.
+func (l Level) Valid() bool {
+	return l.Root().Valid()
+}
+
+// String returns the level's label, e.g. "Resuscitation".
+func (l Level) String() string {
+	return l.Root().String()
+}
+
+// Vitals are the core vital signs. A zero field is missing.
+type Vitals struct {
+	HR   int     // Heart rate, beats per minute
+	RR   int     // Respiratory rate, per minute
+	SBP  int     // Systolic blood pressure, mmHg
+	DBP  int     // Diastolic blood pressure, mmHg
+	Temp float64 // Temperature, Celsius
+	SpO2 int     // Oxygen saturation, percent
+	GCS  int     // Glasgow Coma Scale, 3-15
+}
+
+// VitalsFrom returns the core vital signs of v; its other fields are dropped.
+func VitalsFrom(v score.Vitals) Vitals {
+	return Vitals{HR: v.HR, RR: v.RR, SBP: v.SBP, DBP: v.DBP, Temp: v.Temp, SpO2: v.SpO2, GCS: v.GCS}
+}
+
+// Root returns v as root vitals.
+func (v Vitals) Root() score.Vitals {
+	return score.Vitals{HR: v.HR, RR: v.RR, SBP: v.SBP, DBP: v.DBP, Temp: v.Temp, SpO2: v.SpO2, GCS: v.GCS}
+}
+
+// Params are the core scoring parameters: the weights of the linear acuity
+// formula and the level thresholds T1 > T2 > T3 > T4.
+type Params struct {
+	VitalWeights   [7]float64 // HR, RR, SBP, DBP, Temp, SpO2, GCS
+	MaxResources   int
+	ResourceWeight float64
+	T1, T2, T3, T4 float64
+}
+
+// ParamsFrom returns the core fields of p; its other settings are dropped.
+func ParamsFrom(p triagegeist.Params) Params {
+	return Params{
+		VitalWeights:   p.VitalWeights,
+		MaxResources:   p.MaxResources,
+		ResourceWeight: p.ResourceWeight,
+		T1:             p.T1, T2: p.T2, T3: p.T3, T4: p.T4,
+	}
+}
+
+// Root returns root parameters with p's fields over triagegeist.DefaultParams,
+// so every setting outside v1 keeps its default.
+func (p Params) Root() triagegeist.Params {
+	r := triagegeist.DefaultParams()
+	r.VitalWeights, r.MaxResources, r.ResourceWeight = p.VitalWeights, p.MaxResources, p.ResourceWeight
+	r.T1, r.T2, r.T3, r.T4 = p.T1, p.T2, p.T3, p.T4
+	return r
+}
+
+// Valid returns true if p.Root() passes triagegeist.Params.Validate.
+func (p Params) Valid() bool {
+	return p.Root().Validate()
+}
+
+// EvaluateResult is one evaluation. Deferred is true when the case needs
+// clinician review in the gray zone around a threshold; Insufficient is
+// non-empty when too few vitals were measured, and Acuity and Level are
+// then 0.
+type EvaluateResult struct {
+	Acuity        float64
+	Level         Level
+	Vitals        Vitals
+	ResourceCount int
+	Deferred      bool
+	Insufficient  string
+}
+
+// EvaluateResultFrom returns the v1 fields of r.
+func EvaluateResultFrom(r triagegeist.EvaluateResult) EvaluateResult {
+	return EvaluateResult{
+		Acuity:        r.Acuity,
+		Level:         LevelFrom(r.Level),
+		Vitals:        VitalsFrom(r.Vitals),
+		ResourceCount: r.ResourceCount,
+		Deferred:      r.Deferred,
+		Insufficient:  r.Insufficient,
+	}
+}
+
+// Result is the exported record of one evaluation: the core columns of
+// export.Result.
+type Result struct {
+	ID            string
+	Timestamp     time.Time
+	Vitals        Vitals
+	ResourceCount int
+	Acuity        float64
+	Level         int
+	LevelLabel    string
+}
+
+// ResultFrom returns the core columns of r.
+func ResultFrom(r export.Result) Result {
+	return Result{
+		ID:            r.ID,
+		Timestamp:     r.Timestamp,
+		Vitals:        VitalsFrom(export.ResultToVitals(r)),
+		ResourceCount: r.ResourceCount,
+		Acuity:        r.Acuity,
+		Level:         r.Level,
+		LevelLabel:    r.LevelLabel,
+	}
+}
+
+// Export returns r as an export.Result, for the export package's writers.
+func (r Result) Export() export.Result {
+	x := export.FromVitalsScoreLevel(r.Vitals.Root(), r.ResourceCount, r.Acuity, r.Level, r.LevelLabel)
+	x.ID, x.Timestamp = r.ID, r.Timestamp
+	return x
+}
+
+// Scorer is the core scoring interface. *Engine implements it.
+type Scorer interface {
+	Acuity(v Vitals, resourceCount int) float64
+	Level(v Vitals, resourceCount int) Level
+	ScoreAndLevel(v Vitals, resourceCount int) (float64, Level)
+	Evaluate(v Vitals, resourceCount int) EvaluateResult
+}
+
+var _ Scorer = (*Engine)(nil)
+
+// rootScorer is the part of the root engines an Engine delegates to.
+type rootScorer interface {
+	Acuity(v score.Vitals, resourceCount int) float64
+	ScoreAndLevel(v score.Vitals, resourceCount int) (float64, triagegeist.Level)
+	Evaluate(v score.Vitals, resourceCount int) triagegeist.EvaluateResult
+}
+
+// Engine scores vitals with a root engine behind the v1 types.
+type Engine struct {
+	s rootScorer
+}
+
+// NewEngine returns an engine with the given parameters.
+func NewEngine(p Params) *Engine {
+	return Wrap(triagegeist.NewEngine(p.Root()))
+}
+
+// Wrap returns an Engine that scores with e, including every root setting
+// of e (profiles, hooks, flags and so on).
+func Wrap(e *triagegeist.Engine) *Engine {
+	return &Engine{s: e}
+}
+
+// WrapManaged returns an Engine that scores with m's current engine, so
+// parameter reloads and surge switches take effect.
+func WrapManaged(m *triagegeist.ManagedEngine) *Engine {
+	return &Engine{s: m}
+}
+
+// Acuity returns the normalized acuity score in [0, 1].
+func (e *Engine) Acuity(v Vitals, resourceCount int) float64 {
+	return e.s.Acuity(v.Root(), resourceCount)
+}
+
+// Level returns the triage level.
+func (e *Engine) Level(v Vitals, resourceCount int) Level {
+	_, l := e.ScoreAndLevel(v, resourceCount)
+	return l
+}
+
+// ScoreAndLevel returns both the acuity score and the level.
+func (e *Engine) ScoreAndLevel(v Vitals, resourceCount int) (float64, Level) {
+	a, l := e.s.ScoreAndLevel(v.Root(), resourceCount)
+	return a, LevelFrom(l)
+}
+
+// Evaluate returns a single EvaluateResult.
+func (e *Engine) Evaluate(v Vitals, resourceCount int) EvaluateResult {
+	return EvaluateResultFrom(e.s.Evaluate(v.Root(), resourceCount))
+}
+
+// DefaultParams returns the default five-level ED parameters.
+func DefaultParams() Params {
+	return ParamsFrom(triagegeist.DefaultParams())
+}
+
+// FromScore maps a normalized acuity score to a level using p's thresholds.
+func FromScore(s float64, p Params) Level {
+	return LevelFrom(triagegeist.FromScore(s, p.Root()))
+}
+
+// ToResult builds a Result from one evaluation.
+func ToResult(r EvaluateResult) Result {
+	return Result{
+		Vitals:        r.Vitals,
+		ResourceCount: r.ResourceCount,
+		Acuity:        r.Acuity,
+		Level:         r.Level.Int(),
+		LevelLabel:    r.Level.String(),
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package v1
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/fs"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/olaflaitinen/triagegeist"
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+func TestScorer_MatchesRoot(t *testing.T) {
+	var s Scorer = NewEngine(DefaultParams())
+	v := Vitals{HR: 130, RR: 28, SBP: 88, SpO2: 89}
+	root := triagegeist.NewDefaultEngine()
+	if s.Acuity(v, 3) != root.Acuity(v.Root(), 3) {
+		t.Error("v1 engine should score like the root engine")
+	}
+	r := s.Evaluate(v, 3)
+	if r.Level != FromScore(r.Acuity, DefaultParams()) || r.Level != s.Level(v, 3) {
+		t.Error("Evaluate level disagrees with FromScore")
+	}
+	res := ToResult(r)
+	if res.Level != r.Level.Int() || res.ResourceCount != 3 || res.Vitals.HR != 130 {
+		t.Errorf("ToResult = %+v", res)
+	}
+	if x := res.Export(); x.HR != 130 || x.Level != res.Level || x.LevelLabel != r.Level.String() || ResultFrom(x) != res {
+		t.Errorf("Export = %+v", x)
+	}
+	if Level1.Root() != triagegeist.Level1Resuscitation || Level5.Int() != 5 || Level(0).Valid() {
+		t.Error("level constants")
+	}
+}
+
+func TestConversions(t *testing.T) {
+	if DefaultParams().Root() != triagegeist.DefaultParams() || !DefaultParams().Valid() {
+		t.Error("default params should round-trip")
+	}
+	strict := triagegeist.PresetStrict()
+	if p := ParamsFrom(strict); p.T1 != strict.T1 || p.VitalWeights != strict.VitalWeights {
+		t.Errorf("ParamsFrom = %+v", p)
+	}
+	v := score.Vitals{HR: 90, GCS: 14, GCSEye: 3}
+	if got := VitalsFrom(v).Root(); got != (score.Vitals{HR: 90, GCS: 14}) {
+		t.Errorf("VitalsFrom drops non-core fields: %+v", got)
+	}
+
+	// A wrapped root engine keeps its root settings.
+	p := triagegeist.DefaultParams()
+	p.GrayZone = 0.05
+	e := Wrap(triagegeist.NewEngine(p))
+	m := WrapManaged(triagegeist.NewManagedEngine(triagegeist.NewEngine(p)))
+	for a := 0.0; a <= 1; a += 0.01 {
+		v := Vitals{HR: 60 + int(a*100), RR: 12 + int(a*20), SpO2: 99 - int(a*15)}
+		if r := e.Evaluate(v, 2); r.Deferred {
+			if m.Evaluate(v, 2) != r {
+				t.Error("managed and plain wrappers disagree")
+			}
+			return
+		}
+	}
+	t.Error("no deferred result from a gray-zone engine")
+}
+
+// TestAPI pins the exported surface of v1: any change to a name, field or
+// signature below breaks integrations and needs a v2.
+func TestAPI(t *testing.T) {
+	want := []string{
+		"const Level1 Level",
+		"const Level2 Level",
+		"const Level3 Level",
+		"const Level4 Level",
+		"const Level5 Level",
+		"func (e *Engine) Acuity(v Vitals, resourceCount int) float64",
+		"func (e *Engine) Evaluate(v Vitals, resourceCount int) EvaluateResult",
+		"func (e *Engine) Level(v Vitals, resourceCount int) Level",
+		"func (e *Engine) ScoreAndLevel(v Vitals, resourceCount int) (float64, Level)",
+		"func (l Level) Int() int",
+		"func (l Level) Root() triagegeist.Level",
+		"func (l Level) String() string",
+		"func (l Level) Valid() bool",
+		"func (p Params) Root() triagegeist.Params",
+		"func (p Params) Valid() bool",
+		"func (r Result) Export() export.Result",
+		"func (v Vitals) Root() score.Vitals",
+		"func DefaultParams() Params",
+		"func EvaluateResultFrom(r triagegeist.EvaluateResult) EvaluateResult",
+		"func FromScore(s float64, p Params) Level",
+		"func LevelFrom(l triagegeist.Level) Level",
+		"func NewEngine(p Params) *Engine",
+		"func ParamsFrom(p triagegeist.Params) Params",
+		"func ResultFrom(r export.Result) Result",
+		"func ToResult(r EvaluateResult) Result",
+		"func VitalsFrom(v score.Vitals) Vitals",
+		"func Wrap(e *triagegeist.Engine) *Engine",
+		"func WrapManaged(m *triagegeist.ManagedEngine) *Engine",
+		"type Engine struct { }",
+		"type EvaluateResult struct { Acuity float64; Level Level; Vitals Vitals; ResourceCount int; Deferred bool; Insufficient string }",
+		"type Level int",
+		"type Params struct { VitalWeights [7]float64; MaxResources int; ResourceWeight float64; T1, T2, T3, T4 float64 }",
+		"type Result struct { ID string; Timestamp time.Time; Vitals Vitals; ResourceCount int; Acuity float64; Level int; LevelLabel string }",
+		"type Scorer interface { Acuity(v Vitals, resourceCount int) float64; Level(v Vitals, resourceCount int) Level; ScoreAndLevel(v Vitals, resourceCount int) (float64, Level); Evaluate(v Vitals, resourceCount int) EvaluateResult }",
+		"type Vitals struct { HR int; RR int; SBP int; DBP int; Temp float64; SpO2 int; GCS int }",
+	}
+	got := exportedAPI(t)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("exported API changed:\n%s\n\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// exportedAPI returns one sorted line per exported declaration of the
+// package, with unexported fields left out.
+func exportedAPI(t *testing.T) []string {
+	fset := token.NewFileSet()
+	notTest := func(fi fs.FileInfo) bool { return !strings.HasSuffix(fi.Name(), "_test.go") }
+	pkgs, err := parser.ParseDir(fset, ".", notTest, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	node := func(n ast.Node) string {
+		var b bytes.Buffer
+		printer.Fprint(&b, fset, n)
+		return strings.Join(strings.Fields(b.String()), " ")
+	}
+	for name, pkg := range pkgs {
+		if name != "v1" {
+			continue
+		}
+		for _, f := range pkg.Files {
+			for _, d := range f.Decls {
+				switch d := d.(type) {
+				case *ast.FuncDecl:
+					if !d.Name.IsExported() || (d.Recv != nil && !ast.IsExported(recvName(d.Recv))) {
+						continue
+					}
+					d.Body, d.Doc = nil, nil
+					out = append(out, node(d))
+				case *ast.GenDecl:
+					for _, s := range d.Specs {
+						switch s := s.(type) {
+						case *ast.TypeSpec:
+							if s.Name.IsExported() {
+								out = append(out, "type "+s.Name.Name+" "+typeString(s.Type, node))
+							}
+						case *ast.ValueSpec:
+							for _, n := range s.Names {
+								if n.IsExported() {
+									out = append(out, d.Tok.String()+" "+n.Name+" "+node(s.Type))
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func recvName(recv *ast.FieldList) string {
+	x := recv.List[0].Type
+	if s, ok := x.(*ast.StarExpr); ok {
+		x = s.X
+	}
+	return x.(*ast.Ident).Name
+}
+
+// typeString prints x with struct fields and interface methods separated by
+// "; " and unexported struct fields dropped.
+func typeString(x ast.Expr, node func(ast.Node) string) string {
+	var list *ast.FieldList
+	var kind string
+	switch x := x.(type) {
+	case *ast.StructType:
+		list, kind = x.Fields, "struct"
+	case *ast.InterfaceType:
+		list, kind = x.Methods, "interface"
+	default:
+		return node(x)
+	}
+	var parts []string
+	for _, f := range list.List {
+		var names []string
+		for _, n := range f.Names {
+			if n.IsExported() {
+				names = append(names, n.Name)
+			}
+		}
+		if len(names) == 0 && len(f.Names) > 0 {
+			continue
+		}
+		if kind == "interface" {
+			parts = append(parts, names[0]+strings.TrimPrefix(node(f.Type), "func"))
+			continue
+		}
+		parts = append(parts, strings.Join(names, ", ")+" "+node(f.Type))
+	}
+	if len(parts) == 0 {
+		return kind + " { }"
+	}
+	return kind + " { " + strings.Join(parts, "; ") + " }"
+}
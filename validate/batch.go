@@ -0,0 +1,85 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package validate
+
+import "github.com/olaflaitinen/triagegeist/score"
+
+// Batch change kinds reported by NormalizeBatch.
+const (
+	ChangeSwappedBP = "swapped_bp" // SBP and DBP exchanged because SBP < DBP
+	ChangeDuplicate = "duplicate"  // Same (vitals, resourceCount) as an earlier row
+)
+
+// BatchChange records one change NormalizeBatch made or detected. Index is
+// the row in the input; Of is the first occurrence for a duplicate, else -1.
+type BatchChange struct {
+	Index int
+	Kind  string
+	Of    int
+}
+
+// BatchOptions selects the NormalizeBatch steps.
+//
+//	| Field          | Effect                                              |
+//	|----------------|-----------------------------------------------------|
+//	| SwapBP         | Exchange SBP and DBP when both present and SBP < DBP |
+//	| DropDuplicates | Remove rows identical to an earlier row             |
+type BatchOptions struct {
+	SwapBP         bool
+	DropDuplicates bool
+}
+
+// DefaultBatchOptions enables both steps.
+func DefaultBatchOptions() BatchOptions {
+	return BatchOptions{SwapBP: true, DropDuplicates: true}
+}
+
+// BatchReport lists what NormalizeBatch changed. Kept maps each output row
+// to its input row.
+type BatchReport struct {
+	Changes    []BatchChange
+	Swapped    int
+	Duplicates int
+	Kept       []int
+}
+
+// NormalizeBatch prepares (vitals, resourceCount) rows for batch evaluation:
+// it swaps obviously exchanged SBP/DBP (SBP < DBP) and detects rows identical
+// to an earlier row (after swapping), dropping them if opt.DropDuplicates.
+// Duplicates are always reported. The inputs are not modified. Returns nil
+// slices and an empty report if the lengths differ.
+func NormalizeBatch(vitals []score.Vitals, resourceCounts []int, opt BatchOptions) ([]score.Vitals, []int, BatchReport) {
+	var rep BatchReport
+	if len(vitals) != len(resourceCounts) {
+		return nil, nil, rep
+	}
+	type key struct {
+		v  score.Vitals
+		rc int
+	}
+	first := make(map[key]int, len(vitals))
+	outV := make([]score.Vitals, 0, len(vitals))
+	outRC := make([]int, 0, len(vitals))
+	for i, v := range vitals {
+		if opt.SwapBP && v.SBP > 0 && v.DBP > 0 && v.SBP < v.DBP {
+			v.SBP, v.DBP = v.DBP, v.SBP
+			rep.Changes = append(rep.Changes, BatchChange{Index: i, Kind: ChangeSwappedBP, Of: -1})
+			rep.Swapped++
+		}
+		k := key{v, resourceCounts[i]}
+		if j, dup := first[k]; dup {
+			rep.Changes = append(rep.Changes, BatchChange{Index: i, Kind: ChangeDuplicate, Of: j})
+			rep.Duplicates++
+			if opt.DropDuplicates {
+				continue
+			}
+		} else {
+			first[k] = i
+		}
+		outV = append(outV, v)
+		outRC = append(outRC, resourceCounts[i])
+		rep.Kept = append(rep.Kept, i)
+	}
+	return outV, outRC, rep
+}
@@ -16,13 +16,26 @@
 //	| Temp       | 30 <= Temp <= 45 or 0          | Clamp or mark invalid  |
 //	| SpO2       | 0 <= SpO2 <= 100 or 0         | Clamp or mark invalid  |
 //	| GCS        | 3 <= GCS <= 15 or 0            | Clamp or mark invalid  |
+//	| GCS E/V/M  | E 1-4, V 1-5, M 1-6 or 0       | Clamp or mark invalid  |
+//	| FiO2       | 0.21 <= FiO2 <= 1.0 or 0       | Clamp or mark invalid  |
+//	| MAP        | 40 <= MAP <= 180, SBP > DBP    | Mark invalid           |
 //	| Resources  | 0 <= count <= max (e.g. 20)    | Clamp                  |
+//	| Glucose    | 0.5 <= mmol/L <= 50 or 0       | Clamp or mark invalid  |
+//	| Lactate    | 0.1 <= mmol/L <= 30 or 0       | Clamp or mark invalid  |
+//	| EtCO2      | 1 <= mmHg <= 150 or 0          | Clamp or mark invalid  |
+//	| CapRefill  | 0.1 <= s <= 20 or 0            | Clamp or mark invalid  |
+//	| Pain       | 0 <= NRS <= 10                 | Clamp or mark invalid  |
 //	| Params     | T1>T2>T3>T4, weights in [0,1]   | Return error           |
 package validate
 
 import (
+	"errors"
+	"fmt"
 	"math"
+	"strings"
 
+	"github.com/olaflaitinen/triagegeist/internal/paramrules"
+	"github.com/olaflaitinen/triagegeist/norm"
 	"github.com/olaflaitinen/triagegeist/score"
 )
 
@@ -39,6 +52,16 @@ type VitalsReport struct {
 	SpO2    string
 	GCS     string
 	Clamped score.Vitals // If clamping was applied, the clamped values
+
+	// GCS component statuses. GCS is also "invalid" if a total is given
+	// and it disagrees with the sum of all three components.
+	GCSEye    string
+	GCSVerbal string
+	GCSMotor  string
+
+	FiO2 string
+	// MAP is derived from SBP and DBP; "missing" unless both are present
+	MAP string
 }
 
 const (
@@ -48,20 +71,47 @@ const (
 	StatusMissing = "missing"
 )
 
-// Bounds for each vital (min, max). 0 for a vital means "missing" and is allowed.
+// Bounds for each vital (min, max). 0 for a vital means "missing" and is
+// allowed.
+//
+// Deprecated: HRBounds .. GCSBounds and TempBounds are copies of
+// norm.DefaultBounds kept for compatibility; changing them has no effect.
+// Vitals and ClampVitals check against norm.DefaultBounds; pass a site's
+// norm.BoundsSet to VitalsWithBounds or ClampVitalsWithBounds instead.
+var (
+	HRBounds   = intBounds(norm.DefaultBounds().HR)
+	RRBounds   = intBounds(norm.DefaultBounds().RR)
+	SBPBounds  = intBounds(norm.DefaultBounds().SBP)
+	DBPBounds  = intBounds(norm.DefaultBounds().DBP)
+	SpO2Bounds = intBounds(norm.DefaultBounds().SpO2)
+	GCSBounds  = intBounds(norm.DefaultBounds().GCS)
+)
+
+// Bounds of the GCS components (min, max).
 var (
-	HRBounds   = [2]int{20, 300}
-	RRBounds   = [2]int{0, 60}
-	SBPBounds  = [2]int{40, 300}
-	DBPBounds  = [2]int{20, 200}
-	SpO2Bounds = [2]int{0, 100}
-	GCSBounds  = [2]int{3, 15}
+	GCSEyeBounds    = [2]int{1, 4}
+	GCSVerbalBounds = [2]int{1, 5}
+	GCSMotorBounds  = [2]int{1, 6}
 )
 
+// TempBounds is deprecated; see HRBounds. FiO2Bounds and MAPBounds are the
+// bounds of FiO2 and the derived MAP.
 var (
-	TempBounds = [2]float64{30, 45}
+	TempBounds = norm.DefaultBounds().Temp
+	FiO2Bounds = [2]float64{0.21, 1.0}
+	MAPBounds  = [2]float64{40, 180}
 )
 
+func intBounds(b [2]float64) [2]int {
+	return [2]int{int(b[0]), int(b[1])}
+}
+
+// Bounds returns the bounds of the seven vitals that Vitals and ClampVitals
+// use: norm.DefaultBounds.
+func Bounds() norm.BoundsSet {
+	return norm.DefaultBounds()
+}
+
 func checkBound(v int, bounds [2]int, rStatus *string, rValid *bool) {
 	if v != 0 {
 		if v < bounds[0] || v > bounds[1] {
@@ -90,14 +140,65 @@ func checkBoundFloat(v float64, bounds [2]float64, rStatus *string, rValid *bool
 
 // Vitals checks v against bounds and returns a report. It does not modify v.
 func Vitals(v score.Vitals) VitalsReport {
+	return VitalsWithBounds(v, Bounds())
+}
+
+// VitalsWithBounds is Vitals with the seven vitals checked against b, e.g.
+// a site's overrides of norm.DefaultBounds. The GCS components, FiO2 and
+// MAP keep their package bounds.
+func VitalsWithBounds(v score.Vitals, b norm.BoundsSet) VitalsReport {
 	r := VitalsReport{Valid: true, Clamped: v}
-	checkBound(v.HR, HRBounds, &r.HR, &r.Valid)
-	checkBound(v.RR, RRBounds, &r.RR, &r.Valid)
-	checkBound(v.SBP, SBPBounds, &r.SBP, &r.Valid)
-	checkBound(v.DBP, DBPBounds, &r.DBP, &r.Valid)
-	checkBoundFloat(v.Temp, TempBounds, &r.Temp, &r.Valid)
-	checkBound(v.SpO2, SpO2Bounds, &r.SpO2, &r.Valid)
-	checkBound(v.GCS, GCSBounds, &r.GCS, &r.Valid)
+	checkBoundFloat(float64(v.HR), b.HR, &r.HR, &r.Valid)
+	checkBoundFloat(float64(v.RR), b.RR, &r.RR, &r.Valid)
+	checkBoundFloat(float64(v.SBP), b.SBP, &r.SBP, &r.Valid)
+	checkBoundFloat(float64(v.DBP), b.DBP, &r.DBP, &r.Valid)
+	checkBoundFloat(v.Temp, b.Temp, &r.Temp, &r.Valid)
+	checkBoundFloat(float64(v.SpO2), b.SpO2, &r.SpO2, &r.Valid)
+	checkBoundFloat(float64(v.GCS), b.GCS, &r.GCS, &r.Valid)
+	checkBound(v.GCSEye, GCSEyeBounds, &r.GCSEye, &r.Valid)
+	checkBound(v.GCSVerbal, GCSVerbalBounds, &r.GCSVerbal, &r.Valid)
+	checkBound(v.GCSMotor, GCSMotorBounds, &r.GCSMotor, &r.Valid)
+	checkBoundFloat(v.FiO2, FiO2Bounds, &r.FiO2, &r.Valid)
+	checkBoundFloat(score.MAP(v), MAPBounds, &r.MAP, &r.Valid)
+	if v.SBP > 0 && v.DBP > 0 && v.SBP <= v.DBP {
+		r.MAP = StatusInvalid
+		r.Valid = false
+	}
+	if v.GCS != 0 && v.GCSEye != 0 && v.GCSVerbal != 0 && v.GCSMotor != 0 &&
+		v.GCS != v.GCSEye+v.GCSVerbal+v.GCSMotor {
+		r.GCS = StatusInvalid
+		r.Valid = false
+	}
+	return r
+}
+
+// VitalsOpt checks o like Vitals, except that a measured 0 is not missing:
+// it is "ok" for the vitals scored at 0 (score.ZeroScorable: HR, RR, SBP,
+// DBP, SpO2, e.g. apnea or arrest) and "invalid" for Temp, GCS and the GCS
+// components. Clamped holds o.Vitals(), with measured zeros as 0.
+func VitalsOpt(o score.VitalsOpt) VitalsReport {
+	r := Vitals(o.Vitals())
+	status := [7]*string{&r.HR, &r.RR, &r.SBP, &r.DBP, &r.Temp, &r.SpO2, &r.GCS}
+	for i, z := range o.MeasuredZero() {
+		if !z {
+			continue
+		}
+		if score.ZeroScorable[i] {
+			*status[i] = StatusOK
+		} else {
+			*status[i] = StatusInvalid
+			r.Valid = false
+		}
+	}
+	for _, c := range []struct {
+		p      *int
+		status *string
+	}{{o.GCSEye, &r.GCSEye}, {o.GCSVerbal, &r.GCSVerbal}, {o.GCSMotor, &r.GCSMotor}} {
+		if c.p != nil && *c.p == 0 {
+			*c.status = StatusInvalid
+			r.Valid = false
+		}
+	}
 	return r
 }
 
@@ -128,17 +229,74 @@ func clampFloat(v float64, bounds [2]float64) float64 {
 // ClampVitals returns a copy of v with all present vitals clamped to bounds.
 // Missing (0) values are left as 0.
 func ClampVitals(v score.Vitals) score.Vitals {
+	return ClampVitalsWithBounds(v, Bounds())
+}
+
+// ClampVitalsWithBounds is ClampVitals with the seven vitals clamped to b
+// (see VitalsWithBounds); fractional bounds are rounded inwards.
+func ClampVitalsWithBounds(v score.Vitals, b norm.BoundsSet) score.Vitals {
+	in := func(b [2]float64) [2]int {
+		return [2]int{int(math.Ceil(b[0])), int(math.Floor(b[1]))}
+	}
 	return score.Vitals{
-		HR:   clampInt(v.HR, HRBounds),
-		RR:   clampInt(v.RR, RRBounds),
-		SBP:  clampInt(v.SBP, SBPBounds),
-		DBP:  clampInt(v.DBP, DBPBounds),
-		Temp: clampFloat(v.Temp, TempBounds),
-		SpO2: clampInt(v.SpO2, SpO2Bounds),
-		GCS:  clampInt(v.GCS, GCSBounds),
+		HR:   clampInt(v.HR, in(b.HR)),
+		RR:   clampInt(v.RR, in(b.RR)),
+		SBP:  clampInt(v.SBP, in(b.SBP)),
+		DBP:  clampInt(v.DBP, in(b.DBP)),
+		Temp: clampFloat(v.Temp, b.Temp),
+		SpO2: clampInt(v.SpO2, in(b.SpO2)),
+		GCS:  clampInt(v.GCS, in(b.GCS)),
+
+		GCSEye:    clampInt(v.GCSEye, GCSEyeBounds),
+		GCSVerbal: clampInt(v.GCSVerbal, GCSVerbalBounds),
+		GCSMotor:  clampInt(v.GCSMotor, GCSMotorBounds),
+
+		OnOxygen: v.OnOxygen,
+		FiO2:     clampFloat(v.FiO2, FiO2Bounds),
 	}
 }
 
+// ExtendedBounds holds (min, max) for each sign of score.ExtendedVitals, in
+// score.ExtendedNames order. 0 means "missing" and is allowed.
+var ExtendedBounds = [score.NumExtended][2]float64{{0.5, 50}, {0.1, 30}, {1, 150}, {0.1, 20}, {0, 10}}
+
+// ExtendedReport holds validation results for one score.ExtendedVitals, one
+// status per sign in score.ExtendedNames order.
+type ExtendedReport struct {
+	Valid   bool
+	Status  [score.NumExtended]string
+	Clamped score.ExtendedVitals
+}
+
+// ExtendedVitals checks x against ExtendedBounds and returns a report with
+// the clamped values. It does not modify x.
+func ExtendedVitals(x score.ExtendedVitals) ExtendedReport {
+	r := ExtendedReport{Valid: true}
+	vals := x.Values()
+	for i, v := range vals {
+		switch {
+		case v == 0 && !(i == 4 && x.PainRecorded):
+			r.Status[i] = StatusMissing
+		case !finite(v) || v < ExtendedBounds[i][0] || v > ExtendedBounds[i][1]:
+			r.Status[i] = StatusInvalid
+			r.Valid = false
+			vals[i] = clampFloat(v, ExtendedBounds[i])
+		default:
+			r.Status[i] = StatusOK
+		}
+	}
+	r.Clamped = score.ExtendedVitals{
+		Glucose:   vals[0],
+		Lactate:   vals[1],
+		EtCO2:     vals[2],
+		CapRefill: vals[3],
+		Pain:      int(vals[4]),
+
+		PainRecorded: x.PainRecorded,
+	}
+	return r
+}
+
 // VitalsValid returns true if all present vitals are within bounds.
 func VitalsValid(v score.Vitals) bool {
 	return Vitals(v).Valid
@@ -158,72 +316,137 @@ func ResourceCount(count, maxResources int) int {
 	return count
 }
 
-// ParamsReport holds validation results for triagegeist.Params.
+// ParamError describes one constraint a parameter field violates.
+// triagegeist.ParamError is this type.
+type ParamError struct {
+	Field      string  // Go field name, e.g. "T2" or "VitalWeights[3]"
+	Value      float64 // Offending value
+	Constraint string  // e.g. "must be less than T1 (0.65)"
+}
+
+// Error returns e.g. "T2 (0.7) must be less than T1 (0.65)".
+func (e ParamError) Error() string {
+	return fmt.Sprintf("%s (%v) %s", e.Field, e.Value, e.Constraint)
+}
+
+// ParamsChecker is a parameter set that lists its own constraint
+// violations, as ParamError values, or none if it is valid.
+// triagegeist.Params implements it, so Params and ParamsValid always check
+// its current fields without a copy.
+type ParamsChecker interface {
+	ValidateDetailed() []error
+}
+
+// ParamsReport holds validation results for a parameter set.
 type ParamsReport struct {
 	Valid        bool
 	WeightsOK    bool
 	ThresholdsOK bool
 	MaxResOK     bool
 	ResourceWOK  bool
+	Errors       []error // Every violation, as returned by ValidateDetailed
 }
 
-// Params validates p (weights in [0,1], T1>T2>T3>T4, MaxResources>=0, ResourceWeight>=0).
-// It does not import triagegeist to avoid cycle; the caller passes the struct.
+// ParamsLike is a standalone copy of the core triagegeist.Params fields for
+// callers that do not build a triagegeist.Params. It implements
+// ParamsChecker with the same rules for the fields it has.
+//
+// Deprecated: pass triagegeist.Params to Params or ParamsValid. ParamsLike
+// does not gain the fields Params adds.
 type ParamsLike struct {
 	VitalWeights   [7]float64
 	MaxResources   int
 	ResourceWeight float64
 	T1, T2, T3, T4 float64
-}
-
-// Params validates a parameter set and returns a report.
-func Params(p ParamsLike) ParamsReport {
-	var r ParamsReport
-	r.Valid = true
+	MAPWeight      float64
+	ResourceScale  score.ResourceScale
+	GCSBanded      bool
+	GCSBands       score.GCSBands
 
-	for _, w := range p.VitalWeights {
-		if w < 0 || w > 1 || !finite(w) {
-			r.WeightsOK = false
-			r.Valid = false
-			break
-		}
-	}
-	if r.Valid {
-		r.WeightsOK = true
-	}
+	RespiratoryWeight float64
+	QSOFABump         float64
+	Reliability       score.Reliability
+	GrayZone          float64
+	Hysteresis        float64
+	Asymmetric        bool
+	AsymmetricWeights score.AsymmetricWeights
+}
 
-	if p.MaxResources < 0 {
-		r.MaxResOK = false
-		r.Valid = false
-	} else {
-		r.MaxResOK = true
-	}
+// ValidateDetailed returns one ParamError per violated constraint, in field
+// order, or nil if p is valid. The rules are those triagegeist.Params
+// applies to the same fields.
+func (p ParamsLike) ValidateDetailed() []error {
+	var errs []error
+	paramrules.Check(paramrules.Core{
+		VitalWeights:   p.VitalWeights,
+		MaxResources:   p.MaxResources,
+		ResourceWeight: p.ResourceWeight,
+		T1:             p.T1, T2: p.T2, T3: p.T3, T4: p.T4,
+		MAPWeight:     p.MAPWeight,
+		ResourceScale: p.ResourceScale,
+		GCSBanded:     p.GCSBanded,
+		GCSBands:      p.GCSBands,
 
-	if p.ResourceWeight < 0 || !finite(p.ResourceWeight) {
-		r.ResourceWOK = false
-		r.Valid = false
-	} else {
-		r.ResourceWOK = true
-	}
+		RespiratoryWeight: p.RespiratoryWeight,
+		QSOFABump:         p.QSOFABump,
+		Reliability:       p.Reliability,
+		GrayZone:          p.GrayZone,
+		Hysteresis:        p.Hysteresis,
+		Asymmetric:        p.Asymmetric,
+		AsymmetricWeights: p.AsymmetricWeights,
+	}, func(field string, v float64, constraint string) {
+		errs = append(errs, ParamError{Field: field, Value: v, Constraint: constraint})
+	})
+	return errs
+}
 
-	if !(p.T1 > p.T2 && p.T2 > p.T3 && p.T3 > p.T4 && p.T4 > 0 && p.T1 <= 1) {
-		r.ThresholdsOK = false
+// Params validates a parameter set and returns a report grouping its
+// violations by the field they concern.
+//
+//	| Report field | Fields                                                      |
+//	|--------------|-------------------------------------------------------------|
+//	| WeightsOK    | VitalWeights, MAPWeight, GCSBands, RespiratoryWeight,       |
+//	|              | QSOFABump, Reliability, AsymmetricWeights                   |
+//	| ThresholdsOK | T1..T4, GrayZone, Hysteresis                                |
+//	| MaxResOK     | MaxResources                                                |
+//	| ResourceWOK  | ResourceWeight, ResourceScale, ResourceRate                 |
+//
+// Violations of other fields (and errors that are not ParamError) only
+// clear Valid.
+func Params(p ParamsChecker) ParamsReport {
+	r := ParamsReport{Valid: true, WeightsOK: true, ThresholdsOK: true, MaxResOK: true, ResourceWOK: true}
+	r.Errors = p.ValidateDetailed()
+	for _, err := range r.Errors {
 		r.Valid = false
-	} else {
-		r.ThresholdsOK = true
+		var pe ParamError
+		if !errors.As(err, &pe) {
+			continue
+		}
+		field, _, _ := strings.Cut(pe.Field, "[")
+		field, _, _ = strings.Cut(field, ".")
+		switch field {
+		case "VitalWeights", "MAPWeight", "GCSBands", "RespiratoryWeight", "QSOFABump", "Reliability", "AsymmetricWeights":
+			r.WeightsOK = false
+		case "T1", "T2", "T3", "T4", "GrayZone", "Hysteresis":
+			r.ThresholdsOK = false
+		case "MaxResources":
+			r.MaxResOK = false
+		case "ResourceWeight", "ResourceScale", "ResourceRate":
+			r.ResourceWOK = false
+		}
 	}
-
 	return r
 }
 
 // ParamsValid returns true if p is valid.
-func ParamsValid(p ParamsLike) bool {
-	return Params(p).Valid
+func ParamsValid(p ParamsChecker) bool {
+	return len(p.ValidateDetailed()) == 0
 }
 
 // AtLeastOneVital returns true if at least one of HR, RR, SBP, DBP, Temp, SpO2, GCS is present (non-zero).
+// GCS counts as present when given as a total or as all three components.
 func AtLeastOneVital(v score.Vitals) bool {
-	return v.HR > 0 || v.RR > 0 || v.SBP > 0 || v.DBP > 0 || v.Temp != 0 || v.SpO2 > 0 || v.GCS > 0
+	return v.HR > 0 || v.RR > 0 || v.SBP > 0 || v.DBP > 0 || v.Temp != 0 || v.SpO2 > 0 || score.GCSTotal(v) > 0
 }
 
 // VitalsAndResources returns a combined check: vitals valid and resourceCount in [0, maxResources].
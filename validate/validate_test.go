@@ -1,8 +1,11 @@
 package validate
 
 import (
+	"errors"
+	"math"
 	"testing"
 
+	"github.com/olaflaitinen/triagegeist/norm"
 	"github.com/olaflaitinen/triagegeist/score"
 )
 
@@ -58,8 +61,18 @@ func TestParams(t *testing.T) {
 	}
 	pl.MaxResources = -1
 	report = Params(pl)
-	if report.Valid {
-		t.Error("MaxResources -1 should be invalid")
+	if report.Valid || report.MaxResOK || !report.WeightsOK || !report.ThresholdsOK {
+		t.Errorf("MaxResources -1: %+v", report)
+	}
+	pl.MaxResources = 6
+	pl.T2, pl.VitalWeights[0] = 0.9, math.NaN()
+	report = Params(pl)
+	if report.Valid || report.WeightsOK || report.ThresholdsOK || !report.MaxResOK || len(report.Errors) != 2 {
+		t.Errorf("T2 above T1, NaN weight: %+v", report)
+	}
+	var pe ParamError
+	if !errors.As(report.Errors[1], &pe) || pe.Field != "T2" || pe.Error() != "T2 (0.9) must be less than T1 (0.85)" {
+		t.Errorf("ParamError = %+v", pe)
 	}
 }
 
@@ -71,3 +84,129 @@ func TestAtLeastOneVital(t *testing.T) {
 		t.Error("HR present should be at least one")
 	}
 }
+
+func TestVitals_GCSComponents(t *testing.T) {
+	v := score.Vitals{GCSEye: 3, GCSVerbal: 4, GCSMotor: 6}
+	if r := Vitals(v); !r.Valid || r.GCSEye != StatusOK {
+		t.Errorf("valid components: %+v", r)
+	}
+	v.GCSVerbal = 6
+	if r := Vitals(v); r.Valid || r.GCSVerbal != StatusInvalid {
+		t.Errorf("verbal 6 should be invalid: %+v", r)
+	}
+	v = score.Vitals{GCS: 15, GCSEye: 3, GCSVerbal: 4, GCSMotor: 6}
+	if r := Vitals(v); r.Valid || r.GCS != StatusInvalid {
+		t.Errorf("total 15 vs components 13 should be invalid: %+v", r)
+	}
+}
+
+func TestVitals_FiO2(t *testing.T) {
+	if r := Vitals(score.Vitals{SpO2: 95, OnOxygen: true, FiO2: 0.4}); !r.Valid {
+		t.Errorf("FiO2 0.4 should be valid: %+v", r)
+	}
+	if r := Vitals(score.Vitals{FiO2: 40}); r.Valid || r.FiO2 != StatusInvalid {
+		t.Errorf("FiO2 40 (percent) should be invalid: %+v", r)
+	}
+}
+
+func TestVitals_MAP(t *testing.T) {
+	if r := Vitals(score.Vitals{SBP: 120, DBP: 80}); r.MAP != StatusOK {
+		t.Errorf("MAP 93 should be ok: %+v", r)
+	}
+	if r := Vitals(score.Vitals{SBP: 80, DBP: 90}); r.Valid || r.MAP != StatusInvalid {
+		t.Errorf("SBP <= DBP should be invalid: %+v", r)
+	}
+	if r := Vitals(score.Vitals{SBP: 120}); r.MAP != StatusMissing {
+		t.Errorf("MAP without DBP should be missing: %+v", r)
+	}
+}
+
+func TestNormalizeBatch(t *testing.T) {
+	vitals := []score.Vitals{
+		{HR: 90, SBP: 120, DBP: 80},
+		{HR: 90, SBP: 80, DBP: 120}, // swapped copy of row 0
+		{HR: 100, SBP: 130},
+		{HR: 90, SBP: 120, DBP: 80},
+	}
+	rcs := []int{1, 1, 2, 3} // row 3 differs from row 0 in resource count
+	v, rc, rep := NormalizeBatch(vitals, rcs, DefaultBatchOptions())
+	if len(v) != 3 || len(rc) != 3 || rep.Swapped != 1 || rep.Duplicates != 1 {
+		t.Fatalf("got %d rows, report %+v", len(v), rep)
+	}
+	if rep.Kept[0] != 0 || rep.Kept[1] != 2 || rep.Kept[2] != 3 {
+		t.Errorf("Kept = %v", rep.Kept)
+	}
+	if c := rep.Changes[1]; c.Kind != ChangeDuplicate || c.Index != 1 || c.Of != 0 {
+		t.Errorf("duplicate change = %+v", c)
+	}
+	if vitals[1].SBP != 80 {
+		t.Error("input modified")
+	}
+	v, _, rep = NormalizeBatch(vitals, rcs, BatchOptions{})
+	if len(v) != 4 || rep.Swapped != 0 || rep.Duplicates != 0 {
+		t.Errorf("no-op options: %d rows, %+v", len(v), rep)
+	}
+	if v, _, _ := NormalizeBatch(vitals, rcs[:1], DefaultBatchOptions()); v != nil {
+		t.Error("length mismatch should return nil")
+	}
+}
+
+func TestExtendedVitals(t *testing.T) {
+	r := ExtendedVitals(score.ExtendedVitals{Glucose: 5.5, Lactate: 45, PainRecorded: true})
+	if r.Valid || r.Status != [score.NumExtended]string{StatusOK, StatusInvalid, StatusMissing, StatusMissing, StatusOK} {
+		t.Errorf("report = %+v", r)
+	}
+	if r.Clamped.Lactate != 30 || r.Clamped.Glucose != 5.5 || !r.Clamped.PainRecorded {
+		t.Errorf("Clamped = %+v", r.Clamped)
+	}
+	if r := ExtendedVitals(score.ExtendedVitals{Pain: 11}); r.Valid || r.Clamped.Pain != 10 {
+		t.Errorf("pain 11: %+v", r)
+	}
+}
+
+func TestVitalsOpt(t *testing.T) {
+	r := VitalsOpt(score.VitalsOpt{HR: score.Int(0), RR: score.Int(0), SpO2: score.Int(88)})
+	if !r.Valid || r.HR != StatusOK || r.RR != StatusOK || r.SBP != StatusMissing || r.SpO2 != StatusOK {
+		t.Errorf("report = %+v", r)
+	}
+	if r := VitalsOpt(score.VitalsOpt{GCS: score.Int(0)}); r.Valid || r.GCS != StatusInvalid {
+		t.Errorf("GCS 0: %+v", r)
+	}
+	if r := VitalsOpt(score.VitalsOpt{Temp: score.Float(0)}); r.Valid || r.Temp != StatusInvalid {
+		t.Errorf("Temp 0: %+v", r)
+	}
+}
+
+func TestVitalsWithBounds(t *testing.T) {
+	if Bounds() != norm.DefaultBounds() {
+		t.Fatalf("package bounds %+v disagree with norm.DefaultBounds", Bounds())
+	}
+	for i := 0; i < norm.NumVitals; i++ {
+		lo, hi := norm.CriticalBounds(i)
+		if b := Bounds().Array()[i]; b != [2]float64{lo, hi} {
+			t.Errorf("vital %d: validate %v, norm %v", i, b, [2]float64{lo, hi})
+		}
+	}
+	site := norm.DefaultBounds()
+	site.SpO2 = [2]float64{50, 100}
+	site.HR = [2]float64{25.5, 250}
+	v := score.Vitals{HR: 260, RR: 16, SBP: 120, SpO2: 45}
+	r := VitalsWithBounds(v, site)
+	if r.Valid || r.SpO2 != StatusInvalid || r.HR != StatusInvalid || r.RR != StatusOK {
+		t.Errorf("site bounds report: %+v", r)
+	}
+	if Vitals(v).SpO2 != StatusOK {
+		t.Error("package bounds should accept SpO2 45")
+	}
+	// The deprecated variables no longer move the package bounds.
+	saved := HRBounds
+	HRBounds = [2]int{50, 100}
+	if Vitals(score.Vitals{HR: 140}).HR != StatusOK || ClampVitals(score.Vitals{HR: 140}).HR != 140 {
+		t.Error("HRBounds should not affect Vitals or ClampVitals")
+	}
+	HRBounds = saved
+	c := ClampVitalsWithBounds(score.Vitals{HR: 20, SpO2: 45}, site)
+	if c.HR != 26 || c.SpO2 != 50 {
+		t.Errorf("clamped %+v", c)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is how often a ParamsWatcher polls its file when
+// Start is given a non-positive interval.
+const DefaultWatchInterval = 2 * time.Second
+
+// ReloadEvent reports one reload attempt of a ParamsWatcher. Err is nil on
+// success, when Params holds the parameters now in use.
+type ReloadEvent struct {
+	Path   string
+	Params Params
+	Err    error
+	At     time.Time
+}
+
+// ParamsWatcher reloads a ManagedEngine's parameters when its configuration
+// file changes, so long-lived services pick up threshold tweaks without a
+// redeploy. Changes are detected by polling the file's contents; each new
+// version is parsed as by LoadParams (and so validated) and swapped in
+// atomically. A file that fails to load or validate leaves the current
+// parameters in place and is reported once, not on every poll. Replace the
+// file atomically (write a temporary file, then rename it) so a poll never
+// reads it half-written; an empty file is taken to be mid-write and
+// ignored.
+type ParamsWatcher struct {
+	path     string
+	engine   *ManagedEngine
+	onReload func(ReloadEvent)
+
+	mu   sync.Mutex
+	last [sha256.Size]byte
+	seen bool
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewParamsWatcher returns a watcher of path that updates m and calls
+// onReload (if non-nil) after every reload attempt, successful or not.
+// onReload runs on the watcher's goroutine.
+func NewParamsWatcher(path string, m *ManagedEngine, onReload func(ReloadEvent)) *ParamsWatcher {
+	return &ParamsWatcher{path: path, engine: m, onReload: onReload}
+}
+
+// Check polls the file once. It returns true if the contents changed since
+// the last check, and the error of loading or applying them, if any. A
+// file that cannot be read is an error but not a change, and an empty file
+// is neither.
+func (w *ParamsWatcher) Check() (bool, error) {
+	data, err := os.ReadFile(w.path)
+	if err != nil || len(data) == 0 {
+		return false, err
+	}
+	sum := sha256.Sum256(data)
+	w.mu.Lock()
+	if w.seen && bytes.Equal(sum[:], w.last[:]) {
+		w.mu.Unlock()
+		return false, nil
+	}
+	w.last, w.seen = sum, true
+	w.mu.Unlock()
+
+	ev := ReloadEvent{Path: w.path, At: time.Now()}
+	// Parse the bytes that were hashed: reading the file again could pick
+	// up a newer version and leave it recorded under this one's hash.
+	p, err := parseParams(w.path, data)
+	switch {
+	case err != nil:
+		ev.Err = err
+	case !w.engine.SwapParams(p):
+		ev.Err = errors.New("triagegeist: params: rejected by ManagedEngine.SwapParams")
+	default:
+		ev.Params = p
+	}
+	if w.onReload != nil {
+		w.onReload(ev)
+	}
+	return true, ev.Err
+}
+
+// Start checks the file once and then polls it every interval
+// (DefaultWatchInterval if <= 0) on a new goroutine until Stop. It returns
+// the error of the initial check; polling continues regardless, so a file
+// fixed later is still picked up. Start on a running watcher is a no-op.
+func (w *ParamsWatcher) Start(interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	w.mu.Lock()
+	if w.stop != nil {
+		w.mu.Unlock()
+		return nil
+	}
+	w.stop, w.done = make(chan struct{}), make(chan struct{})
+	stop, done := w.stop, w.done
+	w.mu.Unlock()
+
+	_, err := w.Check()
+	go func() {
+		defer close(done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				w.Check()
+			}
+		}
+	}()
+	return err
+}
+
+// Stop ends polling and waits for the watcher goroutine to exit.
+func (w *ParamsWatcher) Stop() {
+	w.mu.Lock()
+	stop, done := w.stop, w.done
+	w.stop, w.done = nil, nil
+	w.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
@@ -0,0 +1,345 @@
+// Copyright (c) triagegeist authors: Gustav Olaf Yunus Laitinen-Fredriksson Lundström-Imanov.
+// Licensed under the EUPL.
+
+package triagegeist
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/olaflaitinen/triagegeist/score"
+)
+
+// The YAML reader below covers the subset used by configuration files:
+// block mappings, block sequences, flow sequences of scalars, plain and
+// quoted scalars, and # comments. It keeps the module free of dependencies.
+
+type yamlLine struct {
+	num    int // 1-based line number
+	indent int
+	text   string
+}
+
+// parseYAML parses data into map[string]any, []any and scalar values
+// (float64, bool, string, nil) suitable for json.Marshal.
+func parseYAML(data []byte) (any, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(string(data), "\n") {
+		raw = strings.TrimRight(stripYAMLComment(raw), " \r")
+		text := strings.TrimLeft(raw, " ")
+		if text == "" || text == "---" {
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("yaml: line %d: tabs are not allowed in indentation", i+1)
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: len(raw) - len(text), text: text})
+	}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+	v, next, err := parseYAMLBlock(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("yaml: line %d: unexpected indentation", lines[next].num)
+	}
+	return v, nil
+}
+
+// stripYAMLComment removes a # comment that starts the line or follows a
+// space, outside quotes.
+func stripYAMLComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' '):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// parseYAMLBlock parses the block starting at lines[i] with the given
+// indent and returns its value and the index of the first line after it.
+func parseYAMLBlock(lines []yamlLine, i, indent int) (any, int, error) {
+	if isYAMLSeqItem(lines[i].text) {
+		var seq []any
+		for i < len(lines) && lines[i].indent == indent && isYAMLSeqItem(lines[i].text) {
+			rest := strings.TrimSpace(strings.TrimPrefix(lines[i].text, "-"))
+			if rest != "" {
+				v, err := parseYAMLScalar(rest, lines[i].num)
+				if err != nil {
+					return nil, 0, err
+				}
+				seq = append(seq, v)
+				i++
+				continue
+			}
+			v, next, err := parseYAMLNested(lines, i, indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			seq = append(seq, v)
+			i = next
+		}
+		return seq, i, nil
+	}
+	m := make(map[string]any)
+	for i < len(lines) && lines[i].indent == indent {
+		l := lines[i]
+		if isYAMLSeqItem(l.text) {
+			return nil, 0, fmt.Errorf("yaml: line %d: sequence item inside a mapping", l.num)
+		}
+		key, rest, ok := splitYAMLKey(l.text)
+		if !ok {
+			return nil, 0, fmt.Errorf("yaml: line %d: expected \"key: value\"", l.num)
+		}
+		if _, dup := m[key]; dup {
+			return nil, 0, fmt.Errorf("yaml: line %d: duplicate key %q", l.num, key)
+		}
+		if rest != "" {
+			v, err := parseYAMLScalar(rest, l.num)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key] = v
+			i++
+			continue
+		}
+		v, next, err := parseYAMLNested(lines, i, indent)
+		if err != nil {
+			return nil, 0, err
+		}
+		m[key] = v
+		i = next
+	}
+	if i < len(lines) && lines[i].indent > indent {
+		return nil, 0, fmt.Errorf("yaml: line %d: unexpected indentation", lines[i].num)
+	}
+	return m, i, nil
+}
+
+// parseYAMLNested parses the value of a key or sequence item at lines[i]
+// whose content starts on the next, more indented line; nil if none. A
+// block sequence may sit at the same indent as its mapping key.
+func parseYAMLNested(lines []yamlLine, i, indent int) (any, int, error) {
+	if i+1 < len(lines) {
+		next := lines[i+1]
+		if next.indent > indent || (next.indent == indent && isYAMLSeqItem(next.text) && !isYAMLSeqItem(lines[i].text)) {
+			return parseYAMLBlock(lines, i+1, next.indent)
+		}
+	}
+	return nil, i + 1, nil
+}
+
+func isYAMLSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitYAMLKey splits "key: value" or "key:" into key and trimmed value.
+func splitYAMLKey(text string) (key, rest string, ok bool) {
+	if k, r, found := strings.Cut(text, ": "); found {
+		key, rest = k, strings.TrimSpace(r)
+	} else if strings.HasSuffix(text, ":") {
+		key = strings.TrimSuffix(text, ":")
+	} else {
+		return "", "", false
+	}
+	key = strings.TrimSpace(key)
+	if uq, err := strconv.Unquote(key); err == nil {
+		key = uq
+	}
+	return key, rest, key != ""
+}
+
+// parseYAMLScalar parses a scalar or a flow sequence of scalars.
+func parseYAMLScalar(s string, line int) (any, error) {
+	if strings.HasPrefix(s, "[") {
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("yaml: line %d: unterminated flow sequence", line)
+		}
+		inner := strings.TrimSpace(s[1 : len(s)-1])
+		seq := []any{}
+		if inner == "" {
+			return seq, nil
+		}
+		for _, part := range strings.Split(inner, ",") {
+			part = strings.TrimSpace(part)
+			if strings.HasPrefix(part, "[") || strings.HasPrefix(part, "{") {
+				return nil, fmt.Errorf("yaml: line %d: nested flow collections are not supported", line)
+			}
+			v, err := parseYAMLScalar(part, line)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+		}
+		return seq, nil
+	}
+	if strings.HasPrefix(s, "{") || strings.HasPrefix(s, "&") || strings.HasPrefix(s, "*") || strings.HasPrefix(s, "|") || strings.HasPrefix(s, ">") {
+		return nil, fmt.Errorf("yaml: line %d: unsupported syntax %q", line, s)
+	}
+	if strings.HasPrefix(s, "\"") {
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("yaml: line %d: bad quoted string %s", line, s)
+		}
+		return v, nil
+	}
+	if strings.HasPrefix(s, "'") {
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, fmt.Errorf("yaml: line %d: bad quoted string %s", line, s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	switch s {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return s, nil
+}
+
+// paramsYAML renders w as YAML in schema order.
+func paramsYAML(w paramsJSON) []byte {
+	var b bytes.Buffer
+	num := func(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) }
+	list := func(xs []float64) string {
+		parts := make([]string, len(xs))
+		for i, x := range xs {
+			parts[i] = num(x)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	fmt.Fprintf(&b, "schema_version: %d\n", w.SchemaVersion)
+	fmt.Fprintf(&b, "vital_weights: %s\n", list(w.VitalWeights))
+	fmt.Fprintf(&b, "max_resources: %d\n", w.MaxResources)
+	fmt.Fprintf(&b, "resource_weight: %s\n", num(w.ResourceWeight))
+	fmt.Fprintf(&b, "t1: %s\nt2: %s\nt3: %s\nt4: %s\n", num(w.T1), num(w.T2), num(w.T3), num(w.T4))
+	fmt.Fprintf(&b, "map_weight: %s\n", num(w.MAPWeight))
+	fmt.Fprintf(&b, "resource_scale: %s\n", w.ResourceScale)
+	if w.ResourceRate != 0 {
+		fmt.Fprintf(&b, "resource_rate: %s\n", num(w.ResourceRate))
+	}
+	fmt.Fprintf(&b, "gcs_banded: %t\n", w.GCSBanded)
+	fmt.Fprintf(&b, "gcs_bands:\n  normal: %s\n  mild: %s\n  moderate: %s\n  severe: %s\n",
+		num(w.GCSBands.Normal), num(w.GCSBands.Mild), num(w.GCSBands.Moderate), num(w.GCSBands.Severe))
+	fmt.Fprintf(&b, "respiratory_weight: %s\n", num(w.RespiratoryWeight))
+	fmt.Fprintf(&b, "qsofa_bump: %s\n", num(w.QSOFABump))
+	b.WriteString("reliability:\n")
+	for s := score.Source(0); s < score.NumSources; s++ {
+		fmt.Fprintf(&b, "  %s: %s\n", s, list(w.Reliability[s.String()]))
+	}
+	fmt.Fprintf(&b, "gray_zone: %s\n", num(w.GrayZone))
+	fmt.Fprintf(&b, "hysteresis: %s\n", num(w.Hysteresis))
+	if w.Reference != nil {
+		fmt.Fprintf(&b, "reference:\n  quantiles: %s\n  n: %d\n", list(w.Reference.Quantiles), w.Reference.N)
+	}
+	if c := w.Calibration; c != nil {
+		fmt.Fprintf(&b, "probability_calibration:\n  method: %s\n", c.Method)
+		if c.Method == CalibrationPlatt {
+			fmt.Fprintf(&b, "  a: %s\n  b: %s\n", num(c.A), num(c.B))
+		} else {
+			fmt.Fprintf(&b, "  x: %s\n  y: %s\n", list(c.X), list(c.Y))
+		}
+		fmt.Fprintf(&b, "  n: %d\n", c.N)
+	}
+	if v := w.Provenance; v != nil {
+		b.WriteString("provenance:\n")
+		for _, kv := range [][2]string{
+			{"name", v.Name}, {"version", v.Version}, {"calibrated_at", v.CalibratedAt},
+			{"author", v.Author}, {"source_dataset", v.SourceDataset}, {"hash", v.Hash},
+		} {
+			if kv[1] != "" {
+				fmt.Fprintf(&b, "  %s: %s\n", kv[0], strconv.Quote(kv[1]))
+			}
+		}
+	}
+	if w.AsymmetricWeights != nil {
+		fmt.Fprintf(&b, "asymmetric_weights:\n  low: %s\n  high: %s\n", list(w.AsymmetricWeights.Low), list(w.AsymmetricWeights.High))
+	}
+	if h := w.HalfWidths; h != nil {
+		fmt.Fprintf(&b, "half_widths:\n  low: %s\n  high: %s\n", list(h.Low), list(h.High))
+	}
+	if w.MissingPolicy != "" {
+		fmt.Fprintf(&b, "missing_policy: %s\n", w.MissingPolicy)
+	}
+	if w.MinPresentVitals != 0 {
+		fmt.Fprintf(&b, "min_present_vitals: %d\n", w.MinPresentVitals)
+	}
+	if len(w.ExtendedWeights) > 0 {
+		b.WriteString("extended_weights:\n")
+		for _, name := range score.ExtendedNames {
+			if x, ok := w.ExtendedWeights[name]; ok {
+				fmt.Fprintf(&b, "  %s: %s\n", name, num(x))
+			}
+		}
+	}
+	if t := w.Trend; t != nil {
+		fmt.Fprintf(&b, "trend:\n  weights: %s\n  scales: %s\n", list(t.Weights), list(t.Scales))
+	}
+	if w.DeviationCaps != nil {
+		fmt.Fprintf(&b, "deviation_caps: %s\n", list(w.DeviationCaps))
+	}
+	if w.Normalization != "" {
+		fmt.Fprintf(&b, "normalization: %s\n", w.Normalization)
+	}
+	if w.SoftmaxSharpness != 0 {
+		fmt.Fprintf(&b, "softmax_sharpness: %s\n", num(w.SoftmaxSharpness))
+	}
+	if w.FormulaVersion != 0 {
+		fmt.Fprintf(&b, "formula_version: %d\n", w.FormulaVersion)
+	}
+	if r := w.MinimumData; r != nil {
+		fmt.Fprintf(&b, "minimum_data:\n  min_present: %d\n", r.MinPresent)
+		if len(r.AnyOf) > 0 {
+			fmt.Fprintf(&b, "  any_of: [%s]\n", strings.Join(r.AnyOf, ", "))
+		}
+	}
+	if len(w.ZScores) > 0 {
+		b.WriteString("z_scores:\n")
+		for f := FieldHR; f <= FieldGCS; f++ {
+			if z, ok := w.ZScores[f.String()]; ok {
+				fmt.Fprintf(&b, "  %s:\n    mean: %s\n    sd: %s\n", f, num(z.Mean), num(z.SD))
+				if z.Saturation != 0 {
+					fmt.Fprintf(&b, "    saturation: %s\n", num(z.Saturation))
+				}
+			}
+		}
+	}
+	if len(w.Directions) > 0 {
+		b.WriteString("directions:\n")
+		for f := FieldHR; f <= FieldGCS; f++ {
+			if d, ok := w.Directions[f.String()]; ok {
+				fmt.Fprintf(&b, "  %s: %s\n", f, d)
+			}
+		}
+	}
+	if t := w.Transform; t != nil {
+		fmt.Fprintf(&b, "deviation_transform:\n  kind: %s\n", t.Kind)
+		if t.Saturation != 0 {
+			fmt.Fprintf(&b, "  saturation: %s\n", num(t.Saturation))
+		}
+		if t.Shape != 0 {
+			fmt.Fprintf(&b, "  shape: %s\n", num(t.Shape))
+		}
+	}
+	return b.Bytes()
+}